@@ -0,0 +1,83 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/logger"
+)
+
+// Alert types
+const (
+	AlertTypeReconcileMismatch = "reconcile_mismatch"
+)
+
+// An alert raised by the server, to be handled by the operators
+type Alert struct {
+	AlertType string         `json:"alert_type"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields"`
+	AlertTime time.Time      `json:"alert_time"`
+}
+
+// Alerter interface
+type IAlerter interface {
+	Send(alert Alert)
+}
+
+// Alerter instance
+// By default alerts are only written to the log
+var Alerter IAlerter = &logAlerter{}
+
+// Init alerter, must be called after the logger is initialized
+func Init() {
+	if config.Cfg.Alert.WebhookURL != "" {
+		Alerter = &webhookAlerter{
+			url:    config.Cfg.Alert.WebhookURL,
+			client: &http.Client{Timeout: 5 * time.Second},
+		}
+	}
+}
+
+// Raise an alert
+func Send(alertType string, message string, fields map[string]any) {
+	Alerter.Send(Alert{AlertType: alertType, Message: message, Fields: fields, AlertTime: time.Now()})
+}
+
+// Alerter implementation which writes the alert to the log as a JSON line
+type logAlerter struct{}
+
+func (la *logAlerter) Send(alert Alert) {
+	content, err := json.Marshal(alert)
+	if err != nil {
+		logger.Errorf("[ALERT] %s: %s", alert.AlertType, alert.Message)
+		return
+	}
+	logger.Errorf("[ALERT] %s", string(content))
+}
+
+// Alerter implementation which writes the alert to the log, and posts it to a webhook
+type webhookAlerter struct {
+	logAlerter
+	url    string
+	client *http.Client
+}
+
+func (wa *webhookAlerter) Send(alert Alert) {
+	wa.logAlerter.Send(alert)
+	content, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+	resp, err := wa.client.Post(wa.url, "application/json", bytes.NewReader(content))
+	if err != nil {
+		logger.Errorf("Failed to post alert to webhook, err: %s", err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Errorf("Failed to post alert to webhook, status: %d", resp.StatusCode)
+	}
+}
@@ -1,48 +1,457 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"wallet-app-server/app/alert"
+	"wallet-app-server/app/blob"
 	"wallet-app-server/app/config"
 	"wallet-app-server/app/db"
+	"wallet-app-server/app/fee"
+	"wallet-app-server/app/health"
+	"wallet-app-server/app/job"
+	"wallet-app-server/app/kyc"
+	"wallet-app-server/app/limit"
 	"wallet-app-server/app/logger"
+	"wallet-app-server/app/metrics"
+	"wallet-app-server/app/middleware"
 	"wallet-app-server/app/redis"
+	"wallet-app-server/app/risk"
+	"wallet-app-server/app/screening"
+	"wallet-app-server/app/service"
+	"wallet-app-server/app/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func InitAndStart(configPath string) {
-	// Init configuration
-	config.LoadConfig(configPath)
+// Timeouts of the API server, used if not configured
+const (
+	readHeaderTimeout      = 10 * time.Second
+	defaultReadTimeout     = 30 * time.Second
+	defaultWriteTimeout    = 60 * time.Second
+	defaultIdleTimeout     = 120 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+	defaultJobStopTimeout  = 30 * time.Second
+	// The wait for the DB transactions still running on shutdown, they're bound by the statement timeout of each statement
+	dbWaitTimeout = 10 * time.Second
+)
+
+// The API server with its background jobs and connections
+// Create it with New, run it with Run, or with Start and Shutdown
+type App struct {
+	server          *http.Server
+	certFile        string
+	keyFile         string
+	insecureHTTP    bool
+	shutdownTimeout time.Duration
+	jobStopTimeout  time.Duration
+	shutdownDelay   time.Duration
+	checker         *health.Checker
+	listener        net.Listener
+	serveErr        chan error
+	// The internal server of the metrics, nil if the metrics are disabled
+	metricsServer   *http.Server
+	metricsListener net.Listener
+	reloader        *configReloader
+	// Wait for the DB transactions still running on shutdown before the connections are closed, nil if no DB
+	waitDB        func(ctx context.Context) error
+	dbWaitTimeout time.Duration
+	// Closed in order on shutdown, after the requests and the background jobs are finished
+	closers []closer
+}
+
+// A connection closed on shutdown
+type closer struct {
+	name  string
+	close func() error
+}
+
+// Init the app and run it until SIGINT or SIGTERM, the configuration is reloaded on SIGHUP
+// Return the error if the server can't start or can't shut down cleanly
+func InitAndStart(configPath string) error {
+	a, err := New(configPath)
+	if err != nil {
+		return fmt.Errorf("server start failed: %w", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	stopReload := a.reloader.notifySIGHUP()
+	err = a.Run(ctx)
+	stopReload()
+	stop()
+	if err != nil {
+		logger.Errorf("Server stopped with error, err: %s", err.Error())
+		return fmt.Errorf("server stopped with error: %w", err)
+	}
+	logger.Info("Server stopped")
+	return nil
+}
+
+// Init the configuration, the logger, the connections and the other dependencies, and create the app
+// Return the error if any of them can't be initialized
+func New(configPath string) (*App, error) {
+	// Init configuration, all the problems of the configuration are reported at once
+	if err := config.LoadConfig(configPath, configValidators...); err != nil {
+		return nil, err
+	}
+	reloader := newConfigReloader(configPath, configValidators)
 
 	// Init logger
-	logger.Init()
+	if err := logger.Init(); err != nil {
+		return nil, err
+	}
 
 	// Init DB
-	db.Init()
+	if err := db.Init(); err != nil {
+		return nil, fmt.Errorf("DB init error: %w", err)
+	}
+
+	// Migrate the DB to the latest version, if configured
+	if config.Cfg.DB.MigrateOnStartup {
+		if err := migrateOnStartup(); err != nil {
+			return nil, fmt.Errorf("DB migration error: %w", err)
+		}
+	}
 
 	// Init redis
-	redis.Init()
+	if err := redis.Init(); err != nil {
+		return nil, fmt.Errorf("Redis init error: %w", err)
+	}
+
+	// Init alerter
+	alert.Init()
+
+	// Init readiness checks
+	health.Init()
+	health.DefaultChecker.Register("postgres", db.Ping)
+	if db.Replica != nil {
+		health.DefaultChecker.Register("postgres-replica", db.PingReplica)
+	}
+	health.DefaultChecker.Register("redis", redis.Ping)
+
+	// Init blob store
+	if err := blob.Init(); err != nil {
+		return nil, fmt.Errorf("blob store init error: %w", err)
+	}
+
+	// Init risk engine
+	if err := risk.Init(); err != nil {
+		return nil, fmt.Errorf("risk engine init error: %w", err)
+	}
+
+	// Init sanctions screening
+	if err := screening.Init(); err != nil {
+		return nil, fmt.Errorf("sanctions screening init error: %w", err)
+	}
+	if listSet := screening.DefaultScreener.Current(); listSet != nil {
+		logger.Infof("Sanctions lists loaded, version: %s, entries: %d", listSet.Version, len(listSet.Entries))
+	}
+
+	// Init tracing of the requests, the DB queries and the Redis commands
+	if err := initTracing(); err != nil {
+		return nil, fmt.Errorf("tracing init error: %w", err)
+	}
 
 	// Special setting for library github.com/shopspring/decimal
 	// If set to true, the decimal value will be marshaled to number instead of string
 	decimal.MarshalJSONWithoutQuotes = true
 
+	// Init metrics of the DB queries, the DB pool and the Redis commands
+	if config.Cfg.Metrics.Enabled {
+		if err := instrumentMetrics(); err != nil {
+			return nil, fmt.Errorf("metrics init error: %w", err)
+		}
+	}
+
 	// Create gin app
-	r := gin.Default()
+	// The access log replaces the default logger of gin, it has the trace ID of the request
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(tracing.ServiceName(), otelgin.WithFilter(isTracedRequest)))
+	r.Use(middleware.RequestID, middleware.AccessLog)
+	if config.Cfg.Metrics.Enabled {
+		r.Use(middleware.Metrics)
+	}
 
 	// Config API routes
 	configRoutes(r)
 
-	// Start API server
-	// Exit with code -1 if server cannot start
-	if err := r.RunTLS(
-		fmt.Sprintf("%s:%d", config.Cfg.Server.Host, config.Cfg.Server.Port),
-		config.Cfg.Server.SSLCert,
-		config.Cfg.Server.SSLKey,
-	); err != nil {
-		logger.Errorf("Failed to start server, err: %s", err.Error())
-		os.Exit(-1)
+	// Register background jobs, they are started with the server
+	configJobs(reloader)
+
+	// Create app, the DB and Redis connections are closed on shutdown, then the remaining spans are flushed
+	a := newApp(r)
+	a.reloader = reloader
+	a.waitDB = db.WaitIdle
+	if config.Cfg.Metrics.Enabled {
+		a.metricsServer = newMetricsServer(config.Cfg.Metrics.ListenAddr)
+	}
+	a.closers = append(a.closers,
+		closer{name: "DB", close: db.Close},
+		closer{name: "Redis", close: redis.Close},
+		closer{name: "tracer provider", close: tracing.Shutdown},
+	)
+	return a, nil
+}
+
+// Create the app serving the handler, as configured in the Server section
+func newApp(handler http.Handler) *App {
+	serverConf := config.Cfg.Server
+	return &App{
+		server: &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", serverConf.Host, serverConf.Port),
+			Handler:           handler,
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       durationInSecs(serverConf.ReadTimeoutInSecs, defaultReadTimeout),
+			WriteTimeout:      durationInSecs(serverConf.WriteTimeoutInSecs, defaultWriteTimeout),
+			IdleTimeout:       durationInSecs(serverConf.IdleTimeoutInSecs, defaultIdleTimeout),
+		},
+		certFile:        serverConf.SSLCert,
+		keyFile:         serverConf.SSLKey,
+		insecureHTTP:    serverConf.InsecureHTTP,
+		shutdownTimeout: durationInSecs(serverConf.ShutdownTimeoutInSecs, defaultShutdownTimeout),
+		jobStopTimeout:  durationInSecs(serverConf.JobStopTimeoutInSecs, defaultJobStopTimeout),
+		dbWaitTimeout:   dbWaitTimeout,
+		shutdownDelay:   time.Duration(max(serverConf.ShutdownDelayInSecs, 0)) * time.Second,
+		checker:         health.DefaultChecker,
+		serveErr:        make(chan error, 1),
+	}
+}
+
+// Create the internal server of the metrics, apart from the API, so that /metrics is only reachable from the monitoring network
+func newMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       defaultReadTimeout,
+		WriteTimeout:      defaultWriteTimeout,
+		IdleTimeout:       defaultIdleTimeout,
+	}
+}
+
+// Start listening and serving the API, and start the background jobs
+// Return the error if the server can't listen, the errors of serving afterwards are returned by Run
+func (a *App) Start() error {
+	if !a.insecureHTTP && (a.certFile == "" || a.keyFile == "") {
+		return errors.New("ssl-cert and ssl-key are required, unless insecure-http is set")
+	}
+	listener, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return err
+	}
+	a.listener = listener
+	// Serve the metrics over plain HTTP on the internal listener
+	if a.metricsServer != nil {
+		metricsListener, err := net.Listen("tcp", a.metricsServer.Addr)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		a.metricsListener = metricsListener
+		go func() {
+			if err := a.metricsServer.Serve(metricsListener); err != http.ErrServerClosed {
+				a.serveErr <- fmt.Errorf("metrics server: %w", err)
+			}
+		}()
+		logger.Infof("Metrics server started, addr: %s", metricsListener.Addr().String())
+	}
+	go func() {
+		// Serve plain HTTP only if explicitly configured, e.g. behind a TLS terminating proxy
+		var err error
+		if a.insecureHTTP {
+			err = a.server.Serve(listener)
+		} else {
+			err = a.server.ServeTLS(listener, a.certFile, a.keyFile)
+		}
+		if err != http.ErrServerClosed {
+			a.serveErr <- err
+		}
+	}()
+	job.Start()
+	logger.Infof("Server started, addr: %s", listener.Addr().String())
+	return nil
+}
+
+// Start the app, and shut it down when the context is done (e.g. on a signal) or the server fails
+// The shutdown waits for the in-flight requests until the shutdown timeout after the shutdown delay,
+// then for the background jobs until the job stop timeout
+func (a *App) Run(ctx context.Context) error {
+	var runErr error
+	if runErr = a.Start(); runErr == nil {
+		select {
+		case <-ctx.Done():
+			logger.Info("Shutting down server")
+		case runErr = <-a.serveErr:
+			logger.Errorf("Server failed, shutting down, err: %s", runErr.Error())
+		}
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownDelay+a.shutdownTimeout)
+	defer cancel()
+	return errors.Join(runErr, a.Shutdown(shutdownCtx))
+}
+
+// Shut down the app gracefully
+// Report not ready and keep serving for the shutdown delay, so that the load balancer stops sending requests,
+// then stop accepting requests and wait for the in-flight ones, then stop the background jobs,
+// then wait for the DB transactions still running, then close the connections.
+// If the context is done before the requests finish, the remaining client connections are closed, but their handlers
+// keep running, and so do their DB transactions, which aren't cancelled with the request (see db.Writer).
+// The background jobs and the DB transactions have their own timeout, so that a slow drain doesn't leave them no time
+// to finish. The connections are still closed after the timeout, and the DB rolls back the transactions which are still
+// running when the process exits
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	// Report not ready
+	a.checker.SetShuttingDown()
+	if a.shutdownDelay > 0 {
+		logger.Infof("Server is not ready, shutting down in %s", a.shutdownDelay)
+		select {
+		case <-time.After(a.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+	// Drain the in-flight requests
+	if err := a.server.Shutdown(ctx); err != nil {
+		logger.Errorf("Failed to drain in-flight requests, err: %s", err.Error())
+		errs = append(errs, fmt.Errorf("drain requests: %w", err))
+		a.server.Close()
+	}
+	// The metrics server is closed at once, a scrape can be retried
+	if a.metricsServer != nil {
+		a.metricsServer.Close()
+	}
+	// Stop background jobs
+	jobCtx, cancel := context.WithTimeout(context.Background(), a.jobStopTimeout)
+	defer cancel()
+	if err := job.Stop(jobCtx); err != nil {
+		logger.Errorf("Failed to stop background jobs, err: %s", err.Error())
+		errs = append(errs, fmt.Errorf("stop background jobs: %w", err))
+	}
+	// Wait for the DB transactions of the requests and the jobs which are still running
+	if a.waitDB != nil {
+		dbCtx, cancelDB := context.WithTimeout(context.Background(), a.dbWaitTimeout)
+		defer cancelDB()
+		if err := a.waitDB(dbCtx); err != nil {
+			logger.Errorf("Failed to wait for DB transactions, err: %s", err.Error())
+			errs = append(errs, fmt.Errorf("wait DB transactions: %w", err))
+		}
+	}
+	// Close connections
+	for _, c := range a.closers {
+		if err := c.close(); err != nil {
+			logger.Errorf("Failed to close %s, err: %s", c.name, err.Error())
+			errs = append(errs, fmt.Errorf("close %s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Observe the DB queries, the DB pool and the Redis commands
+func instrumentMetrics() error {
+	if err := metrics.InstrumentGORM(db.DB); err != nil {
+		return err
+	}
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	if err := metrics.RegisterDBStats(sqlDB, config.Cfg.DB.DBName); err != nil {
+		return err
+	}
+	if db.Replica != nil {
+		if err := metrics.InstrumentGORM(db.Replica); err != nil {
+			return err
+		}
+		replicaDB, err := db.Replica.DB()
+		if err != nil {
+			return err
+		}
+		if err := metrics.RegisterDBStats(replicaDB, config.Cfg.DB.DBName+"-replica"); err != nil {
+			return err
+		}
+	}
+	redis.Client.AddHook(metrics.RedisHook{})
+	return nil
+}
+
+// Init the tracer provider, and trace the DB queries and the Redis commands of the traced requests
+func initTracing() error {
+	if err := tracing.Init(); err != nil {
+		return err
+	}
+	if err := tracing.InstrumentGORM(db.DB); err != nil {
+		return err
+	}
+	if db.Replica != nil {
+		if err := tracing.InstrumentGORM(db.Replica); err != nil {
+			return err
+		}
+	}
+	redis.Client.AddHook(tracing.RedisHook{})
+	return nil
+}
+
+// The probes are not traced, they would flood the traces
+func isTracedRequest(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz":
+		return false
+	}
+	return true
+}
+
+// Register all the background jobs
+func configJobs(reloader *configReloader) {
+	// Reload the configuration when the file is changed
+	job.Register("config_watch", time.Duration(config.Cfg.Reload.WatchIntervalInSecs)*time.Second, reloader.reloadIfChanged)
+	// Reconcile wallet balances against the transaction history
+	job.Register("reconcile", time.Duration(config.Cfg.Reconcile.IntervalInSecs)*time.Second, func(ctx context.Context) {
+		service.ReconcileService.Reconcile(ctx, config.Cfg.Reconcile.FreezeOnMismatch)
+	})
+	// Take the daily balance snapshot, the job checks more often than daily so that a restart won't miss a day
+	job.Register("balance_snapshot", time.Duration(config.Cfg.Snapshot.IntervalInSecs)*time.Second, func(ctx context.Context) {
+		service.BalanceSnapshotService.TakeDailySnapshot(ctx, time.Now())
+	})
+	// Write the queued payouts to a pain.001 file
+	job.Register("payout_batch", time.Duration(config.Cfg.Payout.IntervalInSecs)*time.Second, func(ctx context.Context) {
+		service.PayoutService.SubmitBatch(ctx, time.Now())
+	})
+	// Expire the operations which haven't been approved or rejected in time
+	job.Register("approval_expiry", time.Duration(config.Cfg.Approval.IntervalInSecs)*time.Second, func(ctx context.Context) {
+		service.ApprovalService.ExpireOperations(ctx, time.Now())
+	})
+}
+
+// Validations of the configuration besides the fields, applied at startup and on every reload
+var configValidators = []config.Validator{
+	func(cfg config.Config) error { return prefixError("Fee.schedules", fee.Validate(cfg.Fee.Schedules)) },
+	func(cfg config.Config) error { return prefixError("Limit.rules", limit.Validate(cfg.Limit.Rules)) },
+	func(cfg config.Config) error { return prefixError("KYC.levels", kyc.Validate(cfg.KYC.Levels)) },
+}
+
+// Prefix the error with the configuration field, nil if no error
+func prefixError(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", field, err)
+}
+
+// Convert the configured seconds to a duration, or the default if not positive
+func durationInSecs(secs int, defaultDuration time.Duration) time.Duration {
+	if secs <= 0 {
+		return defaultDuration
 	}
+	return time.Duration(secs) * time.Second
 }
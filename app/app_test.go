@@ -0,0 +1,185 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/health"
+	"wallet-app-server/app/logger"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// Create an app serving plain HTTP on a random port, with a handler blocking until released
+func newTestApp(t *testing.T, release <-chan struct{}) (*App, chan struct{}) {
+	config.Cfg.Logging.LogLevel = "error"
+	config.Cfg.Logging.LogFormat = "text"
+	config.Cfg.Logging.LogFilePath = filepath.Join(t.TempDir(), "server.log")
+	logger.Init()
+	config.Cfg.Server.Host = "127.0.0.1"
+	config.Cfg.Server.Port = 0
+	config.Cfg.Server.SSLCert = ""
+	config.Cfg.Server.SSLKey = ""
+	config.Cfg.Server.InsecureHTTP = true
+	started := make(chan struct{}, 1)
+	a := newApp(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	a.checker = health.NewChecker(time.Second)
+	return a, started
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	release := make(chan struct{})
+	a, started := newTestApp(t, release)
+	closed := make(chan string, 2)
+	a.closers = []closer{
+		{name: "DB", close: func() error { closed <- "DB"; return nil }},
+		{name: "Redis", close: func() error { closed <- "Redis"; return nil }},
+	}
+	assert.Equal(t, a.Start(), nil)
+	url := "http://" + a.listener.Addr().String()
+
+	// Send a request, and shut down while it's in flight
+	statusCode := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			statusCode <- 0
+			return
+		}
+		resp.Body.Close()
+		statusCode <- resp.StatusCode
+	}()
+	<-started
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- a.Shutdown(context.Background()) }()
+
+	// The in-flight request finishes, and the connections are closed after it
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(closed), 0)
+	close(release)
+	assert.Equal(t, <-statusCode, http.StatusOK)
+	assert.Equal(t, <-shutdownErr, nil)
+	assert.Equal(t, <-closed, "DB")
+	assert.Equal(t, <-closed, "Redis")
+	assert.Equal(t, a.checker.Check(context.Background()).Status, health.StatusShuttingDown)
+
+	// No more requests are accepted
+	_, err := http.Get(url)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestRunUntilContextDone(t *testing.T) {
+	a, _ := newTestApp(t, nil)
+	closed := false
+	a.closers = []closer{{name: "DB", close: func() error { closed = true; return nil }}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, a.Run(ctx), nil)
+	assert.Equal(t, closed, true)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	a, started := newTestApp(t, release)
+	closeErr := errors.New("close failed")
+	dbClosed := false
+	a.closers = []closer{
+		{name: "Redis", close: func() error { return closeErr }},
+		{name: "DB", close: func() error { dbClosed = true; return nil }},
+	}
+	assert.Equal(t, a.Start(), nil)
+	go http.Get("http://" + a.listener.Addr().String())
+	<-started
+
+	// The request doesn't finish before the deadline, the connections are closed anyway
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := a.Shutdown(ctx)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, errors.Is(err, closeErr), true)
+	assert.Equal(t, dbClosed, true)
+}
+
+func TestShutdownWaitsForDBTransactions(t *testing.T) {
+	a, _ := newTestApp(t, nil)
+	transactionDone := make(chan struct{})
+	dbClosed := make(chan struct{}, 1)
+	a.waitDB = func(ctx context.Context) error {
+		select {
+		case <-transactionDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	a.closers = []closer{{name: "DB", close: func() error { dbClosed <- struct{}{}; return nil }}}
+	assert.Equal(t, a.Start(), nil)
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- a.Shutdown(context.Background()) }()
+
+	// The DB is closed only after the transaction still running is done
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(dbClosed), 0)
+	close(transactionDone)
+	assert.Equal(t, <-shutdownErr, nil)
+	assert.Equal(t, len(dbClosed), 1)
+}
+
+func TestShutdownDBWaitTimeout(t *testing.T) {
+	a, _ := newTestApp(t, nil)
+	a.dbWaitTimeout = 50 * time.Millisecond
+	a.waitDB = func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	dbClosed := false
+	a.closers = []closer{{name: "DB", close: func() error { dbClosed = true; return nil }}}
+	assert.Equal(t, a.Start(), nil)
+
+	// The transaction doesn't finish in time, the DB is closed anyway
+	err := a.Shutdown(context.Background())
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, dbClosed, true)
+}
+
+func TestStartListenError(t *testing.T) {
+	a, _ := newTestApp(t, nil)
+	assert.Equal(t, a.Start(), nil)
+	defer a.Shutdown(context.Background())
+
+	// The address is already in use
+	config.Cfg.Server.Port = a.listener.Addr().(*net.TCPAddr).Port
+	b := newApp(http.NotFoundHandler())
+	assert.NotEqual(t, b.Run(context.Background()), nil)
+}
+
+func TestMetricsServer(t *testing.T) {
+	a, _ := newTestApp(t, nil)
+	a.metricsServer = newMetricsServer("127.0.0.1:0")
+	assert.Equal(t, a.Start(), nil)
+
+	// The metrics are served on the internal listener only
+	resp, err := http.Get("http://" + a.metricsListener.Addr().String() + "/metrics")
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, a.Shutdown(context.Background()), nil)
+	_, err = http.Get("http://" + a.metricsListener.Addr().String() + "/metrics")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestDurationInSecs(t *testing.T) {
+	assert.Equal(t, durationInSecs(5, time.Minute), 5*time.Second)
+	assert.Equal(t, durationInSecs(0, time.Minute), time.Minute)
+	assert.Equal(t, durationInSecs(-1, time.Minute), time.Minute)
+}
@@ -0,0 +1,160 @@
+package bankimport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+	"wallet-app-server/app/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bank statement formats
+const (
+	FormatCamt053 = "camt053"
+	FormatCSV     = "csv"
+)
+
+const (
+	ErrUnsupportedFormat = "unsupported bank statement format"
+	ErrInvalidStatement  = "invalid bank statement"
+	ErrMissingAccount    = "bank account of the statement is missing"
+)
+
+// Max lengths in characters of the text fields of a recorded statement line
+const (
+	MaxAccountLength    = 34
+	MaxBankRefLength    = 100
+	MaxReferenceLength  = 255
+	MaxDebtorNameLength = 140
+)
+
+// The amounts of a recorded line must be less than it, NUMERIC(15, 2)
+var maxAmount = decimal.New(1, 13)
+
+// Parse a bank statement file into entries
+// Both credit and debit entries are returned, only booked entries are included
+func Parse(format string, r io.Reader) ([]model.BankStatementEntry, error) {
+	switch format {
+	case FormatCamt053:
+		return parseCamt053(r)
+	case FormatCSV:
+		return parseCSV(r)
+	default:
+		return nil, errors.New(ErrUnsupportedFormat)
+	}
+}
+
+// Set the account of the entries which don't give it, e.g. the CSV entries without the account column
+// Fail if an entry is left without account, since the keys of the entries are unique per account
+func SetAccount(entries []model.BankStatementEntry, account string) error {
+	account = NormalizeAccount(account)
+	for i := range entries {
+		if entries[i].Account == "" {
+			entries[i].Account = account
+		}
+		if entries[i].Account == "" {
+			return errors.New(ErrMissingAccount)
+		}
+		if utf8.RuneCountInString(entries[i].Account) > MaxAccountLength {
+			return fmt.Errorf("%s: account %q is longer than %d characters", ErrInvalidStatement, entries[i].Account, MaxAccountLength)
+		}
+	}
+	return nil
+}
+
+// Normalize the account identifier (IBAN or other ID) of the statement, uppercased without spaces
+func NormalizeAccount(account string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(account), ""))
+}
+
+// The idempotency keys of a statement entry
+type LineKeys struct {
+	// Unique per recorded line, see LineHash
+	LineHash string
+	// Of the entry content, see ContentHash
+	ContentHash string
+	// Of the keys before the account was part of them, to find the lines imported before
+	LegacyHash string
+}
+
+// Compute the idempotency key of a statement entry
+// The bank reference is unique per bank account, so it's used with the account alone if available.
+// Otherwise the key is the content hash of the entry
+func LineHash(entry model.BankStatementEntry, occurrence int) string {
+	if entry.BankRef != "" {
+		return hashKey("ref:" + entry.Account + "|" + entry.BankRef)
+	}
+	return ContentHash(entry, occurrence)
+}
+
+// Compute the hash of the account and the content of a statement entry, and of its occurrence among the identical entries of the file
+// The bank reference isn't part of it, so that an entry has the same content hash in a format without bank reference
+func ContentHash(entry model.BankStatementEntry, occurrence int) string {
+	return hashKey(fmt.Sprintf("content:%s|%s|%s|%s|%t|%s|%s|%d", entry.Account, entry.BookingTime.UTC().Format("2006-01-02T15:04:05"),
+		entry.Amount.StringFixed(2), entry.Currency, entry.Credit, entry.Reference, entry.DebtorName, occurrence))
+}
+
+// Compute the line hash of a statement entry without its account, as the lines were recorded before the account was part of it
+func LegacyLineHash(entry model.BankStatementEntry, occurrence int) string {
+	if entry.BankRef != "" {
+		return hashKey("ref:" + entry.BankRef)
+	}
+	return hashKey(fmt.Sprintf("content:%s|%s|%s|%t|%s|%s|%d", entry.BookingTime.UTC().Format("2006-01-02T15:04:05"),
+		entry.Amount.StringFixed(2), entry.Currency, entry.Credit, entry.Reference, entry.DebtorName, occurrence))
+}
+
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Compute the keys of all the entries of a file
+// The occurrence of an entry is counted among the entries of the same account and content
+func LineKeysOf(entries []model.BankStatementEntry) []LineKeys {
+	occurrences := map[string]int{}
+	result := make([]LineKeys, 0, len(entries))
+	for _, entry := range entries {
+		contentHash := ContentHash(entry, 0)
+		occurrence := occurrences[contentHash]
+		result = append(result, LineKeys{
+			LineHash:    LineHash(entry, occurrence),
+			ContentHash: ContentHash(entry, occurrence),
+			LegacyHash:  LegacyLineHash(entry, occurrence),
+		})
+		occurrences[contentHash]++
+	}
+	return result
+}
+
+// Check the entry can be recorded as a line: the currency is a 3-letter code and the amount fits
+func Validate(entry model.BankStatementEntry) error {
+	if utf8.RuneCountInString(entry.Currency) != 3 {
+		return fmt.Errorf("invalid currency %q", entry.Currency)
+	}
+	if entry.Amount.GreaterThanOrEqual(maxAmount) {
+		return fmt.Errorf("amount %s out of range", entry.Amount.String())
+	}
+	return nil
+}
+
+// Cut the text fields of the entry to the max lengths of a recorded line, and return whether any was cut
+// The line hash and the wallet matching use the full entry, only the recorded line is cut
+func Truncate(entry model.BankStatementEntry) (model.BankStatementEntry, bool) {
+	var bankRefCut, referenceCut, debtorNameCut bool
+	entry.BankRef, bankRefCut = truncate(entry.BankRef, MaxBankRefLength)
+	entry.Reference, referenceCut = truncate(entry.Reference, MaxReferenceLength)
+	entry.DebtorName, debtorNameCut = truncate(entry.DebtorName, MaxDebtorNameLength)
+	return entry, bankRefCut || referenceCut || debtorNameCut
+}
+
+func truncate(value string, maxLength int) (string, bool) {
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value, false
+	}
+	return string([]rune(value)[:maxLength]), true
+}
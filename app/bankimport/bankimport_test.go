@@ -0,0 +1,148 @@
+package bankimport
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseCamt053V02(t *testing.T) {
+	file, _ := os.Open("testdata/camt053_v02.xml")
+	defer file.Close()
+	entries, err := Parse(FormatCamt053, file)
+	assert.Equal(t, nil, err)
+	// The pending entry is skipped
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "GB33BUKB20201555555555", entries[0].Account)
+	assert.Equal(t, "BANKREF0001", entries[0].BankRef)
+	assert.Equal(t, "1000.00", entries[0].Amount.StringFixed(2))
+	assert.Equal(t, "USD", entries[0].Currency)
+	assert.Equal(t, true, entries[0].Credit)
+	assert.Equal(t, "Top up WAA5344DDEA6", entries[0].Reference)
+	assert.Equal(t, "Vence Lin", entries[0].DebtorName)
+	assert.Equal(t, time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), entries[0].BookingTime)
+	assert.Equal(t, false, entries[1].Credit)
+}
+
+func TestParseCamt053V08Batch(t *testing.T) {
+	file, _ := os.Open("testdata/camt053_v08.xml")
+	defer file.Close()
+	entries, err := Parse(FormatCamt053, file)
+	assert.Equal(t, nil, err)
+	// The batch entry is split by transaction
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "BANKREF0101", entries[0].BankRef)
+	assert.Equal(t, "100.00", entries[0].Amount.StringFixed(2))
+	assert.Equal(t, "WAD4598F954E", entries[0].Reference)
+	assert.Equal(t, "Mike Kwok", entries[0].DebtorName)
+	assert.Equal(t, "BANKREF0102", entries[1].BankRef)
+	assert.Equal(t, "200.00", entries[1].Amount.StringFixed(2))
+}
+
+func TestParseCSV(t *testing.T) {
+	file, _ := os.Open("testdata/statement.csv")
+	defer file.Close()
+	entries, err := Parse(FormatCSV, file)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "250.50", entries[0].Amount.StringFixed(2))
+	assert.Equal(t, "USD", entries[0].Currency)
+	assert.Equal(t, true, entries[0].Credit)
+	assert.Equal(t, false, entries[1].Credit)
+	assert.Equal(t, "20.00", entries[1].Amount.StringFixed(2))
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := Parse(FormatCSV, strings.NewReader("bank_ref,amount\nREF,10\n"))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestLineKeys(t *testing.T) {
+	file, _ := os.Open("testdata/statement.csv")
+	defer file.Close()
+	entries, _ := Parse(FormatCSV, file)
+	assert.Equal(t, nil, SetAccount(entries, "GB33 BUKB 2020 1555 5555 55"))
+	assert.Equal(t, "GB33BUKB20201555555555", entries[0].Account)
+	keys := LineKeysOf(entries)
+	// Identical entries without bank reference still get different hashes
+	assert.NotEqual(t, keys[2].LineHash, keys[3].LineHash)
+	// Re-importing the same file gives the same hashes
+	assert.Equal(t, keys, LineKeysOf(entries))
+	// The bank reference alone identifies the entry of the account
+	changed := entries[0]
+	changed.Reference = "changed"
+	assert.Equal(t, keys[0].LineHash, LineHash(changed, 5))
+	// The line hashes recorded before the account was part of them
+	assert.Equal(t, keys[0].LegacyHash, hashKey("ref:CSVREF01"))
+}
+
+func TestLineHashOtherAccount(t *testing.T) {
+	// A bank reference reused by another account is another line
+	entry := model.BankStatementEntry{Account: "GB33BUKB20201555555555", BankRef: "REF1", BookingTime: time.Date(2025, 6, 17, 0, 0, 0, 0, time.UTC),
+		Amount: decimal.NewFromInt(10), Currency: "USD", Credit: true, Reference: "Top up WA34FAD4741D"}
+	other := entry
+	other.Account = "DE89370400440532013000"
+	assert.NotEqual(t, LineHash(entry, 0), LineHash(other, 0))
+	assert.NotEqual(t, ContentHash(entry, 0), ContentHash(other, 0))
+	// So is an entry without bank reference
+	entry.BankRef, other.BankRef = "", ""
+	assert.NotEqual(t, LineHash(entry, 0), LineHash(other, 0))
+}
+
+func TestContentHashWithoutBankRef(t *testing.T) {
+	// The entry has the same content hash whether its format gives the bank reference or not
+	entry := model.BankStatementEntry{Account: "GB33BUKB20201555555555", BankRef: "REF1", BookingTime: time.Date(2025, 6, 17, 0, 0, 0, 0, time.UTC),
+		Amount: decimal.NewFromInt(10), Currency: "USD", Credit: true, Reference: "Top up WA34FAD4741D"}
+	withoutRef := entry
+	withoutRef.BankRef = ""
+	assert.NotEqual(t, LineHash(entry, 0), LineHash(withoutRef, 0))
+	assert.Equal(t, ContentHash(entry, 0), ContentHash(withoutRef, 0))
+	assert.Equal(t, LineHash(withoutRef, 0), ContentHash(withoutRef, 0))
+}
+
+func TestSetAccount(t *testing.T) {
+	// The account of the file is kept, the others get the given one
+	entries := []model.BankStatementEntry{{Account: "DE89370400440532013000"}, {}}
+	assert.Equal(t, nil, SetAccount(entries, "gb33 bukb 2020 1555 5555 55"))
+	assert.Equal(t, "DE89370400440532013000", entries[0].Account)
+	assert.Equal(t, "GB33BUKB20201555555555", entries[1].Account)
+	err := SetAccount([]model.BankStatementEntry{{}}, "")
+	assert.Equal(t, ErrMissingAccount, err.Error())
+	assert.NotEqual(t, nil, SetAccount([]model.BankStatementEntry{{}}, strings.Repeat("A", MaxAccountLength+1)))
+}
+
+func TestParseUnsupportedFormat(t *testing.T) {
+	_, err := Parse("mt940", strings.NewReader(""))
+	assert.Equal(t, ErrUnsupportedFormat, err.Error())
+}
+
+func TestTruncate(t *testing.T) {
+	entry := model.BankStatementEntry{BankRef: "REF", Reference: "Top up WA0123456789", DebtorName: "John Doe"}
+	truncated, cut := Truncate(entry)
+	assert.Equal(t, false, cut)
+	assert.Equal(t, entry, truncated)
+
+	// Cut in characters, not bytes
+	entry.Reference = strings.Repeat("é", 300)
+	entry.DebtorName = strings.Repeat("x", 141)
+	truncated, cut = Truncate(entry)
+	assert.Equal(t, true, cut)
+	assert.Equal(t, strings.Repeat("é", MaxReferenceLength), truncated.Reference)
+	assert.Equal(t, MaxDebtorNameLength, len(truncated.DebtorName))
+	assert.Equal(t, "REF", truncated.BankRef)
+}
+
+func TestValidate(t *testing.T) {
+	entry := model.BankStatementEntry{Amount: decimal.RequireFromString("9999999999999.99"), Currency: "USD"}
+	assert.Equal(t, nil, Validate(entry))
+	entry.Currency = "EURO"
+	assert.NotEqual(t, nil, Validate(entry))
+	entry.Currency = "EUR"
+	entry.Amount = decimal.RequireFromString("10000000000000")
+	assert.NotEqual(t, nil, Validate(entry))
+}
@@ -0,0 +1,159 @@
+package bankimport
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// The subset of the camt.053 bank-to-customer statement needed for deposit reconciliation
+// Elements are matched by local name, so that both camt.053.001.02 and the later versions are supported
+type camtDocument struct {
+	Stmts []struct {
+		// The account is identified by its IBAN, or by another ID
+		IBAN string      `xml:"Acct>Id>IBAN"`
+		Othr string      `xml:"Acct>Id>Othr>Id"`
+		Ccy  string      `xml:"Acct>Ccy"`
+		Ntry []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtEntry struct {
+	NtryRef   string     `xml:"NtryRef"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	RvslInd   string     `xml:"RvslInd"`
+	// Status is a plain code in camt.053.001.02, and a choice of code in the later versions
+	Sts struct {
+		Cd    string `xml:"Cd"`
+		Value string `xml:",chardata"`
+	} `xml:"Sts"`
+	BookgDt struct {
+		Dt   string `xml:"Dt"`
+		DtTm string `xml:"DtTm"`
+	} `xml:"BookgDt"`
+	AcctSvcrRef  string         `xml:"AcctSvcrRef"`
+	TxDtls       []camtTxDetail `xml:"NtryDtls>TxDtls"`
+	AddtlNtryInf string         `xml:"AddtlNtryInf"`
+}
+
+type camtTxDetail struct {
+	AcctSvcrRef string      `xml:"Refs>AcctSvcrRef"`
+	EndToEndId  string      `xml:"Refs>EndToEndId"`
+	Amt         *camtAmount `xml:"Amt"`
+	// Debtor name is Dbtr>Nm in camt.053.001.02, and Dbtr>Pty>Nm in the later versions
+	DbtrNm    string   `xml:"RltdPties>Dbtr>Nm"`
+	DbtrPtyNm string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	Ustrd     []string `xml:"RmtInf>Ustrd"`
+	StrdRef   []string `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+func parseCamt053(r io.Reader) ([]model.BankStatementEntry, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidStatement, err)
+	}
+	var result []model.BankStatementEntry
+	for _, stmt := range doc.Stmts {
+		account := NormalizeAccount(firstNonEmpty(stmt.IBAN, stmt.Othr))
+		for _, ntry := range stmt.Ntry {
+			// Only booked entries, reversals are left to the operators
+			status := strings.TrimSpace(ntry.Sts.Cd)
+			if status == "" {
+				status = strings.TrimSpace(ntry.Sts.Value)
+			}
+			if (status != "" && status != "BOOK") || strings.TrimSpace(ntry.RvslInd) == "true" {
+				continue
+			}
+			bookingTime, err := parseCamtDate(ntry.BookgDt.DtTm, ntry.BookgDt.Dt)
+			if err != nil {
+				return nil, err
+			}
+			credit := strings.TrimSpace(ntry.CdtDbtInd) == "CRDT"
+			// A batch entry with several amounts is split into one entry per transaction
+			details := ntry.TxDtls
+			if len(details) > 1 && details[0].Amt == nil {
+				details = details[:1]
+			}
+			if len(details) == 0 {
+				details = []camtTxDetail{{}}
+			}
+			for i, detail := range details {
+				amount := ntry.Amt
+				if detail.Amt != nil && len(details) > 1 {
+					amount = *detail.Amt
+				}
+				value, err := decimal.NewFromString(strings.TrimSpace(amount.Value))
+				if err != nil {
+					return nil, fmt.Errorf("%s: invalid amount %q", ErrInvalidStatement, amount.Value)
+				}
+				currency := amount.Ccy
+				if currency == "" {
+					currency = stmt.Ccy
+				}
+				bankRef := firstNonEmpty(detail.AcctSvcrRef, ntry.AcctSvcrRef, ntry.NtryRef)
+				if bankRef != "" && len(details) > 1 && detail.AcctSvcrRef == "" {
+					bankRef = fmt.Sprintf("%s/%d", bankRef, i+1)
+				}
+				references := append(append([]string{}, detail.Ustrd...), detail.StrdRef...)
+				if detail.EndToEndId != "" && detail.EndToEndId != "NOTPROVIDED" {
+					references = append(references, detail.EndToEndId)
+				}
+				if ntry.AddtlNtryInf != "" {
+					references = append(references, ntry.AddtlNtryInf)
+				}
+				result = append(result, model.BankStatementEntry{
+					Account:     account,
+					BankRef:     strings.TrimSpace(bankRef),
+					BookingTime: bookingTime,
+					Amount:      value,
+					Currency:    strings.TrimSpace(currency),
+					Credit:      credit,
+					Reference:   strings.TrimSpace(strings.Join(references, " ")),
+					DebtorName:  strings.TrimSpace(firstNonEmpty(detail.DbtrPtyNm, detail.DbtrNm)),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// Parse the ISO date time, or the ISO date
+func parseCamtDate(dateTime string, date string) (time.Time, error) {
+	if dateTime = strings.TrimSpace(dateTime); dateTime != "" {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05"} {
+			if result, err := time.Parse(layout, dateTime); err == nil {
+				return result, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%s: invalid booking date time %q", ErrInvalidStatement, dateTime)
+	}
+	if date = strings.TrimSpace(date); date != "" {
+		result, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: invalid booking date %q", ErrInvalidStatement, date)
+		}
+		return result, nil
+	}
+	return time.Time{}, errors.New(ErrInvalidStatement + ": missing booking date")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
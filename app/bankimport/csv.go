@@ -0,0 +1,92 @@
+package bankimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Columns of the CSV bank statement, the header row is required and the column order doesn't matter
+// A positive amount is a credit, a negative amount is a debit
+// The account column is optional, the account of the statement is given with the import otherwise
+const (
+	csvColumnAccount     = "account"
+	csvColumnBankRef     = "bank_ref"
+	csvColumnBookingDate = "booking_date"
+	csvColumnAmount      = "amount"
+	csvColumnCurrency    = "currency"
+	csvColumnReference   = "reference"
+	csvColumnDebtorName  = "debtor_name"
+)
+
+var csvRequiredColumns = []string{csvColumnBookingDate, csvColumnAmount, csvColumnCurrency, csvColumnReference}
+
+func parseCSV(r io.Reader) ([]model.BankStatementEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	// Header row
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidStatement, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s: missing column %s", ErrInvalidStatement, name)
+		}
+	}
+	get := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	// Data rows
+	var result []model.BankStatementEntry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrInvalidStatement, err)
+		}
+		bookingTime, err := parseCSVDate(get(record, csvColumnBookingDate))
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", ErrInvalidStatement, line, err)
+		}
+		amount, err := decimal.NewFromString(get(record, csvColumnAmount))
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: invalid amount", ErrInvalidStatement, line)
+		}
+		result = append(result, model.BankStatementEntry{
+			Account:     NormalizeAccount(get(record, csvColumnAccount)),
+			BankRef:     get(record, csvColumnBankRef),
+			BookingTime: bookingTime,
+			Amount:      amount.Abs(),
+			Currency:    strings.ToUpper(get(record, csvColumnCurrency)),
+			Credit:      amount.IsPositive(),
+			Reference:   get(record, csvColumnReference),
+			DebtorName:  get(record, csvColumnDebtorName),
+		})
+	}
+	return result, nil
+}
+
+func parseCSVDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if result, err := time.Parse(layout, value); err == nil {
+			return result, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid booking date %q", value)
+}
@@ -0,0 +1,71 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"wallet-app-server/app/config"
+)
+
+const (
+	DriverLocal = "local"
+)
+
+const (
+	ErrBlobNotFound = "blob not found"
+	ErrInvalidKey   = "invalid blob key"
+)
+
+var errBlobNotFound = errors.New(ErrBlobNotFound)
+
+// Store of the binary objects (e.g. the uploaded KYC documents), addressed by slash-separated keys
+type Store interface {
+	// Write the content to the key, replacing the existing blob
+	Put(key string, content io.Reader) error
+	// Open the blob of the key, return ErrBlobNotFound if it doesn't exist
+	Open(key string) (io.ReadCloser, error)
+	// Delete the blob of the key, deleting a missing blob is not an error
+	Delete(key string) error
+}
+
+// Create a store from the configuration
+type DriverFactory func(cfg config.Config) (Store, error)
+
+var driverFactories = map[string]DriverFactory{
+	DriverLocal: func(cfg config.Config) (Store, error) {
+		return NewLocalStore(cfg.Blob.LocalDir)
+	},
+}
+
+// Register a store driver, so that it can be used in the configuration
+// Should be called before the store is initialized
+func RegisterDriver(driver string, factory DriverFactory) {
+	driverFactories[driver] = factory
+}
+
+// Store instance
+// By default there is no store, and storing a blob fails
+var DefaultStore Store
+
+// Init the store of the driver in the configuration
+// If no driver is configured, there is no store
+func Init() error {
+	if config.Cfg.Blob.Driver == "" {
+		return nil
+	}
+	factory, found := driverFactories[config.Cfg.Blob.Driver]
+	if !found {
+		return fmt.Errorf("unknown blob store driver %q", config.Cfg.Blob.Driver)
+	}
+	store, err := factory(config.Cfg)
+	if err != nil {
+		return err
+	}
+	DefaultStore = store
+	return nil
+}
+
+// Check if the error is a blob not found error
+func IsNotFound(err error) bool {
+	return err != nil && err.Error() == ErrBlobNotFound
+}
@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store on the local file system, a blob is a file under the root directory
+type LocalStore struct {
+	root string
+}
+
+// Create a store under the root directory, the directory is created if it doesn't exist
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, errors.New("local-dir of the local blob store is required")
+	}
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// The blob is written to a temporary file first and renamed when it's complete,
+// so a reader never sees a partially written blob
+func (ls *LocalStore) Put(key string, content io.Reader) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func (ls *LocalStore) Open(key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return file, err
+}
+
+func (ls *LocalStore) Delete(key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Path of the blob file, the key can't point outside the root directory
+func (ls *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", errors.New(ErrInvalidKey)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, ".tmp-") {
+			return "", errors.New(ErrInvalidKey)
+		}
+	}
+	return filepath.Join(ls.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,55 @@
+package blob
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLocalStore(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "blobs")
+	store, err := NewLocalStore(root)
+	assert.Equal(t, nil, err)
+
+	// Put and open
+	assert.Equal(t, nil, store.Put("kyc/user-1/doc-1", strings.NewReader("first")))
+	assert.Equal(t, "first", readBlob(t, store, "kyc/user-1/doc-1"))
+	// Put replaces the blob
+	assert.Equal(t, nil, store.Put("kyc/user-1/doc-1", strings.NewReader("second")))
+	assert.Equal(t, "second", readBlob(t, store, "kyc/user-1/doc-1"))
+	// No temporary file is left
+	entries, err := os.ReadDir(filepath.Join(root, "kyc", "user-1"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(entries))
+
+	// Delete
+	assert.Equal(t, nil, store.Delete("kyc/user-1/doc-1"))
+	_, err = store.Open("kyc/user-1/doc-1")
+	assert.Equal(t, true, IsNotFound(err))
+	assert.Equal(t, nil, store.Delete("kyc/user-1/doc-1"))
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.Equal(t, nil, err)
+	for _, key := range []string{"", "/etc/passwd", "../outside", "kyc/../../outside", "kyc//doc", "kyc/.tmp-1", `kyc\doc`} {
+		err := store.Put(key, strings.NewReader("content"))
+		assert.NotEqual(t, nil, err)
+		assert.Equal(t, ErrInvalidKey, err.Error())
+	}
+	_, err = NewLocalStore("")
+	assert.NotEqual(t, nil, err)
+}
+
+func readBlob(t *testing.T, store Store, key string) string {
+	reader, err := store.Open(key)
+	assert.Equal(t, nil, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	assert.Equal(t, nil, err)
+	return string(content)
+}
@@ -2,20 +2,46 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
-	"github.com/BurntSushi/toml"
+	"github.com/shopspring/decimal"
 )
 
 // The configuration struct
 // All the fields should align to config.toml
+// The secrets are tagged with secret:"true", so that their values are masked in the changes of a reload
 type Config struct {
 	Server struct {
-		Host                    string `toml:"host"`
-		Port                    int    `toml:"port"`
-		SSLCert                 string `toml:"ssl-cert"`
-		SSLKey                  string `toml:"ssl-key"`
-		SessionExpireTimeInSecs int    `toml:"session-expire-time-in-secs"`
+		Host    string `toml:"host"`
+		Port    int    `toml:"port"`
+		SSLCert string `toml:"ssl-cert"`
+		SSLKey  string `toml:"ssl-key"`
+		// Serve plain HTTP without ssl-cert and ssl-key, e.g. behind a TLS terminating proxy
+		InsecureHTTP            bool `toml:"insecure-http"`
+		SessionExpireTimeInSecs int  `toml:"session-expire-time-in-secs"`
+		ReadTimeoutInSecs       int  `toml:"read-timeout-in-secs"`
+		WriteTimeoutInSecs      int  `toml:"write-timeout-in-secs"`
+		IdleTimeoutInSecs       int  `toml:"idle-timeout-in-secs"`
+		ShutdownTimeoutInSecs   int  `toml:"shutdown-timeout-in-secs"`
+		ShutdownDelayInSecs     int  `toml:"shutdown-delay-in-secs"`
+		JobStopTimeoutInSecs    int  `toml:"job-stop-timeout-in-secs"`
+	}
+	Reload struct {
+		WatchIntervalInSecs int `toml:"watch-interval-in-secs"`
+	}
+	Health struct {
+		TimeoutInMillis int `toml:"timeout-in-millis"`
+	}
+	Metrics struct {
+		Enabled bool `toml:"enabled"`
+		// The internal listener serving /metrics, apart from the API
+		ListenAddr string `toml:"listen-addr"`
+	}
+	Tracing struct {
+		Exporter    string  `toml:"exporter"`
+		Endpoint    string  `toml:"endpoint"`
+		ServiceName string  `toml:"service-name"`
+		SampleRatio float64 `toml:"sample-ratio"`
 	}
 	Logging struct {
 		LogLevel               string `toml:"log-level"`
@@ -30,27 +56,141 @@ type Config struct {
 		DBName   string `toml:"dbname"`
 		Schema   string `toml:"schema"`
 		Username string `toml:"username"`
-		Password string `toml:"password"`
+		Password string `toml:"password" secret:"true"`
 		SSLMode  string `toml:"sslmode"`
+		// Apply the migrations which aren't applied when the server starts
+		MigrateOnStartup bool `toml:"migrate-on-startup"`
+		// Connection pool, of the primary and of the replica each
+		MaxOpenConns          int `toml:"max-open-conns"`
+		MaxIdleConns          int `toml:"max-idle-conns"`
+		ConnMaxLifetimeInSecs int `toml:"conn-max-lifetime-in-secs"`
+		ConnMaxIdleTimeInSecs int `toml:"conn-max-idle-time-in-secs"`
+		// Retries of the connection at startup, waiting the backoff before the first retry and doubling it after each
+		ConnectRetries              int `toml:"connect-retries"`
+		ConnectRetryBackoffInMillis int `toml:"connect-retry-backoff-in-millis"`
+		// Timeout of each statement, 0 for no timeout
+		StatementTimeoutInMillis int `toml:"statement-timeout-in-millis"`
+		// The read replica serving the balance and history reads, no replica if the host is empty
+		ReplicaHost string `toml:"replica-host"`
+		ReplicaPort int    `toml:"replica-port"`
 	}
 	Redis struct {
 		Addr     string `toml:"addr"`
-		Password string `toml:"password"`
+		Password string `toml:"password" secret:"true"`
 		DB       int    `toml:"db"`
 	}
+	Alert struct {
+		WebhookURL string `toml:"webhook-url" secret:"true"`
+	}
+	Reconcile struct {
+		IntervalInSecs   int  `toml:"interval-in-secs"`
+		FreezeOnMismatch bool `toml:"freeze-on-mismatch"`
+	}
+	Snapshot struct {
+		IntervalInSecs int `toml:"interval-in-secs"`
+		// The snapshot at midnight is taken after the lag, so that the transactions stamped before midnight have committed
+		LagInSecs int `toml:"lag-in-secs"`
+	}
+	Export struct {
+		Currency string `toml:"currency"`
+		BankID   string `toml:"bank-id"`
+	}
+	Payout struct {
+		IntervalInSecs int    `toml:"interval-in-secs"`
+		MaxBatchSize   int    `toml:"max-batch-size"`
+		OutputDir      string `toml:"output-dir"`
+		DebtorName     string `toml:"debtor-name"`
+		DebtorIBAN     string `toml:"debtor-iban" secret:"true"`
+		DebtorBIC      string `toml:"debtor-bic"`
+	}
+	Fee struct {
+		Schedules []FeeSchedule `toml:"schedules"`
+	}
+	Limit struct {
+		Rules []LimitRule `toml:"rules"`
+	}
+	Risk struct {
+		RulesFile string `toml:"rules-file"`
+	}
+	Screening struct {
+		OFACSDNFile        string  `toml:"ofac-sdn-file"`
+		OFACAltFile        string  `toml:"ofac-alt-file"`
+		EUConsolidatedFile string  `toml:"eu-consolidated-file"`
+		PersonThreshold    float64 `toml:"person-threshold"`
+		EntityThreshold    float64 `toml:"entity-threshold"`
+	}
+	KYC struct {
+		Levels []KYCLevel `toml:"levels"`
+	}
+	Blob struct {
+		Driver   string `toml:"driver"`
+		LocalDir string `toml:"local-dir"`
+	}
+	Approval struct {
+		ExpireTimeInSecs    int             `toml:"expire-time-in-secs"`
+		IntervalInSecs      int             `toml:"interval-in-secs"`
+		LargeTransferAmount decimal.Decimal `toml:"large-transfer-amount"`
+	}
+}
+
+// Capabilities of a KYC level
+// A level without a KYCLevel configured can make every transaction
+type KYCLevel struct {
+	Level        string          `toml:"level"`
+	Capabilities []KYCCapability `toml:"capabilities"`
+}
+
+// A transaction type allowed at the KYC level, up to the max amount per transaction (no upper bound if zero)
+type KYCCapability struct {
+	TxnType   string          `toml:"txn-type"`
+	MaxAmount decimal.Decimal `toml:"max-amount"`
+}
+
+// Transaction limits of a transaction type, for a user tier (or all the tiers if empty)
+// Zero means no limit
+type LimitRule struct {
+	TxnType       string          `toml:"txn-type"`
+	UserTier      string          `toml:"user-tier"`
+	PerTxnAmount  decimal.Decimal `toml:"per-txn-amount"`
+	DailyAmount   decimal.Decimal `toml:"daily-amount"`
+	DailyCount    int64           `toml:"daily-count"`
+	MonthlyAmount decimal.Decimal `toml:"monthly-amount"`
+	MonthlyCount  int64           `toml:"monthly-count"`
+}
+
+// Fee schedule of a transaction type, for a user tier (or all the tiers if empty)
+type FeeSchedule struct {
+	TxnType    string          `toml:"txn-type"`
+	UserTier   string          `toml:"user-tier"`
+	FeeType    string          `toml:"fee-type"`
+	Flat       decimal.Decimal `toml:"flat"`
+	Percentage decimal.Decimal `toml:"percentage"`
+	MinFee     decimal.Decimal `toml:"min-fee"`
+	MaxFee     decimal.Decimal `toml:"max-fee"`
+	Tiers      []FeeTier       `toml:"tiers"`
+}
+
+// Amount band of a tiered fee schedule, up to the given amount (no upper bound if zero)
+type FeeTier struct {
+	UpTo       decimal.Decimal `toml:"up-to"`
+	Flat       decimal.Decimal `toml:"flat"`
+	Percentage decimal.Decimal `toml:"percentage"`
 }
 
 // The global configuration
 // DO NOT change the value when using it
 // They're supposed to be loaded when the app is initialized
+// The reloadable fields (see Reload) keep the values loaded at startup here, read them with Current
 var Cfg Config
 
-// Load server configuration
-// If failed, server cannot be started, and will print error in stdout
-func LoadConfig(configPath string) {
-	if _, err := toml.DecodeFile(configPath, &Cfg); err != nil {
-		// FATAL ERROR: configuration can't be loaded, server exit
-		fmt.Printf("[FATAL] server start failed since configuration cannot be loaded, err: %s\n", err.Error())
-		os.Exit(-1)
+// Load server configuration, and validate it together with the validators
+// If failed, server cannot be started, and the error lists all the problems, one per line
+func LoadConfig(configPath string, validators ...Validator) error {
+	cfg, err := Load(configPath, validators...)
+	if err != nil {
+		return fmt.Errorf("configuration is invalid:\n  - %s", strings.ReplaceAll(err.Error(), "\n", "\n  - "))
 	}
+	Cfg = cfg
+	current.Store(&cfg)
+	return nil
 }
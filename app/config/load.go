@@ -0,0 +1,169 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Prefix of the environment variables overriding the configuration
+const EnvPrefix = "WALLET_"
+
+// Suffix of the environment variables pointing to a file holding the value, e.g. a Docker or Kubernetes secret
+const fileEnvSuffix = "_FILE"
+
+// A validation of the loaded configuration, besides the validation of the fields, e.g. of the fee schedules
+type Validator func(cfg Config) error
+
+// The default configuration, the fields not in the configuration file keep these values
+func Default() Config {
+	var cfg Config
+	cfg.Server.Host = "localhost"
+	cfg.Server.Port = 8227
+	cfg.Server.SessionExpireTimeInSecs = 900
+	cfg.Server.ReadTimeoutInSecs = 30
+	cfg.Server.WriteTimeoutInSecs = 60
+	cfg.Server.IdleTimeoutInSecs = 120
+	cfg.Server.ShutdownTimeoutInSecs = 30
+	cfg.Server.JobStopTimeoutInSecs = 30
+	cfg.Reload.WatchIntervalInSecs = 10
+	cfg.Health.TimeoutInMillis = 1000
+	cfg.Metrics.ListenAddr = "127.0.0.1:9227"
+	cfg.Tracing.Exporter = "none"
+	cfg.Tracing.SampleRatio = 1
+	cfg.Logging.LogLevel = "info"
+	cfg.Logging.LogFormat = "text"
+	cfg.Logging.LogFilePath = "log/server.log"
+	cfg.Logging.LogFileMaxSizeInMB = 10
+	cfg.Logging.LogFileRetentionInDays = 7
+	cfg.DB.Port = 5432
+	cfg.DB.Schema = "wallet_app"
+	cfg.DB.SSLMode = "prefer"
+	cfg.DB.MaxOpenConns = 25
+	cfg.DB.MaxIdleConns = 10
+	cfg.DB.ConnMaxLifetimeInSecs = 1800
+	cfg.DB.ConnMaxIdleTimeInSecs = 300
+	cfg.DB.ConnectRetries = 5
+	cfg.DB.ConnectRetryBackoffInMillis = 500
+	cfg.Redis.Addr = "localhost:6379"
+	cfg.Reconcile.IntervalInSecs = 3600
+	cfg.Snapshot.IntervalInSecs = 3600
+	cfg.Snapshot.LagInSecs = 300
+	cfg.Export.Currency = "USD"
+	cfg.Payout.IntervalInSecs = 3600
+	cfg.Payout.MaxBatchSize = 1000
+	cfg.Payout.OutputDir = "payout"
+	cfg.Screening.PersonThreshold = 0.92
+	cfg.Screening.EntityThreshold = 0.95
+	cfg.Blob.Driver = "local"
+	cfg.Blob.LocalDir = "documents"
+	cfg.Approval.ExpireTimeInSecs = 86400
+	cfg.Approval.IntervalInSecs = 600
+	return cfg
+}
+
+// Load the configuration in layers: the defaults, then the configuration file, then the environment variables,
+// then the files referenced by the environment variables
+// The environment variable of a field is WALLET_<SECTION>_<KEY>, e.g. WALLET_DB_PASSWORD for the password of the DB section,
+// and WALLET_DB_PASSWORD_FILE reads the password from the file
+// Return all the problems of the configuration at once: the environment variables, the fields and then the validators
+func Load(configPath string, validators ...Validator) (Config, error) {
+	cfg := Default()
+	if _, err := toml.DecodeFile(configPath, &cfg); err != nil {
+		return Config{}, err
+	}
+	errs := []error{applyEnv(&cfg, os.LookupEnv), cfg.Validate()}
+	for _, validate := range validators {
+		errs = append(errs, validate(cfg))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	cfg.Risk.RulesFile = resolvePath(configPath, cfg.Risk.RulesFile)
+	return cfg, nil
+}
+
+// Resolve a relative path of the configuration against the directory of the configuration file,
+// so it doesn't depend on the working directory the server is started from
+func resolvePath(configPath string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(configPath), path)
+}
+
+// The environment variable of a field, e.g. WALLET_SERVER_SESSION_EXPIRE_TIME_IN_SECS
+func envName(section string, key string) string {
+	return EnvPrefix + strings.ToUpper(section) + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Override the fields with the environment variables, the *_FILE variable takes precedence over the plain one
+// Only the fields of a single value can be overridden, not the lists (e.g. the fee schedules)
+func applyEnv(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		sectionName := sections.Type().Field(i).Name
+		section := sections.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			key := section.Type().Field(j).Tag.Get("toml")
+			name := envName(sectionName, key)
+			value, found := lookupEnv(name)
+			if filePath, fileFound := lookupEnv(name + fileEnvSuffix); fileFound {
+				content, err := os.ReadFile(filePath)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s%s: %w", name, fileEnvSuffix, err))
+					continue
+				}
+				value, found = strings.TrimRight(string(content), "\r\n"), true
+				name += fileEnvSuffix
+			}
+			if !found {
+				continue
+			}
+			if err := setField(section.Field(j), value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Set the field of a single value from the text
+func setField(field reflect.Value, value string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	default:
+		return errors.New("can't be set from an environment variable")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Write the configuration file to a temp dir
+func writeConfig(t *testing.T, content string) string {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+const minimalConfig = `
+[Server]
+insecure-http = true
+` + minimalDBConfig
+
+// The DB section of the minimal configuration
+const minimalDBConfig = `
+[DB]
+host = "db"
+dbname = "postgres"
+username = "wallet"
+password = "from-file"
+`
+
+func TestLoadDistConfig(t *testing.T) {
+	cfg, err := Load("../../dist/config.toml")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cfg.Server.Port, 8227)
+	assert.Equal(t, len(cfg.Limit.Rules), 2)
+}
+
+// The end-to-end tests run with the dist configuration, only with limits above the test amounts and the test DB password
+func TestEndToEndConfig(t *testing.T) {
+	dist, err := Load("../../dist/config.toml")
+	assert.Equal(t, err, nil)
+	endToEnd, err := Load("../../tests/end2end/config.toml")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, endToEnd.DB.Password, "P@ssw0rd")
+	// The rules files are relative to the configuration files, both are the one of dist/
+	assert.Equal(t, dist.Risk.RulesFile, filepath.Join("..", "..", "dist", "risk_rules.toml"))
+	assert.Equal(t, len(endToEnd.Limit.Rules), len(dist.Limit.Rules))
+	endToEnd.DB.Password, endToEnd.Limit = dist.DB.Password, dist.Limit
+	for _, change := range Diff(dist, endToEnd) {
+		t.Errorf("tests/end2end/config.toml differs from dist/config.toml: %s", change)
+	}
+}
+
+// A log format of the former logger is accepted, the logger falls back to text
+func TestLoadLegacyLogFormat(t *testing.T) {
+	cfg, err := Load(writeConfig(t, minimalConfig+`
+[Logging]
+log-format = "%{time:2006-01-02 15:04:05.000} %{shortfunc} ▶ %{message}"
+`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, IsLegacyLogFormat(cfg.Logging.LogFormat), true)
+	assert.Equal(t, IsLegacyLogFormat("json"), false)
+}
+
+func TestLoadLayers(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "redis_password")
+	os.WriteFile(secretPath, []byte("from-secret\n"), 0600)
+	t.Setenv("WALLET_DB_PASSWORD", "from-env")
+	t.Setenv("WALLET_SERVER_SESSION_EXPIRE_TIME_IN_SECS", "300")
+	t.Setenv("WALLET_METRICS_ENABLED", "true")
+	t.Setenv("WALLET_METRICS_LISTEN_ADDR", "0.0.0.0:9227")
+	t.Setenv("WALLET_APPROVAL_LARGE_TRANSFER_AMOUNT", "5000.50")
+	t.Setenv("WALLET_REDIS_PASSWORD", "from-env")
+	t.Setenv("WALLET_REDIS_PASSWORD_FILE", secretPath)
+
+	cfg, err := Load(writeConfig(t, minimalConfig))
+	assert.Equal(t, err, nil)
+	// Defaults
+	assert.Equal(t, cfg.Server.Port, 8227)
+	assert.Equal(t, cfg.Logging.LogFormat, "text")
+	// File
+	assert.Equal(t, cfg.DB.Host, "db")
+	// Environment variables
+	assert.Equal(t, cfg.DB.Password, "from-env")
+	assert.Equal(t, cfg.Server.SessionExpireTimeInSecs, 300)
+	assert.Equal(t, cfg.Metrics.Enabled, true)
+	assert.Equal(t, cfg.Metrics.ListenAddr, "0.0.0.0:9227")
+	assert.Equal(t, cfg.Approval.LargeTransferAmount.Equal(decimal.RequireFromString("5000.50")), true)
+	// The file referenced by the environment variable, without the trailing newline
+	assert.Equal(t, cfg.Redis.Password, "from-secret")
+}
+
+func TestLoadAggregatedErrors(t *testing.T) {
+	t.Setenv("WALLET_REDIS_DB", "one")
+	t.Setenv("WALLET_DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	configPath := writeConfig(t, `
+[Server]
+port = 70000
+[Logging]
+log-level = "verbose"
+[DB]
+dbname = "postgres"
+username = "wallet"
+`)
+	feeErr := errors.New("fee schedule of withdraw is duplicated")
+	_, err := Load(configPath, func(cfg Config) error { return feeErr })
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, errors.Is(err, feeErr), true)
+	// All the problems are reported, one per line
+	problems := strings.Split(err.Error(), "\n")
+	assert.Equal(t, len(problems), 7)
+	assert.Equal(t, strings.HasPrefix(problems[0], "WALLET_DB_PASSWORD_FILE: "), true)
+	assert.Equal(t, strings.HasPrefix(problems[1], "WALLET_REDIS_DB: invalid integer"), true)
+	assert.Equal(t, problems[2], "Server.port: must be between 1 and 65535, got 70000")
+	assert.Equal(t, problems[3], "Server.ssl-cert: ssl-cert and ssl-key are required, unless insecure-http = true to serve plain HTTP")
+	assert.Equal(t, strings.HasPrefix(problems[4], `Logging.log-level: must be one of`), true)
+	assert.Equal(t, problems[5], "DB.host: is required")
+	assert.Equal(t, problems[6], feeErr.Error())
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	_, err := Load(writeConfig(t, "[Server\nport = 1"))
+	assert.NotEqual(t, err, nil)
+	_, err = Load(filepath.Join(t.TempDir(), "missing.toml"))
+	assert.NotEqual(t, err, nil)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, envName("DB", "password"), "WALLET_DB_PASSWORD")
+	assert.Equal(t, envName("Server", "session-expire-time-in-secs"), "WALLET_SERVER_SESSION_EXPIRE_TIME_IN_SECS")
+}
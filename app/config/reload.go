@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// The fields which are applied to the running server on a reload, the other fields need a restart
+var reloadableFields = []string{
+	"Server.session-expire-time-in-secs",
+	"Logging.log-level",
+	"Fee.schedules",
+	"Limit.rules",
+}
+
+var (
+	// The configuration with the reloaded fields, nil until a configuration is loaded with LoadConfig
+	current atomic.Pointer[Config]
+	// Serialize the reloads, so that a reload never overwrites a newer one
+	reloadMu sync.Mutex
+)
+
+// The current configuration, with the reloadable fields as of the last reload
+// A reload replaces the whole configuration at once, so the fields read from one Current are consistent,
+// read it once and keep the result rather than calling it for every field
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	return &Cfg
+}
+
+// A changed field of the configuration, the secrets are masked
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.Old, c.New)
+}
+
+// The changes of a reload, the applied ones and the rejected ones which need a restart
+type ReloadResult struct {
+	Applied  []Change
+	Rejected []Change
+}
+
+// Load the configuration file again, and apply the changes of the reloadable fields
+// The changes of the other fields are rejected and returned, the running server keeps the loaded values
+// If the configuration can't be loaded or is invalid, nothing is changed and all the problems are returned
+func Reload(configPath string, validators ...Validator) (ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	loaded, err := Load(configPath, validators...)
+	if err != nil {
+		return ReloadResult{}, err
+	}
+	old := Current()
+	var result ReloadResult
+	for _, change := range Diff(*old, loaded) {
+		if slices.Contains(reloadableFields, change.Field) {
+			result.Applied = append(result.Applied, change)
+		} else {
+			result.Rejected = append(result.Rejected, change)
+		}
+	}
+	next := *old
+	next.Server.SessionExpireTimeInSecs = loaded.Server.SessionExpireTimeInSecs
+	next.Logging.LogLevel = loaded.Logging.LogLevel
+	next.Fee.Schedules = loaded.Fee.Schedules
+	next.Limit.Rules = loaded.Limit.Rules
+	current.Store(&next)
+	return result, nil
+}
+
+// The changed fields from one configuration to the other, in the order of the sections
+// The values of the secrets (tagged with secret:"true") are masked
+func Diff(from Config, to Config) []Change {
+	var changes []Change
+	oldSections, newSections := reflect.ValueOf(from), reflect.ValueOf(to)
+	for i := 0; i < oldSections.NumField(); i++ {
+		sectionName := oldSections.Type().Field(i).Name
+		oldSection, newSection := oldSections.Field(i), newSections.Field(i)
+		for j := 0; j < oldSection.NumField(); j++ {
+			oldValue, newValue := oldSection.Field(j).Interface(), newSection.Field(j).Interface()
+			if reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+			field := oldSection.Type().Field(j)
+			change := Change{Field: sectionName + "." + field.Tag.Get("toml"), Old: formatValue(oldValue), New: formatValue(newValue)}
+			if field.Tag.Get("secret") == "true" {
+				change.Old, change.New = "***", "***"
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Format the value of a field in a change, the texts are quoted and the lists have their field names
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%+v", value)
+}
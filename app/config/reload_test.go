@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// The changes of the reloadable fields
+const reloadableChanges = `
+[Server]
+insecure-http = true
+session-expire-time-in-secs = 300
+[Logging]
+log-level = "debug"
+[[Fee.schedules]]
+txn-type = "withdraw"
+fee-type = "flat"
+flat = "1.00"
+`
+
+// Load the configuration as LoadConfig does, and restore the global configuration after the test
+func loadCurrent(t *testing.T, configPath string) {
+	cfg, err := Load(configPath)
+	assert.Equal(t, err, nil)
+	saved := Cfg
+	Cfg = cfg
+	current.Store(&cfg)
+	t.Cleanup(func() {
+		Cfg = saved
+		current.Store(nil)
+	})
+}
+
+func TestReload(t *testing.T) {
+	configPath := writeConfig(t, minimalConfig)
+	loadCurrent(t, configPath)
+	before := Current()
+
+	// The DB host and password aren't reloadable
+	os.WriteFile(configPath, []byte(`
+[DB]
+host = "db-2"
+dbname = "postgres"
+username = "wallet"
+password = "rotated"
+`+reloadableChanges), 0600)
+	result, err := Reload(configPath)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(result.Applied), 3)
+	assert.Equal(t, result.Applied[0].String(), "Server.session-expire-time-in-secs: 900 -> 300")
+	assert.Equal(t, result.Applied[1].String(), `Logging.log-level: "info" -> "debug"`)
+	assert.Equal(t, result.Applied[2].Field, "Fee.schedules")
+	assert.Equal(t, len(result.Rejected), 2)
+	assert.Equal(t, result.Rejected[0].String(), `DB.host: "db" -> "db-2"`)
+	// The passwords are masked
+	assert.Equal(t, result.Rejected[1].String(), "DB.password: *** -> ***")
+
+	// The reloadable fields are replaced at once, the others keep the loaded values
+	cfg := Current()
+	assert.Equal(t, cfg.Server.SessionExpireTimeInSecs, 300)
+	assert.Equal(t, cfg.Logging.LogLevel, "debug")
+	assert.Equal(t, len(cfg.Fee.Schedules), 1)
+	assert.Equal(t, cfg.DB.Host, "db")
+	assert.Equal(t, cfg.DB.Password, "from-file")
+	// The configuration read before the reload is unchanged
+	assert.Equal(t, before.Server.SessionExpireTimeInSecs, 900)
+	assert.Equal(t, len(before.Fee.Schedules), 0)
+	assert.Equal(t, Cfg.Logging.LogLevel, "info")
+
+	// Reloading the same file changes nothing
+	result, err = Reload(configPath)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(result.Applied), 0)
+	assert.Equal(t, len(result.Rejected), 2)
+}
+
+func TestReloadInvalid(t *testing.T) {
+	configPath := writeConfig(t, minimalConfig)
+	loadCurrent(t, configPath)
+
+	os.WriteFile(configPath, []byte(minimalDBConfig+reloadableChanges), 0600)
+	feeErr := errors.New("fee schedule of withdraw is duplicated")
+	_, err := Reload(configPath, func(cfg Config) error { return feeErr })
+	assert.Equal(t, errors.Is(err, feeErr), true)
+	// The current configuration is kept
+	assert.Equal(t, Current().Server.SessionExpireTimeInSecs, 900)
+	assert.Equal(t, len(Current().Fee.Schedules), 0)
+}
+
+func TestDiffSecrets(t *testing.T) {
+	var from, to Config
+	to.Alert.WebhookURL = "https://hooks.example.com/services/T000/B000/XXXX"
+	to.Redis.Password = "rotated"
+	to.Redis.Addr = "redis-2:6379"
+	changes := Diff(from, to)
+	assert.Equal(t, len(changes), 3)
+	assert.Equal(t, changes[0].String(), `Redis.addr: "" -> "redis-2:6379"`)
+	assert.Equal(t, changes[1].String(), "Redis.password: *** -> ***")
+	assert.Equal(t, changes[2].String(), "Alert.webhook-url: *** -> ***")
+}
+
+func TestCurrentBeforeLoad(t *testing.T) {
+	assert.Equal(t, Current(), &Cfg)
+}
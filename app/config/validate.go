@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Valid values of the enumerated fields
+var (
+	logLevels      = []string{"debug", "info", "notice", "warn", "warning", "error", "critical"}
+	logFormats     = []string{"text", "json"}
+	dbSSLModes     = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	traceExporters = []string{"otlp", "stdout", "none"}
+)
+
+// Whether the log format is a format string of the former logger (e.g. "%{time} ▶ %{message}")
+// It's still accepted, and the logs are written in the text format with a warning
+func IsLegacyLogFormat(format string) bool {
+	return strings.Contains(format, "%{")
+}
+
+// The validation errors of the configuration, each prefixed with the field, e.g. "DB.port: must be between 1 and 65535"
+type validation struct {
+	errs []error
+}
+
+func (v *validation) fail(field string, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (v *validation) required(field string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(field, "is required")
+	}
+}
+
+func (v *validation) between(field string, value int, min int, max int) {
+	if value < min || value > max {
+		v.fail(field, "must be between %d and %d, got %d", min, max, value)
+	}
+}
+
+func (v *validation) notNegative(field string, value int) {
+	if value < 0 {
+		v.fail(field, "must not be negative, got %d", value)
+	}
+}
+
+func (v *validation) positive(field string, value int) {
+	if value <= 0 {
+		v.fail(field, "must be positive, got %d", value)
+	}
+}
+
+func (v *validation) ratio(field string, value float64) {
+	if value < 0 || value > 1 {
+		v.fail(field, "must be between 0 and 1, got %g", value)
+	}
+}
+
+func (v *validation) oneOf(field string, value string, valid []string) {
+	if !slices.Contains(valid, strings.ToLower(value)) {
+		v.fail(field, "must be one of %s, got %q", strings.Join(valid, ", "), value)
+	}
+}
+
+// Validate the required fields and the ranges of the fields, and return all the problems at once
+func (c Config) Validate() error {
+	v := &validation{}
+	// Server
+	v.between("Server.port", c.Server.Port, 1, 65535)
+	if (c.Server.SSLCert == "") != (c.Server.SSLKey == "") {
+		v.fail("Server.ssl-cert", "ssl-cert and ssl-key must be both set or both empty")
+	} else if c.Server.SSLCert == "" && !c.Server.InsecureHTTP {
+		v.fail("Server.ssl-cert", "ssl-cert and ssl-key are required, unless insecure-http = true to serve plain HTTP")
+	} else if c.Server.SSLCert != "" && c.Server.InsecureHTTP {
+		v.fail("Server.insecure-http", "must be false if ssl-cert and ssl-key are set")
+	}
+	v.positive("Server.session-expire-time-in-secs", c.Server.SessionExpireTimeInSecs)
+	v.notNegative("Server.read-timeout-in-secs", c.Server.ReadTimeoutInSecs)
+	v.notNegative("Server.write-timeout-in-secs", c.Server.WriteTimeoutInSecs)
+	v.notNegative("Server.idle-timeout-in-secs", c.Server.IdleTimeoutInSecs)
+	v.notNegative("Server.shutdown-timeout-in-secs", c.Server.ShutdownTimeoutInSecs)
+	v.notNegative("Server.shutdown-delay-in-secs", c.Server.ShutdownDelayInSecs)
+	v.notNegative("Server.job-stop-timeout-in-secs", c.Server.JobStopTimeoutInSecs)
+	v.notNegative("Reload.watch-interval-in-secs", c.Reload.WatchIntervalInSecs)
+	v.notNegative("Health.timeout-in-millis", c.Health.TimeoutInMillis)
+	// Metrics
+	if c.Metrics.Enabled {
+		v.required("Metrics.listen-addr", c.Metrics.ListenAddr)
+	}
+	// Tracing
+	v.oneOf("Tracing.exporter", c.Tracing.Exporter, traceExporters)
+	v.ratio("Tracing.sample-ratio", c.Tracing.SampleRatio)
+	// Logging
+	v.oneOf("Logging.log-level", c.Logging.LogLevel, logLevels)
+	if !IsLegacyLogFormat(c.Logging.LogFormat) {
+		v.oneOf("Logging.log-format", c.Logging.LogFormat, logFormats)
+	}
+	v.required("Logging.log-file-path", c.Logging.LogFilePath)
+	v.notNegative("Logging.log-file-max-size-in-mb", c.Logging.LogFileMaxSizeInMB)
+	v.notNegative("Logging.log-file-retention-in-days", c.Logging.LogFileRetentionInDays)
+	// DB and Redis
+	v.required("DB.host", c.DB.Host)
+	v.between("DB.port", c.DB.Port, 1, 65535)
+	v.required("DB.dbname", c.DB.DBName)
+	v.required("DB.schema", c.DB.Schema)
+	v.required("DB.username", c.DB.Username)
+	v.oneOf("DB.sslmode", c.DB.SSLMode, dbSSLModes)
+	v.notNegative("DB.max-open-conns", c.DB.MaxOpenConns)
+	v.notNegative("DB.max-idle-conns", c.DB.MaxIdleConns)
+	v.notNegative("DB.conn-max-lifetime-in-secs", c.DB.ConnMaxLifetimeInSecs)
+	v.notNegative("DB.conn-max-idle-time-in-secs", c.DB.ConnMaxIdleTimeInSecs)
+	v.notNegative("DB.connect-retries", c.DB.ConnectRetries)
+	v.notNegative("DB.connect-retry-backoff-in-millis", c.DB.ConnectRetryBackoffInMillis)
+	v.notNegative("DB.statement-timeout-in-millis", c.DB.StatementTimeoutInMillis)
+	// The port of the primary if 0
+	v.between("DB.replica-port", c.DB.ReplicaPort, 0, 65535)
+	v.required("Redis.addr", c.Redis.Addr)
+	v.between("Redis.db", c.Redis.DB, 0, 15)
+	// Background jobs, an interval of 0 disables the job
+	v.notNegative("Reconcile.interval-in-secs", c.Reconcile.IntervalInSecs)
+	v.notNegative("Snapshot.interval-in-secs", c.Snapshot.IntervalInSecs)
+	v.notNegative("Snapshot.lag-in-secs", c.Snapshot.LagInSecs)
+	v.notNegative("Payout.interval-in-secs", c.Payout.IntervalInSecs)
+	v.positive("Payout.max-batch-size", c.Payout.MaxBatchSize)
+	v.notNegative("Approval.interval-in-secs", c.Approval.IntervalInSecs)
+	// Pending operations would expire at once
+	v.positive("Approval.expire-time-in-secs", c.Approval.ExpireTimeInSecs)
+	if c.Approval.LargeTransferAmount.IsNegative() {
+		v.fail("Approval.large-transfer-amount", "must not be negative, got %s", c.Approval.LargeTransferAmount.String())
+	}
+	v.ratio("Screening.person-threshold", c.Screening.PersonThreshold)
+	v.ratio("Screening.entity-threshold", c.Screening.EntityThreshold)
+	if len(c.Export.Currency) != 3 {
+		v.fail("Export.currency", "must be an ISO 4217 code of 3 letters, got %q", c.Export.Currency)
+	}
+	return errors.Join(v.errs...)
+}
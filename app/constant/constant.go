@@ -2,15 +2,206 @@ package constant
 
 // Transaction types
 const (
-	TxnTypeTransfer = "transfer"
-	TxnTypeDeposit  = "deposit"
-	TxnTypeWithdraw = "withdraw"
+	TxnTypeTransfer     = "transfer"
+	TxnTypeDeposit      = "deposit"
+	TxnTypeWithdraw     = "withdraw"
+	TxnTypePayoutReturn = "payout_return"
+	TxnTypeFee          = "fee"
+	TxnTypeFeeRefund    = "fee_refund"
+	TxnTypeFX           = "fx"
+	TxnTypeAdjustment   = "adjustment"
+	TxnTypeHold         = "hold"
+	TxnTypeHoldRelease  = "hold_release"
+)
+
+// Risk review status
+const (
+	RiskReviewStatusPending  = "pending"
+	RiskReviewStatusApproved = "approved"
+	RiskReviewStatusRejected = "rejected"
+)
+
+// Risk decision outcomes, what became of the assessed transaction
+const (
+	RiskDecisionOutcomeCompleted = "completed"
+	RiskDecisionOutcomeRejected  = "rejected"
+	RiskDecisionOutcomeExpired   = "expired"
+)
+
+// Screening case statuses
+// A cleared case is a false positive, a confirmed case is a true hit
+const (
+	ScreeningCaseStatusOpen      = "open"
+	ScreeningCaseStatusCleared   = "cleared"
+	ScreeningCaseStatusConfirmed = "confirmed"
+)
+
+// Actions screened against the sanctions lists
+const (
+	ScreeningContextRegister          = "register"
+	ScreeningContextProfile           = "profile"
+	ScreeningContextTransfer          = "transfer"
+	ScreeningContextPayoutDestination = "payout_destination"
+	ScreeningContextPayout            = "payout"
+	ScreeningContextPayoutBatch       = "payout_batch"
+)
+
+// Status of a requested transaction
+const (
+	TxnStatusCompleted       = "completed"
+	TxnStatusPendingReview   = "pending_review"
+	TxnStatusPendingApproval = "pending_approval"
+)
+
+// Types of the operations which need the approval of a second person
+const (
+	OperationTypeTransfer        = "transfer"
+	OperationTypeSetUserLimit    = "set_user_limit"
+	OperationTypeRemoveUserLimit = "remove_user_limit"
+	OperationTypeUnfreezeWallet  = "unfreeze_wallet"
+	OperationTypeAdjustWallet    = "adjust_wallet"
+)
+
+// Pending operation statuses
+const (
+	OperationStatusPending  = "pending"
+	OperationStatusApproved = "approved"
+	OperationStatusRejected = "rejected"
+	OperationStatusExpired  = "expired"
+)
+
+// Actions in the audit trail of a pending operation
+// A failed action is an approval whose execution failed, the operation stays pending
+const (
+	OperationActionRequested = "requested"
+	OperationActionApproved  = "approved"
+	OperationActionRejected  = "rejected"
+	OperationActionExpired   = "expired"
+	OperationActionFailed    = "failed"
+)
+
+// Actor of the actions taken by the server itself, e.g. the background jobs
+const ActorSystem = "system"
+
+// Sources of the transaction limits
+const (
+	LimitSourceUser = "user"
+	LimitSourceTier = "tier"
+	LimitSourceNone = "none"
+)
+
+// User tiers
+const (
+	UserTierStandard = "standard"
+)
+
+// User roles
+// Every user is a customer, unless the user is staff given another role
+const (
+	UserRoleCustomer   = "customer"
+	UserRoleSupport    = "support"
+	UserRoleCompliance = "compliance"
+	UserRoleAdmin      = "admin"
+)
+
+// KYC levels, from the lowest to the highest
+const (
+	KYCLevelUnverified = "unverified"
+	KYCLevelBasic      = "basic"
+	KYCLevelFull       = "full"
+	// The level of the users from before the KYC levels, ranked as unverified, but not restricted unless it's configured
+	KYCLevelGrandfathered = "grandfathered"
+)
+
+// KYC submission statuses
+const (
+	KYCSubmissionStatusPending  = "pending"
+	KYCSubmissionStatusApproved = "approved"
+	KYCSubmissionStatusRejected = "rejected"
+)
+
+// KYC document types
+const (
+	KYCDocumentTypePassport       = "passport"
+	KYCDocumentTypeNationalID     = "national_id"
+	KYCDocumentTypeDrivingLicense = "driving_license"
+	KYCDocumentTypeProofOfAddress = "proof_of_address"
+	KYCDocumentTypeSelfie         = "selfie"
+)
+
+// Wallet types
+const (
+	WalletTypeUser   = "user"
+	WalletTypeSystem = "system"
+)
+
+// Wallet statuses
+const (
+	WalletStatusActive = "active"
+	WalletStatusFrozen = "frozen"
+)
+
+// System wallet IDs
+// System wallets are the ledger accounts for money entering or leaving the system,
+// their balances can be negative
+const (
+	SystemWalletCashIn     = "system-cash-in"
+	SystemWalletCashOut    = "system-cash-out"
+	SystemWalletFee        = "system-fee"
+	SystemWalletFX         = "system-fx"
+	SystemWalletAdjustment = "system-adjustment"
+	SystemWalletHold       = "system-hold"
+)
+
+// Bank statement line statuses
+const (
+	BankLineStatusMatched   = "matched"
+	BankLineStatusUnmatched = "unmatched"
+	BankLineStatusAssigned  = "assigned"
+	BankLineStatusIgnored   = "ignored"
+)
+
+// Payout destination statuses
+const (
+	PayoutDestinationStatusPending  = "pending"
+	PayoutDestinationStatusVerified = "verified"
+)
+
+// Payout statuses
+const (
+	PayoutStatusQueued    = "queued"
+	PayoutStatusSubmitted = "submitted"
+	PayoutStatusSettled   = "settled"
+	PayoutStatusReturned  = "returned"
 )
 
 // User activity types
 const (
-	UserActTypeLogin    = "login"
-	UserActTypeTransfer = "transfer"
-	UserActTypeDeposit  = "deposit"
-	UserActTypeWithdraw = "withdraw"
+	UserActTypeLogin        = "login"
+	UserActTypeRegister     = "register"
+	UserActTypeProfile      = "profile"
+	UserActTypeKYC          = "kyc"
+	UserActTypeRole         = "role"
+	UserActTypeTransfer     = "transfer"
+	UserActTypeDeposit      = "deposit"
+	UserActTypeWithdraw     = "withdraw"
+	UserActTypePayout       = "payout"
+	UserActTypePayoutReturn = "payout_return"
+	UserActTypeAdjustment   = "adjustment"
+)
+
+// Directions of a manual balance adjustment
+const (
+	AdjustmentDirectionCredit = "credit"
+	AdjustmentDirectionDebit  = "debit"
+)
+
+// Reason codes of a manual balance adjustment
+const (
+	AdjustmentReasonIncident         = "incident"
+	AdjustmentReasonDuplicatePosting = "duplicate_posting"
+	AdjustmentReasonMissingPosting   = "missing_posting"
+	AdjustmentReasonGoodwill         = "goodwill"
+	AdjustmentReasonFeeRefund        = "fee_refund"
+	AdjustmentReasonCorrection       = "correction"
 )
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"net/http"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// Reconcile wallet balances against the transaction history
+// POST /admin/reconcile
+func Reconcile(c *gin.Context) {
+	// Parse request body, the body is optional
+	req := struct {
+		Freeze bool `json:"freeze"`
+	}{}
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			respondeWithError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// Reconcile
+	report, statusCode, err := service.ReconcileService.Reconcile(c.Request.Context(), req.Freeze)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"report": report})
+}
+
+// Request to unfreeze a frozen wallet, the wallet is unfrozen after a second admin approves the request
+// POST /admin/wallet/unfreeze
+func UnfreezeWallet(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		WalletID string `json:"wallet_id" binding:"required"`
+		Note     string `json:"note"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Request to unfreeze wallet
+	operation, statusCode, err := service.WalletService.UnfreezeWallet(c.Request.Context(), currentUserID, req.WalletID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
+
+// Request a manual credit or debit of a user wallet, e.g. to fix an incident, applied once a second operator approves it
+// POST /admin/wallet/adjust
+func AdjustWallet(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		WalletID      string          `json:"wallet_id" binding:"required"`
+		Direction     string          `json:"direction" binding:"required"`
+		Amount        decimal.Decimal `json:"amount"`
+		ReasonCode    string          `json:"reason_code" binding:"required"`
+		Justification string          `json:"justification" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Request the adjustment
+	operation, statusCode, err := service.AdjustmentService.AdjustWallet(c.Request.Context(), currentUserID, model.WalletAdjustment{
+		WalletID:      req.WalletID,
+		Direction:     req.Direction,
+		Amount:        req.Amount,
+		ReasonCode:    req.ReasonCode,
+		Justification: req.Justification,
+	})
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
+
+// Search users by the user ID, or a part of the user name or the full name
+// GET /admin/user/search?keyword=
+func SearchUsers(c *gin.Context) {
+	// Search users
+	users, statusCode, err := service.AdminService.SearchUsers(c.Request.Context(), c.Query("keyword"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"users": users})
+}
+
+// List the wallets of any user
+// GET /admin/user/:user_id/wallets
+func ListUserWalletsByAdmin(c *gin.Context) {
+	// List wallets
+	wallets, statusCode, err := service.AdminService.ListUserWallets(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"wallets": wallets})
+}
+
+// List the latest activities of any user
+// GET /admin/user/:user_id/activities
+func ListUserActivities(c *gin.Context) {
+	// List activities
+	activities, statusCode, err := service.AdminService.ListUserActivities(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"activities": activities})
+}
+
+// Give a user a role (customer, support, compliance or admin), the role applies at once to the user's sessions
+// POST /admin/user/role
+func SetUserRole(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		UserID   string `json:"user_id" binding:"required"`
+		UserRole string `json:"user_role" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Set role
+	user, statusCode, err := service.AdminService.SetUserRole(c.Request.Context(), currentUserID, req.UserID, req.UserRole)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"user": user})
+}
+
+// Get any wallet with its status, balance and owner
+// GET /admin/wallet/:wallet_id
+func GetWalletByAdmin(c *gin.Context) {
+	// Get wallet
+	wallet, statusCode, err := service.AdminService.GetWallet(c.Request.Context(), c.Param("wallet_id"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"wallet": wallet})
+}
+
+// List the transaction history of any wallet
+// GET /admin/wallet/:wallet_id/history
+func ListWalletHistoryByAdmin(c *gin.Context) {
+	// List transaction history
+	txnHistory, statusCode, err := service.AdminService.ListWalletHistory(c.Request.Context(), c.Param("wallet_id"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"txn_history": txnHistory})
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"net/http"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// List the operations waiting for approval
+// GET /admin/approval/pending
+func ListPendingOperations(c *gin.Context) {
+	// List operations
+	operations, statusCode, err := service.ApprovalService.ListPendingOperations(c.Request.Context())
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operations": operations})
+}
+
+// Get an operation requested for approval, with its audit trail
+// GET /admin/approval/:operation_id
+func GetPendingOperation(c *gin.Context) {
+	// Get operation
+	operation, statusCode, err := service.ApprovalService.GetOperation(c.Request.Context(), c.Param("operation_id"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
+
+// Approve an operation requested by another user, and execute the operation
+// POST /admin/approval/approve
+func ApproveOperation(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		OperationID string `json:"operation_id" binding:"required"`
+		Note        string `json:"note"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Approve operation
+	operation, statusCode, err := service.ApprovalService.ApproveOperation(c.Request.Context(), currentUserID, req.OperationID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
+
+// Reject an operation requested by another user
+// POST /admin/approval/reject
+func RejectOperation(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		OperationID string `json:"operation_id" binding:"required"`
+		Note        string `json:"note" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Reject operation
+	operation, statusCode, err := service.ApprovalService.RejectOperation(c.Request.Context(), currentUserID, req.OperationID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
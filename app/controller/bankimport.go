@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"wallet-app-server/app/bankimport"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maximum size of an uploaded bank statement file
+const maxBankStatementSize = 10 << 20
+
+// Import a bank statement file and deposit the matched credits
+// POST /admin/bank-import (multipart form, fields: file, format, account)
+func ImportBankStatement(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Read the uploaded file
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if fileHeader.Size > maxBankStatementSize {
+		respondeWithError(c, http.StatusBadRequest, fmt.Errorf("file is larger than %d bytes", maxBankStatementSize))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// The format defaults to the one of the file extension
+	format := c.PostForm("format")
+	if format == "" {
+		format = bankStatementFormatOf(fileHeader.Filename)
+	}
+
+	// Import, the account of the statement is needed if the file doesn't give it
+	result, statusCode, err := service.BankImportService.ImportStatement(c.Request.Context(), currentUserID, fileHeader.Filename, format, c.PostForm("account"), content)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"result": result})
+}
+
+// List the bank statement lines waiting for review
+// GET /admin/bank-import/unmatched
+func ListUnmatchedBankLines(c *gin.Context) {
+	// List lines
+	lines, statusCode, err := service.BankImportService.ListUnmatchedLines(c.Request.Context())
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"lines": lines})
+}
+
+// Assign an unmatched bank statement line to a wallet
+// POST /admin/bank-import/assign
+func AssignBankLine(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		LineID   string `json:"line_id" binding:"required"`
+		WalletID string `json:"wallet_id" binding:"required"`
+		Note     string `json:"note"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Assign line
+	line, statusCode, err := service.BankImportService.AssignLine(c.Request.Context(), currentUserID, req.LineID, req.WalletID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"line": line})
+}
+
+// Ignore an unmatched bank statement line
+// POST /admin/bank-import/ignore
+func IgnoreBankLine(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		LineID string `json:"line_id" binding:"required"`
+		Note   string `json:"note" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Ignore line
+	line, statusCode, err := service.BankImportService.IgnoreLine(c.Request.Context(), currentUserID, req.LineID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"line": line})
+}
+
+// Guess the bank statement format from the file extension
+func bankStatementFormatOf(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xml":
+		return bankimport.FormatCamt053
+	case ".csv":
+		return bankimport.FormatCSV
+	}
+	return ""
+}
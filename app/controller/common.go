@@ -1,11 +1,20 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
+	"wallet-app-server/app/metrics"
+	"wallet-app-server/app/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
+// The request header identifying the user's device, used by the risk checks
+const HeaderDeviceID = "X-Device-ID"
+
 func resposneWithData(c *gin.Context, data gin.H) {
 	// Define response map
 	resp := gin.H{
@@ -26,3 +35,57 @@ func respondeWithError(c *gin.Context, statusCode int, err error) {
 		"error":   err.Error(),
 	})
 }
+
+// Observe the outcome of a requested deposit, withdraw or transfer in the metrics
+// A failed request is a rejection, unless it failed for insufficient balance or an internal error
+func observeTransaction(txnType string, amount decimal.Decimal, status string, statusCode int, err error) {
+	outcome := status
+	if err != nil {
+		outcome = metrics.OutcomeRejected
+		var serviceErr service.ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.ErrMessage == service.ErrMessageInsufficientBalance {
+			outcome = metrics.OutcomeInsufficientBalance
+		} else if statusCode >= http.StatusInternalServerError {
+			outcome = metrics.OutcomeError
+		}
+	}
+	metrics.ObserveTransaction(txnType, outcome, amount)
+}
+
+// An io.Writer for file download responses
+// The download headers are only set before the first byte is written,
+// so that an error response can still be returned if nothing has been written
+type downloadWriter struct {
+	c           *gin.Context
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func newDownloadWriter(c *gin.Context, contentType string, fileName string) *downloadWriter {
+	return &downloadWriter{c: c, contentType: contentType, fileName: fileName}
+}
+
+func (dw *downloadWriter) Write(p []byte) (int, error) {
+	if !dw.started {
+		dw.started = true
+		dw.c.Header("Content-Type", dw.contentType)
+		dw.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", dw.fileName))
+		dw.c.Status(http.StatusOK)
+	}
+	return dw.c.Writer.Write(p)
+}
+
+// Parse an optional RFC3339 timestamp query parameter
+// If the parameter is not given, return the default value
+func parseTimeQuery(c *gin.Context, key string, defaultValue time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, service.ServiceError{ErrType: service.ErrTypeInvalidRequestBody, ErrMessage: service.ErrMessageInvalidTimestamp}
+	}
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"wallet-app-server/app/health"
+	"wallet-app-server/app/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness of the server, always OK while the server can handle requests
+// GET /healthz
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"version": version.Get(),
+	})
+}
+
+// Readiness of the server, with the status and the latency of each component
+// Return 503 if any component is down or the server is shutting down
+// GET /readyz
+func Readyz(c *gin.Context) {
+	report := health.DefaultChecker.Check(c.Request.Context())
+	statusCode := http.StatusOK
+	if !report.Ready() {
+		statusCode = http.StatusServiceUnavailable
+	}
+	c.JSON(statusCode, gin.H{
+		"status":     report.Status,
+		"components": report.Components,
+		"version":    version.Get(),
+	})
+}
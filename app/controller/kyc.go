@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maximum size of an uploaded KYC document
+const maxKYCDocumentSize = 10 << 20
+
+// Upload a KYC document (JPEG, PNG or PDF)
+// POST /kyc/document (multipart form, fields: file, document_type)
+func UploadKYCDocument(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Read the uploaded file
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if fileHeader.Size > maxKYCDocumentSize {
+		respondeWithError(c, http.StatusBadRequest, fmt.Errorf("file is larger than %d bytes", maxKYCDocumentSize))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Upload document
+	document, statusCode, err := service.KYCService.UploadDocument(c.Request.Context(), currentUserID, c.PostForm("document_type"), fileHeader.Filename, content)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"document": document})
+}
+
+// Submit the uploaded documents for the review of a higher KYC level
+// POST /kyc/submit
+func SubmitKYC(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		TargetLevel string   `json:"target_level" binding:"required"`
+		DocumentIDs []string `json:"document_ids" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Submit
+	submission, statusCode, err := service.KYCService.Submit(c.Request.Context(), currentUserID, req.TargetLevel, req.DocumentIDs)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"submission": submission})
+}
+
+// Get user's KYC level, the capabilities of the level and the latest submission
+// GET /kyc/status
+func GetKYCStatus(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Get status
+	status, statusCode, err := service.KYCService.GetStatus(c.Request.Context(), currentUserID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"kyc": status})
+}
+
+// List the KYC submissions waiting for review
+// GET /admin/kyc/submission/pending
+func ListPendingKYCSubmissions(c *gin.Context) {
+	// List submissions
+	submissions, statusCode, err := service.KYCService.ListPendingSubmissions(c.Request.Context())
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"submissions": submissions})
+}
+
+// Download a KYC document for review
+// GET /admin/kyc/document/:document_id
+func DownloadKYCDocument(c *gin.Context) {
+	// Open document
+	document, reader, statusCode, err := service.KYCService.OpenDocument(c.Request.Context(), c.Param("document_id"))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+	defer reader.Close()
+
+	// Return the file
+	c.DataFromReader(http.StatusOK, document.FileSize, document.ContentType, reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", document.FileName),
+	})
+}
+
+// Approve a KYC submission, the user is raised to the target level
+// POST /admin/kyc/submission/approve
+func ApproveKYCSubmission(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		SubmissionID string `json:"submission_id" binding:"required"`
+		Reason       string `json:"reason"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Approve submission
+	submission, statusCode, err := service.KYCService.ApproveSubmission(c.Request.Context(), currentUserID, req.SubmissionID, req.Reason)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"submission": submission})
+}
+
+// Reject a KYC submission with the reason
+// POST /admin/kyc/submission/reject
+func RejectKYCSubmission(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		SubmissionID string `json:"submission_id" binding:"required"`
+		Reason       string `json:"reason" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Reject submission
+	submission, statusCode, err := service.KYCService.RejectSubmission(c.Request.Context(), currentUserID, req.SubmissionID, req.Reason)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"submission": submission})
+}
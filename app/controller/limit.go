@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// Get user's transaction limits, with the amount and count used and remaining in the current day and month
+// GET /user/limits
+func GetUserLimits(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Get limits
+	limits, statusCode, err := service.LimitService.GetUserLimits(c.Request.Context(), currentUserID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"limits": limits})
+}
+
+// Request to set a user's own limits of a transaction type, overriding the limits of the user tier
+// The limits are set after a second admin approves the request
+// POST /admin/limit/user
+func SetUserLimit(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		UserID        string          `json:"user_id" binding:"required"`
+		TxnType       string          `json:"txn_type" binding:"required"`
+		PerTxnAmount  decimal.Decimal `json:"per_txn_amount"`
+		DailyAmount   decimal.Decimal `json:"daily_amount"`
+		DailyCount    int64           `json:"daily_count"`
+		MonthlyAmount decimal.Decimal `json:"monthly_amount"`
+		MonthlyCount  int64           `json:"monthly_count"`
+		Note          string          `json:"note"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Request to set limit
+	userLimit := model.UserLimit{
+		UserID:        req.UserID,
+		TxnType:       req.TxnType,
+		PerTxnAmount:  req.PerTxnAmount,
+		DailyAmount:   req.DailyAmount,
+		DailyCount:    req.DailyCount,
+		MonthlyAmount: req.MonthlyAmount,
+		MonthlyCount:  req.MonthlyCount,
+	}
+	operation, statusCode, err := service.LimitService.SetUserLimit(c.Request.Context(), currentUserID, userLimit, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
+
+// Request to remove a user's own limits of a transaction type, so that the limits of the user tier apply again
+// The limits are removed after a second admin approves the request
+// POST /admin/limit/user/remove
+func RemoveUserLimit(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		UserID  string `json:"user_id" binding:"required"`
+		TxnType string `json:"txn_type" binding:"required"`
+		Note    string `json:"note"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Request to remove limit
+	operation, statusCode, err := service.LimitService.RemoveUserLimit(c.Request.Context(), currentUserID, req.UserID, req.TxnType, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"operation": operation})
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// Register an external bank account as a payout destination
+// POST /payout/destination
+func CreatePayoutDestination(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		IBAN       string `json:"iban" binding:"required"`
+		HolderName string `json:"holder_name" binding:"required"`
+		BIC        string `json:"bic"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Create destination
+	destination, statusCode, err := service.PayoutService.CreateDestination(c.Request.Context(), currentUserID, req.IBAN, req.HolderName, req.BIC)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"destination": destination})
+}
+
+// List user's payout destinations
+// GET /payout/destination/list
+func ListPayoutDestinations(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// List destinations
+	destinations, statusCode, err := service.PayoutService.ListDestinations(c.Request.Context(), currentUserID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"destinations": destinations})
+}
+
+// Withdraw from user's wallet to a verified payout destination
+// POST /payout/withdraw
+func RequestPayout(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		WalletID      string          `json:"wallet_id"`
+		DestinationID string          `json:"destination_id"`
+		Amount        decimal.Decimal `json:"amount"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Request payout
+	result, statusCode, err := service.PayoutService.RequestPayout(c.Request.Context(), currentUserID, req.WalletID, req.DestinationID, req.Amount, c.GetHeader(HeaderDeviceID))
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne, a payout held for the risk review isn't queued yet
+	if result.ReviewID != "" {
+		resposneWithData(c, gin.H{"status": result.Status, "review_id": result.ReviewID})
+		return
+	}
+	resposneWithData(c, gin.H{"status": result.Status, "payout": result.Payout})
+}
+
+// List user's payouts
+// GET /payout/list
+func ListPayouts(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// List payouts
+	payouts, statusCode, err := service.PayoutService.ListPayouts(c.Request.Context(), currentUserID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"payouts": payouts})
+}
+
+// Verify a payout destination
+// POST /admin/payout/destination/verify
+func VerifyPayoutDestination(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		DestinationID string `json:"destination_id" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Verify destination
+	destination, statusCode, err := service.PayoutService.VerifyDestination(c.Request.Context(), currentUserID, req.DestinationID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"destination": destination})
+}
+
+// Write the queued payouts to a pain.001 file now, without waiting for the background job
+// POST /admin/payout/batch
+func SubmitPayoutBatch(c *gin.Context) {
+	// Submit batch
+	batch, statusCode, err := service.PayoutService.SubmitBatch(c.Request.Context(), time.Now())
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"batch": batch})
+}
+
+// Mark a submitted payout as settled
+// POST /admin/payout/settle
+func SettlePayout(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		PayoutID string `json:"payout_id" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Settle payout
+	payout, statusCode, err := service.PayoutService.SettlePayout(c.Request.Context(), currentUserID, req.PayoutID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"payout": payout})
+}
+
+// Mark a payout as returned by the bank and re-credit the wallet
+// POST /admin/payout/return
+func ReturnPayout(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		PayoutID string `json:"payout_id" binding:"required"`
+		Reason   string `json:"reason" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Return payout
+	payout, statusCode, err := service.PayoutService.ReturnPayout(c.Request.Context(), currentUserID, req.PayoutID, req.Reason)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"payout": payout})
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"net/http"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// List the transactions held for the risk review
+// GET /admin/risk/review/pending
+func ListPendingRiskReviews(c *gin.Context) {
+	// List reviews
+	reviews, statusCode, err := service.RiskService.ListPendingReviews(c.Request.Context())
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"reviews": reviews})
+}
+
+// Approve a transaction held for the risk review, and make the transaction
+// POST /admin/risk/review/approve
+func ApproveRiskReview(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		ReviewID string `json:"review_id" binding:"required"`
+		Note     string `json:"note"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Approve review
+	review, statusCode, err := service.RiskService.ApproveReview(c.Request.Context(), currentUserID, req.ReviewID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"review": review})
+}
+
+// Reject a transaction held for the risk review
+// POST /admin/risk/review/reject
+func RejectRiskReview(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		ReviewID string `json:"review_id" binding:"required"`
+		Note     string `json:"note" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Reject review
+	review, statusCode, err := service.RiskService.RejectReview(c.Request.Context(), currentUserID, req.ReviewID, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"review": review})
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"net/http"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reload the sanctions lists from the configured files
+// POST /admin/screening/reload
+func ReloadScreeningLists(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Reload lists
+	listVersion, statusCode, err := service.ScreeningService.ReloadLists(c.Request.Context(), currentUserID)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"list_version": listVersion})
+}
+
+// List the open screening cases
+// GET /admin/screening/case/open
+func ListOpenScreeningCases(c *gin.Context) {
+	// List cases
+	cases, statusCode, err := service.ScreeningService.ListOpenCases(c.Request.Context())
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"cases": cases})
+}
+
+// Close an open screening case as cleared or confirmed
+// POST /admin/screening/case/close
+func CloseScreeningCase(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		CaseID     string `json:"case_id" binding:"required"`
+		Resolution string `json:"resolution" binding:"required"`
+		Note       string `json:"note" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Close case
+	screeningCase, statusCode, err := service.ScreeningService.CloseCase(c.Request.Context(), currentUserID, req.CaseID, req.Resolution, req.Note)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"case": screeningCase})
+}
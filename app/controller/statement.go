@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/service"
+	"wallet-app-server/app/statement"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Download wallet's statement in the time range (from, to]
+// The statement is streamed in CSV (default), NDJSON or PDF format
+// GET /wallet/:wallet_id/statement?from=2025-06-01T00:00:00Z&to=2025-07-01T00:00:00Z&format=csv
+func Statement(c *gin.Context) {
+	format := c.DefaultQuery("format", statement.FormatCSV)
+	if format != statement.FormatCSV && format != statement.FormatNDJSON && format != statement.FormatPDF {
+		respondeWithError(c, http.StatusBadRequest, service.ServiceError{ErrType: service.ErrTypeInvalidRequestBody, ErrMessage: service.ErrMessageInvalidFormat})
+		return
+	}
+	exportStatement(c, format)
+}
+
+// Export wallet's activity in the time range (from, to] for accounting software
+// The file is streamed in OFX 2.x (default) or ISO 20022 camt.053 format
+// GET /wallet/:wallet_id/export?from=2025-06-01T00:00:00Z&to=2025-07-01T00:00:00Z&format=ofx
+func Export(c *gin.Context) {
+	format := c.DefaultQuery("format", statement.FormatOFX)
+	if !statement.IsBankingFormat(format) {
+		respondeWithError(c, http.StatusBadRequest, service.ServiceError{ErrType: service.ErrTypeInvalidRequestBody, ErrMessage: service.ErrMessageInvalidFormat})
+		return
+	}
+	exportStatement(c, format)
+}
+
+// Stream the statement of the wallet in the path in the given format
+func exportStatement(c *gin.Context, format string) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse path and query parameters
+	walletID := c.Param("wallet_id")
+	if c.Query("from") == "" {
+		respondeWithError(c, http.StatusBadRequest, service.ServiceError{ErrType: service.ErrTypeInvalidRequestBody, ErrMessage: service.ErrMessageInvalidTimestamp})
+		return
+	}
+	fromTime, err := parseTimeQuery(c, "from", time.Time{})
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	toTime, err := parseTimeQuery(c, "to", time.Now())
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Create statement writer
+	fileName := fmt.Sprintf("statement-%s-%s-%s.%s", walletID, fromTime.Format("20060102"), toTime.Format("20060102"), statement.FileExtension(format))
+	dw := newDownloadWriter(c, statement.ContentType(format), fileName)
+	writer, err := statement.NewWriter(format, dw)
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, service.ServiceError{ErrType: service.ErrTypeInvalidRequestBody, ErrMessage: service.ErrMessageInvalidFormat})
+		return
+	}
+
+	// A statement of a long time range may take longer to stream than the write timeout of the server,
+	// so the write deadline is cleared, the statement is streamed until it's complete or the client disconnects
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warnf("Failed to clear the write deadline of the statement, walletID: %s, err: %s", walletID, err.Error())
+	}
+
+	// Export statement
+	statusCode, err := service.StatementService.ExportStatement(c.Request.Context(), currentUserID, walletID, fromTime, toTime, writer)
+	if err != nil {
+		// The statement has been partially sent, the response can only be aborted
+		if dw.started {
+			logger.Errorf("Statement aborted, walletID: %s, err: %s", walletID, err.Error())
+			c.Abort()
+			return
+		}
+		respondeWithError(c, statusCode, err)
+		return
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"wallet-app-server/app/constant"
 	"wallet-app-server/app/service"
 
 	"github.com/gin-gonic/gin"
@@ -26,14 +27,50 @@ func Transfer(c *gin.Context) {
 	}
 
 	// Make transfer
-	txnID, statusCode, err := service.TransactionService.Transfer(currentUserID, req.FromWalletID, req.ToWalletID, req.Amount)
+	result, statusCode, err := service.TransactionService.Transfer(c.Request.Context(), currentUserID, req.FromWalletID, req.ToWalletID, req.Amount, c.GetHeader(HeaderDeviceID))
+	observeTransaction(constant.TxnTypeTransfer, req.Amount, result.Status, statusCode, err)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne, a transfer held for the risk review or for approval has no txn_id yet
+	if result.ReviewID != "" {
+		resposneWithData(c, gin.H{"status": result.Status, "review_id": result.ReviewID})
+		return
+	}
+	if result.OperationID != "" {
+		resposneWithData(c, gin.H{"status": result.Status, "operation_id": result.OperationID})
+		return
+	}
+	resposneWithData(c, gin.H{"status": result.Status, "txn_id": result.TxnID})
+}
+
+// Quote the fee of a transaction before making it
+// POST /transaction/quote
+func Quote(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		TxnType string          `json:"txn_type"`
+		Amount  decimal.Decimal `json:"amount"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Quote fee
+	quote, statusCode, err := service.FeeService.Quote(c.Request.Context(), currentUserID, req.TxnType, req.Amount)
 	if err != nil {
 		respondeWithError(c, statusCode, err)
 		return
 	}
 
 	// Return resposne
-	resposneWithData(c, gin.H{"txn_id": txnID})
+	resposneWithData(c, gin.H{"quote": quote})
 }
 
 // List user wallet's transaction history
@@ -52,7 +89,7 @@ func History(c *gin.Context) {
 	}
 
 	// List transaction history
-	txnHistory, statusCode, err := service.TransactionService.ListHistory(currentUserID, req.WalletID)
+	txnHistory, statusCode, err := service.TransactionService.ListHistory(c.Request.Context(), currentUserID, req.WalletID)
 	if err != nil {
 		respondeWithError(c, statusCode, err)
 		return
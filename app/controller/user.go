@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"wallet-app-server/app/metrics"
 	"wallet-app-server/app/service"
 
 	"github.com/gin-gonic/gin"
@@ -21,12 +22,65 @@ func Login(c *gin.Context) {
 	}
 
 	// User login
-	accessToken, statusCode, err := service.UserService.Login(req.Username, req.Password)
+	accessToken, statusCode, err := service.UserService.Login(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
+		metrics.ObserveLogin(metrics.LoginFailure)
 		respondeWithError(c, statusCode, err)
 		return
 	}
+	metrics.ObserveLogin(metrics.LoginSuccess)
 
 	// Return resposne
 	resposneWithData(c, gin.H{"access_token": accessToken})
 }
+
+// User registration, the user gets a default wallet
+// POST /user/register
+func Register(c *gin.Context) {
+	// Parse request body
+	req := struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+		FullName string `json:"full_name" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Register user
+	result, statusCode, err := service.UserService.Register(c.Request.Context(), req.Username, req.Password, req.FullName)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"user": result.User, "wallet": result.Wallet})
+}
+
+// Update user profile
+// POST /user/profile
+func UpdateProfile(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse request body
+	req := struct {
+		FullName string `json:"full_name" binding:"required"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Update profile
+	user, statusCode, err := service.UserService.UpdateProfile(c.Request.Context(), currentUserID, req.FullName)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne
+	resposneWithData(c, gin.H{"user": user})
+}
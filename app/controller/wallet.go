@@ -2,6 +2,8 @@ package controller
 
 import (
 	"net/http"
+	"time"
+	"wallet-app-server/app/constant"
 	"wallet-app-server/app/service"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +17,7 @@ func ListWallets(c *gin.Context) {
 	currentUserID := c.GetString("current_user_id")
 
 	// List wallets
-	wallets, statusCode, err := service.WalletService.ListUserWallets(currentUserID)
+	wallets, statusCode, err := service.WalletService.ListUserWallets(c.Request.Context(), currentUserID)
 	if err != nil {
 		respondeWithError(c, statusCode, err)
 		return
@@ -41,7 +43,7 @@ func CheckWalletBalance(c *gin.Context) {
 	}
 
 	// Check wallet balance
-	balance, statusCode, err := service.WalletService.CheckWalletBallance(currentUserID, req.WalletID)
+	balance, statusCode, err := service.WalletService.CheckWalletBallance(c.Request.Context(), currentUserID, req.WalletID)
 	if err != nil {
 		respondeWithError(c, statusCode, err)
 		return
@@ -68,7 +70,8 @@ func Deposit(c *gin.Context) {
 	}
 
 	// Deposit to user wallet
-	latestBalance, statusCode, err := service.WalletService.Deposit(currentUserID, req.WalletID, req.Amount)
+	latestBalance, statusCode, err := service.WalletService.Deposit(c.Request.Context(), currentUserID, req.WalletID, req.Amount)
+	observeTransaction(constant.TxnTypeDeposit, req.Amount, constant.TxnStatusCompleted, statusCode, err)
 	if err != nil {
 		respondeWithError(c, statusCode, err)
 		return
@@ -95,12 +98,54 @@ func Withdraw(c *gin.Context) {
 	}
 
 	// Withdraw from user wallet
-	latestBalance, statusCode, err := service.WalletService.Withdraw(currentUserID, req.WalletID, req.Amount)
+	result, statusCode, err := service.WalletService.Withdraw(c.Request.Context(), currentUserID, req.WalletID, req.Amount, c.GetHeader(HeaderDeviceID))
+	observeTransaction(constant.TxnTypeWithdraw, req.Amount, result.Status, statusCode, err)
+	if err != nil {
+		respondeWithError(c, statusCode, err)
+		return
+	}
+
+	// Return resposne, a withdraw held for the risk review hasn't changed the balance yet
+	if result.ReviewID != "" {
+		resposneWithData(c, gin.H{"status": result.Status, "review_id": result.ReviewID})
+		return
+	}
+	resposneWithData(c, gin.H{"status": result.Status, "balance": result.Balance})
+}
+
+// Get wallet's balance at a specific time, or the current balance if as_of is not given
+// GET /wallet/:wallet_id/balance?as_of=2025-06-30T23:59:59Z
+func GetWalletBalance(c *gin.Context) {
+	// Get current user ID
+	currentUserID := c.GetString("current_user_id")
+
+	// Parse path and query parameters
+	walletID := c.Param("wallet_id")
+	asOfParam := c.Query("as_of")
+
+	// Current balance
+	if asOfParam == "" {
+		balance, statusCode, err := service.WalletService.CheckWalletBallance(c.Request.Context(), currentUserID, walletID)
+		if err != nil {
+			respondeWithError(c, statusCode, err)
+			return
+		}
+		resposneWithData(c, gin.H{"wallet_id": walletID, "balance": balance})
+		return
+	}
+
+	// Historical balance
+	asOf, err := parseTimeQuery(c, "as_of", time.Time{})
+	if err != nil {
+		respondeWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	balance, statusCode, err := service.WalletService.GetWalletBalanceAsOf(c.Request.Context(), currentUserID, walletID, asOf)
 	if err != nil {
 		respondeWithError(c, statusCode, err)
 		return
 	}
 
 	// Return resposne
-	resposneWithData(c, gin.H{"balance": latestBalance})
+	resposneWithData(c, gin.H{"wallet_id": walletID, "balance": balance, "as_of": asOf})
 }
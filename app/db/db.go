@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"time"
 	"wallet-app-server/app/config"
 	"wallet-app-server/app/logger"
 
@@ -10,20 +12,164 @@ import (
 	"gorm.io/gorm"
 )
 
-// A global GORM DB
+// Max wait between the connection retries at startup
+const maxRetryBackoff = 30 * time.Second
+
+// Interval of checking whether the connections are still in use, while waiting for them on shutdown
+const idleCheckInterval = 50 * time.Millisecond
+
+// A global GORM DB, of the primary
 var DB *gorm.DB
 
+// The GORM DB of the read replica, nil if no replica is configured
+// Use Reader rather than it directly
+var Replica *gorm.DB
+
+// Wait between the connection retries, replaced in the tests
+var sleep = time.Sleep
+
 // Init DB, must be called before using the DB
-func Init() {
+// Connect to the primary, and to the read replica if configured, retrying with a backoff if the DB isn't up yet
+// Return the error if the retries run out
+func Init() error {
 	dbConf := config.Cfg.DB
-	connString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s search_path=%s sslmode=%s",
-		dbConf.Host, dbConf.Port, dbConf.Username, dbConf.Password, dbConf.DBName, dbConf.Schema, dbConf.SSLMode)
-	logger.Debug("DB connection string:", connString)
-	db, err := gorm.Open(postgres.Open(connString))
+	db, err := open("primary", dbConf.Host, dbConf.Port)
 	if err != nil {
-		logger.Error("DB init error: ", err.Error())
-		os.Exit(-1)
+		return err
 	}
 	DB = db
+	if dbConf.ReplicaHost != "" {
+		replicaPort := dbConf.ReplicaPort
+		if replicaPort == 0 {
+			replicaPort = dbConf.Port
+		}
+		replica, err := open("replica", dbConf.ReplicaHost, replicaPort)
+		if err != nil {
+			return fmt.Errorf("replica: %w", err)
+		}
+		Replica = replica
+	}
 	logger.Info("DB init sucess")
+	return nil
+}
+
+// The DB for the reads which can be served by the read replica, i.e. the balances and the transaction history,
+// the primary if no replica is configured
+// The replica may lag behind the primary, so never use it for a read deciding a write, nor in a transaction with writes,
+// the locked reads and the writes always go to the primary (DB)
+func Reader() *gorm.DB {
+	if Replica != nil {
+		return Replica
+	}
+	return DB
+}
+
+// The DB for the DB transactions of the writes of a request or a job, with its context but not cancelled with it,
+// so that a client disconnect or a shutdown can't abort a transaction halfway, the statement timeout still applies
+func Writer(ctx context.Context) *gorm.DB {
+	return DB.WithContext(context.WithoutCancel(ctx))
+}
+
+// Open the connection to the DB at the host, with the pool settings and the statement timeout
+func open(name string, host string, port int) (*gorm.DB, error) {
+	dbConf := config.Cfg.DB
+	connString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s search_path=%s sslmode=%s",
+		host, port, dbConf.Username, dbConf.Password, dbConf.DBName, dbConf.Schema, dbConf.SSLMode)
+	// The password is masked by the logger, as every secret in a log line
+	logger.Debugf("DB %s connection string: %s", name, connString)
+	var db *gorm.DB
+	backoff := time.Duration(dbConf.ConnectRetryBackoffInMillis) * time.Millisecond
+	err := retry(dbConf.ConnectRetries, backoff, func() error {
+		var err error
+		db, err = gorm.Open(postgres.Open(connString))
+		return err
+	}, func(attempt int, wait time.Duration, err error) {
+		logger.Warnf("Failed to connect to DB %s, retrying in %s, attempt: %d/%d, err: %s", name, wait, attempt, dbConf.ConnectRetries, err.Error())
+	})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(dbConf.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(dbConf.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(dbConf.ConnMaxLifetimeInSecs) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(dbConf.ConnMaxIdleTimeInSecs) * time.Second)
+	if err := registerStatementTimeout(db, time.Duration(dbConf.StatementTimeoutInMillis)*time.Millisecond); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Call the function until it succeeds or the retries run out, and return the last error
+// Wait the backoff before the first retry, and double it after each retry, up to maxRetryBackoff
+func retry(retries int, backoff time.Duration, fn func() error, onRetry func(attempt int, wait time.Duration, err error)) error {
+	err := fn()
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		onRetry(attempt, backoff, err)
+		sleep(backoff)
+		backoff = min(backoff*2, maxRetryBackoff)
+		err = fn()
+	}
+	return err
+}
+
+// Ping the DB, used by the readiness check
+func Ping(ctx context.Context) error {
+	return ping(ctx, DB)
+}
+
+// Ping the read replica, used by the readiness check if a replica is configured
+func PingReplica(ctx context.Context) error {
+	return ping(ctx, Replica)
+}
+
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Wait until no connection of the primary is in use, or the context is done, called on shutdown before Close
+// The DB transactions of the writes aren't cancelled with their request (see Writer), so they may still run
+// after the requests are drained, and closing the DB doesn't wait for them
+func WaitIdle(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		inUse := sqlDB.Stats().InUse
+		if inUse == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d connections still in use: %w", inUse, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close the connections of the DB and the replica, the DB can't be used after closed
+func Close() error {
+	var errs []error
+	for _, db := range []*gorm.DB{DB, Replica} {
+		if db == nil {
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, sqlDB.Close())
+	}
+	return errors.Join(errs...)
 }
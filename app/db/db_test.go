@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestRetry(t *testing.T) {
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	// Succeed at the third attempt
+	calls := 0
+	err := retry(5, 10*time.Second, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, func(attempt int, wait time.Duration, err error) {})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, calls, 3)
+	assert.Equal(t, waits, []time.Duration{10 * time.Second, 20 * time.Second})
+
+	// The retries run out, the backoff is capped
+	waits, calls = nil, 0
+	err = retry(3, 10*time.Second, func() error {
+		calls++
+		return errors.New("connection refused")
+	}, func(attempt int, wait time.Duration, err error) {})
+	assert.Equal(t, err.Error(), "connection refused")
+	assert.Equal(t, calls, 4)
+	assert.Equal(t, waits, []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second})
+}
+
+func TestStatementTimeout(t *testing.T) {
+	// The statements are only built, no DB is needed
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, registerStatementTimeout(db, 5*time.Second), nil)
+	var deadlines []time.Time
+	var contexts []context.Context
+	db.Callback().Query().After("db:before_query").Register("test:deadline", func(db *gorm.DB) {
+		deadline, _ := db.Statement.Context.Deadline()
+		deadlines = append(deadlines, deadline)
+		contexts = append(contexts, db.Statement.Context)
+	})
+
+	var balances []string
+	conn := db.WithContext(context.Background())
+	conn.Table("wallet").Pluck("balance", &balances)
+	conn.Table("wallet").Pluck("balance", &balances)
+	// Each statement has its own timeout, released after the statement
+	assert.Equal(t, len(deadlines), 2)
+	assert.Equal(t, time.Until(deadlines[0]) > 4*time.Second, true)
+	assert.Equal(t, deadlines[1].After(deadlines[0]), true)
+	assert.Equal(t, contexts[0].Err(), context.Canceled)
+
+	// The earlier deadline of the context is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	db.WithContext(ctx).Table("wallet").Pluck("balance", &balances)
+	assert.Equal(t, time.Until(deadlines[2]) <= time.Second, true)
+
+	// No timeout
+	db.WithContext(WithoutStatementTimeout(context.Background())).Table("wallet").Pluck("balance", &balances)
+	assert.Equal(t, deadlines[3].IsZero(), true)
+
+	// A raw query read with Find has the timeout too, released after the statement
+	db.WithContext(context.Background()).Raw("SELECT balance FROM wallet").Find(&balances)
+	assert.Equal(t, time.Until(deadlines[4]) > 4*time.Second, true)
+	assert.Equal(t, contexts[4].Err(), context.Canceled)
+
+	// The Row and Rows statements are read after the callbacks, they have no timeout which would be left running
+	var rowContexts []context.Context
+	db.Callback().Row().Before("gorm:row").Register("test:deadline", func(db *gorm.DB) {
+		rowContexts = append(rowContexts, db.Statement.Context)
+	})
+	conn.Table("wallet").Select("balance").Row()
+	conn.Table("wallet").Select("balance").Rows()
+	assert.Equal(t, len(rowContexts), 2)
+	for _, ctx := range rowContexts {
+		_, ok := ctx.Deadline()
+		assert.Equal(t, ok, false)
+	}
+}
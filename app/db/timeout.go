@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Key of the cancel function of the statement timeout in the GORM statement
+const cancelKey = "db:cancel_timeout"
+
+type noTimeoutKey struct{}
+
+// Run the statements with the context without the statement timeout, e.g. the migrations
+func WithoutStatementTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noTimeoutKey{}, true)
+}
+
+// Register the GORM callbacks running every statement with the timeout, no timeout if not positive
+// The timeout is added to the context of the statement, so a statement whose context has an earlier deadline
+// (e.g. of the request) keeps it
+// The Row and Rows statements (including Scan, which reads through Rows) are read by the caller after the callbacks
+// return, so a timeout couldn't be released after them, they have no timeout. So the repositories read with
+// Find, First, Take, Pluck or Count, which read the rows inside the callbacks, and use Rows only to stream
+// e.g. the statement exports
+func registerStatementTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("db:before_create", beforeStatement(timeout)),
+		callback.Create().After("gorm:create").Register("db:after_create", afterStatement),
+		callback.Query().Before("gorm:query").Register("db:before_query", beforeStatement(timeout)),
+		callback.Query().After("gorm:query").Register("db:after_query", afterStatement),
+		callback.Update().Before("gorm:update").Register("db:before_update", beforeStatement(timeout)),
+		callback.Update().After("gorm:update").Register("db:after_update", afterStatement),
+		callback.Delete().Before("gorm:delete").Register("db:before_delete", beforeStatement(timeout)),
+		callback.Delete().After("gorm:delete").Register("db:after_delete", afterStatement),
+		callback.Raw().Before("gorm:raw").Register("db:before_raw", beforeStatement(timeout)),
+		callback.Raw().After("gorm:raw").Register("db:after_raw", afterStatement),
+	)
+}
+
+func beforeStatement(timeout time.Duration) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if ctx.Value(noTimeoutKey{}) != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		db.Statement.Context = ctx
+		db.InstanceSet(cancelKey, cancel)
+	}
+}
+
+// Release the context of the statement
+// Every statement of a session has its own GORM statement, so the next one gets its own timeout
+func afterStatement(db *gorm.DB) {
+	value, ok := db.InstanceGet(cancelKey)
+	if !ok {
+		return
+	}
+	value.(context.CancelFunc)()
+}
@@ -8,11 +8,15 @@ import (
 )
 
 type User struct {
-	UserID     string       `gorm:"primaryKey;column:user_id"`
-	UserName   string       `gorm:"column:user_name"`
-	UserHash   string       `gorm:"column:user_hash"`
-	CreateTime time.Time    `gorm:"column:create_time"`
-	UpdateTime sql.NullTime `gorm:"column:update_time"`
+	UserID     string         `gorm:"primaryKey;column:user_id"`
+	UserName   string         `gorm:"column:user_name"`
+	UserHash   string         `gorm:"column:user_hash"`
+	UserTier   string         `gorm:"column:user_tier"`
+	FullName   sql.NullString `gorm:"column:full_name"`
+	KYCLevel   string         `gorm:"column:kyc_level"`
+	UserRole   string         `gorm:"column:user_role"`
+	CreateTime time.Time      `gorm:"column:create_time"`
+	UpdateTime sql.NullTime   `gorm:"column:update_time"`
 }
 
 func (u *User) TableName() string {
@@ -20,17 +24,31 @@ func (u *User) TableName() string {
 }
 
 type Wallet struct {
-	WalletID   string          `gorm:"primaryKey;column:wallet_id"`
-	WalletName string          `gorm:"column:wallet_name"`
-	Balance    decimal.Decimal `gorm:"column:balance"`
-	CreateTime time.Time       `gorm:"column:create_time"`
-	UpdateTime sql.NullTime    `gorm:"column:update_time"`
+	WalletID      string          `gorm:"primaryKey;column:wallet_id"`
+	WalletName    string          `gorm:"column:wallet_name"`
+	WalletType    string          `gorm:"column:wallet_type"`
+	WalletStatus  string          `gorm:"column:wallet_status"`
+	ReferenceCode sql.NullString  `gorm:"column:reference_code"`
+	Balance       decimal.Decimal `gorm:"column:balance"`
+	CreateTime    time.Time       `gorm:"column:create_time"`
+	UpdateTime    sql.NullTime    `gorm:"column:update_time"`
 }
 
 func (w *Wallet) TableName() string {
 	return "wallet"
 }
 
+type UserWalletBridge struct {
+	UserID     string    `gorm:"primaryKey;column:user_id"`
+	WalletID   string    `gorm:"primaryKey;column:wallet_id"`
+	Seq        int       `gorm:"column:seq"`
+	CreateTime time.Time `gorm:"column:create_time"`
+}
+
+func (uwb *UserWalletBridge) TableName() string {
+	return "user_wallet_bridge"
+}
+
 type TxnHistory struct {
 	TxnID        string          `gorm:"primaryKey;column:txn_id"`
 	FromWalletID string          `gorm:"column:from_wallet_id"`
@@ -38,12 +56,36 @@ type TxnHistory struct {
 	TxnType      string          `gorm:"column:txn_type"`
 	TxnAmount    decimal.Decimal `gorm:"column:txn_amount"`
 	TxnTime      time.Time       `gorm:"column:txn_time"`
+	ParentTxnID  sql.NullString  `gorm:"column:parent_txn_id"`
 }
 
 func (th *TxnHistory) TableName() string {
 	return "txn_history"
 }
 
+type TxnPosting struct {
+	PostingID     string          `gorm:"primaryKey;column:posting_id"`
+	TxnID         string          `gorm:"column:txn_id"`
+	WalletID      string          `gorm:"column:wallet_id"`
+	PostingAmount decimal.Decimal `gorm:"column:posting_amount"`
+	PostingTime   time.Time       `gorm:"column:posting_time"`
+}
+
+func (tp *TxnPosting) TableName() string {
+	return "txn_posting"
+}
+
+type WalletBalanceSnapshot struct {
+	WalletID     string          `gorm:"primaryKey;column:wallet_id"`
+	SnapshotTime time.Time       `gorm:"primaryKey;column:snapshot_time"`
+	Balance      decimal.Decimal `gorm:"column:balance"`
+	CreateTime   time.Time       `gorm:"column:create_time"`
+}
+
+func (wbs *WalletBalanceSnapshot) TableName() string {
+	return "wallet_balance_snapshot"
+}
+
 type UserActivity struct {
 	UserActID     string         `gorm:"primaryKey;column:user_act_id"`
 	UserID        string         `gorm:"column:user_id"`
@@ -56,3 +98,280 @@ type UserActivity struct {
 func (ua *UserActivity) TableName() string {
 	return "user_activity"
 }
+
+type BankStatementImport struct {
+	ImportID       string    `gorm:"primaryKey;column:import_id"`
+	FileName       string    `gorm:"column:file_name"`
+	FileFormat     string    `gorm:"column:file_format"`
+	FileHash       string    `gorm:"column:file_hash"`
+	LineCount      int       `gorm:"column:line_count"`
+	MatchedCount   int       `gorm:"column:matched_count"`
+	UnmatchedCount int       `gorm:"column:unmatched_count"`
+	DuplicateCount int       `gorm:"column:duplicate_count"`
+	ImportedBy     string    `gorm:"column:imported_by"`
+	ImportTime     time.Time `gorm:"column:import_time"`
+}
+
+func (bsi *BankStatementImport) TableName() string {
+	return "bank_statement_import"
+}
+
+type BankStatementLine struct {
+	LineID      string          `gorm:"primaryKey;column:line_id"`
+	ImportID    string          `gorm:"column:import_id"`
+	LineHash    string          `gorm:"column:line_hash"`
+	Account     string          `gorm:"column:account"`
+	ContentHash sql.NullString  `gorm:"column:content_hash"`
+	BankRef     string          `gorm:"column:bank_ref"`
+	BookingTime time.Time       `gorm:"column:booking_time"`
+	Amount      decimal.Decimal `gorm:"column:amount"`
+	Currency    string          `gorm:"column:currency"`
+	Reference   string          `gorm:"column:reference"`
+	DebtorName  string          `gorm:"column:debtor_name"`
+	LineStatus  string          `gorm:"column:line_status"`
+	WalletID    sql.NullString  `gorm:"column:wallet_id"`
+	TxnID       sql.NullString  `gorm:"column:txn_id"`
+	ReviewBy    sql.NullString  `gorm:"column:review_by"`
+	ReviewNote  sql.NullString  `gorm:"column:review_note"`
+	ReviewTime  sql.NullTime    `gorm:"column:review_time"`
+	CreateTime  time.Time       `gorm:"column:create_time"`
+}
+
+func (bsl *BankStatementLine) TableName() string {
+	return "bank_statement_line"
+}
+
+type PayoutDestination struct {
+	DestinationID     string         `gorm:"primaryKey;column:destination_id"`
+	UserID            string         `gorm:"column:user_id"`
+	IBAN              string         `gorm:"column:iban"`
+	HolderName        string         `gorm:"column:holder_name"`
+	BIC               sql.NullString `gorm:"column:bic"`
+	DestinationStatus string         `gorm:"column:destination_status"`
+	VerifiedBy        sql.NullString `gorm:"column:verified_by"`
+	VerifiedTime      sql.NullTime   `gorm:"column:verified_time"`
+	CreateTime        time.Time      `gorm:"column:create_time"`
+}
+
+func (pd *PayoutDestination) TableName() string {
+	return "payout_destination"
+}
+
+type PayoutBatch struct {
+	BatchID     string          `gorm:"primaryKey;column:batch_id"`
+	MsgID       string          `gorm:"column:msg_id"`
+	PayoutCount int             `gorm:"column:payout_count"`
+	TotalAmount decimal.Decimal `gorm:"column:total_amount"`
+	FileName    string          `gorm:"column:file_name"`
+	CreateTime  time.Time       `gorm:"column:create_time"`
+}
+
+func (pb *PayoutBatch) TableName() string {
+	return "payout_batch"
+}
+
+type Payout struct {
+	PayoutID      string          `gorm:"primaryKey;column:payout_id"`
+	UserID        string          `gorm:"column:user_id"`
+	WalletID      string          `gorm:"column:wallet_id"`
+	DestinationID string          `gorm:"column:destination_id"`
+	Amount        decimal.Decimal `gorm:"column:amount"`
+	Currency      string          `gorm:"column:currency"`
+	TxnID         string          `gorm:"column:txn_id"`
+	PayoutStatus  string          `gorm:"column:payout_status"`
+	BatchID       sql.NullString  `gorm:"column:batch_id"`
+	ReturnReason  sql.NullString  `gorm:"column:return_reason"`
+	ReturnTxnID   sql.NullString  `gorm:"column:return_txn_id"`
+	// The version of the sanctions lists the account holder was last screened against
+	ScreeningListVersion sql.NullString `gorm:"column:screening_list_version"`
+	CreateTime           time.Time      `gorm:"column:create_time"`
+	UpdateTime           sql.NullTime   `gorm:"column:update_time"`
+}
+
+func (p *Payout) TableName() string {
+	return "payout"
+}
+
+type UserLimit struct {
+	UserID        string          `gorm:"primaryKey;column:user_id"`
+	TxnType       string          `gorm:"primaryKey;column:txn_type"`
+	PerTxnAmount  decimal.Decimal `gorm:"column:per_txn_amount"`
+	DailyAmount   decimal.Decimal `gorm:"column:daily_amount"`
+	DailyCount    int64           `gorm:"column:daily_count"`
+	MonthlyAmount decimal.Decimal `gorm:"column:monthly_amount"`
+	MonthlyCount  int64           `gorm:"column:monthly_count"`
+	UpdateBy      string          `gorm:"column:update_by"`
+	UpdateTime    time.Time       `gorm:"column:update_time"`
+}
+
+func (ul *UserLimit) TableName() string {
+	return "user_limit"
+}
+
+type UserDevice struct {
+	UserID        string    `gorm:"primaryKey;column:user_id"`
+	DeviceID      string    `gorm:"primaryKey;column:device_id"`
+	FirstSeenTime time.Time `gorm:"column:first_seen_time"`
+	LastSeenTime  time.Time `gorm:"column:last_seen_time"`
+}
+
+func (ud *UserDevice) TableName() string {
+	return "user_device"
+}
+
+type RiskDecision struct {
+	DecisionID   string          `gorm:"primaryKey;column:decision_id"`
+	UserID       string          `gorm:"column:user_id"`
+	TxnType      string          `gorm:"column:txn_type"`
+	FromWalletID string          `gorm:"column:from_wallet_id"`
+	ToWalletID   sql.NullString  `gorm:"column:to_wallet_id"`
+	Amount       decimal.Decimal `gorm:"column:amount"`
+	DeviceID     sql.NullString  `gorm:"column:device_id"`
+	RiskScore    int             `gorm:"column:risk_score"`
+	Decision     string          `gorm:"column:decision"`
+	MatchedRules string          `gorm:"column:matched_rules"`
+	TxnID        sql.NullString  `gorm:"column:txn_id"`
+	ReviewID     sql.NullString  `gorm:"column:review_id"`
+	Outcome      sql.NullString  `gorm:"column:outcome"`
+	CreateTime   time.Time       `gorm:"column:create_time"`
+}
+
+func (rd *RiskDecision) TableName() string {
+	return "risk_decision"
+}
+
+type RiskReview struct {
+	ReviewID     string          `gorm:"primaryKey;column:review_id"`
+	UserID       string          `gorm:"column:user_id"`
+	TxnType      string          `gorm:"column:txn_type"`
+	FromWalletID string          `gorm:"column:from_wallet_id"`
+	ToWalletID   sql.NullString  `gorm:"column:to_wallet_id"`
+	Amount       decimal.Decimal `gorm:"column:amount"`
+	DeviceID     sql.NullString  `gorm:"column:device_id"`
+	RiskScore    int             `gorm:"column:risk_score"`
+	MatchedRules string          `gorm:"column:matched_rules"`
+	ReviewStatus string          `gorm:"column:review_status"`
+	TxnID        sql.NullString  `gorm:"column:txn_id"`
+	ReviewBy     sql.NullString  `gorm:"column:review_by"`
+	ReviewNote   sql.NullString  `gorm:"column:review_note"`
+	// The destination of a held payout, the approved withdraw is queued to it
+	PayoutDestinationID sql.NullString `gorm:"column:payout_destination_id"`
+	CreateTime          time.Time      `gorm:"column:create_time"`
+	UpdateTime          sql.NullTime   `gorm:"column:update_time"`
+}
+
+func (rr *RiskReview) TableName() string {
+	return "risk_review"
+}
+
+type ScreeningListVersion struct {
+	ListVersion string    `gorm:"primaryKey;column:list_version"`
+	EntryCount  int       `gorm:"column:entry_count"`
+	LoadBy      string    `gorm:"column:load_by"`
+	LoadTime    time.Time `gorm:"primaryKey;column:load_time"`
+}
+
+func (slv *ScreeningListVersion) TableName() string {
+	return "screening_list_version"
+}
+
+type ScreeningCase struct {
+	CaseID           string          `gorm:"primaryKey;column:case_id"`
+	UserID           sql.NullString  `gorm:"column:user_id"`
+	SubjectRef       string          `gorm:"column:subject_ref"`
+	ScreenedName     string          `gorm:"column:screened_name"`
+	ScreeningContext string          `gorm:"column:screening_context"`
+	ListVersion      string          `gorm:"column:list_version"`
+	MatchScore       decimal.Decimal `gorm:"column:match_score"`
+	Matches          string          `gorm:"column:matches"`
+	CaseStatus       string          `gorm:"column:case_status"`
+	ReviewBy         sql.NullString  `gorm:"column:review_by"`
+	ReviewNote       sql.NullString  `gorm:"column:review_note"`
+	CreateTime       time.Time       `gorm:"column:create_time"`
+	UpdateTime       sql.NullTime    `gorm:"column:update_time"`
+}
+
+func (sc *ScreeningCase) TableName() string {
+	return "screening_case"
+}
+
+type KYCSubmission struct {
+	SubmissionID     string         `gorm:"primaryKey;column:submission_id"`
+	UserID           string         `gorm:"column:user_id"`
+	TargetLevel      string         `gorm:"column:target_level"`
+	SubmissionStatus string         `gorm:"column:submission_status"`
+	ReviewBy         sql.NullString `gorm:"column:review_by"`
+	ReviewReason     sql.NullString `gorm:"column:review_reason"`
+	CreateTime       time.Time      `gorm:"column:create_time"`
+	UpdateTime       sql.NullTime   `gorm:"column:update_time"`
+}
+
+func (ks *KYCSubmission) TableName() string {
+	return "kyc_submission"
+}
+
+type KYCDocument struct {
+	DocumentID   string         `gorm:"primaryKey;column:document_id"`
+	UserID       string         `gorm:"column:user_id"`
+	DocumentType string         `gorm:"column:document_type"`
+	FileName     string         `gorm:"column:file_name"`
+	ContentType  string         `gorm:"column:content_type"`
+	FileSize     int64          `gorm:"column:file_size"`
+	FileHash     string         `gorm:"column:file_hash"`
+	BlobKey      string         `gorm:"column:blob_key"`
+	SubmissionID sql.NullString `gorm:"column:submission_id"`
+	CreateTime   time.Time      `gorm:"column:create_time"`
+}
+
+func (kd *KYCDocument) TableName() string {
+	return "kyc_document"
+}
+
+type PendingOperation struct {
+	OperationID     string         `gorm:"primaryKey;column:operation_id"`
+	OperationType   string         `gorm:"column:operation_type"`
+	Payload         string         `gorm:"column:payload"`
+	Summary         string         `gorm:"column:summary"`
+	OperationStatus string         `gorm:"column:operation_status"`
+	RequestBy       string         `gorm:"column:request_by"`
+	RequestNote     sql.NullString `gorm:"column:request_note"`
+	ReviewBy        sql.NullString `gorm:"column:review_by"`
+	ReviewNote      sql.NullString `gorm:"column:review_note"`
+	ResultRef       sql.NullString `gorm:"column:result_ref"`
+	ExpireTime      time.Time      `gorm:"column:expire_time"`
+	CreateTime      time.Time      `gorm:"column:create_time"`
+	UpdateTime      sql.NullTime   `gorm:"column:update_time"`
+}
+
+func (po *PendingOperation) TableName() string {
+	return "pending_operation"
+}
+
+type PendingOperationAudit struct {
+	AuditID     string         `gorm:"primaryKey;column:audit_id"`
+	OperationID string         `gorm:"column:operation_id"`
+	AuditAction string         `gorm:"column:audit_action"`
+	ActorID     string         `gorm:"column:actor_id"`
+	AuditNote   sql.NullString `gorm:"column:audit_note"`
+	CreateTime  time.Time      `gorm:"column:create_time"`
+}
+
+func (poa *PendingOperationAudit) TableName() string {
+	return "pending_operation_audit"
+}
+
+type AdminAuditLog struct {
+	AuditID      string         `gorm:"primaryKey;column:audit_id"`
+	OperatorID   string         `gorm:"column:operator_id"`
+	OperatorRole string         `gorm:"column:operator_role"`
+	AuditAction  string         `gorm:"column:audit_action"`
+	RequestPath  sql.NullString `gorm:"column:request_path"`
+	StatusCode   sql.NullInt64  `gorm:"column:status_code"`
+	ClientIP     sql.NullString `gorm:"column:client_ip"`
+	AuditDetail  sql.NullString `gorm:"column:audit_detail"`
+	CreateTime   time.Time      `gorm:"column:create_time"`
+}
+
+func (aal *AdminAuditLog) TableName() string {
+	return "admin_audit_log"
+}
@@ -0,0 +1,122 @@
+package fee
+
+import (
+	"errors"
+	"fmt"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fee types
+const (
+	FeeTypeFlat       = "flat"
+	FeeTypePercentage = "percentage"
+	FeeTypeTiered     = "tiered"
+)
+
+var oneHundred = decimal.NewFromInt(100)
+
+// Find the fee schedule of the transaction type for the user tier
+// A schedule of the user tier takes precedence over a schedule for all the tiers (empty user tier)
+// Return false if no schedule applies, which means no fee
+func FindSchedule(schedules []config.FeeSchedule, txnType string, userTier string) (config.FeeSchedule, bool) {
+	var result config.FeeSchedule
+	found := false
+	for _, schedule := range schedules {
+		if schedule.TxnType != txnType {
+			continue
+		}
+		if schedule.UserTier == userTier {
+			return schedule, true
+		}
+		if schedule.UserTier == "" && !found {
+			result, found = schedule, true
+		}
+	}
+	return result, found
+}
+
+// Calculate the fee of the amount, rounded to 2 decimal places
+// The fee is capped by the min fee and the max fee (no max if zero), and it's charged on top of the amount
+func Calculate(schedule config.FeeSchedule, amount decimal.Decimal) decimal.Decimal {
+	var result decimal.Decimal
+	switch schedule.FeeType {
+	case FeeTypeFlat:
+		result = schedule.Flat
+	case FeeTypePercentage:
+		result = schedule.Flat.Add(amount.Mul(schedule.Percentage).Div(oneHundred))
+	case FeeTypeTiered:
+		tier := findTier(schedule.Tiers, amount)
+		result = tier.Flat.Add(amount.Mul(tier.Percentage).Div(oneHundred))
+	}
+	if result.LessThan(schedule.MinFee) {
+		result = schedule.MinFee
+	}
+	if schedule.MaxFee.IsPositive() && result.GreaterThan(schedule.MaxFee) {
+		result = schedule.MaxFee
+	}
+	return result.Round(2)
+}
+
+// Validate the fee schedules, all the problems are returned together
+func Validate(schedules []config.FeeSchedule) error {
+	var errs []error
+	seen := map[string]bool{}
+	for i, schedule := range schedules {
+		name := fmt.Sprintf("fee schedule #%d (%s/%s)", i+1, schedule.TxnType, schedule.UserTier)
+		switch schedule.TxnType {
+		case "":
+			errs = append(errs, fmt.Errorf("%s: txn-type is required", name))
+		case constant.TxnTypeWithdraw, constant.TxnTypeTransfer, constant.TxnTypeFX:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown txn-type %q, the fees apply to withdraw, transfer and fx", name, schedule.TxnType))
+		}
+		key := schedule.TxnType + "|" + schedule.UserTier
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%s: duplicated txn-type and user-tier", name))
+		}
+		seen[key] = true
+		switch schedule.FeeType {
+		case FeeTypeFlat, FeeTypePercentage:
+		case FeeTypeTiered:
+			if len(schedule.Tiers) == 0 {
+				errs = append(errs, fmt.Errorf("%s: tiered fee needs at least one tier", name))
+			}
+			for j, tier := range schedule.Tiers {
+				if j > 0 && !schedule.Tiers[j-1].UpTo.IsPositive() {
+					errs = append(errs, fmt.Errorf("%s: only the last tier can be unbounded", name))
+				}
+				if j > 0 && tier.UpTo.IsPositive() && !tier.UpTo.GreaterThan(schedule.Tiers[j-1].UpTo) {
+					errs = append(errs, fmt.Errorf("%s: tier up-to must be increasing", name))
+				}
+				if tier.Flat.IsNegative() || tier.Percentage.IsNegative() {
+					errs = append(errs, fmt.Errorf("%s: tier fee can't be negative", name))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown fee-type %q", name, schedule.FeeType))
+		}
+		if schedule.Flat.IsNegative() || schedule.Percentage.IsNegative() || schedule.MinFee.IsNegative() || schedule.MaxFee.IsNegative() {
+			errs = append(errs, fmt.Errorf("%s: fee can't be negative", name))
+		}
+		if schedule.MaxFee.IsPositive() && schedule.MaxFee.LessThan(schedule.MinFee) {
+			errs = append(errs, fmt.Errorf("%s: max-fee is less than min-fee", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Find the tier of the amount, the amount above the last bounded tier falls in the last tier
+func findTier(tiers []config.FeeTier, amount decimal.Decimal) config.FeeTier {
+	for _, tier := range tiers {
+		if !tier.UpTo.IsPositive() || amount.LessThanOrEqual(tier.UpTo) {
+			return tier
+		}
+	}
+	if len(tiers) == 0 {
+		return config.FeeTier{}
+	}
+	return tiers[len(tiers)-1]
+}
@@ -0,0 +1,81 @@
+package fee
+
+import (
+	"testing"
+	"wallet-app-server/app/config"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestFindSchedule(t *testing.T) {
+	schedules := []config.FeeSchedule{
+		{TxnType: "withdraw", FeeType: FeeTypeFlat, Flat: d("2")},
+		{TxnType: "withdraw", UserTier: "premium", FeeType: FeeTypeFlat, Flat: d("0")},
+		{TxnType: "transfer", UserTier: "premium", FeeType: FeeTypeFlat, Flat: d("1")},
+	}
+	schedule, found := FindSchedule(schedules, "withdraw", "premium")
+	assert.Equal(t, true, found)
+	assert.Equal(t, "premium", schedule.UserTier)
+	schedule, found = FindSchedule(schedules, "withdraw", "standard")
+	assert.Equal(t, true, found)
+	assert.Equal(t, "", schedule.UserTier)
+	_, found = FindSchedule(schedules, "transfer", "standard")
+	assert.Equal(t, false, found)
+}
+
+func TestCalculateFlat(t *testing.T) {
+	schedule := config.FeeSchedule{FeeType: FeeTypeFlat, Flat: d("1.5")}
+	assert.Equal(t, "1.50", Calculate(schedule, d("1000")).StringFixed(2))
+}
+
+func TestCalculatePercentageWithCaps(t *testing.T) {
+	schedule := config.FeeSchedule{FeeType: FeeTypePercentage, Percentage: d("0.5"), MinFee: d("1"), MaxFee: d("20")}
+	assert.Equal(t, "1.00", Calculate(schedule, d("100")).StringFixed(2))
+	assert.Equal(t, "2.50", Calculate(schedule, d("500")).StringFixed(2))
+	assert.Equal(t, "20.00", Calculate(schedule, d("10000")).StringFixed(2))
+	// Rounded to 2 decimal places
+	assert.Equal(t, "1.23", Calculate(schedule, d("246.9")).StringFixed(2))
+}
+
+func TestCalculateTiered(t *testing.T) {
+	schedule := config.FeeSchedule{FeeType: FeeTypeTiered, Tiers: []config.FeeTier{
+		{UpTo: d("100"), Flat: d("1")},
+		{UpTo: d("1000"), Percentage: d("1")},
+		{Flat: d("5"), Percentage: d("0.5")},
+	}}
+	assert.Equal(t, "1.00", Calculate(schedule, d("100")).StringFixed(2))
+	assert.Equal(t, "1.01", Calculate(schedule, d("100.5")).StringFixed(2))
+	assert.Equal(t, "30.00", Calculate(schedule, d("5000")).StringFixed(2))
+}
+
+func TestCalculateTieredBounded(t *testing.T) {
+	// The amount above the last tier falls in the last tier
+	schedule := config.FeeSchedule{FeeType: FeeTypeTiered, Tiers: []config.FeeTier{{UpTo: d("100"), Flat: d("1")}}}
+	assert.Equal(t, "1.00", Calculate(schedule, d("500")).StringFixed(2))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, nil, Validate([]config.FeeSchedule{
+		{TxnType: "withdraw", FeeType: FeeTypePercentage, Percentage: d("0.5"), MinFee: d("1"), MaxFee: d("20")},
+		{TxnType: "withdraw", UserTier: "premium", FeeType: FeeTypeTiered, Tiers: []config.FeeTier{{UpTo: d("100"), Flat: d("1")}, {Percentage: d("1")}}},
+	}))
+}
+
+func TestValidateInvalid(t *testing.T) {
+	err := Validate([]config.FeeSchedule{
+		{TxnType: "withdraw", FeeType: "unknown"},
+		{TxnType: "withdraw", FeeType: FeeTypeFlat, Flat: d("-1")},
+		{TxnType: "transfer", FeeType: FeeTypeTiered, Tiers: []config.FeeTier{{Flat: d("1")}, {UpTo: d("100")}}},
+		{TxnType: "transfer", UserTier: "premium", FeeType: FeeTypePercentage, MinFee: d("5"), MaxFee: d("1")},
+		{TxnType: "deposit", FeeType: FeeTypeFlat},
+	})
+	assert.NotEqual(t, nil, err)
+	for _, message := range []string{"unknown fee-type", "duplicated", "can't be negative", "only the last tier", "max-fee is less than min-fee", "unknown txn-type"} {
+		assert.MatchRegex(t, err.Error(), message)
+	}
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/logger"
+)
+
+// Readiness statuses
+const (
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Component statuses
+// A component is down if its check fails, or times out if its check doesn't return in time
+const (
+	ComponentStatusUp      = "up"
+	ComponentStatusDown    = "down"
+	ComponentStatusTimeout = "timeout"
+)
+
+// Timeout of each check, used if not configured
+const defaultTimeout = time.Second
+
+// A readiness check of a component, e.g. a ping of the DB
+// The check must return when the context is done
+type Check func(ctx context.Context) error
+
+// Status of a component in the readiness report
+// The error of a failed check is only logged, since the report is public
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// The readiness report, the server is ready if all the components are up and it's not shutting down
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Check if the server is ready
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+type component struct {
+	name  string
+	check Check
+}
+
+// The readiness checker of the server
+// The components are registered at startup, and checked concurrently on every readiness check
+type Checker struct {
+	components   []component
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// The readiness checker of the server
+var DefaultChecker = NewChecker(defaultTimeout)
+
+// Create a readiness checker, each check times out after the timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Init the timeout of the default readiness checker, must be called before the server starts
+func Init() {
+	if timeoutInMillis := config.Cfg.Health.TimeoutInMillis; timeoutInMillis > 0 {
+		DefaultChecker.timeout = time.Duration(timeoutInMillis) * time.Millisecond
+	}
+}
+
+// Register a component to be checked, must be called before the server starts
+func (hc *Checker) Register(name string, check Check) {
+	hc.components = append(hc.components, component{name: name, check: check})
+}
+
+// Mark the server as shutting down, the server is not ready from then on
+// so that the load balancer stops sending requests while the in-flight ones are drained
+func (hc *Checker) SetShuttingDown() {
+	hc.shuttingDown.Store(true)
+}
+
+// Check all the components concurrently, each with the timeout
+// The components are not checked if the server is shutting down
+func (hc *Checker) Check(ctx context.Context) Report {
+	if hc.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown, Components: map[string]ComponentStatus{}}
+	}
+	statuses := make([]ComponentStatus, len(hc.components))
+	var wg sync.WaitGroup
+	for i, c := range hc.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = hc.checkComponent(ctx, c)
+		}()
+	}
+	wg.Wait()
+	report := Report{Status: StatusReady, Components: make(map[string]ComponentStatus, len(hc.components))}
+	for i, c := range hc.components {
+		report.Components[c.name] = statuses[i]
+		if statuses[i].Status != ComponentStatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+// Check the component with the timeout, and measure the latency
+func (hc *Checker) checkComponent(ctx context.Context, c component) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout)
+	defer cancel()
+	startTime := time.Now()
+	err := c.check(ctx)
+	status := ComponentStatus{Status: ComponentStatusUp, LatencyMs: float64(time.Since(startTime).Microseconds()) / 1000}
+	if err != nil {
+		status.Status = ComponentStatusDown
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			status.Status = ComponentStatusTimeout
+		}
+		logger.Warnf("Readiness check failed, component: %s, status: %s, err: %s", c.name, status.Status, err.Error())
+	}
+	return status
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/logger"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMain(m *testing.M) {
+	// The failed checks are logged
+	logDir, _ := os.MkdirTemp("", "health")
+	config.Cfg.Logging.LogLevel = "critical"
+	config.Cfg.Logging.LogFormat = "text"
+	config.Cfg.Logging.LogFilePath = filepath.Join(logDir, "server.log")
+	logger.Init()
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+func TestCheckReady(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("postgres", func(ctx context.Context) error { return nil })
+	checker.Register("redis", func(ctx context.Context) error { return nil })
+	report := checker.Check(context.Background())
+	assert.Equal(t, report.Ready(), true)
+	assert.Equal(t, len(report.Components), 2)
+	assert.Equal(t, report.Components["redis"].Status, ComponentStatusUp)
+}
+
+func TestCheckNotReady(t *testing.T) {
+	checker := NewChecker(50 * time.Millisecond)
+	checker.Register("postgres", func(ctx context.Context) error { return errors.New("connection refused") })
+	checker.Register("redis", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.Register("blob", func(ctx context.Context) error { return nil })
+	startTime := time.Now()
+	report := checker.Check(context.Background())
+	// The components are checked concurrently
+	assert.Equal(t, time.Since(startTime) < time.Second, true)
+	assert.Equal(t, report.Status, StatusNotReady)
+	assert.Equal(t, report.Components["postgres"].Status, ComponentStatusDown)
+	assert.Equal(t, report.Components["redis"].Status, ComponentStatusTimeout)
+	assert.Equal(t, report.Components["redis"].LatencyMs >= 50, true)
+	assert.Equal(t, report.Components["blob"].Status, ComponentStatusUp)
+}
+
+func TestCheckShuttingDown(t *testing.T) {
+	checked := false
+	checker := NewChecker(time.Second)
+	checker.Register("postgres", func(ctx context.Context) error { checked = true; return nil })
+	checker.SetShuttingDown()
+	report := checker.Check(context.Background())
+	assert.Equal(t, report.Ready(), false)
+	assert.Equal(t, report.Status, StatusShuttingDown)
+	assert.Equal(t, checked, false)
+}
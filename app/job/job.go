@@ -0,0 +1,92 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-app-server/app/logger"
+)
+
+// A background job which runs periodically
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+}
+
+var (
+	jobs   []job
+	cancel context.CancelFunc
+	// The jobs started by the last Start, a new one for each Start, so a job left running by a timed out Stop
+	// doesn't hold the next Start
+	wg = &sync.WaitGroup{}
+)
+
+// Register a background job, must be called before Start
+// The job is skipped if the interval is not positive
+func Register(name string, interval time.Duration, run func(ctx context.Context)) {
+	if interval <= 0 {
+		logger.Infof("Background job %s is disabled", name)
+		return
+	}
+	jobs = append(jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start all the registered background jobs
+// Each job runs in its own goroutine, first after one interval and then every interval
+func Start() {
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	running := &sync.WaitGroup{}
+	wg = running
+	for _, j := range jobs {
+		running.Add(1)
+		go func(j job) {
+			defer running.Done()
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			logger.Infof("Background job %s started, interval: %s", j.name, j.interval)
+			for {
+				select {
+				case <-ctx.Done():
+					logger.Infof("Background job %s stopped", j.name)
+					return
+				case <-ticker.C:
+					runJob(ctx, j)
+				}
+			}
+		}(j)
+	}
+}
+
+// Stop all the background jobs, and wait for the running ones to finish until the context is done
+// The context of the running jobs is cancelled, so that they can stop early
+func Stop(ctx context.Context) error {
+	if cancel != nil {
+		cancel()
+	}
+	done := make(chan struct{})
+	running := wg
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run the job once, a panic in the job won't crash the server
+func runJob(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Background job %s panic: %v", j.name, r)
+		}
+	}()
+	startTime := time.Now()
+	j.run(ctx)
+	logger.Debugf("Background job %s finished in %s", j.name, time.Since(startTime))
+}
@@ -0,0 +1,100 @@
+package job
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/logger"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMain(m *testing.M) {
+	// The job panics are logged
+	logDir, _ := os.MkdirTemp("", "job")
+	config.Cfg.Logging.LogLevel = "critical"
+	config.Cfg.Logging.LogFormat = "text"
+	config.Cfg.Logging.LogFilePath = filepath.Join(logDir, "server.log")
+	logger.Init()
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+// Reset the registered jobs after the test
+func reset(t *testing.T) {
+	t.Cleanup(func() {
+		jobs, cancel = nil, nil
+	})
+}
+
+func TestRegisterDisabled(t *testing.T) {
+	reset(t)
+	Register("disabled", 0, func(ctx context.Context) {})
+	Register("enabled", time.Second, func(ctx context.Context) {})
+	assert.Equal(t, len(jobs), 1)
+	assert.Equal(t, jobs[0].name, "enabled")
+}
+
+func TestStartStop(t *testing.T) {
+	reset(t)
+	var runs, panics atomic.Int32
+	Register("counter", 10*time.Millisecond, func(ctx context.Context) { runs.Add(1) })
+	// A panic doesn't stop the job
+	Register("panic", 10*time.Millisecond, func(ctx context.Context) {
+		panics.Add(1)
+		panic("job failed")
+	})
+	Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, Stop(context.Background()), nil)
+	assert.Equal(t, runs.Load() >= 2, true)
+	assert.Equal(t, panics.Load() >= 2, true)
+	// No run after stopped
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, runs.Load(), stopped)
+}
+
+func TestStopCancelsRunningJob(t *testing.T) {
+	reset(t)
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	Register("long", 10*time.Millisecond, func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+			return
+		}
+		<-ctx.Done()
+		close(cancelled)
+	})
+	Start()
+	<-started
+	assert.Equal(t, Stop(context.Background()), nil)
+	<-cancelled
+}
+
+func TestStopTimeout(t *testing.T) {
+	reset(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	// The job ignores the cancellation
+	Register("stuck", 10*time.Millisecond, func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+			<-release
+		default:
+		}
+	})
+	Start()
+	<-started
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelTimeout()
+	assert.Equal(t, Stop(ctx), context.DeadlineExceeded)
+	// Stopped once the job returns
+	close(release)
+	assert.Equal(t, Stop(context.Background()), nil)
+}
@@ -0,0 +1,105 @@
+package kyc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ErrTxnTypeNotAllowed = "transaction type not allowed at the KYC level"
+	ErrMaxAmountExceeded = "amount exceeds the max amount of the KYC level"
+)
+
+// The KYC levels, from the lowest to the highest
+var Levels = []string{constant.KYCLevelUnverified, constant.KYCLevelBasic, constant.KYCLevelFull}
+
+// The document types accepted for the KYC submissions
+var DocumentTypes = []string{
+	constant.KYCDocumentTypePassport,
+	constant.KYCDocumentTypeNationalID,
+	constant.KYCDocumentTypeDrivingLicense,
+	constant.KYCDocumentTypeProofOfAddress,
+	constant.KYCDocumentTypeSelfie,
+}
+
+// Rank of the KYC level, the higher the more verified
+// Return -1 if the level is unknown
+func Rank(level string) int {
+	if level == constant.KYCLevelGrandfathered {
+		return Rank(constant.KYCLevelUnverified)
+	}
+	return slices.Index(Levels, level)
+}
+
+// Find the capabilities of the KYC level
+// Return false if the level has no capabilities configured, which means no restriction
+func FindLevel(levels []config.KYCLevel, level string) (config.KYCLevel, bool) {
+	for _, kycLevel := range levels {
+		if kycLevel.Level == level {
+			return kycLevel, true
+		}
+	}
+	return config.KYCLevel{}, false
+}
+
+// Check if a transaction of the type and amount is allowed at the KYC level
+func Check(levels []config.KYCLevel, level string, txnType string, amount decimal.Decimal) error {
+	kycLevel, found := FindLevel(levels, level)
+	if !found {
+		return nil
+	}
+	for _, capability := range kycLevel.Capabilities {
+		if capability.TxnType != txnType {
+			continue
+		}
+		if capability.MaxAmount.IsPositive() && amount.GreaterThan(capability.MaxAmount) {
+			return errors.New(ErrMaxAmountExceeded)
+		}
+		return nil
+	}
+	return errors.New(ErrTxnTypeNotAllowed)
+}
+
+// Check if the error is a KYC capability error
+func IsKYCError(err error) bool {
+	switch err.Error() {
+	case ErrTxnTypeNotAllowed, ErrMaxAmountExceeded:
+		return true
+	}
+	return false
+}
+
+// Validate the KYC level capabilities, all the problems are reported together
+func Validate(levels []config.KYCLevel) error {
+	var errs []error
+	seenLevels := map[string]bool{}
+	for i, kycLevel := range levels {
+		name := fmt.Sprintf("KYC level #%d (%s)", i+1, kycLevel.Level)
+		if Rank(kycLevel.Level) < 0 {
+			errs = append(errs, fmt.Errorf("%s: unknown level, expected one of %v", name, Levels))
+		}
+		if seenLevels[kycLevel.Level] {
+			errs = append(errs, fmt.Errorf("%s: duplicated level", name))
+		}
+		seenLevels[kycLevel.Level] = true
+		seenTxnTypes := map[string]bool{}
+		for _, capability := range kycLevel.Capabilities {
+			if capability.TxnType == "" {
+				errs = append(errs, fmt.Errorf("%s: txn-type is required", name))
+			}
+			if seenTxnTypes[capability.TxnType] {
+				errs = append(errs, fmt.Errorf("%s: duplicated txn-type %s", name, capability.TxnType))
+			}
+			seenTxnTypes[capability.TxnType] = true
+			if capability.MaxAmount.IsNegative() {
+				errs = append(errs, fmt.Errorf("%s: max-amount of %s can't be negative", name, capability.TxnType))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
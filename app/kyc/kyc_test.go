@@ -0,0 +1,58 @@
+package kyc
+
+import (
+	"errors"
+	"testing"
+	"wallet-app-server/app/config"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+var testLevels = []config.KYCLevel{
+	{Level: "unverified", Capabilities: []config.KYCCapability{{TxnType: "transfer", MaxAmount: d("100")}}},
+	{Level: "basic", Capabilities: []config.KYCCapability{{TxnType: "transfer"}, {TxnType: "withdraw", MaxAmount: d("1000")}}},
+}
+
+func TestRank(t *testing.T) {
+	assert.Equal(t, 0, Rank("unverified"))
+	assert.Equal(t, 2, Rank("full"))
+	assert.Equal(t, true, Rank("basic") > Rank("unverified"))
+	assert.Equal(t, -1, Rank("gold"))
+	assert.Equal(t, Rank("unverified"), Rank("grandfathered"))
+}
+
+func TestCheck(t *testing.T) {
+	// Capped transaction type
+	assert.Equal(t, nil, Check(testLevels, "unverified", "transfer", d("100")))
+	assert.Equal(t, errors.New(ErrMaxAmountExceeded), Check(testLevels, "unverified", "transfer", d("100.01")))
+	// Transaction type not listed
+	assert.Equal(t, errors.New(ErrTxnTypeNotAllowed), Check(testLevels, "unverified", "withdraw", d("1")))
+	// Zero max amount means no cap
+	assert.Equal(t, nil, Check(testLevels, "basic", "transfer", d("1000000")))
+	assert.Equal(t, errors.New(ErrMaxAmountExceeded), Check(testLevels, "basic", "withdraw", d("1000.01")))
+	// Level not configured means no restriction
+	assert.Equal(t, nil, Check(testLevels, "full", "withdraw", d("1000000")))
+	assert.Equal(t, nil, Check(nil, "unverified", "withdraw", d("1000000")))
+}
+
+func TestIsKYCError(t *testing.T) {
+	assert.Equal(t, true, IsKYCError(errors.New(ErrTxnTypeNotAllowed)))
+	assert.Equal(t, true, IsKYCError(errors.New(ErrMaxAmountExceeded)))
+	assert.Equal(t, false, IsKYCError(errors.New("insufficient balance")))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, nil, Validate(testLevels))
+	assert.Equal(t, nil, Validate(nil))
+	assert.Equal(t, nil, Validate([]config.KYCLevel{{Level: "grandfathered"}}))
+	assert.NotEqual(t, nil, Validate([]config.KYCLevel{{Level: "gold"}}))
+	assert.NotEqual(t, nil, Validate([]config.KYCLevel{{Level: "basic"}, {Level: "basic"}}))
+	assert.NotEqual(t, nil, Validate([]config.KYCLevel{{Level: "basic", Capabilities: []config.KYCCapability{{MaxAmount: d("1")}}}}))
+	assert.NotEqual(t, nil, Validate([]config.KYCLevel{{Level: "basic", Capabilities: []config.KYCCapability{{TxnType: "withdraw"}, {TxnType: "withdraw"}}}}))
+	assert.NotEqual(t, nil, Validate([]config.KYCLevel{{Level: "basic", Capabilities: []config.KYCCapability{{TxnType: "withdraw", MaxAmount: d("-1")}}}}))
+}
@@ -0,0 +1,118 @@
+package limit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"wallet-app-server/app/config"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ErrPerTxnAmountExceeded  = "per transaction amount limit exceeded"
+	ErrDailyAmountExceeded   = "daily amount limit exceeded"
+	ErrDailyCountExceeded    = "daily count limit exceeded"
+	ErrMonthlyAmountExceeded = "monthly amount limit exceeded"
+	ErrMonthlyCountExceeded  = "monthly count limit exceeded"
+)
+
+// Amount and count of the transactions made in the current day and month
+type Usage struct {
+	DailyAmount   decimal.Decimal
+	DailyCount    int64
+	MonthlyAmount decimal.Decimal
+	MonthlyCount  int64
+}
+
+// Find the limit rule of the transaction type for the user tier
+// A rule of the user tier takes precedence over a rule for all the tiers (empty user tier)
+// Return false if no rule applies, which means no limit
+func FindRule(rules []config.LimitRule, txnType string, userTier string) (config.LimitRule, bool) {
+	var result config.LimitRule
+	found := false
+	for _, rule := range rules {
+		if rule.TxnType != txnType {
+			continue
+		}
+		if rule.UserTier == userTier {
+			return rule, true
+		}
+		if rule.UserTier == "" && !found {
+			result, found = rule, true
+		}
+	}
+	return result, found
+}
+
+// Check if a transaction of the amount is allowed by the rule, given the usage before the transaction
+func Check(rule config.LimitRule, usage Usage, amount decimal.Decimal) error {
+	if rule.PerTxnAmount.IsPositive() && amount.GreaterThan(rule.PerTxnAmount) {
+		return errors.New(ErrPerTxnAmountExceeded)
+	}
+	if rule.DailyAmount.IsPositive() && usage.DailyAmount.Add(amount).GreaterThan(rule.DailyAmount) {
+		return errors.New(ErrDailyAmountExceeded)
+	}
+	if rule.DailyCount > 0 && usage.DailyCount+1 > rule.DailyCount {
+		return errors.New(ErrDailyCountExceeded)
+	}
+	if rule.MonthlyAmount.IsPositive() && usage.MonthlyAmount.Add(amount).GreaterThan(rule.MonthlyAmount) {
+		return errors.New(ErrMonthlyAmountExceeded)
+	}
+	if rule.MonthlyCount > 0 && usage.MonthlyCount+1 > rule.MonthlyCount {
+		return errors.New(ErrMonthlyCountExceeded)
+	}
+	return nil
+}
+
+// Check if the error is a limit exceeded error
+func IsLimitError(err error) bool {
+	switch err.Error() {
+	case ErrPerTxnAmountExceeded, ErrDailyAmountExceeded, ErrDailyCountExceeded, ErrMonthlyAmountExceeded, ErrMonthlyCountExceeded:
+		return true
+	}
+	return false
+}
+
+// Start of the day and start of the month of the time, in the location of the time
+func PeriodStart(t time.Time) (time.Time, time.Time) {
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	monthStart := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return dayStart, monthStart
+}
+
+// Validate the limit rules, all the problems are reported together
+func Validate(rules []config.LimitRule) error {
+	var errs []error
+	seen := map[string]bool{}
+	for i, rule := range rules {
+		name := fmt.Sprintf("limit rule #%d (%s/%s)", i+1, rule.TxnType, rule.UserTier)
+		if rule.TxnType == "" {
+			errs = append(errs, fmt.Errorf("%s: txn-type is required", name))
+		}
+		key := rule.TxnType + "|" + rule.UserTier
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%s: duplicated txn-type and user-tier", name))
+		}
+		seen[key] = true
+		if err := ValidateRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate the values of a limit rule
+func ValidateRule(rule config.LimitRule) error {
+	if rule.PerTxnAmount.IsNegative() || rule.DailyAmount.IsNegative() || rule.MonthlyAmount.IsNegative() ||
+		rule.DailyCount < 0 || rule.MonthlyCount < 0 {
+		return errors.New("limit can't be negative")
+	}
+	if rule.DailyAmount.IsPositive() && rule.MonthlyAmount.IsPositive() && rule.DailyAmount.GreaterThan(rule.MonthlyAmount) {
+		return errors.New("daily-amount is greater than monthly-amount")
+	}
+	if rule.DailyCount > 0 && rule.MonthlyCount > 0 && rule.DailyCount > rule.MonthlyCount {
+		return errors.New("daily-count is greater than monthly-count")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package limit
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wallet-app-server/app/config"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestFindRule(t *testing.T) {
+	rules := []config.LimitRule{
+		{TxnType: "withdraw", DailyAmount: d("1000")},
+		{TxnType: "withdraw", UserTier: "premium", DailyAmount: d("10000")},
+	}
+	rule, found := FindRule(rules, "withdraw", "premium")
+	assert.Equal(t, true, found)
+	assert.Equal(t, "10000", rule.DailyAmount.String())
+	rule, found = FindRule(rules, "withdraw", "standard")
+	assert.Equal(t, true, found)
+	assert.Equal(t, "1000", rule.DailyAmount.String())
+	_, found = FindRule(rules, "transfer", "standard")
+	assert.Equal(t, false, found)
+}
+
+func TestCheck(t *testing.T) {
+	rule := config.LimitRule{
+		PerTxnAmount:  d("500"),
+		DailyAmount:   d("1000"),
+		DailyCount:    3,
+		MonthlyAmount: d("5000"),
+		MonthlyCount:  20,
+	}
+	usage := Usage{DailyAmount: d("600"), DailyCount: 2, MonthlyAmount: d("4000"), MonthlyCount: 10}
+	assert.Equal(t, nil, Check(rule, usage, d("400")))
+	assert.Equal(t, ErrPerTxnAmountExceeded, Check(rule, usage, d("500.01")).Error())
+	assert.Equal(t, ErrDailyAmountExceeded, Check(rule, usage, d("400.01")).Error())
+	usage.DailyCount = 3
+	assert.Equal(t, ErrDailyCountExceeded, Check(rule, usage, d("1")).Error())
+	usage = Usage{MonthlyAmount: d("4900"), MonthlyCount: 10}
+	assert.Equal(t, ErrMonthlyAmountExceeded, Check(rule, usage, d("100.01")).Error())
+	usage = Usage{MonthlyAmount: d("100"), MonthlyCount: 20}
+	assert.Equal(t, ErrMonthlyCountExceeded, Check(rule, usage, d("1")).Error())
+}
+
+func TestCheckNoLimit(t *testing.T) {
+	usage := Usage{DailyAmount: d("1000000"), DailyCount: 1000, MonthlyAmount: d("1000000"), MonthlyCount: 1000}
+	assert.Equal(t, nil, Check(config.LimitRule{}, usage, d("1000000")))
+}
+
+func TestIsLimitError(t *testing.T) {
+	assert.Equal(t, true, IsLimitError(Check(config.LimitRule{DailyCount: 1}, Usage{DailyCount: 1}, d("1"))))
+	assert.Equal(t, false, IsLimitError(errors.New("insufficient balance")))
+}
+
+func TestPeriodStart(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*3600)
+	dayStart, monthStart := PeriodStart(time.Date(2025, 6, 15, 13, 45, 0, 0, location))
+	assert.Equal(t, time.Date(2025, 6, 15, 0, 0, 0, 0, location), dayStart)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, location), monthStart)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, nil, Validate([]config.LimitRule{
+		{TxnType: "withdraw", DailyAmount: d("1000"), MonthlyAmount: d("5000")},
+		{TxnType: "withdraw", UserTier: "premium"},
+	}))
+	err := Validate([]config.LimitRule{
+		{DailyCount: -1},
+		{TxnType: "transfer", DailyAmount: d("1000"), MonthlyAmount: d("100")},
+		{TxnType: "transfer", DailyCount: 10, MonthlyCount: 5},
+	})
+	assert.NotEqual(t, nil, err)
+	assert.MatchRegex(t, err.Error(), "txn-type is required")
+	assert.MatchRegex(t, err.Error(), "limit can't be negative")
+	assert.MatchRegex(t, err.Error(), "daily-amount is greater than monthly-amount")
+	assert.MatchRegex(t, err.Error(), "duplicated txn-type and user-tier")
+}
@@ -1,73 +1,233 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"runtime"
+	"strings"
+	"time"
 	"wallet-app-server/app/config"
 
-	"github.com/op/go-logging"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var logger *logging.Logger
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// The critical level, above the error level, so that only the critical logs are written
+const LevelCritical = slog.LevelError + 4
+
+// Name of the server in every log line
+const serviceName = "wallet-app-server"
 
-func Init() {
+var logger *slog.Logger
+
+// The level of the logger, changed by SetLevel without recreating the logger
+var level = new(slog.LevelVar)
+
+// Init the logger as configured in the Logging section, the logs are written to the stdout and the log file
+// Return the error if the log level or the log format is not valid, a log format of the former logger falls back to text with a warning
+func Init() error {
 	loggingCfg := config.Cfg.Logging
-	logLvl, err := logging.LogLevel(loggingCfg.LogLevel)
+	logLvl, err := ParseLevel(loggingCfg.LogLevel)
 	if err != nil {
-		log.Fatal("Log level is not valid:", err.Error())
+		return fmt.Errorf("log level is not valid: %w", err)
 	}
 
-	stdoutBackend := logging.AddModuleLevel(logging.NewBackendFormatter(
-		logging.NewLogBackend(os.Stdout, "", 0),
-		logging.MustStringFormatter(loggingCfg.LogFormat),
-	))
-	stdoutBackend.SetLevel(logLvl, "")
-
 	logWriter := &lumberjack.Logger{
 		Filename: loggingCfg.LogFilePath,
 		MaxSize:  loggingCfg.LogFileMaxSizeInMB,
 		MaxAge:   loggingCfg.LogFileRetentionInDays,
 		Compress: true,
 	}
-	fileBackend := logging.AddModuleLevel(logging.NewBackendFormatter(
-		logging.NewLogBackend(logWriter, "", 0),
-		logging.MustStringFormatter(loggingCfg.LogFormat),
-	))
-	fileBackend.SetLevel(logLvl, "")
+	level.Set(logLvl)
+	handler, err := newHandler(io.MultiWriter(os.Stdout, logWriter), loggingCfg.LogFormat, level)
+	if err != nil {
+		return fmt.Errorf("log format is not valid: %w", err)
+	}
+	logger = slog.New(handler).With(slog.String("service", serviceName))
+	if config.IsLegacyLogFormat(loggingCfg.LogFormat) {
+		Warnf("Log format %q of the former logger isn't supported anymore, the logs are written as text, set log-format to \"text\" or \"json\"", loggingCfg.LogFormat)
+	}
+	return nil
+}
+
+// Change the log level of the logger, e.g. on a reload of the configuration
+// The level is changed atomically, the logs being written use either the old or the new level
+func SetLevel(logLevel string) error {
+	logLvl, err := ParseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	level.Set(logLvl)
+	return nil
+}
+
+// Parse the log level, case insensitive
+// The levels of the former logger (notice, warning, critical) are still accepted
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "notice":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "critical":
+		return LevelCritical, nil
+	}
+	return 0, fmt.Errorf("unknown level %q", level)
+}
+
+// Create the handler of the format writing to the writer, with the trace IDs and the context fields
+func newHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 {
+				switch a.Key {
+				case slog.TimeKey:
+					return a
+				case slog.LevelKey:
+					if level, ok := a.Value.Any().(slog.Level); ok && level == LevelCritical {
+						return slog.String(slog.LevelKey, "CRITICAL")
+					}
+					return a
+				case slog.SourceKey:
+					// Keep the file name and the line only
+					if source, ok := a.Value.Any().(*slog.Source); ok {
+						file := source.File[strings.LastIndex(source.File, "/")+1:]
+						return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", file, source.Line))
+					}
+					return a
+				}
+			}
+			// Mask the secrets in the message and the fields, so that they never reach the logs
+			return redactAttr(a)
+		},
+	}
+	if config.IsLegacyLogFormat(format) {
+		format = FormatText
+	}
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		return contextHandler{slog.NewTextHandler(w, opts)}, nil
+	case FormatJSON:
+		return contextHandler{slog.NewJSONHandler(w, opts)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type contextKey struct{}
 
-	logger = logging.MustGetLogger("fcca-fm-db-engine")
-	logging.SetBackend(stdoutBackend, fileBackend)
+// Add the fields to the context, they are added to every log line written with the context
+// e.g. the request ID and the user ID of a request
+func WithFields(ctx context.Context, fields ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// The handler adding the trace ID and the span ID of the span in the context, and the fields of the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		r.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()), slog.String("span_id", spanContext.SpanID().String()))
+	}
+	if fields, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Write the log record with the caller of the logging function as the source
+func write(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip runtime.Callers, write and the logging function
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	logger.Handler().Handle(ctx, r)
 }
 
 func Debug(args ...any) {
-	logger.Debug(args...)
+	write(context.Background(), slog.LevelDebug, fmt.Sprint(args...))
 }
 
 func Debugf(format string, args ...any) {
-	logger.Debugf(format, args...)
+	write(context.Background(), slog.LevelDebug, fmt.Sprintf(format, args...))
 }
 
 func Info(args ...any) {
-	logger.Info(args...)
+	write(context.Background(), slog.LevelInfo, fmt.Sprint(args...))
 }
 
 func Infof(format string, args ...any) {
-	logger.Infof(format, args...)
+	write(context.Background(), slog.LevelInfo, fmt.Sprintf(format, args...))
 }
 
 func Warn(args ...any) {
-	logger.Warning(args...)
+	write(context.Background(), slog.LevelWarn, fmt.Sprint(args...))
 }
 
 func Warnf(format string, args ...any) {
-	logger.Warningf(format, args...)
+	write(context.Background(), slog.LevelWarn, fmt.Sprintf(format, args...))
 }
 
 func Error(args ...any) {
-	logger.Error(args...)
+	write(context.Background(), slog.LevelError, fmt.Sprint(args...))
 }
 
 func Errorf(format string, args ...any) {
-	logger.Errorf(format, args...)
+	write(context.Background(), slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+// The logging functions with a context add the trace ID, the span ID and the fields of the context,
+// so that the log lines of a request can be found from its request ID or its trace
+
+func DebugfContext(ctx context.Context, format string, args ...any) {
+	write(ctx, slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func InfofContext(ctx context.Context, format string, args ...any) {
+	write(ctx, slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func WarnfContext(ctx context.Context, format string, args ...any) {
+	write(ctx, slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func ErrorfContext(ctx context.Context, format string, args ...any) {
+	write(ctx, slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+// Write a structured log line, the fields are key-value pairs or slog.Attr like log/slog
+func Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	write(ctx, level, msg, args...)
 }
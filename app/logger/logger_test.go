@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log to a buffer in the format, at the level
+func logToBuffer(t *testing.T, format string, level slog.Level) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	handler, err := newHandler(buf, format, level)
+	assert.Equal(t, err, nil)
+	logger = slog.New(handler).With(slog.String("service", serviceName))
+	return buf
+}
+
+func TestJSONFormat(t *testing.T) {
+	buf := logToBuffer(t, FormatJSON, slog.LevelInfo)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	ctx = WithFields(ctx, slog.String("request_id", "req-1"))
+	ctx = WithFields(ctx, slog.String("user_id", "user-1"))
+	InfofContext(ctx, "Transfer done, txnID: %s", "txn-1")
+
+	var line map[string]any
+	assert.Equal(t, json.Unmarshal(buf.Bytes(), &line), nil)
+	assert.Equal(t, line["level"], "INFO")
+	assert.Equal(t, line["msg"], "Transfer done, txnID: txn-1")
+	assert.Equal(t, line["service"], "wallet-app-server")
+	assert.Equal(t, line["trace_id"], "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, line["span_id"], "00f067aa0ba902b7")
+	assert.Equal(t, line["request_id"], "req-1")
+	assert.Equal(t, line["user_id"], "user-1")
+	// The source is the caller of the logging function
+	assert.Equal(t, line["source"].(string)[:len("logger_test.go:")], "logger_test.go:")
+}
+
+func TestLevel(t *testing.T) {
+	buf := logToBuffer(t, FormatText, slog.LevelWarn)
+	Infof("not written")
+	assert.Equal(t, buf.Len(), 0)
+	Errorf("written, err: %s", "timeout")
+	assert.Equal(t, bytes.Contains(buf.Bytes(), []byte(`level=ERROR source=logger_test.go:`)), true)
+	assert.Equal(t, bytes.Contains(buf.Bytes(), []byte(`msg="written, err: timeout" service=wallet-app-server`)), true)
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler, err := newHandler(buf, FormatText, level)
+	assert.Equal(t, err, nil)
+	logger = slog.New(handler)
+	assert.Equal(t, SetLevel("error"), nil)
+	Infof("not written")
+	assert.Equal(t, buf.Len(), 0)
+	assert.Equal(t, SetLevel("debug"), nil)
+	Debugf("written")
+	assert.Equal(t, bytes.Contains(buf.Bytes(), []byte(`msg=written`)), true)
+	// The level is kept if the new one is not valid
+	assert.NotEqual(t, SetLevel("verbose"), nil)
+	assert.Equal(t, level.Level(), slog.LevelDebug)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARNING")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, level, slog.LevelWarn)
+	level, err = ParseLevel("critical")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, level, LevelCritical)
+	_, err = ParseLevel("verbose")
+	assert.NotEqual(t, err, nil)
+	_, err = newHandler(&bytes.Buffer{}, "xml", slog.LevelInfo)
+	assert.NotEqual(t, err, nil)
+}
+
+// A log format of the former logger falls back to text
+func TestLegacyFormat(t *testing.T) {
+	buf := logToBuffer(t, "%{time:2006-01-02 15:04:05.000} %{shortfunc} ▶ %{message}", slog.LevelInfo)
+	Info("started")
+	assert.Equal(t, strings.Contains(buf.String(), "msg=started"), true)
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Key of the query start time in the GORM statement
+const startTimeKey = "metrics:start_time"
+
+// Register the GORM callbacks timing every DB query
+func InstrumentGORM(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("metrics:before_create", beforeQuery),
+		callback.Create().After("gorm:create").Register("metrics:after_create", afterQuery("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", beforeQuery),
+		callback.Query().After("gorm:query").Register("metrics:after_query", afterQuery("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", beforeQuery),
+		callback.Update().After("gorm:update").Register("metrics:after_update", afterQuery("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", beforeQuery),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", afterQuery("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", beforeQuery),
+		callback.Row().After("gorm:row").Register("metrics:after_row", afterQuery("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", beforeQuery),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", afterQuery("raw")),
+	)
+}
+
+func beforeQuery(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+// Observe the query of the operation, a record not found is not a failed query
+func afterQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		startTime, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "none"
+		}
+		dbQueryDuration.WithLabelValues(operation, table, resultLabel(err)).Observe(time.Since(startTime.(time.Time)).Seconds())
+	}
+}
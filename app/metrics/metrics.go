@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+)
+
+// Namespace of all the metrics of the server
+const namespace = "wallet"
+
+// Outcomes of a transaction request
+// A rejected transaction is refused by a business rule (e.g. a limit, the KYC level, the risk check),
+// an insufficient balance is counted on its own
+const (
+	OutcomeCompleted           = "completed"
+	OutcomePendingReview       = "pending_review"
+	OutcomePendingApproval     = "pending_approval"
+	OutcomeInsufficientBalance = "insufficient_balance"
+	OutcomeRejected            = "rejected"
+	OutcomeError               = "error"
+)
+
+// Outcomes of a login
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Route label of the requests not matching any route, so that the unknown paths don't create new series
+const unmatchedRoute = "unmatched"
+
+// The registry of the server metrics, with the Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of DB queries by operation, table and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "result"})
+	redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Latency of Redis commands by command and result.",
+		Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"command", "result"})
+	transactionAmount = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transaction_amount",
+		Help:      "Amount of the requested deposits, withdrawals and transfers by type and outcome.",
+		Buckets:   []float64{10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000},
+	}, []string{"txn_type", "outcome"})
+	insufficientBalanceTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_balance_rejections_total",
+		Help:      "Number of transactions rejected for insufficient balance by type.",
+	}, []string{"txn_type"})
+	loginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of logins by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		dbQueryDuration,
+		redisCommandDuration,
+		transactionAmount,
+		insufficientBalanceTotal,
+		loginsTotal,
+	)
+}
+
+// The handler serving the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Register the connection pool stats of the DB
+func RegisterDBStats(sqlDB *sql.DB, dbName string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(sqlDB, dbName))
+}
+
+// Observe a handled HTTP request
+// The route is the route pattern (e.g. /api/v1/wallet/:wallet_id/balance), empty if no route matches
+func ObserveHTTPRequest(method string, route string, statusCode int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	status := strconv.Itoa(statusCode)
+	httpRequestsTotal.WithLabelValues(method, route, status).Inc()
+	httpRequestDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// Observe a requested transaction with its outcome
+func ObserveTransaction(txnType string, outcome string, amount decimal.Decimal) {
+	transactionAmount.WithLabelValues(txnType, outcome).Observe(amount.InexactFloat64())
+	if outcome == OutcomeInsufficientBalance {
+		insufficientBalanceTotal.WithLabelValues(txnType).Inc()
+	}
+}
+
+// Observe a login with its outcome
+func ObserveLogin(outcome string) {
+	loginsTotal.WithLabelValues(outcome).Inc()
+}
+
+// Result label of a DB query or a Redis command
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
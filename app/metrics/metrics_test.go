@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	ObserveHTTPRequest("GET", "/api/v1/wallet/:wallet_id/balance", 200, 10*time.Millisecond)
+	ObserveHTTPRequest("GET", "", 404, time.Millisecond)
+	assert.Equal(t, testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "/api/v1/wallet/:wallet_id/balance", "200")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", unmatchedRoute, "404")), float64(1))
+}
+
+func TestObserveTransaction(t *testing.T) {
+	ObserveTransaction("withdraw", OutcomeCompleted, decimal.NewFromInt(100))
+	ObserveTransaction("withdraw", OutcomeInsufficientBalance, decimal.NewFromInt(5000))
+	ObserveTransaction("transfer", OutcomeInsufficientBalance, decimal.NewFromInt(20))
+	assert.Equal(t, testutil.ToFloat64(insufficientBalanceTotal.WithLabelValues("withdraw")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(insufficientBalanceTotal.WithLabelValues("transfer")), float64(1))
+	assert.Equal(t, testutil.CollectAndCount(transactionAmount, namespace+"_transaction_amount"), 3)
+}
+
+func TestRedisResultLabel(t *testing.T) {
+	assert.Equal(t, redisResultLabel(nil), "ok")
+	assert.Equal(t, redisResultLabel(goredis.Nil), "ok")
+	assert.Equal(t, redisResultLabel(errors.New("i/o timeout")), "error")
+}
+
+func TestHandler(t *testing.T) {
+	ObserveLogin(LoginFailure)
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+	assert.Equal(t, w.Code, 200)
+	assert.Equal(t, strings.Contains(string(body), `wallet_logins_total{outcome="failure"} 1`), true)
+	assert.Equal(t, strings.Contains(string(body), "go_goroutines"), true)
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Redis hook timing every command, a pipeline is observed as a single "pipeline" command
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		startTime := time.Now()
+		err := next(ctx, cmd)
+		redisCommandDuration.WithLabelValues(cmd.Name(), redisResultLabel(err)).Observe(time.Since(startTime).Seconds())
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		startTime := time.Now()
+		err := next(ctx, cmds)
+		redisCommandDuration.WithLabelValues("pipeline", redisResultLabel(err)).Observe(time.Since(startTime).Seconds())
+		return err
+	}
+}
+
+// A missing key is not a failed command
+func redisResultLabel(err error) string {
+	if errors.Is(err, goredis.Nil) {
+		return resultLabel(nil)
+	}
+	return resultLabel(err)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+	"wallet-app-server/app/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Paths of the probes, their access logs are at the debug level not to flood the logs
+var quietPaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+// Access log middleware
+// Log every request after it's handled, at the warn level for a client error and at the error level for a server error
+// Must be placed after the RequestID middleware, so that the access log has the request ID
+func AccessLog(c *gin.Context) {
+	startTime := time.Now()
+	// Process next handler
+	c.Next()
+	statusCode := c.Writer.Status()
+	level := slog.LevelInfo
+	if quietPaths[c.Request.URL.Path] {
+		level = slog.LevelDebug
+	}
+	if statusCode >= 500 {
+		level = slog.LevelError
+	} else if statusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	// The context has the user ID if the request is authenticated
+	logger.Log(c.Request.Context(), level, "HTTP request",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.Int("status", statusCode),
+		slog.Float64("latency_ms", float64(time.Since(startTime).Microseconds())/1000),
+		slog.Int("bytes", c.Writer.Size()),
+		slog.String("client_ip", c.ClientIP()),
+		slog.String("user_agent", c.Request.UserAgent()),
+	)
+}
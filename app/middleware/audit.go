@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Max length of the request body kept in the admin audit log
+const maxAuditBodyLength = 500
+
+// Admin audit middleware
+// Every request to the admin endpoints is recorded in the admin audit log after it's handled,
+// including the ones denied by the Authorization middleware, must be placed after the Authentication middleware
+// The JSON request body of a write is recorded as the detail, the uploaded files are not
+func AdminAudit(c *gin.Context) {
+	var auditDetail string
+	if c.Request.Method != "GET" && strings.HasPrefix(c.ContentType(), "application/json") && c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err == nil {
+			auditDetail = string(body)
+			if len(auditDetail) > maxAuditBodyLength {
+				auditDetail = strings.ToValidUTF8(auditDetail[:maxAuditBodyLength], "")
+			}
+		}
+		// Restore the request body for the handler
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	// Process next handler
+	c.Next()
+	// The route is empty if no route matches
+	auditAction := c.Request.Method + " " + c.FullPath()
+	if c.FullPath() == "" {
+		auditAction = c.Request.Method + " " + c.Request.URL.Path
+	}
+	service.AuditService.RecordAdminRequest(c.Request.Context(), model.AdminAudit{
+		OperatorID:   c.GetString("current_user_id"),
+		OperatorRole: c.GetString("current_user_role"),
+		AuditAction:  auditAction,
+		RequestPath:  c.Request.URL.RequestURI(),
+		StatusCode:   c.Writer.Status(),
+		ClientIP:     c.ClientIP(),
+		AuditDetail:  auditDetail,
+	})
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strings"
 	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
 	"wallet-app-server/app/redis"
 	"wallet-app-server/app/service"
 
@@ -14,6 +17,7 @@ import (
 // Authentication middleware
 // This is used to protect wallet/transaction endpoints
 // that require a authenticated user session
+// The user's role is loaded by the CurrentRole middleware where it's needed
 func Authentication(c *gin.Context) {
 	// Extract authorization header
 	authHeader := c.Request.Header.Get("Authorization")
@@ -26,12 +30,12 @@ func Authentication(c *gin.Context) {
 	}
 	// Extract access token
 	accessToken := authHeader[7:]
-	// Fetch user ID from Redis
-	currentUserID, err := redis.Client.Get(accessToken)
+	// Fetch user session from Redis
+	sessionValue, err := redis.Client.Get(c.Request.Context(), accessToken)
 	if err != nil {
 		// Record not found error
 		if err == goredis.Nil {
-			logger.Warn("Failed to fetch currentUserID, accessToken: %s, err: %s", accessToken, err.Error())
+			logger.WarnfContext(c.Request.Context(), "Failed to fetch user session, accessToken: %s, err: %s", logger.Mask(accessToken), err.Error())
 			serviceErr := service.ServiceError{ErrType: service.ErrTypeAuthenticationFailed, ErrMessage: service.ErrMessageInvalidAccessToken}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"success": false,
@@ -47,8 +51,20 @@ func Authentication(c *gin.Context) {
 		})
 		return
 	}
+	// Parse user session, the session of an older server version must login again
+	var session model.Session
+	if err := json.Unmarshal([]byte(sessionValue), &session); err != nil || session.UserID == "" {
+		serviceErr := service.ServiceError{ErrType: service.ErrTypeAuthenticationFailed, ErrMessage: service.ErrMessageInvalidAccessToken}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   serviceErr.Error(),
+		})
+		return
+	}
 	// Set current_user_id
-	c.Set("current_user_id", currentUserID)
+	c.Set("current_user_id", session.UserID)
+	// Add the user ID to the context of the request, so that it's in the log lines of the request
+	c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(), slog.String("user_id", session.UserID)))
 	// Process next handler
 	c.Next()
 }
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"wallet-app-server/app/rbac"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorization middleware
+// This is used to protect the endpoints which need a permission, must be placed after the CurrentRole middleware
+// Only the users whose role has the permission are allowed
+func Authorization(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUserRole := c.GetString("current_user_role")
+		if !rbac.HasPermission(currentUserRole, permission) {
+			serviceErr := service.ServiceError{ErrType: service.ErrTypePermissionDenied, ErrMessage: service.ErrMessageNoPermission}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   serviceErr.Error(),
+			})
+			return
+		}
+		// Process next handler
+		c.Next()
+	}
+}
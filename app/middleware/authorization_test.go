@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/rbac"
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+// Serve a request as a user of the role, to an endpoint needing the permission
+func serveAsRole(role string, permission string) int {
+	r := gin.New()
+	r.GET("/test", func(c *gin.Context) {
+		if role != "" {
+			c.Set("current_user_role", role)
+		}
+		c.Next()
+	}, Authorization(permission), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	return w.Code
+}
+
+func TestAuthorization(t *testing.T) {
+	assert.Equal(t, serveAsRole(constant.UserRoleSupport, rbac.PermissionWalletRead), http.StatusOK)
+	assert.Equal(t, serveAsRole(constant.UserRoleSupport, rbac.PermissionLimitManage), http.StatusForbidden)
+	assert.Equal(t, serveAsRole(constant.UserRoleCustomer, rbac.PermissionAdminAPI), http.StatusForbidden)
+	assert.Equal(t, serveAsRole(constant.UserRoleAdmin, rbac.PermissionLimitManage), http.StatusOK)
+	assert.Equal(t, serveAsRole("", rbac.PermissionAdminAPI), http.StatusForbidden)
+}
+
+// User service returning the current roles of the users
+type fakeRoleUserService struct {
+	service.IUserService
+	roles map[string]string
+}
+
+func (s *fakeRoleUserService) GetUserRole(ctx context.Context, currentUserID string) (string, int, error) {
+	role, found := s.roles[currentUserID]
+	if !found {
+		return "", http.StatusUnauthorized, service.ServiceError{ErrType: service.ErrTypeAuthenticationFailed, ErrMessage: service.ErrMessageInvalidAccessToken}
+	}
+	return role, http.StatusOK, nil
+}
+
+// Serve a request of the user, to an endpoint needing the permission, with the role loaded by CurrentRole
+func serveAsUser(userID string, permission string) int {
+	r := gin.New()
+	r.GET("/test", func(c *gin.Context) {
+		c.Set("current_user_id", userID)
+		c.Next()
+	}, CurrentRole, Authorization(permission), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	return w.Code
+}
+
+func TestCurrentRole(t *testing.T) {
+	userService := &fakeRoleUserService{roles: map[string]string{"staff": constant.UserRoleSupport}}
+	originalService := service.UserService
+	service.UserService = userService
+	t.Cleanup(func() { service.UserService = originalService })
+	assert.Equal(t, serveAsUser("staff", rbac.PermissionWalletRead), http.StatusOK)
+	// The role change applies to the next request of the same session
+	userService.roles["staff"] = constant.UserRoleCustomer
+	assert.Equal(t, serveAsUser("staff", rbac.PermissionWalletRead), http.StatusForbidden)
+	// The user doesn't exist anymore
+	assert.Equal(t, serveAsUser("removed", rbac.PermissionWalletRead), http.StatusUnauthorized)
+}
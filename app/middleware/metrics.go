@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"time"
+	"wallet-app-server/app/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics middleware
+// Observe the count and the latency of every request by the route pattern and the status code,
+// must be placed before the other middlewares so that the aborted requests are observed too
+func Metrics(c *gin.Context) {
+	startTime := time.Now()
+	// Process next handler
+	c.Next()
+	metrics.ObserveHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(startTime))
+}
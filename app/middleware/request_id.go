@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"log/slog"
+	"wallet-app-server/app/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Header of the request ID, taken from the request or generated, and echoed in the response
+const HeaderRequestID = "X-Request-ID"
+
+// Max length of a request ID taken from the request, a longer one is replaced
+const maxRequestIDLength = 128
+
+// Request ID middleware
+// Take the request ID from the request, or generate one, and echo it in the response
+// The request ID and the route are added to the context of the request, so that they are in every log line of the request
+func RequestID(c *gin.Context) {
+	requestID := c.GetHeader(HeaderRequestID)
+	if !isValidRequestID(requestID) {
+		requestID = uuid.New().String()
+	}
+	c.Set("request_id", requestID)
+	c.Header(HeaderRequestID, requestID)
+	ctx := logger.WithFields(c.Request.Context(), slog.String("request_id", requestID), slog.String("route", c.FullPath()))
+	c.Request = c.Request.WithContext(ctx)
+	// Process next handler
+	c.Next()
+}
+
+// A request ID from the request must be printable ASCII and not too long, since it's written to the logs
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+// Serve a request with the request ID header, and return the request ID echoed and the one seen by the handler
+func serveWithRequestID(requestID string) (string, string) {
+	var handlerRequestID string
+	r := gin.New()
+	r.GET("/test", RequestID, func(c *gin.Context) {
+		handlerRequestID = c.GetString("request_id")
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if requestID != "" {
+		req.Header.Set(HeaderRequestID, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Header().Get(HeaderRequestID), handlerRequestID
+}
+
+func TestRequestID(t *testing.T) {
+	// Taken from the request
+	echoed, seen := serveWithRequestID("lb-7f3a2c")
+	assert.Equal(t, echoed, "lb-7f3a2c")
+	assert.Equal(t, seen, "lb-7f3a2c")
+
+	// Generated
+	echoed, seen = serveWithRequestID("")
+	assert.Equal(t, len(echoed), 36)
+	assert.Equal(t, seen, echoed)
+
+	// Replaced if it can't be written to the logs as is
+	echoed, _ = serveWithRequestID("id with spaces")
+	assert.NotEqual(t, echoed, "id with spaces")
+	echoed, _ = serveWithRequestID(strings.Repeat("a", maxRequestIDLength+1))
+	assert.Equal(t, len(echoed), 36)
+}
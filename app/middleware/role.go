@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"wallet-app-server/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Current role middleware
+// The user's role is loaded from the DB on every request, so that a role change applies to the open sessions at once,
+// must be placed after the Authentication middleware
+func CurrentRole(c *gin.Context) {
+	userRole, statusCode, err := service.UserService.GetUserRole(c.Request.Context(), c.GetString("current_user_id"))
+	if err != nil {
+		c.AbortWithStatusJSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	// Set current_user_role
+	c.Set("current_user_role", userRole)
+	// Process next handler
+	c.Next()
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/migrate"
+)
+
+// Usage of the migrate subcommand
+const migrateUsage = `Usage: wallet-app-server [-c <config_path>] migrate <command>
+Commands:
+  status          list the migrations and whether they are applied
+  up              apply all the migrations which aren't applied
+  down [steps]    roll back the latest applied migration, or the latest steps of them
+  to <version>    migrate up or down to the version, 0 to roll back all the migrations
+Options of down and to:
+  --force         roll back the baseline migration 1 too, which drops all the tables`
+
+// Returned by Migrate if the arguments are invalid, after printing the usage
+var ErrMigrateUsage = errors.New("invalid arguments of the migrate subcommand")
+
+// Run the migrate subcommand with its arguments
+// Return ErrMigrateUsage if the arguments are invalid, or the error if the migration fails
+func Migrate(configPath string, args []string) error {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return ErrMigrateUsage
+	}
+	// Rolling back the baseline needs --force, after the arguments of down and to
+	force := false
+	if len(args) > 1 && args[len(args)-1] == "--force" && (args[0] == "down" || args[0] == "to") {
+		force, args = true, args[:len(args)-1]
+	}
+
+	// Init configuration, logger and DB
+	if err := config.LoadConfig(configPath, configValidators...); err != nil {
+		return err
+	}
+	if err := logger.Init(); err != nil {
+		return err
+	}
+	if err := db.Init(); err != nil {
+		return fmt.Errorf("DB init error: %w", err)
+	}
+	defer db.Close()
+	migrator, err := migrate.New(db.DB, config.Cfg.DB.Schema)
+	if err != nil {
+		return err
+	}
+
+	// The migrations may take longer than the statement timeout
+	ctx := db.WithoutStatementTimeout(context.Background())
+	var steps []migrate.Step
+	switch {
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatuses(statuses)
+		return nil
+	case args[0] == "up" && len(args) == 1:
+		steps, err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) <= 2:
+		n := 1
+		if len(args) == 2 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+				fmt.Printf("Invalid number of steps %q\n%s\n", args[1], migrateUsage)
+				return ErrMigrateUsage
+			}
+		}
+		steps, err = migrator.Down(ctx, n, force)
+	case args[0] == "to" && len(args) == 2:
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil || version < 0 {
+			fmt.Printf("Invalid version %q\n%s\n", args[1], migrateUsage)
+			return ErrMigrateUsage
+		}
+		steps, err = migrator.To(ctx, version, force)
+	default:
+		fmt.Println(migrateUsage)
+		return ErrMigrateUsage
+	}
+	if err != nil {
+		return fmt.Errorf("migration failed, the DB is unchanged: %w", err)
+	}
+	if len(steps) == 0 {
+		fmt.Println("No migration to apply or roll back")
+	}
+	for _, step := range steps {
+		action := "Applied"
+		if !step.Up {
+			action = "Rolled back"
+		}
+		fmt.Printf("%s %d %s\n", action, step.Migration.Version, step.Migration.Name)
+	}
+	return nil
+}
+
+// Print the statuses of the migrations as a table
+func printStatuses(statuses []migrate.Status) {
+	fmt.Printf("%-8s %-40s %-20s %s\n", "VERSION", "NAME", "APPLIED AT", "NOTE")
+	for _, status := range statuses {
+		appliedAt, note := "pending", ""
+		if status.Applied {
+			appliedAt = status.AppliedTime.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case status.Unknown:
+			note = "unknown to this server"
+		case status.Modified:
+			note = "checksum mismatch, the file has been changed since it was applied"
+		}
+		fmt.Printf("%-8d %-40s %-20s %s\n", status.Version, status.Name, appliedAt, note)
+	}
+}
+
+// Apply the migrations which aren't applied, the other instances starting at the same time wait for it
+func migrateOnStartup() error {
+	migrator, err := migrate.New(db.DB, config.Cfg.DB.Schema)
+	if err != nil {
+		return err
+	}
+	steps, err := migrator.Up(db.WithoutStatementTimeout(context.Background()))
+	if err != nil {
+		return err
+	}
+	logger.Infof("DB migrated, version: %d, applied migrations: %d", migrator.Latest(), len(steps))
+	return nil
+}
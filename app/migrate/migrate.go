@@ -0,0 +1,334 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/database"
+
+	"gorm.io/gorm"
+)
+
+// Key of the advisory lock held while migrating, so that only one server instance migrates at a time
+// The other instances wait for the lock, and then find the migrations applied
+const lockKey int64 = 0x77616c6c65745f6d // "wallet_m"
+
+// Directory of the migration files in the embedded file system
+const migrationDir = "migrations"
+
+// Version of the baseline migration, the schema as of the last upgrade script of database/upgrade
+// Rolling it back drops all the tables, so it needs to be forced
+const baselineVersion int64 = 1
+
+// Name of a migration file, the version, the name and the direction, e.g. 001-baseline.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)-([\w-]+)\.(up|down)\.sql$`)
+
+// A versioned migration of the schema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// SHA-256 of the up file, an applied migration must not be changed
+	Checksum string
+}
+
+// The record of an applied migration in the schema_migrations table
+type appliedMigration struct {
+	Version     int64     `gorm:"primaryKey;column:version"`
+	Name        string    `gorm:"column:migration_name"`
+	Checksum    string    `gorm:"column:checksum"`
+	AppliedTime time.Time `gorm:"column:applied_time"`
+}
+
+func (am *appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status of a migration
+type Status struct {
+	Version     int64
+	Name        string
+	Applied     bool
+	AppliedTime time.Time
+	// The migration file has been changed since it was applied
+	Modified bool
+	// Applied to the DB but unknown to this server, e.g. applied by a newer version of the server
+	Unknown bool
+}
+
+// A migration to apply (up) or to roll back (down)
+type Step struct {
+	Migration Migration
+	Up        bool
+}
+
+// Load the migrations from the directory of the file system, sorted by version
+// Every version must have both an up file and a down file
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, must be <version>-<name>.up.sql or <version>-<name>.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid version of migration file %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("duplicated migration version %d: %s and %s", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			checksum := sha256.Sum256(content)
+			migration.Up, migration.Checksum = string(content), hex.EncodeToString(checksum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migration %d %s must have both an up file and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// The statuses of the migrations, by version, with the applied migrations unknown to this server
+func statuses(migrations []Migration, applied []appliedMigration) []Status {
+	appliedByVersion := map[int64]appliedMigration{}
+	for _, am := range applied {
+		appliedByVersion[am.Version] = am
+	}
+	result := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if am, found := appliedByVersion[migration.Version]; found {
+			status.Applied, status.AppliedTime = true, am.AppliedTime
+			status.Modified = am.Checksum != migration.Checksum
+			delete(appliedByVersion, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, am := range appliedByVersion {
+		result = append(result, Status{Version: am.Version, Name: am.Name, Applied: true, AppliedTime: am.AppliedTime, Unknown: true})
+	}
+	slices.SortFunc(result, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return result
+}
+
+// Plan the steps to migrate to the target version, 0 to roll back all the migrations
+// The migrations up to the target which aren't applied are applied in order,
+// then the applied migrations after the target are rolled back in reverse order
+// Refuse to migrate if an applied migration has been changed or is unknown to this server,
+// or to roll back the baseline unless forced
+func plan(migrations []Migration, applied []appliedMigration, target int64, force bool) ([]Step, error) {
+	if target != 0 && !slices.ContainsFunc(migrations, func(m Migration) bool { return m.Version == target }) {
+		return nil, fmt.Errorf("unknown migration version %d", target)
+	}
+	var ups, downs []Step
+	for _, status := range statuses(migrations, applied) {
+		if status.Unknown {
+			return nil, fmt.Errorf("applied migration %d %s is unknown to this server, the DB may have been migrated by a newer version", status.Version, status.Name)
+		}
+		if status.Modified {
+			return nil, fmt.Errorf("checksum of applied migration %d %s doesn't match, the migration file has been changed since it was applied", status.Version, status.Name)
+		}
+		migration := migrations[slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == status.Version })]
+		if !status.Applied && status.Version <= target {
+			ups = append(ups, Step{Migration: migration, Up: true})
+		} else if status.Applied && status.Version > target {
+			if status.Version == baselineVersion && !force {
+				return nil, fmt.Errorf("rolling back the baseline migration %d %s drops all the tables, force it to roll back", status.Version, status.Name)
+			}
+			downs = append(downs, Step{Migration: migration, Up: false})
+		}
+	}
+	slices.Reverse(downs)
+	return append(ups, downs...), nil
+}
+
+// Migrator of the DB schema with the embedded migrations
+type Migrator struct {
+	db         *gorm.DB
+	schema     string
+	migrations []Migration
+}
+
+// Create the migrator of the schema of the DB with the embedded migrations
+func New(db *gorm.DB, schema string) (*Migrator, error) {
+	migrations, err := Load(database.Migrations, migrationDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, schema: schema, migrations: migrations}, nil
+}
+
+// The latest version of the migrations, 0 if there is no migration
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// The statuses of all the migrations, known to this server or applied to the DB
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn := m.db.WithContext(ctx)
+	var applied []appliedMigration
+	if conn.Migrator().HasTable(&appliedMigration{}) {
+		if err := conn.Order("version").Find(&applied).Error; err != nil {
+			return nil, err
+		}
+	}
+	return statuses(m.migrations, applied), nil
+}
+
+// Apply all the migrations which aren't applied
+func (m *Migrator) Up(ctx context.Context) ([]Step, error) {
+	return m.migrate(ctx, func([]appliedMigration) int64 { return m.Latest() }, false)
+}
+
+// Roll back the latest applied migrations, by the number of steps
+// The baseline is only rolled back if forced
+func (m *Migrator) Down(ctx context.Context, steps int, force bool) ([]Step, error) {
+	return m.migrate(ctx, func(applied []appliedMigration) int64 {
+		if steps >= len(applied) {
+			return 0
+		}
+		return applied[len(applied)-1-steps].Version
+	}, force)
+}
+
+// Migrate up or down to the version, 0 to roll back all the migrations
+// The baseline is only rolled back if forced
+func (m *Migrator) To(ctx context.Context, version int64, force bool) ([]Step, error) {
+	return m.migrate(ctx, func([]appliedMigration) int64 { return version }, force)
+}
+
+// Migrate to the target version given the applied migrations, in a single transaction holding the advisory lock
+// If any migration fails, the transaction is rolled back and the DB stays at the version it was
+// The DDL of Postgres is transactional, but a migration can't use the statements which can't run in a transaction,
+// e.g. CREATE INDEX CONCURRENTLY
+func (m *Migrator) migrate(ctx context.Context, target func(applied []appliedMigration) int64, force bool) ([]Step, error) {
+	var steps []Step
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Wait for the other instances migrating, the lock is released at the end of the transaction
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return fmt.Errorf("lock: %w", err)
+		}
+		if err := m.createTable(tx); err != nil {
+			return fmt.Errorf("create schema_migrations: %w", err)
+		}
+		var applied []appliedMigration
+		if err := tx.Order("version").Find(&applied).Error; err != nil {
+			return err
+		}
+		var err error
+		if steps, err = plan(m.migrations, applied, target(applied), force); err != nil {
+			return err
+		}
+		for _, step := range steps {
+			if err := apply(tx, step); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// Create the schema and the schema_migrations table if not exist
+func (m *Migrator) createTable(tx *gorm.DB) error {
+	if err := tx.Exec(fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s"`, strings.ReplaceAll(m.schema, `"`, `""`))).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL,
+    migration_name VARCHAR(100) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    applied_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_schema_migrations PRIMARY KEY(version)
+)`).Error
+}
+
+// Check an existing DB is upgraded with all the scripts of database/upgrade before the baseline is applied,
+// the baseline would otherwise create the missing tables of the skipped scripts but not their other changes
+// A DB without the wallet table is a new DB, created by the baseline
+func checkBaseline(tx *gorm.DB) error {
+	var existing bool
+	if err := tx.Raw("SELECT to_regclass('wallet_app.wallet') IS NOT NULL").Scan(&existing).Error; err != nil {
+		return err
+	}
+	if !existing {
+		return nil
+	}
+	// The system adjustment wallet is added by the last upgrade script, 013-adjustment.sql
+	var count int64
+	if err := tx.Raw("SELECT COUNT(*) FROM wallet_app.wallet WHERE wallet_id = 'system-adjustment'").Scan(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("the DB isn't upgraded to database/upgrade/013-adjustment.sql, apply the remaining upgrade scripts before the migrations")
+	}
+	return nil
+}
+
+// Apply or roll back the migration, and record it in the schema_migrations table
+func apply(tx *gorm.DB, step Step) error {
+	migration := step.Migration
+	if step.Up {
+		if migration.Version == baselineVersion {
+			if err := checkBaseline(tx); err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return fmt.Errorf("apply migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+		if err := tx.Create(&appliedMigration{Version: migration.Version, Name: migration.Name, Checksum: migration.Checksum, AppliedTime: time.Now()}).Error; err != nil {
+			return err
+		}
+		logger.Infof("Migration applied, version: %d, name: %s", migration.Version, migration.Name)
+		return nil
+	}
+	if err := tx.Exec(migration.Down).Error; err != nil {
+		return fmt.Errorf("roll back migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	if err := tx.Where("version = ?", migration.Version).Delete(&appliedMigration{}).Error; err != nil {
+		return err
+	}
+	logger.Infof("Migration rolled back, version: %d, name: %s", migration.Version, migration.Name)
+	return nil
+}
@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"wallet-app-server/database"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "baseline", Checksum: "c1"},
+	{Version: 2, Name: "wallet-note", Checksum: "c2"},
+	{Version: 3, Name: "wallet-note-index", Checksum: "c3"},
+}
+
+func applied(versions ...int64) []appliedMigration {
+	var result []appliedMigration
+	for _, version := range versions {
+		result = append(result, appliedMigration{Version: version, Name: testMigrations[version-1].Name, Checksum: testMigrations[version-1].Checksum})
+	}
+	return result
+}
+
+func versions(steps []Step) []int64 {
+	var result []int64
+	for _, step := range steps {
+		if !step.Up {
+			result = append(result, -step.Migration.Version)
+			continue
+		}
+		result = append(result, step.Migration.Version)
+	}
+	return result
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load(database.Migrations, migrationDir)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, migrations[0].Version, int64(1))
+	assert.Equal(t, migrations[0].Name, "baseline")
+	assert.Equal(t, len(migrations[0].Checksum), 64)
+}
+
+// Every table of wallet-app.sql is created by the migrations
+func TestMigrationsMatchSchema(t *testing.T) {
+	schema, err := os.ReadFile("../../database/wallet-app.sql")
+	assert.Equal(t, err, nil)
+	migrations, err := Load(database.Migrations, migrationDir)
+	assert.Equal(t, err, nil)
+	var ups strings.Builder
+	for _, migration := range migrations {
+		ups.WriteString(migration.Up)
+	}
+	for _, table := range regexp.MustCompile(`CREATE TABLE (\S+)`).FindAllStringSubmatch(string(schema), -1) {
+		assert.Equal(t, strings.Contains(ups.String(), "CREATE TABLE IF NOT EXISTS "+table[1]+" ("), true)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/002-wallet-note.up.sql":   {Data: []byte("ALTER TABLE wallet ADD COLUMN note TEXT;")},
+		"migrations/002-wallet-note.down.sql": {Data: []byte("ALTER TABLE wallet DROP COLUMN note;")},
+		"migrations/001-baseline.up.sql":      {Data: []byte("CREATE TABLE wallet (wallet_id TEXT);")},
+		"migrations/001-baseline.down.sql":    {Data: []byte("DROP TABLE wallet;")},
+	}
+	migrations, err := Load(fsys, "migrations")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(migrations), 2)
+	assert.Equal(t, migrations[0].Name, "baseline")
+	assert.Equal(t, migrations[1].Version, int64(2))
+	assert.Equal(t, migrations[1].Down, "ALTER TABLE wallet DROP COLUMN note;")
+
+	// Missing down file
+	delete(fsys, "migrations/002-wallet-note.down.sql")
+	_, err = Load(fsys, "migrations")
+	assert.NotEqual(t, err, nil)
+	// Duplicated version
+	fsys["migrations/002-wallet-note.down.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE wallet DROP COLUMN note;")}
+	fsys["migrations/002-other.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = Load(fsys, "migrations")
+	assert.NotEqual(t, err, nil)
+	// Invalid file name
+	delete(fsys, "migrations/002-other.up.sql")
+	fsys["migrations/wallet-note.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = Load(fsys, "migrations")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestPlan(t *testing.T) {
+	cases := []struct {
+		applied []appliedMigration
+		target  int64
+		steps   []int64
+	}{
+		// Up from an empty DB
+		{nil, 3, []int64{1, 2, 3}},
+		{applied(1), 3, []int64{2, 3}},
+		// Already at the target
+		{applied(1, 2, 3), 3, nil},
+		// Down, in reverse order
+		{applied(1, 2, 3), 1, []int64{-3, -2}},
+		// A missing migration before the latest applied one is applied
+		{applied(1, 3), 3, []int64{2}},
+	}
+	for _, c := range cases {
+		steps, err := plan(testMigrations, c.applied, c.target, false)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, versions(steps), c.steps)
+	}
+}
+
+// Rolling back the baseline drops all the tables, it's refused unless forced
+func TestPlanBaselineRollback(t *testing.T) {
+	_, err := plan(testMigrations, applied(1, 2), 0, false)
+	assert.NotEqual(t, err, nil)
+	steps, err := plan(testMigrations, applied(1, 2), 0, true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versions(steps), []int64{-2, -1})
+}
+
+func TestPlanRefused(t *testing.T) {
+	// Unknown target
+	_, err := plan(testMigrations, nil, 4, false)
+	assert.NotEqual(t, err, nil)
+	// The applied migration has been changed
+	changed := applied(1, 2)
+	changed[1].Checksum = "changed"
+	_, err = plan(testMigrations, changed, 3, false)
+	assert.NotEqual(t, err, nil)
+	// The DB has been migrated by a newer server
+	_, err = plan(testMigrations, append(applied(1), appliedMigration{Version: 4, Name: "newer"}), 3, false)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestStatuses(t *testing.T) {
+	changed := applied(1, 2)
+	changed[1].Checksum = "changed"
+	result := statuses(testMigrations, append(changed, appliedMigration{Version: 4, Name: "newer"}))
+	assert.Equal(t, len(result), 4)
+	assert.Equal(t, result[0].Applied, true)
+	assert.Equal(t, result[0].Modified, false)
+	assert.Equal(t, result[1].Modified, true)
+	assert.Equal(t, result[2].Applied, false)
+	assert.Equal(t, result[3].Unknown, true)
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// An operation waiting for the approval of a second person
+// Payload is the operation to be executed on approval, its fields depend on the operation type
+type PendingOperation struct {
+	OperationID     string                  `json:"operation_id"`
+	OperationType   string                  `json:"operation_type"`
+	Payload         json.RawMessage         `json:"payload"`
+	Summary         string                  `json:"summary"`
+	OperationStatus string                  `json:"operation_status"`
+	RequestBy       string                  `json:"request_by"`
+	RequestNote     string                  `json:"request_note,omitempty"`
+	ReviewBy        string                  `json:"review_by,omitempty"`
+	ReviewNote      string                  `json:"review_note,omitempty"`
+	ResultRef       string                  `json:"result_ref,omitempty"`
+	ExpireTime      time.Time               `json:"expire_time"`
+	CreateTime      time.Time               `json:"create_time"`
+	AuditTrail      []PendingOperationAudit `json:"audit_trail,omitempty"`
+}
+
+type PendingOperationAudit struct {
+	AuditAction string    `json:"audit_action"`
+	ActorID     string    `json:"actor_id"`
+	AuditNote   string    `json:"audit_note,omitempty"`
+	CreateTime  time.Time `json:"create_time"`
+}
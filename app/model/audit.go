@@ -0,0 +1,12 @@
+package model
+
+// A request to the admin endpoints, to be recorded in the admin audit log
+type AdminAudit struct {
+	OperatorID   string
+	OperatorRole string
+	AuditAction  string
+	RequestPath  string
+	StatusCode   int
+	ClientIP     string
+	AuditDetail  string
+}
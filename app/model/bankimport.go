@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type BankStatementEntry struct {
+	// The IBAN or other ID of the bank account of the statement, normalized
+	Account     string          `json:"account"`
+	BankRef     string          `json:"bank_ref"`
+	BookingTime time.Time       `json:"booking_time"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	Credit      bool            `json:"credit"`
+	Reference   string          `json:"reference"`
+	DebtorName  string          `json:"debtor_name"`
+}
+
+type BankImportResult struct {
+	ImportID       string `json:"import_id"`
+	LineCount      int    `json:"line_count"`
+	MatchedCount   int    `json:"matched_count"`
+	UnmatchedCount int    `json:"unmatched_count"`
+	DuplicateCount int    `json:"duplicate_count"`
+	SkippedCount   int    `json:"skipped_count"`
+}
+
+type BankStatementLine struct {
+	LineID      string          `json:"line_id"`
+	ImportID    string          `json:"import_id"`
+	Account     string          `json:"account,omitempty"`
+	BankRef     string          `json:"bank_ref"`
+	BookingTime time.Time       `json:"booking_time"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	Reference   string          `json:"reference"`
+	DebtorName  string          `json:"debtor_name"`
+	LineStatus  string          `json:"line_status"`
+	WalletID    string          `json:"wallet_id,omitempty"`
+	TxnID       string          `json:"txn_id,omitempty"`
+	ReviewBy    string          `json:"review_by,omitempty"`
+	ReviewNote  string          `json:"review_note,omitempty"`
+}
@@ -0,0 +1,10 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+type FeeQuote struct {
+	TxnType    string          `json:"txn_type"`
+	Amount     decimal.Decimal `json:"amount"`
+	Fee        decimal.Decimal `json:"fee"`
+	TotalDebit decimal.Decimal `json:"total_debit"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type KYCDocument struct {
+	DocumentID   string    `json:"document_id"`
+	UserID       string    `json:"user_id"`
+	DocumentType string    `json:"document_type"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	FileSize     int64     `json:"file_size"`
+	FileHash     string    `json:"file_hash"`
+	SubmissionID string    `json:"submission_id,omitempty"`
+	CreateTime   time.Time `json:"create_time"`
+}
+
+type KYCSubmission struct {
+	SubmissionID     string        `json:"submission_id"`
+	UserID           string        `json:"user_id"`
+	TargetLevel      string        `json:"target_level"`
+	SubmissionStatus string        `json:"submission_status"`
+	Documents        []KYCDocument `json:"documents"`
+	ReviewBy         string        `json:"review_by,omitempty"`
+	ReviewReason     string        `json:"review_reason,omitempty"`
+	CreateTime       time.Time     `json:"create_time"`
+	UpdateTime       *time.Time    `json:"update_time,omitempty"`
+}
+
+// The user's KYC level and what the user can do at the level
+// If the level isn't restricted, every transaction is allowed and capabilities is null
+type KYCStatus struct {
+	KYCLevel         string          `json:"kyc_level"`
+	Restricted       bool            `json:"restricted"`
+	Capabilities     []KYCCapability `json:"capabilities"`
+	LatestSubmission *KYCSubmission  `json:"latest_submission"`
+}
+
+// A transaction type allowed at the KYC level, a null max amount means no upper bound
+type KYCCapability struct {
+	TxnType   string           `json:"txn_type"`
+	MaxAmount *decimal.Decimal `json:"max_amount"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// Limits of a transaction type, zero means no limit
+type UserLimit struct {
+	UserID        string          `json:"user_id"`
+	TxnType       string          `json:"txn_type"`
+	PerTxnAmount  decimal.Decimal `json:"per_txn_amount"`
+	DailyAmount   decimal.Decimal `json:"daily_amount"`
+	DailyCount    int64           `json:"daily_count"`
+	MonthlyAmount decimal.Decimal `json:"monthly_amount"`
+	MonthlyCount  int64           `json:"monthly_count"`
+}
+
+// Limits of a transaction type with the usage, a null limit means no limit
+type LimitStatus struct {
+	TxnType      string            `json:"txn_type"`
+	Source       string            `json:"source"`
+	PerTxnAmount *decimal.Decimal  `json:"per_txn_amount"`
+	Daily        LimitPeriodStatus `json:"daily"`
+	Monthly      LimitPeriodStatus `json:"monthly"`
+}
+
+type LimitPeriodStatus struct {
+	AmountLimit     *decimal.Decimal `json:"amount_limit"`
+	AmountUsed      decimal.Decimal  `json:"amount_used"`
+	AmountRemaining *decimal.Decimal `json:"amount_remaining"`
+	CountLimit      *int64           `json:"count_limit"`
+	CountUsed       int64            `json:"count_used"`
+	CountRemaining  *int64           `json:"count_remaining"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PayoutDestination struct {
+	DestinationID     string    `json:"destination_id"`
+	IBAN              string    `json:"iban"`
+	HolderName        string    `json:"holder_name"`
+	BIC               string    `json:"bic,omitempty"`
+	DestinationStatus string    `json:"destination_status"`
+	CreateTime        time.Time `json:"create_time"`
+}
+
+type PayoutInfo struct {
+	PayoutID      string          `json:"payout_id"`
+	WalletID      string          `json:"wallet_id"`
+	DestinationID string          `json:"destination_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	TxnID         string          `json:"txn_id"`
+	PayoutStatus  string          `json:"payout_status"`
+	BatchID       string          `json:"batch_id,omitempty"`
+	ReturnReason  string          `json:"return_reason,omitempty"`
+	ReturnTxnID   string          `json:"return_txn_id,omitempty"`
+	CreateTime    time.Time       `json:"create_time"`
+}
+
+// Result of a payout request, the payout is only queued if completed
+type PayoutResult struct {
+	Payout   PayoutInfo
+	Status   string
+	ReviewID string
+}
+
+type PayoutBatchResult struct {
+	BatchID     string          `json:"batch_id,omitempty"`
+	MsgID       string          `json:"msg_id,omitempty"`
+	PayoutCount int             `json:"payout_count"`
+	TotalAmount decimal.Decimal `json:"total_amount"`
+	FileName    string          `json:"file_name,omitempty"`
+	// The queued payouts left out of the batch since their account holder is blocked by the sanctions screening
+	BlockedCount int `json:"blocked_count"`
+}
+
+// A batch of payouts to be written as one payment instruction of a pain.001 file
+type PayoutBatch struct {
+	MsgID         string
+	CreateTime    time.Time
+	ExecutionDate time.Time
+	Currency      string
+	DebtorName    string
+	DebtorIBAN    string
+	DebtorBIC     string
+	Transfers     []PayoutTransfer
+}
+
+type PayoutTransfer struct {
+	EndToEndID     string
+	Amount         decimal.Decimal
+	CreditorName   string
+	CreditorIBAN   string
+	CreditorBIC    string
+	RemittanceInfo string
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type ReconcileReport struct {
+	ReconcileTime time.Time       `json:"reconcile_time"`
+	WalletCount   int             `json:"wallet_count"`
+	MismatchCount int             `json:"mismatch_count"`
+	TotalDrift    decimal.Decimal `json:"total_drift"`
+	Mismatches    []WalletDrift   `json:"mismatches"`
+}
+
+type WalletDrift struct {
+	WalletID       string          `json:"wallet_id"`
+	Balance        decimal.Decimal `json:"balance"`
+	HistoryBalance decimal.Decimal `json:"history_balance"`
+	Drift          decimal.Decimal `json:"drift"`
+	Frozen         bool            `json:"frozen"`
+}
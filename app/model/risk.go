@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type RiskReview struct {
+	ReviewID     string          `json:"review_id"`
+	UserID       string          `json:"user_id"`
+	TxnType      string          `json:"txn_type"`
+	FromWalletID string          `json:"from_wallet_id"`
+	ToWalletID   string          `json:"to_wallet_id,omitempty"`
+	Amount       decimal.Decimal `json:"amount"`
+	DeviceID     string          `json:"device_id,omitempty"`
+	RiskScore    int             `json:"risk_score"`
+	MatchedRules []string        `json:"matched_rules"`
+	ReviewStatus string          `json:"review_status"`
+	TxnID        string          `json:"txn_id,omitempty"`
+	ReviewBy     string          `json:"review_by,omitempty"`
+	ReviewNote   string          `json:"review_note,omitempty"`
+	// The destination of a held payout
+	PayoutDestinationID string    `json:"payout_destination_id,omitempty"`
+	CreateTime          time.Time `json:"create_time"`
+}
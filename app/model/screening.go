@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type ScreeningListVersion struct {
+	ListVersion string    `json:"list_version"`
+	EntryCount  int       `json:"entry_count"`
+	LoadTime    time.Time `json:"load_time"`
+}
+
+type ScreeningCase struct {
+	CaseID           string           `json:"case_id"`
+	UserID           string           `json:"user_id,omitempty"`
+	SubjectRef       string           `json:"subject_ref"`
+	ScreenedName     string           `json:"screened_name"`
+	ScreeningContext string           `json:"screening_context"`
+	ListVersion      string           `json:"list_version"`
+	MatchScore       decimal.Decimal  `json:"match_score"`
+	Matches          []ScreeningMatch `json:"matches"`
+	CaseStatus       string           `json:"case_status"`
+	ReviewBy         string           `json:"review_by,omitempty"`
+	ReviewNote       string           `json:"review_note,omitempty"`
+	CreateTime       time.Time        `json:"create_time"`
+}
+
+// A sanctions list entry matching the screened name
+type ScreeningMatch struct {
+	Source      string  `json:"source"`
+	EntryID     string  `json:"entry_id"`
+	EntryName   string  `json:"entry_name"`
+	MatchedName string  `json:"matched_name"`
+	SubjectType string  `json:"subject_type"`
+	Programs    string  `json:"programs,omitempty"`
+	Score       float64 `json:"score"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type StatementSummary struct {
+	WalletID       string          `json:"wallet_id"`
+	FromTime       time.Time       `json:"from_time"`
+	ToTime         time.Time       `json:"to_time"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+	TxnCount       int             `json:"txn_count"`
+}
+
+type StatementLine struct {
+	TxnID          string          `json:"txn_id"`
+	FromWalletID   string          `json:"from_wallet_id"`
+	ToWalletID     string          `json:"to_wallet_id"`
+	TxnAmount      decimal.Decimal `json:"txn_amount"`
+	TxnTypeDesc    string          `json:"txn_type_desc"`
+	TxnTime        time.Time       `json:"txn_time"`
+	PostingAmount  decimal.Decimal `json:"posting_amount"`
+	RunningBalance decimal.Decimal `json:"running_balance"`
+}
@@ -13,4 +13,13 @@ type TransactionHistory struct {
 	TxnAmount    decimal.Decimal `json:"txn_amount"`
 	TxnTypeDesc  string          `json:"txn_type_desc"`
 	TxnTime      time.Time       `json:"txn_time"`
+	ParentTxnID  string          `json:"parent_txn_id,omitempty"`
+}
+
+// Result of a requested transfer, either completed, pending for the risk review, or pending for approval
+type TransferResult struct {
+	TxnID       string
+	Status      string
+	ReviewID    string
+	OperationID string
 }
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type UserProfile struct {
+	UserID     string    `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	FullName   string    `json:"full_name,omitempty"`
+	UserTier   string    `json:"user_tier"`
+	KYCLevel   string    `json:"kyc_level"`
+	UserRole   string    `json:"user_role"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+// Result of a registration, the user and the user's default wallet
+type RegisterResult struct {
+	User   UserProfile `json:"user"`
+	Wallet WalletInfo  `json:"wallet"`
+}
+
+// The user session stored with the access token
+// The role isn't attached to the session, it's loaded from the DB on every request to the admin endpoints
+type Session struct {
+	UserID string `json:"user_id"`
+}
+
+type UserActivity struct {
+	UserActID     string    `json:"user_act_id"`
+	UserActType   string    `json:"user_act_type"`
+	UserActDetail string    `json:"user_act_detail"`
+	UserWalletID  string    `json:"user_wallet_id,omitempty"`
+	UserActTime   time.Time `json:"user_act_time"`
+}
@@ -1,6 +1,36 @@
 package model
 
+import "github.com/shopspring/decimal"
+
 type WalletInfo struct {
-	WalletID   string `json:"wallet_id"`
-	WalletName string `json:"wallet_name"`
+	WalletID      string `json:"wallet_id"`
+	WalletName    string `json:"wallet_name"`
+	ReferenceCode string `json:"reference_code,omitempty"`
+}
+
+// A wallet seen by the staff, with its status, balance and owner
+type WalletDetail struct {
+	WalletID      string          `json:"wallet_id"`
+	WalletName    string          `json:"wallet_name"`
+	WalletType    string          `json:"wallet_type"`
+	WalletStatus  string          `json:"wallet_status"`
+	ReferenceCode string          `json:"reference_code,omitempty"`
+	OwnerID       string          `json:"owner_id,omitempty"`
+	Balance       decimal.Decimal `json:"balance"`
+}
+
+// Result of a requested withdraw, either completed, or pending for the risk review
+type WithdrawResult struct {
+	Balance  decimal.Decimal
+	Status   string
+	ReviewID string
+}
+
+// A manual balance adjustment of a wallet by the staff
+type WalletAdjustment struct {
+	WalletID      string          `json:"wallet_id"`
+	Direction     string          `json:"direction"`
+	Amount        decimal.Decimal `json:"amount"`
+	ReasonCode    string          `json:"reason_code"`
+	Justification string          `json:"justification"`
 }
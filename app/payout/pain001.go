@@ -0,0 +1,163 @@
+package payout
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"wallet-app-server/app/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// ISO 20022 customer credit transfer initiation (pain.001.001.10) namespace
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.10"
+
+// ISO 20022 code values
+const (
+	painPaymentMethodTransfer = "TRF"
+	painChargeBearerShared    = "SLEV"
+	painNotProvided           = "NOTPROVIDED"
+	painDateTimeFormat        = "2006-01-02T15:04:05.000Z07:00"
+	painDateFormat            = "2006-01-02"
+	painMaxTextLen            = 140
+)
+
+const ErrEmptyBatch = "payout batch has no transfer"
+
+type painDocument struct {
+	XMLName xml.Name             `xml:"Document"`
+	Xmlns   string               `xml:"xmlns,attr"`
+	GrpHdr  painGroupHeader      `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PmtInf  painPaymentInstrInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type painGroupHeader struct {
+	MsgId    string `xml:"MsgId"`
+	CreDtTm  string `xml:"CreDtTm"`
+	NbOfTxs  string `xml:"NbOfTxs"`
+	CtrlSum  string `xml:"CtrlSum"`
+	InitgPty string `xml:"InitgPty>Nm"`
+}
+
+type painPaymentInstrInfo struct {
+	PmtInfId    string            `xml:"PmtInfId"`
+	PmtMtd      string            `xml:"PmtMtd"`
+	BtchBookg   bool              `xml:"BtchBookg"`
+	NbOfTxs     string            `xml:"NbOfTxs"`
+	CtrlSum     string            `xml:"CtrlSum"`
+	ReqdExctnDt string            `xml:"ReqdExctnDt>Dt"`
+	Dbtr        string            `xml:"Dbtr>Nm"`
+	DbtrAcct    string            `xml:"DbtrAcct>Id>IBAN"`
+	DbtrAgt     painAgent         `xml:"DbtrAgt"`
+	ChrgBr      string            `xml:"ChrgBr"`
+	CdtTrfTxInf []painTransaction `xml:"CdtTrfTxInf"`
+}
+
+// Financial institution, identified by BIC or "not provided"
+type painAgent struct {
+	BICFI string     `xml:"FinInstnId>BICFI,omitempty"`
+	Othr  *painOther `xml:"FinInstnId>Othr,omitempty"`
+}
+
+type painOther struct {
+	Id string `xml:"Id"`
+}
+
+type painAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type painTransaction struct {
+	InstrId    string     `xml:"PmtId>InstrId"`
+	EndToEndId string     `xml:"PmtId>EndToEndId"`
+	InstdAmt   painAmount `xml:"Amt>InstdAmt"`
+	CdtrAgt    *painAgent `xml:"CdtrAgt,omitempty"`
+	Cdtr       string     `xml:"Cdtr>Nm"`
+	CdtrAcct   string     `xml:"CdtrAcct>Id>IBAN"`
+	Ustrd      string     `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+// Write the payout batch as a pain.001 credit transfer initiation file,
+// with one payment instruction debiting the configured account
+func WritePain001(w io.Writer, batch model.PayoutBatch) error {
+	if len(batch.Transfers) == 0 {
+		return errors.New(ErrEmptyBatch)
+	}
+	numberOfTxs := strconv.Itoa(len(batch.Transfers))
+	controlSum := ControlSum(batch.Transfers).StringFixed(2)
+	doc := painDocument{
+		Xmlns: pain001Namespace,
+		GrpHdr: painGroupHeader{
+			MsgId:    batch.MsgID,
+			CreDtTm:  batch.CreateTime.Format(painDateTimeFormat),
+			NbOfTxs:  numberOfTxs,
+			CtrlSum:  controlSum,
+			InitgPty: truncate(batch.DebtorName, painMaxTextLen),
+		},
+		PmtInf: painPaymentInstrInfo{
+			PmtInfId:    batch.MsgID,
+			PmtMtd:      painPaymentMethodTransfer,
+			BtchBookg:   true,
+			NbOfTxs:     numberOfTxs,
+			CtrlSum:     controlSum,
+			ReqdExctnDt: batch.ExecutionDate.Format(painDateFormat),
+			Dbtr:        truncate(batch.DebtorName, painMaxTextLen),
+			DbtrAcct:    batch.DebtorIBAN,
+			DbtrAgt:     newAgent(batch.DebtorBIC),
+			ChrgBr:      painChargeBearerShared,
+		},
+	}
+	for _, transfer := range batch.Transfers {
+		tx := painTransaction{
+			InstrId:    transfer.EndToEndID,
+			EndToEndId: transfer.EndToEndID,
+			InstdAmt:   painAmount{Ccy: batch.Currency, Value: transfer.Amount.StringFixed(2)},
+			Cdtr:       truncate(transfer.CreditorName, painMaxTextLen),
+			CdtrAcct:   transfer.CreditorIBAN,
+			Ustrd:      truncate(transfer.RemittanceInfo, painMaxTextLen),
+		}
+		if transfer.CreditorBIC != "" {
+			agent := newAgent(transfer.CreditorBIC)
+			tx.CdtrAgt = &agent
+		}
+		doc.PmtInf.CdtTrfTxInf = append(doc.PmtInf.CdtTrfTxInf, tx)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Sum of the transfer amounts
+func ControlSum(transfers []model.PayoutTransfer) decimal.Decimal {
+	sum := decimal.Zero
+	for _, transfer := range transfers {
+		sum = sum.Add(transfer.Amount)
+	}
+	return sum
+}
+
+func newAgent(bic string) painAgent {
+	if bic != "" {
+		return painAgent{BICFI: bic}
+	}
+	return painAgent{Othr: &painOther{Id: painNotProvided}}
+}
+
+// Truncate the text to the maximum number of characters
+func truncate(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) > maxLen {
+		return string(runes[:maxLen])
+	}
+	return text
+}
@@ -0,0 +1,81 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func testPayoutBatch() model.PayoutBatch {
+	return model.PayoutBatch{
+		MsgID:         "c0ffee00c0ffee00c0ffee00c0ffee00",
+		CreateTime:    time.Date(2025, 6, 16, 18, 0, 0, 0, time.UTC),
+		ExecutionDate: time.Date(2025, 6, 17, 0, 0, 0, 0, time.UTC),
+		Currency:      "EUR",
+		DebtorName:    "Wallet App Ltd",
+		DebtorIBAN:    "GB82WEST12345698765432",
+		Transfers: []model.PayoutTransfer{
+			{EndToEndID: "payout0", Amount: decimal.RequireFromString("100.5"), CreditorName: "Vence Lin", CreditorIBAN: "DE89370400440532013000", CreditorBIC: "COBADEFFXXX", RemittanceInfo: "Wallet payout payout0"},
+			{EndToEndID: "payout1", Amount: decimal.RequireFromString("20"), CreditorName: "Mike Kwok & Co", CreditorIBAN: "FR1420041010050500013M02606"},
+		},
+	}
+}
+
+// Validate the pain.001 file against the published ISO 20022 schema (testdata/pain.001.001.10.xsd)
+// xmllint is used as the schema validator, the test is skipped if it's not installed
+func TestPain001Schema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed, skip schema validation")
+	}
+	var buf bytes.Buffer
+	assert.Equal(t, nil, WritePain001(&buf, testPayoutBatch()))
+	path := filepath.Join(t.TempDir(), "pain001.xml")
+	assert.Equal(t, nil, os.WriteFile(path, buf.Bytes(), 0644))
+	output, err := exec.Command(xmllint, "--noout", "--schema", "testdata/pain.001.001.10.xsd", path).CombinedOutput()
+	t.Log(string(output))
+	assert.Equal(t, nil, err)
+}
+
+func TestPain001Content(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, nil, WritePain001(&buf, testPayoutBatch()))
+	var doc struct {
+		NbOfTxs string `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
+		CtrlSum string `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+		DbtrAgt string `xml:"CstmrCdtTrfInitn>PmtInf>DbtrAgt>FinInstnId>Othr>Id"`
+		Txs     []struct {
+			EndToEndId string `xml:"PmtId>EndToEndId"`
+			Amt        string `xml:"Amt>InstdAmt"`
+			BIC        string `xml:"CdtrAgt>FinInstnId>BICFI"`
+			Cdtr       string `xml:"Cdtr>Nm"`
+			IBAN       string `xml:"CdtrAcct>Id>IBAN"`
+		} `xml:"CstmrCdtTrfInitn>PmtInf>CdtTrfTxInf"`
+	}
+	assert.Equal(t, nil, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "2", doc.NbOfTxs)
+	assert.Equal(t, "120.50", doc.CtrlSum)
+	assert.Equal(t, "NOTPROVIDED", doc.DbtrAgt)
+	assert.Equal(t, 2, len(doc.Txs))
+	assert.Equal(t, "payout0", doc.Txs[0].EndToEndId)
+	assert.Equal(t, "100.50", doc.Txs[0].Amt)
+	assert.Equal(t, "COBADEFFXXX", doc.Txs[0].BIC)
+	assert.Equal(t, "", doc.Txs[1].BIC)
+	assert.Equal(t, "Mike Kwok & Co", doc.Txs[1].Cdtr)
+	assert.Equal(t, "FR1420041010050500013M02606", doc.Txs[1].IBAN)
+}
+
+func TestPain001EmptyBatch(t *testing.T) {
+	batch := testPayoutBatch()
+	batch.Transfers = nil
+	err := WritePain001(&bytes.Buffer{}, batch)
+	assert.Equal(t, ErrEmptyBatch, err.Error())
+}
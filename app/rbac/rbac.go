@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"slices"
+	"wallet-app-server/app/constant"
+)
+
+// Permissions of the operations on the admin API
+const (
+	PermissionAdminAPI        = "admin_api.access"
+	PermissionUserRead        = "user.read"
+	PermissionUserManage      = "user.manage"
+	PermissionWalletRead      = "wallet.read"
+	PermissionWalletManage    = "wallet.manage"
+	PermissionWalletAdjust    = "wallet.adjust"
+	PermissionBankImport      = "bank_import.manage"
+	PermissionPayoutManage    = "payout.manage"
+	PermissionLimitManage     = "limit.manage"
+	PermissionRiskReview      = "risk.review"
+	PermissionScreeningManage = "screening.manage"
+	PermissionKYCReview       = "kyc.review"
+	PermissionApprovalReview  = "approval.review"
+)
+
+// The user roles, a customer has none of the permissions
+var Roles = []string{constant.UserRoleCustomer, constant.UserRoleSupport, constant.UserRoleCompliance, constant.UserRoleAdmin}
+
+// Permissions of each role
+var rolePermissions = map[string][]string{
+	constant.UserRoleSupport: {
+		PermissionAdminAPI,
+		PermissionUserRead,
+		PermissionWalletRead,
+	},
+	constant.UserRoleCompliance: {
+		PermissionAdminAPI,
+		PermissionUserRead,
+		PermissionWalletRead,
+		PermissionRiskReview,
+		PermissionScreeningManage,
+		PermissionKYCReview,
+		PermissionApprovalReview,
+	},
+	constant.UserRoleAdmin: {
+		PermissionAdminAPI,
+		PermissionUserRead,
+		PermissionUserManage,
+		PermissionWalletRead,
+		PermissionWalletManage,
+		PermissionWalletAdjust,
+		PermissionBankImport,
+		PermissionPayoutManage,
+		PermissionLimitManage,
+		PermissionRiskReview,
+		PermissionScreeningManage,
+		PermissionKYCReview,
+		PermissionApprovalReview,
+	},
+}
+
+// Check if the role is one of the user roles
+func IsRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// Check if the role has the permission, an unknown role has no permission
+func HasPermission(role string, permission string) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// Permissions of the role
+func Permissions(role string) []string {
+	return slices.Clone(rolePermissions[role])
+}
@@ -0,0 +1,44 @@
+package rbac
+
+import (
+	"testing"
+	"wallet-app-server/app/constant"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestHasPermission(t *testing.T) {
+	assert.Equal(t, HasPermission(constant.UserRoleCustomer, PermissionAdminAPI), false)
+	assert.Equal(t, HasPermission(constant.UserRoleSupport, PermissionWalletRead), true)
+	assert.Equal(t, HasPermission(constant.UserRoleSupport, PermissionWalletAdjust), false)
+	assert.Equal(t, HasPermission(constant.UserRoleSupport, PermissionApprovalReview), false)
+	assert.Equal(t, HasPermission(constant.UserRoleCompliance, PermissionWalletAdjust), false)
+	assert.Equal(t, HasPermission(constant.UserRoleCompliance, PermissionKYCReview), true)
+	assert.Equal(t, HasPermission(constant.UserRoleCompliance, PermissionLimitManage), false)
+	assert.Equal(t, HasPermission(constant.UserRoleAdmin, PermissionUserManage), true)
+	assert.Equal(t, HasPermission("root", PermissionAdminAPI), false)
+	assert.Equal(t, HasPermission("", PermissionAdminAPI), false)
+}
+
+func TestEveryStaffRoleCanAccessAdminAPI(t *testing.T) {
+	for _, role := range Roles {
+		if role == constant.UserRoleCustomer {
+			continue
+		}
+		assert.Equal(t, HasPermission(role, PermissionAdminAPI), true)
+	}
+}
+
+func TestAdminHasEveryPermission(t *testing.T) {
+	for _, role := range Roles {
+		for _, permission := range Permissions(role) {
+			assert.Equal(t, HasPermission(constant.UserRoleAdmin, permission), true)
+		}
+	}
+}
+
+func TestIsRole(t *testing.T) {
+	assert.Equal(t, IsRole(constant.UserRoleCustomer), true)
+	assert.Equal(t, IsRole(constant.UserRoleAdmin), true)
+	assert.Equal(t, IsRole("superuser"), false)
+}
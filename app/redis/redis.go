@@ -2,7 +2,6 @@ package redis
 
 import (
 	"context"
-	"os"
 	"time"
 	"wallet-app-server/app/config"
 	"wallet-app-server/app/logger"
@@ -14,7 +13,8 @@ import (
 var Client *RedisClient
 
 // Init Redis, must be called before using the client
-func Init() {
+// Return the error if Redis can't be reached
+func Init() error {
 	redisConf := config.Cfg.Redis
 	Client = &RedisClient{
 		rdb: goredis.NewClient(&goredis.Options{
@@ -24,10 +24,23 @@ func Init() {
 		}),
 	}
 	if _, err := Client.rdb.Ping(context.Background()).Result(); err != nil {
-		logger.Error("Redis init error: ", err.Error())
-		os.Exit(-1)
+		return err
 	}
 	logger.Info("Redis init sucess")
+	return nil
+}
+
+// Ping Redis, used by the readiness check
+func Ping(ctx context.Context) error {
+	return Client.rdb.Ping(ctx).Err()
+}
+
+// Close the Redis client, the client can't be used after closed
+func Close() error {
+	if Client == nil {
+		return nil
+	}
+	return Client.rdb.Close()
 }
 
 // A wrapper for the underlying Redis client library
@@ -35,9 +48,14 @@ type RedisClient struct {
 	rdb *goredis.Client
 }
 
+// Add a hook to the underlying client, e.g. to observe the commands
+func (rc *RedisClient) AddHook(hook goredis.Hook) {
+	rc.rdb.AddHook(hook)
+}
+
 // Get value by key
-func (rc *RedisClient) Get(key string) (string, error) {
-	res, err := rc.rdb.Get(context.Background(), key).Result()
+func (rc *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	res, err := rc.rdb.Get(ctx, key).Result()
 	if err != nil {
 		return "", err
 	}
@@ -45,7 +63,7 @@ func (rc *RedisClient) Get(key string) (string, error) {
 }
 
 // Set key and value
-func (rc *RedisClient) Set(key string, value any, expiry time.Duration) error {
-	_, err := rc.rdb.SetNX(context.Background(), key, value, expiry).Result()
+func (rc *RedisClient) Set(ctx context.Context, key string, value any, expiry time.Duration) error {
+	_, err := rc.rdb.SetNX(ctx, key, value, expiry).Result()
 	return err
 }
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/logger"
+)
+
+// Reloads the configuration file on SIGHUP, and when the file is changed
+type configReloader struct {
+	configPath string
+	validators []config.Validator
+	mu         sync.Mutex
+	// Modification time and size of the file when it was last loaded
+	modTime time.Time
+	size    int64
+}
+
+func newConfigReloader(configPath string, validators []config.Validator) *configReloader {
+	r := &configReloader{configPath: configPath, validators: validators}
+	if info, err := os.Stat(configPath); err == nil {
+		r.modTime, r.size = info.ModTime(), info.Size()
+	}
+	return r
+}
+
+// Reload the configuration, and log the applied and the rejected changes
+// If the configuration is invalid, the current one is kept
+func (r *configReloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if info, err := os.Stat(r.configPath); err == nil {
+		r.modTime, r.size = info.ModTime(), info.Size()
+	}
+
+	result, err := config.Reload(r.configPath, r.validators...)
+	if err != nil {
+		logger.Errorf("Failed to reload configuration on %s, the current configuration is kept, err: %s", trigger, strings.ReplaceAll(err.Error(), "\n", "; "))
+		return
+	}
+	for _, change := range result.Rejected {
+		logger.Warnf("Configuration change rejected since %s is not reloadable, restart the server to apply it, %s", change.Field, change.String())
+	}
+	if len(result.Applied) == 0 {
+		logger.Infof("Configuration reloaded on %s, no change applied", trigger)
+		return
+	}
+	changes := make([]string, 0, len(result.Applied))
+	for _, change := range result.Applied {
+		changes = append(changes, change.String())
+	}
+	logger.Infof("Configuration reloaded on %s, changes: %s", trigger, strings.Join(changes, "; "))
+	// The level is applied after logging the changes, so that the reload is logged even if the level is raised
+	if err := logger.SetLevel(config.Current().Logging.LogLevel); err != nil {
+		logger.Errorf("Failed to change log level, err: %s", err.Error())
+	}
+}
+
+// Reload the configuration if the file has been changed since it was last loaded, run as a background job
+func (r *configReloader) reloadIfChanged(ctx context.Context) {
+	info, err := os.Stat(r.configPath)
+	if err != nil {
+		logger.Warnf("Failed to check configuration file, err: %s", err.Error())
+		return
+	}
+	r.mu.Lock()
+	changed := !info.ModTime().Equal(r.modTime) || info.Size() != r.size
+	r.mu.Unlock()
+	if changed {
+		r.reload("file change")
+	}
+}
+
+// Reload the configuration on every SIGHUP until the returned function is called
+func (r *configReloader) notifySIGHUP() (stop func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range hup {
+			r.reload("SIGHUP")
+		}
+	}()
+	return func() {
+		signal.Stop(hup)
+		close(hup)
+		<-done
+	}
+}
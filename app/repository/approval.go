@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Approval repository interface
+type IApprovalRepository interface {
+	CreateOperation(db *gorm.DB, operation entity.PendingOperation) error
+	GetOperation(db *gorm.DB, operationID string) (entity.PendingOperation, error)
+	ListOperationsByStatus(db *gorm.DB, operationStatus string) ([]entity.PendingOperation, error)
+	ListExpiredOperationIDs(db *gorm.DB, operationStatus string, currTime time.Time) ([]string, error)
+	LockOperation(db *gorm.DB, operationID string) (entity.PendingOperation, error)
+	UpdateOperation(db *gorm.DB, operationID string, operationStatus string, reviewBy string, reviewNote string, resultRef string, updateTime time.Time) error
+	CreateAudit(db *gorm.DB, audit entity.PendingOperationAudit) error
+	ListAudits(db *gorm.DB, operationID string) ([]entity.PendingOperationAudit, error)
+}
+
+// Approval repository instance
+var ApprovalRepository IApprovalRepository = &approvalRepositoryImpl{}
+
+// Approval repository implementation
+type approvalRepositoryImpl struct{}
+
+// Create pending operation
+func (ar *approvalRepositoryImpl) CreateOperation(db *gorm.DB, operation entity.PendingOperation) error {
+	return db.Create(&operation).Error
+}
+
+// Get pending operation by ID
+// If not found, return gorm.ErrRecordNotFound
+func (ar *approvalRepositoryImpl) GetOperation(db *gorm.DB, operationID string) (entity.PendingOperation, error) {
+	var operation entity.PendingOperation
+	err := db.Where("operation_id = ?", operationID).First(&operation).Error
+	return operation, err
+}
+
+// List the pending operations in the status, the oldest first
+func (ar *approvalRepositoryImpl) ListOperationsByStatus(db *gorm.DB, operationStatus string) ([]entity.PendingOperation, error) {
+	var operations []entity.PendingOperation
+	if err := db.Where("operation_status = ?", operationStatus).Order("create_time").Find(&operations).Error; err != nil {
+		return []entity.PendingOperation{}, err
+	}
+	return operations, nil
+}
+
+// List the IDs of the operations in the status which have expired at the current time
+func (ar *approvalRepositoryImpl) ListExpiredOperationIDs(db *gorm.DB, operationStatus string, currTime time.Time) ([]string, error) {
+	var operationIDs []string
+	err := db.Table("pending_operation").Where("operation_status = ? and expire_time <= ?", operationStatus, currTime).
+		Order("expire_time").Pluck("operation_id", &operationIDs).Error
+	return operationIDs, err
+}
+
+// Fetch the pending operation and lock its row until the end of the transaction
+// If not found, return gorm.ErrRecordNotFound
+func (ar *approvalRepositoryImpl) LockOperation(tx *gorm.DB, operationID string) (entity.PendingOperation, error) {
+	var operation entity.PendingOperation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("operation_id = ?", operationID).First(&operation).Error
+	return operation, err
+}
+
+// Update the result of the pending operation
+func (ar *approvalRepositoryImpl) UpdateOperation(db *gorm.DB, operationID string, operationStatus string, reviewBy string, reviewNote string, resultRef string, updateTime time.Time) error {
+	return db.Table("pending_operation").Where("operation_id = ?", operationID).Updates(map[string]any{
+		"operation_status": operationStatus,
+		"review_by":        sql.NullString{String: reviewBy, Valid: reviewBy != ""},
+		"review_note":      sql.NullString{String: reviewNote, Valid: reviewNote != ""},
+		"result_ref":       sql.NullString{String: resultRef, Valid: resultRef != ""},
+		"update_time":      updateTime,
+	}).Error
+}
+
+// Create an entry of the audit trail of a pending operation
+func (ar *approvalRepositoryImpl) CreateAudit(db *gorm.DB, audit entity.PendingOperationAudit) error {
+	return db.Create(&audit).Error
+}
+
+// List the audit trail of the pending operation, the oldest first
+func (ar *approvalRepositoryImpl) ListAudits(db *gorm.DB, operationID string) ([]entity.PendingOperationAudit, error) {
+	var audits []entity.PendingOperationAudit
+	if err := db.Where("operation_id = ?", operationID).Order("create_time").Find(&audits).Error; err != nil {
+		return []entity.PendingOperationAudit{}, err
+	}
+	return audits, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+)
+
+// Audit repository interface
+type IAuditRepository interface {
+	CreateAdminAudit(db *gorm.DB, audit entity.AdminAuditLog) error
+}
+
+// Audit repository instance
+var AuditRepository IAuditRepository = &auditRepositoryImpl{}
+
+// Audit repository implementation
+type auditRepositoryImpl struct{}
+
+// Create an entry of the admin audit log
+func (ar *auditRepositoryImpl) CreateAdminAudit(db *gorm.DB, audit entity.AdminAuditLog) error {
+	return db.Create(&audit).Error
+}
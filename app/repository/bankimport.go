@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bank import repository interface
+type IBankImportRepository interface {
+	CreateImport(db *gorm.DB, bankImport entity.BankStatementImport) error
+	UpdateImportCounts(db *gorm.DB, bankImport entity.BankStatementImport) error
+	CreateLine(db *gorm.DB, line entity.BankStatementLine) (bool, error)
+	LockLineContent(tx *gorm.DB, contentHash string) error
+	ExistsLineOfContent(db *gorm.DB, contentHash string, bankRef string, legacyHash string) (bool, error)
+	ListLinesByStatus(db *gorm.DB, lineStatus string) ([]entity.BankStatementLine, error)
+	LockLine(db *gorm.DB, lineID string) (entity.BankStatementLine, error)
+	UpdateLineReview(db *gorm.DB, lineID string, lineStatus string, walletID string, txnID string, reviewBy string, reviewNote string, reviewTime time.Time) error
+}
+
+// Bank import repository instance
+var BankImportRepository IBankImportRepository = &bankImportRepositoryImpl{}
+
+// Bank import repository implementation
+type bankImportRepositoryImpl struct{}
+
+// Create bank statement import record
+func (bir *bankImportRepositoryImpl) CreateImport(db *gorm.DB, bankImport entity.BankStatementImport) error {
+	return db.Create(&bankImport).Error
+}
+
+// Update the line counts of the import record
+func (bir *bankImportRepositoryImpl) UpdateImportCounts(db *gorm.DB, bankImport entity.BankStatementImport) error {
+	return db.Table("bank_statement_import").Where("import_id = ?", bankImport.ImportID).Updates(map[string]any{
+		"line_count":      bankImport.LineCount,
+		"matched_count":   bankImport.MatchedCount,
+		"unmatched_count": bankImport.UnmatchedCount,
+		"duplicate_count": bankImport.DuplicateCount,
+	}).Error
+}
+
+// Create bank statement line
+// The line hash is unique, if the line has already been imported nothing is inserted and false is returned
+func (bir *bankImportRepositoryImpl) CreateLine(db *gorm.DB, line entity.BankStatementLine) (bool, error) {
+	result := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "line_hash"}}, DoNothing: true}).Create(&line)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Lock the content hash of a line until the end of the transaction, so that the lines of the same content are recorded one at a time
+func (bir *bankImportRepositoryImpl) LockLineContent(tx *gorm.DB, contentHash string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", contentHash).Error
+}
+
+// Check if the line has been recorded under another line hash: a line of the same content of which one has no bank reference,
+// or a line imported before the account was part of the line hash
+func (bir *bankImportRepositoryImpl) ExistsLineOfContent(db *gorm.DB, contentHash string, bankRef string, legacyHash string) (bool, error) {
+	var count int64
+	err := db.Table("bank_statement_line").
+		Where("(content_hash = ? AND (bank_ref = '' OR ? = '')) OR (account = '' AND line_hash = ?)", contentHash, bankRef, legacyHash).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List bank statement lines by status, in the order of booking time
+func (bir *bankImportRepositoryImpl) ListLinesByStatus(db *gorm.DB, lineStatus string) ([]entity.BankStatementLine, error) {
+	var lines []entity.BankStatementLine
+	if err := db.Where("line_status = ?", lineStatus).Order("booking_time").Find(&lines).Error; err != nil {
+		return []entity.BankStatementLine{}, err
+	}
+	return lines, nil
+}
+
+// Fetch the bank statement line and lock its row until the end of the transaction
+// If not found, return gorm.ErrRecordNotFound
+func (bir *bankImportRepositoryImpl) LockLine(tx *gorm.DB, lineID string) (entity.BankStatementLine, error) {
+	var line entity.BankStatementLine
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("line_id = ?", lineID).First(&line).Error
+	return line, err
+}
+
+// Update the review result of the bank statement line
+func (bir *bankImportRepositoryImpl) UpdateLineReview(db *gorm.DB, lineID string, lineStatus string, walletID string, txnID string, reviewBy string, reviewNote string, reviewTime time.Time) error {
+	return db.Table("bank_statement_line").Where("line_id = ?", lineID).Updates(map[string]any{
+		"line_status": lineStatus,
+		"wallet_id":   sql.NullString{String: walletID, Valid: walletID != ""},
+		"txn_id":      sql.NullString{String: txnID, Valid: txnID != ""},
+		"review_by":   sql.NullString{String: reviewBy, Valid: reviewBy != ""},
+		"review_note": sql.NullString{String: reviewNote, Valid: reviewNote != ""},
+		"review_time": reviewTime,
+	}).Error
+}
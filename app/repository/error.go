@@ -1,6 +1,11 @@
 package repository
 
 const (
-	ErrNegativeOrZeroAmount = "amount must be positive"
-	ErrInsufficientBalance  = "insufficient balance"
+	ErrNegativeOrZeroAmount   = "amount must be positive"
+	ErrInsufficientBalance    = "insufficient balance"
+	ErrSameWalletTransfer     = "cannot transfer to the same wallet"
+	ErrWalletFrozen           = "wallet is frozen"
+	ErrWalletNotFound         = "wallet not found"
+	ErrUnbalancedJournalEntry = "journal entry postings do not sum to zero"
+	ErrSystemWalletNotFound   = "system wallet not found"
 )
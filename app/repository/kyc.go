@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KYC repository interface
+type IKYCRepository interface {
+	CreateDocument(db *gorm.DB, document entity.KYCDocument) error
+	GetDocumentByID(db *gorm.DB, documentID string) (entity.KYCDocument, error)
+	AttachDocuments(db *gorm.DB, userID string, submissionID string, documentIDs []string) (int64, error)
+	ListSubmissionDocuments(db *gorm.DB, submissionIDs []string) ([]entity.KYCDocument, error)
+	CreateSubmission(db *gorm.DB, submission entity.KYCSubmission) error
+	HasPendingSubmission(db *gorm.DB, userID string) (bool, error)
+	GetLatestSubmission(db *gorm.DB, userID string) (entity.KYCSubmission, error)
+	ListSubmissionsByStatus(db *gorm.DB, submissionStatus string) ([]entity.KYCSubmission, error)
+	LockSubmission(db *gorm.DB, submissionID string) (entity.KYCSubmission, error)
+	UpdateSubmission(db *gorm.DB, submissionID string, submissionStatus string, reviewBy string, reviewReason string, updateTime time.Time) error
+}
+
+// KYC repository instance
+var KYCRepository IKYCRepository = &kycRepositoryImpl{}
+
+// KYC repository implementation
+type kycRepositoryImpl struct{}
+
+// Create KYC document
+func (kr *kycRepositoryImpl) CreateDocument(db *gorm.DB, document entity.KYCDocument) error {
+	return db.Create(&document).Error
+}
+
+// Get KYC document by ID
+// If not found, return gorm.ErrRecordNotFound
+func (kr *kycRepositoryImpl) GetDocumentByID(db *gorm.DB, documentID string) (entity.KYCDocument, error) {
+	var document entity.KYCDocument
+	err := db.Where("document_id = ?", documentID).First(&document).Error
+	return document, err
+}
+
+// Attach the user's documents to the submission, the documents already attached to a submission are skipped
+// Return the number of documents attached
+func (kr *kycRepositoryImpl) AttachDocuments(db *gorm.DB, userID string, submissionID string, documentIDs []string) (int64, error) {
+	result := db.Table("kyc_document").
+		Where("document_id IN ? and user_id = ? and submission_id IS NULL", documentIDs, userID).
+		Update("submission_id", submissionID)
+	return result.RowsAffected, result.Error
+}
+
+// List the documents of the submissions
+func (kr *kycRepositoryImpl) ListSubmissionDocuments(db *gorm.DB, submissionIDs []string) ([]entity.KYCDocument, error) {
+	var documents []entity.KYCDocument
+	if err := db.Where("submission_id IN ?", submissionIDs).Order("create_time").Find(&documents).Error; err != nil {
+		return []entity.KYCDocument{}, err
+	}
+	return documents, nil
+}
+
+// Create KYC submission
+func (kr *kycRepositoryImpl) CreateSubmission(db *gorm.DB, submission entity.KYCSubmission) error {
+	return db.Create(&submission).Error
+}
+
+// Check if the user has a submission waiting for review
+func (kr *kycRepositoryImpl) HasPendingSubmission(db *gorm.DB, userID string) (bool, error) {
+	var count int64
+	err := db.Table("kyc_submission").Where("user_id = ? and submission_status = ?", userID, constant.KYCSubmissionStatusPending).Count(&count).Error
+	return count > 0, err
+}
+
+// Get the user's latest submission
+// If not found, return gorm.ErrRecordNotFound
+func (kr *kycRepositoryImpl) GetLatestSubmission(db *gorm.DB, userID string) (entity.KYCSubmission, error) {
+	var submission entity.KYCSubmission
+	err := db.Where("user_id = ?", userID).Order("create_time desc").First(&submission).Error
+	return submission, err
+}
+
+// List the submissions in the status, the oldest first
+func (kr *kycRepositoryImpl) ListSubmissionsByStatus(db *gorm.DB, submissionStatus string) ([]entity.KYCSubmission, error) {
+	var submissions []entity.KYCSubmission
+	if err := db.Where("submission_status = ?", submissionStatus).Order("create_time").Find(&submissions).Error; err != nil {
+		return []entity.KYCSubmission{}, err
+	}
+	return submissions, nil
+}
+
+// Fetch the submission and lock its row until the end of the transaction
+// If not found, return gorm.ErrRecordNotFound
+func (kr *kycRepositoryImpl) LockSubmission(tx *gorm.DB, submissionID string) (entity.KYCSubmission, error) {
+	var submission entity.KYCSubmission
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("submission_id = ?", submissionID).First(&submission).Error
+	return submission, err
+}
+
+// Update the result of the submission review
+func (kr *kycRepositoryImpl) UpdateSubmission(db *gorm.DB, submissionID string, submissionStatus string, reviewBy string, reviewReason string, updateTime time.Time) error {
+	return db.Table("kyc_submission").Where("submission_id = ?", submissionID).Updates(map[string]any{
+		"submission_status": submissionStatus,
+		"review_by":         reviewBy,
+		"review_reason":     sql.NullString{String: reviewReason, Valid: reviewReason != ""},
+		"update_time":       updateTime,
+	}).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"time"
+	"wallet-app-server/app/entity"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Limit repository interface
+type ILimitRepository interface {
+	GetUserLimit(db *gorm.DB, userID string, txnType string) (entity.UserLimit, error)
+	SaveUserLimit(db *gorm.DB, userLimit entity.UserLimit) error
+	DeleteUserLimit(db *gorm.DB, userID string, txnType string) (bool, error)
+	SumUserTransactionsSince(db *gorm.DB, userID string, txnType string, since time.Time) (decimal.Decimal, int64, error)
+}
+
+// Limit repository instance
+var LimitRepository ILimitRepository = &limitRepositoryImpl{}
+
+// Limit repository implementation
+type limitRepositoryImpl struct{}
+
+// Get the user's own limits of the transaction type
+// If not found, return gorm.ErrRecordNotFound
+func (lr *limitRepositoryImpl) GetUserLimit(db *gorm.DB, userID string, txnType string) (entity.UserLimit, error) {
+	var userLimit entity.UserLimit
+	err := db.Where("user_id = ? and txn_type = ?", userID, txnType).First(&userLimit).Error
+	return userLimit, err
+}
+
+// Create or replace the user's own limits of the transaction type
+func (lr *limitRepositoryImpl) SaveUserLimit(db *gorm.DB, userLimit entity.UserLimit) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&userLimit).Error
+}
+
+// Delete the user's own limits of the transaction type, so that the limits of the user tier apply again
+// Return false if the user has no own limits of the transaction type
+func (lr *limitRepositoryImpl) DeleteUserLimit(db *gorm.DB, userID string, txnType string) (bool, error) {
+	result := db.Where("user_id = ? and txn_type = ?", userID, txnType).Delete(&entity.UserLimit{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Sum the amount and count the transactions of the type made from the user's wallets since the given time
+func (lr *limitRepositoryImpl) SumUserTransactionsSince(db *gorm.DB, userID string, txnType string, since time.Time) (decimal.Decimal, int64, error) {
+	var result struct {
+		Amount decimal.Decimal
+		Count  int64
+	}
+	err := db.Table("txn_history").
+		Joins("JOIN user_wallet_bridge ON user_wallet_bridge.wallet_id = txn_history.from_wallet_id").
+		Where("user_wallet_bridge.user_id = ? and txn_history.txn_type = ? and txn_history.txn_time >= ?", userID, txnType, since).
+		Select("COALESCE(SUM(txn_history.txn_amount), 0) AS amount, COUNT(*) AS count").
+		Scan(&result).Error
+	return result.Amount, result.Count, err
+}
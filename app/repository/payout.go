@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Payout repository interface
+type IPayoutRepository interface {
+	CreateDestination(db *gorm.DB, destination entity.PayoutDestination) error
+	GetDestinationByID(db *gorm.DB, destinationID string) (entity.PayoutDestination, error)
+	ListUserDestinations(db *gorm.DB, userID string) ([]entity.PayoutDestination, error)
+	VerifyDestination(db *gorm.DB, destinationID string, verifiedBy string, verifiedTime time.Time) (bool, error)
+	CreatePayout(db *gorm.DB, payout entity.Payout) error
+	GetPayoutByID(db *gorm.DB, payoutID string) (entity.Payout, error)
+	ListUserPayouts(db *gorm.DB, userID string) ([]entity.Payout, error)
+	LockQueuedPayouts(db *gorm.DB, limit int) ([]entity.Payout, error)
+	LockPayout(db *gorm.DB, payoutID string) (entity.Payout, error)
+	CreateBatch(db *gorm.DB, batch entity.PayoutBatch) error
+	MarkPayoutsSubmitted(db *gorm.DB, payoutIDs []string, batchID string, listVersion sql.NullString, updateTime time.Time) error
+	MarkPayoutSettled(db *gorm.DB, payoutID string, updateTime time.Time) error
+	MarkPayoutReturned(db *gorm.DB, payoutID string, returnReason string, returnTxnID string, updateTime time.Time) error
+}
+
+// Payout repository instance
+var PayoutRepository IPayoutRepository = &payoutRepositoryImpl{}
+
+// Payout repository implementation
+type payoutRepositoryImpl struct{}
+
+// Create payout destination
+func (pr *payoutRepositoryImpl) CreateDestination(db *gorm.DB, destination entity.PayoutDestination) error {
+	return db.Create(&destination).Error
+}
+
+// Get payout destination by ID
+// If not found, return gorm.ErrRecordNotFound
+func (pr *payoutRepositoryImpl) GetDestinationByID(db *gorm.DB, destinationID string) (entity.PayoutDestination, error) {
+	var destination entity.PayoutDestination
+	err := db.Where("destination_id = ?", destinationID).First(&destination).Error
+	return destination, err
+}
+
+// List user's payout destinations
+func (pr *payoutRepositoryImpl) ListUserDestinations(db *gorm.DB, userID string) ([]entity.PayoutDestination, error) {
+	var destinations []entity.PayoutDestination
+	if err := db.Where("user_id = ?", userID).Order("create_time").Find(&destinations).Error; err != nil {
+		return []entity.PayoutDestination{}, err
+	}
+	return destinations, nil
+}
+
+// Mark the pending payout destination as verified
+// Return false if the destination is not found or not pending
+func (pr *payoutRepositoryImpl) VerifyDestination(db *gorm.DB, destinationID string, verifiedBy string, verifiedTime time.Time) (bool, error) {
+	result := db.Table("payout_destination").
+		Where("destination_id = ? and destination_status = ?", destinationID, constant.PayoutDestinationStatusPending).
+		Updates(map[string]any{
+			"destination_status": constant.PayoutDestinationStatusVerified,
+			"verified_by":        verifiedBy,
+			"verified_time":      verifiedTime,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Create payout
+func (pr *payoutRepositoryImpl) CreatePayout(db *gorm.DB, payout entity.Payout) error {
+	return db.Create(&payout).Error
+}
+
+// Get payout by ID
+// If not found, return gorm.ErrRecordNotFound
+func (pr *payoutRepositoryImpl) GetPayoutByID(db *gorm.DB, payoutID string) (entity.Payout, error) {
+	var payout entity.Payout
+	err := db.Where("payout_id = ?", payoutID).First(&payout).Error
+	return payout, err
+}
+
+// List user's payouts, the latest first
+func (pr *payoutRepositoryImpl) ListUserPayouts(db *gorm.DB, userID string) ([]entity.Payout, error) {
+	var payouts []entity.Payout
+	if err := db.Where("user_id = ?", userID).Order("create_time desc").Find(&payouts).Error; err != nil {
+		return []entity.Payout{}, err
+	}
+	return payouts, nil
+}
+
+// Fetch the oldest queued payouts and lock their rows until the end of the transaction
+// The rows locked by another transaction are skipped, so that concurrent batches never include the same payout
+func (pr *payoutRepositoryImpl) LockQueuedPayouts(tx *gorm.DB, limit int) ([]entity.Payout, error) {
+	var payouts []entity.Payout
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("payout_status = ?", constant.PayoutStatusQueued).Order("create_time").Limit(limit).Find(&payouts).Error; err != nil {
+		return []entity.Payout{}, err
+	}
+	return payouts, nil
+}
+
+// Fetch the payout and lock its row until the end of the transaction
+// If not found, return gorm.ErrRecordNotFound
+func (pr *payoutRepositoryImpl) LockPayout(tx *gorm.DB, payoutID string) (entity.Payout, error) {
+	var payout entity.Payout
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("payout_id = ?", payoutID).First(&payout).Error
+	return payout, err
+}
+
+// Create payout batch record
+func (pr *payoutRepositoryImpl) CreateBatch(db *gorm.DB, batch entity.PayoutBatch) error {
+	return db.Create(&batch).Error
+}
+
+// Mark the payouts as submitted in the batch
+func (pr *payoutRepositoryImpl) MarkPayoutsSubmitted(db *gorm.DB, payoutIDs []string, batchID string, listVersion sql.NullString, updateTime time.Time) error {
+	return db.Table("payout").Where("payout_id IN ?", payoutIDs).Updates(map[string]any{
+		"payout_status":          constant.PayoutStatusSubmitted,
+		"batch_id":               batchID,
+		"screening_list_version": listVersion,
+		"update_time":            updateTime,
+	}).Error
+}
+
+// Mark the payout as settled
+func (pr *payoutRepositoryImpl) MarkPayoutSettled(db *gorm.DB, payoutID string, updateTime time.Time) error {
+	return db.Table("payout").Where("payout_id = ?", payoutID).Updates(map[string]any{
+		"payout_status": constant.PayoutStatusSettled,
+		"update_time":   updateTime,
+	}).Error
+}
+
+// Mark the payout as returned, with the transaction re-crediting the wallet
+func (pr *payoutRepositoryImpl) MarkPayoutReturned(db *gorm.DB, payoutID string, returnReason string, returnTxnID string, updateTime time.Time) error {
+	return db.Table("payout").Where("payout_id = ?", payoutID).Updates(map[string]any{
+		"payout_status": constant.PayoutStatusReturned,
+		"return_reason": sql.NullString{String: returnReason, Valid: returnReason != ""},
+		"return_txn_id": returnTxnID,
+		"update_time":   updateTime,
+	}).Error
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/entity"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Risk repository interface
+type IRiskRepository interface {
+	CreateDecision(db *gorm.DB, decision entity.RiskDecision) error
+	UpdateDecisionTxnID(db *gorm.DB, decisionID string, txnID string) error
+	UpdateDecisionOutcome(db *gorm.DB, decisionID string, outcome string) error
+	IsKnownDevice(db *gorm.DB, userID string, deviceID string) (bool, error)
+	RememberDevice(db *gorm.DB, userID string, deviceID string, seenTime time.Time) error
+	HasTransferredTo(db *gorm.DB, userID string, toWalletID string) (bool, error)
+	AverageRecentAmount(db *gorm.DB, userID string, txnType string, limit int) (decimal.Decimal, int64, error)
+	CreateReview(db *gorm.DB, review entity.RiskReview) error
+	ListReviewsByStatus(db *gorm.DB, reviewStatus string) ([]entity.RiskReview, error)
+	LockReview(db *gorm.DB, reviewID string) (entity.RiskReview, error)
+	UpdateReview(db *gorm.DB, reviewID string, reviewStatus string, txnID string, reviewBy string, reviewNote string, updateTime time.Time) error
+}
+
+// Risk repository instance
+var RiskRepository IRiskRepository = &riskRepositoryImpl{}
+
+// Risk repository implementation
+type riskRepositoryImpl struct{}
+
+// Create risk decision
+func (rr *riskRepositoryImpl) CreateDecision(db *gorm.DB, decision entity.RiskDecision) error {
+	return db.Create(&decision).Error
+}
+
+// Link the risk decision to the transaction it allowed, and mark it completed
+func (rr *riskRepositoryImpl) UpdateDecisionTxnID(db *gorm.DB, decisionID string, txnID string) error {
+	return db.Table("risk_decision").Where("decision_id = ?", decisionID).Updates(map[string]any{
+		"txn_id":  txnID,
+		"outcome": constant.RiskDecisionOutcomeCompleted,
+	}).Error
+}
+
+// Record the outcome of the allowed transaction which was never made, e.g. its approval was rejected
+func (rr *riskRepositoryImpl) UpdateDecisionOutcome(db *gorm.DB, decisionID string, outcome string) error {
+	return db.Table("risk_decision").Where("decision_id = ?", decisionID).Update("outcome", outcome).Error
+}
+
+// Check if the user has used the device before
+func (rr *riskRepositoryImpl) IsKnownDevice(db *gorm.DB, userID string, deviceID string) (bool, error) {
+	var count int64
+	err := db.Table("user_device").Where("user_id = ? and device_id = ?", userID, deviceID).Count(&count).Error
+	return count > 0, err
+}
+
+// Record that the user has used the device
+func (rr *riskRepositoryImpl) RememberDevice(db *gorm.DB, userID string, deviceID string, seenTime time.Time) error {
+	device := entity.UserDevice{UserID: userID, DeviceID: deviceID, FirstSeenTime: seenTime, LastSeenTime: seenTime}
+	return db.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"last_seen_time"})}).Create(&device).Error
+}
+
+// Check if the user has transferred to the wallet before, from any of the user's wallets
+func (rr *riskRepositoryImpl) HasTransferredTo(db *gorm.DB, userID string, toWalletID string) (bool, error) {
+	var count int64
+	err := db.Table("txn_history").
+		Joins("JOIN user_wallet_bridge ON user_wallet_bridge.wallet_id = txn_history.from_wallet_id").
+		Where("user_wallet_bridge.user_id = ? and txn_history.to_wallet_id = ? and txn_history.txn_type = ?", userID, toWalletID, constant.TxnTypeTransfer).
+		Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// Average amount and number of the user's latest transactions of the type, up to limit transactions
+func (rr *riskRepositoryImpl) AverageRecentAmount(db *gorm.DB, userID string, txnType string, limit int) (decimal.Decimal, int64, error) {
+	recent := db.Table("txn_history").
+		Joins("JOIN user_wallet_bridge ON user_wallet_bridge.wallet_id = txn_history.from_wallet_id").
+		Where("user_wallet_bridge.user_id = ? and txn_history.txn_type = ?", userID, txnType).
+		Order("txn_history.txn_time desc").Limit(limit).Select("txn_history.txn_amount")
+	var result struct {
+		Amount decimal.Decimal
+		Count  int64
+	}
+	err := db.Table("(?) AS recent", recent).
+		Select("COALESCE(AVG(recent.txn_amount), 0) AS amount, COUNT(*) AS count").
+		Scan(&result).Error
+	return result.Amount, result.Count, err
+}
+
+// Create risk review
+func (rr *riskRepositoryImpl) CreateReview(db *gorm.DB, review entity.RiskReview) error {
+	return db.Create(&review).Error
+}
+
+// List the risk reviews in the status, the oldest first
+func (rr *riskRepositoryImpl) ListReviewsByStatus(db *gorm.DB, reviewStatus string) ([]entity.RiskReview, error) {
+	var reviews []entity.RiskReview
+	if err := db.Where("review_status = ?", reviewStatus).Order("create_time").Find(&reviews).Error; err != nil {
+		return []entity.RiskReview{}, err
+	}
+	return reviews, nil
+}
+
+// Fetch the risk review and lock its row until the end of the transaction
+// If not found, return gorm.ErrRecordNotFound
+func (rr *riskRepositoryImpl) LockReview(tx *gorm.DB, reviewID string) (entity.RiskReview, error) {
+	var review entity.RiskReview
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("review_id = ?", reviewID).First(&review).Error
+	return review, err
+}
+
+// Update the result of the risk review
+func (rr *riskRepositoryImpl) UpdateReview(db *gorm.DB, reviewID string, reviewStatus string, txnID string, reviewBy string, reviewNote string, updateTime time.Time) error {
+	return db.Table("risk_review").Where("review_id = ?", reviewID).Updates(map[string]any{
+		"review_status": reviewStatus,
+		"txn_id":        sql.NullString{String: txnID, Valid: txnID != ""},
+		"review_by":     reviewBy,
+		"review_note":   sql.NullString{String: reviewNote, Valid: reviewNote != ""},
+		"update_time":   updateTime,
+	}).Error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Screening repository interface
+type IScreeningRepository interface {
+	CreateListVersion(db *gorm.DB, listVersion entity.ScreeningListVersion) error
+	CreateCase(db *gorm.DB, screeningCase entity.ScreeningCase) error
+	GetLatestCase(db *gorm.DB, subjectRef string, screenedName string, listVersion string) (entity.ScreeningCase, error)
+	ListCasesByStatus(db *gorm.DB, caseStatus string) ([]entity.ScreeningCase, error)
+	LockCase(db *gorm.DB, caseID string) (entity.ScreeningCase, error)
+	UpdateCase(db *gorm.DB, caseID string, caseStatus string, reviewBy string, reviewNote string, updateTime time.Time) error
+}
+
+// Screening repository instance
+var ScreeningRepository IScreeningRepository = &screeningRepositoryImpl{}
+
+// Screening repository implementation
+type screeningRepositoryImpl struct{}
+
+// Record a loaded version of the sanctions lists
+func (sr *screeningRepositoryImpl) CreateListVersion(db *gorm.DB, listVersion entity.ScreeningListVersion) error {
+	return db.Create(&listVersion).Error
+}
+
+// Create screening case
+func (sr *screeningRepositoryImpl) CreateCase(db *gorm.DB, screeningCase entity.ScreeningCase) error {
+	return db.Create(&screeningCase).Error
+}
+
+// Get the latest case of the name screened for the subject against the list version
+// If not found, return gorm.ErrRecordNotFound
+func (sr *screeningRepositoryImpl) GetLatestCase(db *gorm.DB, subjectRef string, screenedName string, listVersion string) (entity.ScreeningCase, error) {
+	var screeningCase entity.ScreeningCase
+	err := db.Where("subject_ref = ? and screened_name = ? and list_version = ?", subjectRef, screenedName, listVersion).
+		Order("create_time desc").First(&screeningCase).Error
+	return screeningCase, err
+}
+
+// List the screening cases in the status, the oldest first
+func (sr *screeningRepositoryImpl) ListCasesByStatus(db *gorm.DB, caseStatus string) ([]entity.ScreeningCase, error) {
+	var screeningCases []entity.ScreeningCase
+	if err := db.Where("case_status = ?", caseStatus).Order("create_time").Find(&screeningCases).Error; err != nil {
+		return []entity.ScreeningCase{}, err
+	}
+	return screeningCases, nil
+}
+
+// Fetch the screening case and lock its row until the end of the transaction
+// If not found, return gorm.ErrRecordNotFound
+func (sr *screeningRepositoryImpl) LockCase(tx *gorm.DB, caseID string) (entity.ScreeningCase, error) {
+	var screeningCase entity.ScreeningCase
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("case_id = ?", caseID).First(&screeningCase).Error
+	return screeningCase, err
+}
+
+// Update the result of the screening case
+func (sr *screeningRepositoryImpl) UpdateCase(db *gorm.DB, caseID string, caseStatus string, reviewBy string, reviewNote string, updateTime time.Time) error {
+	return db.Table("screening_case").Where("case_id = ?", caseID).Updates(map[string]any{
+		"case_status": caseStatus,
+		"review_by":   reviewBy,
+		"review_note": sql.NullString{String: reviewNote, Valid: reviewNote != ""},
+		"update_time": updateTime,
+	}).Error
+}
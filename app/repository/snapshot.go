@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+	"wallet-app-server/app/entity"
+
+	"gorm.io/gorm"
+)
+
+// Balance snapshot repository interface
+type IBalanceSnapshotRepository interface {
+	GetLatestSnapshot(db *gorm.DB, walletID string, asOf time.Time) (entity.WalletBalanceSnapshot, error)
+	ExistsSnapshot(db *gorm.DB, snapshotTime time.Time) (bool, error)
+	GetPreviousSnapshotTime(db *gorm.DB, snapshotTime time.Time) (time.Time, error)
+	CreateSnapshots(db *gorm.DB, snapshotTime time.Time) (int64, error)
+	RecomputeSnapshots(db *gorm.DB, snapshotTime time.Time) (int64, error)
+}
+
+// Balance snapshot repository instance
+var BalanceSnapshotRepository IBalanceSnapshotRepository = &balanceSnapshotRepositoryImpl{}
+
+// Balance snapshot repository implementation
+type balanceSnapshotRepositoryImpl struct{}
+
+// Get the latest snapshot of the wallet taken at or before the given time
+// If not found, return gorm.ErrRecordNotFound
+func (bsr *balanceSnapshotRepositoryImpl) GetLatestSnapshot(db *gorm.DB, walletID string, asOf time.Time) (entity.WalletBalanceSnapshot, error) {
+	var snapshot entity.WalletBalanceSnapshot
+	err := db.Where("wallet_id = ? and snapshot_time <= ?", walletID, asOf).Order("snapshot_time desc").First(&snapshot).Error
+	return snapshot, err
+}
+
+// Check if the snapshots at the given time have been taken
+func (bsr *balanceSnapshotRepositoryImpl) ExistsSnapshot(db *gorm.DB, snapshotTime time.Time) (bool, error) {
+	var count int64
+	if err := db.Table("wallet_balance_snapshot").Where("snapshot_time = ?", snapshotTime).Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Get the time of the latest snapshots taken before the given time
+// If not found, return gorm.ErrRecordNotFound
+func (bsr *balanceSnapshotRepositoryImpl) GetPreviousSnapshotTime(db *gorm.DB, snapshotTime time.Time) (time.Time, error) {
+	var snapshot entity.WalletBalanceSnapshot
+	err := db.Where("snapshot_time < ?", snapshotTime).Order("snapshot_time desc").First(&snapshot).Error
+	return snapshot.SnapshotTime, err
+}
+
+// Take a balance snapshot of every wallet at the given time
+// The balance is computed incrementally: the previous snapshot plus the postings after it
+// Existing snapshots are kept, so that it's safe to run more than once
+func (bsr *balanceSnapshotRepositoryImpl) CreateSnapshots(db *gorm.DB, snapshotTime time.Time) (int64, error) {
+	result := db.Exec(`INSERT INTO wallet_balance_snapshot (wallet_id, snapshot_time, balance, create_time)
+		SELECT w.wallet_id, @snapshot_time, COALESCE(s.balance, 0) + COALESCE((
+			SELECT SUM(p.posting_amount) FROM txn_posting p
+			WHERE p.wallet_id = w.wallet_id AND p.posting_time <= @snapshot_time
+			AND (s.snapshot_time IS NULL OR p.posting_time > s.snapshot_time)
+		), 0), @create_time
+		FROM wallet w LEFT JOIN LATERAL (
+			SELECT balance, snapshot_time FROM wallet_balance_snapshot
+			WHERE wallet_id = w.wallet_id AND snapshot_time < @snapshot_time
+			ORDER BY snapshot_time DESC LIMIT 1
+		) s ON TRUE
+		ON CONFLICT (wallet_id, snapshot_time) DO NOTHING`,
+		map[string]any{"snapshot_time": snapshotTime, "create_time": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// Compute the balance snapshots at the given time again, as CreateSnapshots does, e.g. to count a transaction
+// stamped before the snapshot time which committed after the snapshot was taken
+// Return the number of the snapshots whose balance changed
+func (bsr *balanceSnapshotRepositoryImpl) RecomputeSnapshots(db *gorm.DB, snapshotTime time.Time) (int64, error) {
+	result := db.Exec(`WITH recomputed AS (
+			SELECT t.wallet_id, COALESCE(s.balance, 0) + COALESCE((
+				SELECT SUM(p.posting_amount) FROM txn_posting p
+				WHERE p.wallet_id = t.wallet_id AND p.posting_time <= @snapshot_time
+				AND (s.snapshot_time IS NULL OR p.posting_time > s.snapshot_time)
+			), 0) AS balance
+			FROM wallet_balance_snapshot t LEFT JOIN LATERAL (
+				SELECT balance, snapshot_time FROM wallet_balance_snapshot
+				WHERE wallet_id = t.wallet_id AND snapshot_time < @snapshot_time
+				ORDER BY snapshot_time DESC LIMIT 1
+			) s ON TRUE
+			WHERE t.snapshot_time = @snapshot_time
+		)
+		UPDATE wallet_balance_snapshot t SET balance = r.balance
+		FROM recomputed r
+		WHERE t.wallet_id = r.wallet_id AND t.snapshot_time = @snapshot_time AND t.balance <> r.balance`,
+		map[string]any{"snapshot_time": snapshotTime})
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"time"
 	"wallet-app-server/app/entity"
 
@@ -13,6 +15,17 @@ import (
 type ITransactionRepository interface {
 	ListTransactionHistory(db *gorm.DB, walletID string) ([]entity.TxnHistory, error)
 	CreateTransactionHistory(db *gorm.DB, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error)
+	CreateLinkedTransactionHistory(db *gorm.DB, parentTxnID string, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error)
+	GetLinkedTransactionHistory(db *gorm.DB, parentTxnID string, txnType string) (entity.TxnHistory, error)
+	ListTransactionPostings(db *gorm.DB, txnID string) ([]entity.TxnPosting, error)
+	SumWalletPostingsBetween(db *gorm.DB, walletID string, after time.Time, until time.Time) (decimal.Decimal, error)
+	IterateWalletStatement(db *gorm.DB, walletID string, after time.Time, until time.Time, fn func(row WalletStatementRow) error) error
+}
+
+// A transaction history row with the posting amount of a wallet
+type WalletStatementRow struct {
+	entity.TxnHistory
+	PostingAmount decimal.Decimal `gorm:"column:posting_amount"`
 }
 
 // Transaction repository instance
@@ -29,7 +42,106 @@ func (tr *transactionRepositoryImpl) ListTransactionHistory(db *gorm.DB, walletI
 }
 
 // Create new transaction history
+// The transaction is recorded as a journal entry: one header row in txn_history
+// and the balanced postings (debit the from wallet, credit the to wallet) in txn_posting
 func (tr *transactionRepositoryImpl) CreateTransactionHistory(db *gorm.DB, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error) {
+	return createTransactionHistory(db, "", fromWalletID, toWalletID, txnType, txnAmount, txnTime)
+}
+
+// Create new transaction history linked to a parent transaction (e.g. the return of a payout)
+func (tr *transactionRepositoryImpl) CreateLinkedTransactionHistory(db *gorm.DB, parentTxnID string, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error) {
+	return createTransactionHistory(db, parentTxnID, fromWalletID, toWalletID, txnType, txnAmount, txnTime)
+}
+
+// Get the transaction of the type linked to a parent transaction (e.g. the fee of a withdrawal)
+// If not found, return gorm.ErrRecordNotFound
+func (tr *transactionRepositoryImpl) GetLinkedTransactionHistory(db *gorm.DB, parentTxnID string, txnType string) (entity.TxnHistory, error) {
+	var result entity.TxnHistory
+	err := db.Where("parent_txn_id = ? and txn_type = ?", parentTxnID, txnType).First(&result).Error
+	return result, err
+}
+
+// List the journal postings of a transaction
+func (tr *transactionRepositoryImpl) ListTransactionPostings(db *gorm.DB, txnID string) ([]entity.TxnPosting, error) {
+	var result []entity.TxnPosting
+	err := db.Where("txn_id = ?", txnID).Order("posting_amount").Find(&result).Error
+	return result, err
+}
+
+// Sum the journal postings of a wallet in the time range (after, until]
+// If after is zero time, sum all the postings until the given time
+func (tr *transactionRepositoryImpl) SumWalletPostingsBetween(db *gorm.DB, walletID string, after time.Time, until time.Time) (decimal.Decimal, error) {
+	var result struct {
+		Total decimal.Decimal `gorm:"column:total"`
+	}
+	query := db.Table("txn_posting").Where("wallet_id = ? and posting_time <= ?", walletID, until)
+	if !after.IsZero() {
+		query = query.Where("posting_time > ?", after)
+	}
+	err := query.Select("COALESCE(SUM(posting_amount), 0) AS total").Take(&result).Error
+	return result.Total, err
+}
+
+// Iterate the transaction history of a wallet in the time range (after, until], ordered by time
+// Rows are fetched with a cursor and passed to fn one by one, so the whole range is never loaded into memory
+func (tr *transactionRepositoryImpl) IterateWalletStatement(db *gorm.DB, walletID string, after time.Time, until time.Time, fn func(row WalletStatementRow) error) error {
+	rows, err := db.Table("txn_posting").Joins("INNER JOIN txn_history ON txn_history.txn_id = txn_posting.txn_id").
+		Where("txn_posting.wallet_id = ? and txn_posting.posting_time > ? and txn_posting.posting_time <= ?", walletID, after, until).
+		Select("txn_history.*, txn_posting.posting_amount").Order("txn_posting.posting_time, txn_posting.posting_id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row WalletStatementRow
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Build the postings of a two-legged journal entry
+// The from wallet is debited (negative amount) and the to wallet is credited (positive amount)
+func newJournalPostings(txnID string, fromWalletID string, toWalletID string, txnAmount decimal.Decimal, txnTime time.Time) []entity.TxnPosting {
+	return []entity.TxnPosting{
+		{
+			PostingID:     uuid.New().String(),
+			TxnID:         txnID,
+			WalletID:      fromWalletID,
+			PostingAmount: txnAmount.Neg(),
+			PostingTime:   txnTime,
+		},
+		{
+			PostingID:     uuid.New().String(),
+			TxnID:         txnID,
+			WalletID:      toWalletID,
+			PostingAmount: txnAmount,
+			PostingTime:   txnTime,
+		},
+	}
+}
+
+// Verify the postings of a journal entry sum to zero
+func validateJournalPostings(postings []entity.TxnPosting) error {
+	if len(postings) < 2 {
+		return errors.New(ErrUnbalancedJournalEntry)
+	}
+	sum := decimal.Zero
+	for _, posting := range postings {
+		sum = sum.Add(posting.PostingAmount)
+	}
+	if !sum.IsZero() {
+		return errors.New(ErrUnbalancedJournalEntry)
+	}
+	return nil
+}
+
+// Create the txn_history row and its journal postings
+func createTransactionHistory(db *gorm.DB, parentTxnID string, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error) {
 	txnID := uuid.New().String()
 	txnHistory := entity.TxnHistory{
 		TxnID:        txnID,
@@ -38,9 +150,18 @@ func (tr *transactionRepositoryImpl) CreateTransactionHistory(db *gorm.DB, fromW
 		TxnType:      txnType,
 		TxnAmount:    txnAmount,
 		TxnTime:      txnTime,
+		ParentTxnID:  sql.NullString{String: parentTxnID, Valid: parentTxnID != ""},
 	}
 	if err := db.Create(txnHistory).Error; err != nil {
 		return "", err
 	}
+	// Create journal postings
+	postings := newJournalPostings(txnID, fromWalletID, toWalletID, txnAmount, txnTime)
+	if err := validateJournalPostings(postings); err != nil {
+		return "", err
+	}
+	if err := db.Create(&postings).Error; err != nil {
+		return "", err
+	}
 	return txnID, nil
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+	"time"
+	"wallet-app-server/app/entity"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestNewJournalPostings(t *testing.T) {
+	postings := newJournalPostings("txn-1", "wallet-a", "wallet-b", decimal.RequireFromString("100.50"), time.Now())
+	assert.Equal(t, 2, len(postings))
+	assert.Equal(t, "wallet-a", postings[0].WalletID)
+	assert.Equal(t, "-100.5", postings[0].PostingAmount.String())
+	assert.Equal(t, "wallet-b", postings[1].WalletID)
+	assert.Equal(t, "100.5", postings[1].PostingAmount.String())
+	assert.Equal(t, nil, validateJournalPostings(postings))
+}
+
+func TestValidateJournalPostingsUnbalanced(t *testing.T) {
+	postings := []entity.TxnPosting{
+		{WalletID: "wallet-a", PostingAmount: decimal.RequireFromString("-100")},
+		{WalletID: "wallet-b", PostingAmount: decimal.RequireFromString("99.99")},
+	}
+	err := validateJournalPostings(postings)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, ErrUnbalancedJournalEntry, err.Error())
+}
+
+func TestValidateJournalPostingsSingleLeg(t *testing.T) {
+	postings := []entity.TxnPosting{
+		{WalletID: "wallet-a", PostingAmount: decimal.Zero},
+	}
+	assert.NotEqual(t, nil, validateJournalPostings(postings))
+}
@@ -2,18 +2,27 @@ package repository
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 	"wallet-app-server/app/entity"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // User repository interface
 type IUserRepository interface {
 	GetUserByID(db *gorm.DB, userID string) (entity.User, error)
 	GetUserByName(db *gorm.DB, userName string) (entity.User, error)
+	LockUser(db *gorm.DB, userID string) (entity.User, error)
+	CreateUser(db *gorm.DB, user entity.User) error
+	UpdateFullName(db *gorm.DB, userID string, fullName string, updateTime time.Time) error
+	UpdateKYCLevel(db *gorm.DB, userID string, kycLevel string, updateTime time.Time) error
+	UpdateUserRole(db *gorm.DB, userID string, userRole string, updateTime time.Time) error
+	SearchUsers(db *gorm.DB, keyword string, limit int) ([]entity.User, error)
 	CreateUserActivity(db *gorm.DB, userID string, userActType string, userActDetail string, userWalletID string, userActTime time.Time) error
+	ListUserActivities(db *gorm.DB, userID string, limit int) ([]entity.UserActivity, error)
 }
 
 // User repository instance
@@ -38,6 +47,56 @@ func (ur *userRepositoryImpl) GetUserByName(db *gorm.DB, userName string) (entit
 	return user, err
 }
 
+// Fetch the user and lock its row until the end of the transaction
+// It serializes the user's operations which depend on the user's previous transactions (e.g. limit checks)
+// If not found, return gorm.ErrRecordNotFound
+func (ur *userRepositoryImpl) LockUser(tx *gorm.DB, userID string) (entity.User, error) {
+	var user entity.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&user).Error
+	return user, err
+}
+
+// Create user
+func (ur *userRepositoryImpl) CreateUser(db *gorm.DB, user entity.User) error {
+	return db.Create(&user).Error
+}
+
+// Update the user's full name
+func (ur *userRepositoryImpl) UpdateFullName(db *gorm.DB, userID string, fullName string, updateTime time.Time) error {
+	return db.Table("user").Where("user_id = ?", userID).Updates(map[string]any{
+		"full_name":   sql.NullString{String: fullName, Valid: fullName != ""},
+		"update_time": updateTime,
+	}).Error
+}
+
+// Update the user's KYC level
+func (ur *userRepositoryImpl) UpdateKYCLevel(db *gorm.DB, userID string, kycLevel string, updateTime time.Time) error {
+	return db.Table("user").Where("user_id = ?", userID).Updates(map[string]any{
+		"kyc_level":   kycLevel,
+		"update_time": updateTime,
+	}).Error
+}
+
+// Update the user's role
+func (ur *userRepositoryImpl) UpdateUserRole(db *gorm.DB, userID string, userRole string, updateTime time.Time) error {
+	return db.Table("user").Where("user_id = ?", userID).Updates(map[string]any{
+		"user_role":   userRole,
+		"update_time": updateTime,
+	}).Error
+}
+
+// Search users by the exact user ID, or by a part of the user name or the full name (case insensitive)
+// Return at most limit users, ordered by user name
+func (ur *userRepositoryImpl) SearchUsers(db *gorm.DB, keyword string, limit int) ([]entity.User, error) {
+	pattern := "%" + likeEscaper.Replace(keyword) + "%"
+	var users []entity.User
+	if err := db.Where("user_id = ? or user_name ILIKE ? or full_name ILIKE ?", keyword, pattern, pattern).
+		Order("user_name").Limit(limit).Find(&users).Error; err != nil {
+		return []entity.User{}, err
+	}
+	return users, nil
+}
+
 // Create user activity
 func (ur *userRepositoryImpl) CreateUserActivity(db *gorm.DB, userID string, userActType string, userActDetail string, userWalletID string, userActTime time.Time) error {
 	userActivity := entity.UserActivity{
@@ -52,3 +111,15 @@ func (ur *userRepositoryImpl) CreateUserActivity(db *gorm.DB, userID string, use
 	}
 	return db.Create(&userActivity).Error
 }
+
+// List the user's latest activities, the latest first
+func (ur *userRepositoryImpl) ListUserActivities(db *gorm.DB, userID string, limit int) ([]entity.UserActivity, error) {
+	var activities []entity.UserActivity
+	if err := db.Where("user_id = ?", userID).Order("user_act_time desc").Limit(limit).Find(&activities).Error; err != nil {
+		return []entity.UserActivity{}, err
+	}
+	return activities, nil
+}
+
+// Escape the wildcards of the LIKE patterns, so that they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
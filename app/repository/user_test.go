@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLikeEscaper(t *testing.T) {
+	assert.Equal(t, "alice", likeEscaper.Replace("alice"))
+	assert.Equal(t, `100\%`, likeEscaper.Replace("100%"))
+	assert.Equal(t, `a\_b`, likeEscaper.Replace("a_b"))
+	assert.Equal(t, `c:\\dir`, likeEscaper.Replace(`c:\dir`))
+}
@@ -1,9 +1,16 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"wallet-app-server/app/constant"
 	"wallet-app-server/app/entity"
+	"wallet-app-server/app/util"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -14,15 +21,38 @@ type IWalletRepository interface {
 	VerifyUserWalletPossession(db *gorm.DB, userID string, walletID string) (bool, error)
 	ListUserWallets(db *gorm.DB, userID string) ([]entity.Wallet, error)
 	GetWalletByID(db *gorm.DB, walletID string) (entity.Wallet, error)
+	GetWalletByReferenceCode(db *gorm.DB, referenceCode string) (entity.Wallet, error)
+	GetWalletOwnerID(db *gorm.DB, walletID string) (string, error)
+	CreateUserWallet(db *gorm.DB, userID string, walletName string, createTime time.Time) (entity.Wallet, error)
 	Deposit(db *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error)
 	Withdraw(db *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error)
+	ReturnWithdrawal(db *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error)
+	Adjust(db *gorm.DB, walletID string, direction string, amount decimal.Decimal) (decimal.Decimal, error)
+	ChargeFee(db *gorm.DB, walletID string, fee decimal.Decimal) (decimal.Decimal, error)
+	RefundFee(db *gorm.DB, walletID string, fee decimal.Decimal) (decimal.Decimal, error)
+	Hold(db *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error)
+	ReleaseHold(db *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error)
+	LockWallets(db *gorm.DB, walletIDs ...string) error
 	Transfer(db *gorm.DB, userID string, fromWalletID string, toWalletID string, amount decimal.Decimal) error
+	ListWalletHistoryBalances(db *gorm.DB) ([]WalletHistoryBalance, error)
+	UpdateWalletStatus(db *gorm.DB, walletID string, walletStatus string) error
+}
+
+// The wallet balance compared with the net of its transaction history
+type WalletHistoryBalance struct {
+	WalletID       string          `gorm:"column:wallet_id"`
+	WalletType     string          `gorm:"column:wallet_type"`
+	WalletStatus   string          `gorm:"column:wallet_status"`
+	Balance        decimal.Decimal `gorm:"column:balance"`
+	HistoryBalance decimal.Decimal `gorm:"column:history_balance"`
 }
 
 // Wallet repository instance
 var WalletRepository IWalletRepository = &walletRepositoryImpl{}
 
 // Wallet repository implementation
+// The functions locking the wallet rows and writing must be called with a transaction of the primary (db.DB),
+// never of the read replica (db.Reader)
 type walletRepositoryImpl struct{}
 
 // Verify if the wallet is belong to the user
@@ -56,52 +86,123 @@ func (wr *walletRepositoryImpl) GetWalletByID(db *gorm.DB, walletID string) (ent
 	return wallet, nil
 }
 
-// Deposit to wallet
+// Get user wallet by its reference code
+// If not found, return gorm.ErrRecordNotFound
+func (wr *walletRepositoryImpl) GetWalletByReferenceCode(db *gorm.DB, referenceCode string) (entity.Wallet, error) {
+	var wallet entity.Wallet
+	err := db.Table("wallet").Where("reference_code = ? and wallet_type = ?", referenceCode, constant.WalletTypeUser).First(&wallet).Error
+	return wallet, err
+}
+
+// Get the ID of the user owning the wallet
+// If not found, return gorm.ErrRecordNotFound
+func (wr *walletRepositoryImpl) GetWalletOwnerID(db *gorm.DB, walletID string) (string, error) {
+	var userIDs []string
+	if err := db.Table("user_wallet_bridge").Where("wallet_id = ?", walletID).Order("seq").Limit(1).Pluck("user_id", &userIDs).Error; err != nil {
+		return "", err
+	}
+	if len(userIDs) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return userIDs[0], nil
+}
+
+// Create an empty wallet of the user, after the user's existing wallets
 // Should call this method inside a transaction
-// Note that the wallet row will be locked during the transaction to achieve consistency
-func (wr *walletRepositoryImpl) Deposit(tx *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
-	// Ensure transaction amount > 0
-	if amount.IsNegative() || amount.IsZero() {
-		return decimal.Zero, errors.New(ErrNegativeOrZeroAmount)
+func (wr *walletRepositoryImpl) CreateUserWallet(tx *gorm.DB, userID string, walletName string, createTime time.Time) (entity.Wallet, error) {
+	wallet := entity.Wallet{
+		WalletID:      uuid.New().String(),
+		WalletName:    walletName,
+		WalletType:    constant.WalletTypeUser,
+		WalletStatus:  constant.WalletStatusActive,
+		ReferenceCode: sql.NullString{String: util.GenerateReferenceCode(), Valid: true},
+		Balance:       decimal.Zero,
+		CreateTime:    createTime,
 	}
-	// Fetch wallet balance
-	// [NOTE] use clause Strengh = "UPDATE" to implement SELECT ... FOR UPDATE in PostgreSQL
-	var walletBalance decimal.Decimal
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Table("wallet").Where("wallet_id = ?", walletID).Select("balance").Scan(&walletBalance).Error; err != nil {
-		return decimal.Zero, err
+	if err := tx.Create(&wallet).Error; err != nil {
+		return entity.Wallet{}, err
 	}
-	// Modify to wallet balance (+ amount)
-	newWalletBalance := walletBalance.Add(amount)
-	if err := tx.Table("wallet").Where("wallet_id = ?", walletID).Update("balance", newWalletBalance).Error; err != nil {
-		return decimal.Zero, err
+	var count int64
+	if err := tx.Table("user_wallet_bridge").Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return entity.Wallet{}, err
 	}
-	return newWalletBalance, nil
+	bridge := entity.UserWalletBridge{UserID: userID, WalletID: wallet.WalletID, Seq: int(count) + 1, CreateTime: createTime}
+	if err := tx.Create(&bridge).Error; err != nil {
+		return entity.Wallet{}, err
+	}
+	return wallet, nil
+}
+
+// Deposit to wallet
+// Should call this method inside a transaction
+// The money comes from the system cash-in wallet, which is the ledger counterparty of the deposit
+func (wr *walletRepositoryImpl) Deposit(tx *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return postWallet(tx, walletID, true, amount, constant.SystemWalletCashIn, postingRule{})
 }
 
 // Withdraw from wallet
 // Should call this method inside a transaction
-// Note that the wallet row will be locked during the transaction to achieve consistency
+// The money goes to the system cash-out wallet, which is the ledger counterparty of the withdrawal
 func (wr *walletRepositoryImpl) Withdraw(tx *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
-	// Ensure transaction amount > 0
-	if amount.IsNegative() || amount.IsZero() {
-		return decimal.Zero, errors.New(ErrNegativeOrZeroAmount)
-	}
-	// Fetch wallet balance
-	// [NOTE] use clause Strengh = "UPDATE" to implement SELECT ... FOR UPDATE in PostgreSQL
-	var walletBalance decimal.Decimal
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Table("wallet").Where("wallet_id = ?", walletID).Select("balance").Scan(&walletBalance).Error; err != nil {
-		return decimal.Zero, err
-	}
-	// Check balance sufficiency
-	if walletBalance.Cmp(amount) < 0 {
-		return decimal.Zero, errors.New(ErrInsufficientBalance)
-	}
-	// Modify from wallet balance (- amount)
-	newWalletBalance := walletBalance.Sub(amount)
-	if err := tx.Table("wallet").Where("wallet_id = ?", walletID).Update("balance", newWalletBalance).Error; err != nil {
-		return decimal.Zero, err
+	return postWallet(tx, walletID, false, amount, constant.SystemWalletCashOut, postingRule{})
+}
+
+// Return a withdrawal to the wallet (e.g. a payout returned by the bank)
+// Should call this method inside a transaction
+// The money comes back from the system cash-out wallet. The returned money is the user's money,
+// so it's credited even if the wallet is frozen (and stays frozen)
+func (wr *walletRepositoryImpl) ReturnWithdrawal(tx *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return postWallet(tx, walletID, true, amount, constant.SystemWalletCashOut, postingRule{allowFrozen: true})
+}
+
+// Manually adjust the wallet balance, credit or debit the amount
+// Should call this method inside a transaction
+// The system adjustment wallet is the ledger counterparty. An adjustment corrects the user's money,
+// so it's applied even if the wallet is frozen (and stays frozen), but a debit can't make the balance negative
+// Only user wallets can be adjusted, the system wallets are only moved by their counterparties
+func (wr *walletRepositoryImpl) Adjust(tx *gorm.DB, walletID string, direction string, amount decimal.Decimal) (decimal.Decimal, error) {
+	credit := direction != constant.AdjustmentDirectionDebit
+	return postWallet(tx, walletID, credit, amount, constant.SystemWalletAdjustment, postingRule{allowFrozen: true, userWalletOnly: true})
+}
+
+// Charge a fee from the wallet
+// Should call this method inside a transaction, usually the transaction of the charged operation
+// The fee goes to the system fee wallet
+func (wr *walletRepositoryImpl) ChargeFee(tx *gorm.DB, walletID string, fee decimal.Decimal) (decimal.Decimal, error) {
+	return postWallet(tx, walletID, false, fee, constant.SystemWalletFee, postingRule{})
+}
+
+// Refund a fee from the system fee wallet to the wallet, e.g. the fee of a returned payout
+// Should call this method inside a transaction
+// The refunded fee is the user's money, so it's credited even if the wallet is frozen
+func (wr *walletRepositoryImpl) RefundFee(tx *gorm.DB, walletID string, fee decimal.Decimal) (decimal.Decimal, error) {
+	return postWallet(tx, walletID, true, fee, constant.SystemWalletFee, postingRule{allowFrozen: true})
+}
+
+// Hold the amount of the wallet, e.g. for a transfer waiting for approval
+// Should call this method inside a transaction
+// The held money goes to the system hold wallet until it's released
+func (wr *walletRepositoryImpl) Hold(tx *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return postWallet(tx, walletID, false, amount, constant.SystemWalletHold, postingRule{})
+}
+
+// Release the held amount from the system hold wallet back to the wallet
+// Should call this method inside a transaction
+// The held money is the user's money, so it's released even if the wallet is frozen
+func (wr *walletRepositoryImpl) ReleaseHold(tx *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return postWallet(tx, walletID, true, amount, constant.SystemWalletHold, postingRule{allowFrozen: true})
+}
+
+// Lock the wallet rows until the end of the transaction in the order of wallet ID, whatever their status
+// Should call this method first in a transaction which moves several wallets one after another,
+// so that it can't deadlock with a transfer between them, see Transfer
+func (wr *walletRepositoryImpl) LockWallets(tx *gorm.DB, walletIDs ...string) error {
+	for _, walletID := range sortedWalletIDs(walletIDs...) {
+		if _, err := lockWalletRow(tx, walletID); err != nil {
+			return err
+		}
 	}
-	return newWalletBalance, nil
+	return nil
 }
 
 // Transfer money from a wallet to another
@@ -112,31 +213,169 @@ func (wr *walletRepositoryImpl) Transfer(tx *gorm.DB, userID string, fromWalletI
 	if amount.IsNegative() || amount.IsZero() {
 		return errors.New(ErrNegativeOrZeroAmount)
 	}
-	// Fetch from wallet balance
-	// [NOTE] use clause Strengh = "UPDATE" to implement SELECT ... FOR UPDATE in PostgreSQL
-	var fromWalletBalance decimal.Decimal
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Table("wallet").Where("wallet_id = ?", fromWalletID).Select("balance").Scan(&fromWalletBalance).Error; err != nil {
-		return err
+	// Ensure the wallets are different
+	if fromWalletID == toWalletID {
+		return errors.New(ErrSameWalletTransfer)
+	}
+	// Lock both wallets in the order of wallet ID,
+	// so that two concurrent transfers in opposite directions can't deadlock
+	wallets := make(map[string]entity.Wallet, 2)
+	for _, walletID := range sortedWalletIDs(fromWalletID, toWalletID) {
+		wallet, err := lockWallet(tx, walletID)
+		if err != nil {
+			return err
+		}
+		wallets[walletID] = wallet
+	}
+	fromWallet, toWallet := wallets[fromWalletID], wallets[toWalletID]
+	// Only user wallets can receive a transfer
+	if toWallet.WalletType != constant.WalletTypeUser {
+		return errors.New(ErrWalletNotFound)
 	}
 	// Check balance sufficiency
-	if fromWalletBalance.Cmp(amount) < 0 {
+	if fromWallet.Balance.Cmp(amount) < 0 {
 		return errors.New(ErrInsufficientBalance)
 	}
-	// Fetch to wallet balance
-	// [NOTE] use clause Strengh = "UPDATE" to implement SELECT ... FOR UPDATE in PostgreSQL
-	var toWalletBalance decimal.Decimal
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Table("wallet").Where("wallet_id = ?", toWalletID).Select("balance").Scan(&toWalletBalance).Error; err != nil {
-		return err
-	}
 	// Modify from wallet balance (- amount)
-	newFromWalletBalance := fromWalletBalance.Sub(amount)
+	newFromWalletBalance := fromWallet.Balance.Sub(amount)
 	if err := tx.Table("wallet").Where("wallet_id = ?", fromWalletID).Update("balance", newFromWalletBalance).Error; err != nil {
 		return err
 	}
 	// Modify to wallet balance (+ amount)
-	newToWalletBalance := toWalletBalance.Add(amount)
+	newToWalletBalance := toWallet.Balance.Add(amount)
 	if err := tx.Table("wallet").Where("wallet_id = ?", toWalletID).Update("balance", newToWalletBalance).Error; err != nil {
 		return err
 	}
 	return nil
 }
+
+// List all the wallets with the balance recomputed from txn_history
+// The history balance is the sum of the credits (to_wallet_id) minus the sum of the debits (from_wallet_id)
+// The balance of a system wallet is the sum of its postings, see postWallet
+func (wr *walletRepositoryImpl) ListWalletHistoryBalances(db *gorm.DB) ([]WalletHistoryBalance, error) {
+	var result []WalletHistoryBalance
+	err := db.Raw(`SELECT w.wallet_id, w.wallet_type, w.wallet_status,
+		CASE WHEN w.wallet_type = ? THEN COALESCE((SELECT SUM(p.posting_amount) FROM txn_posting p WHERE p.wallet_id = w.wallet_id), 0)
+			ELSE w.balance END AS balance,
+		COALESCE(h.net_amount, 0) AS history_balance
+		FROM wallet w LEFT JOIN (
+			SELECT wallet_id, SUM(amount) AS net_amount FROM (
+				SELECT to_wallet_id AS wallet_id, txn_amount AS amount FROM txn_history
+				UNION ALL
+				SELECT from_wallet_id AS wallet_id, -txn_amount AS amount FROM txn_history
+			) t GROUP BY wallet_id
+		) h ON w.wallet_id = h.wallet_id
+		ORDER BY w.wallet_id`, constant.WalletTypeSystem).Find(&result).Error
+	return result, err
+}
+
+// Update wallet status (e.g. freeze or unfreeze the wallet)
+func (wr *walletRepositoryImpl) UpdateWalletStatus(db *gorm.DB, walletID string, walletStatus string) error {
+	return db.Table("wallet").Where("wallet_id = ?", walletID).Updates(map[string]any{
+		"wallet_status": walletStatus,
+		"update_time":   time.Now(),
+	}).Error
+}
+
+// Fetch the wallet and lock its row until the end of the transaction
+// If not found, return ErrWalletNotFound
+// If the wallet is frozen, return ErrWalletFrozen
+func lockWallet(tx *gorm.DB, walletID string) (entity.Wallet, error) {
+	wallet, err := lockWalletRow(tx, walletID)
+	if err != nil {
+		return entity.Wallet{}, err
+	}
+	if wallet.WalletStatus == constant.WalletStatusFrozen {
+		return entity.Wallet{}, errors.New(ErrWalletFrozen)
+	}
+	return wallet, nil
+}
+
+// Fetch the wallet and lock its row until the end of the transaction, whatever its status
+// If not found, return ErrWalletNotFound
+func lockWalletRow(tx *gorm.DB, walletID string) (entity.Wallet, error) {
+	// [NOTE] use clause Strengh = "UPDATE" to implement SELECT ... FOR UPDATE in PostgreSQL
+	var wallet entity.Wallet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Table("wallet").Where("wallet_id = ?", walletID).First(&wallet).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.Wallet{}, errors.New(ErrWalletNotFound)
+		}
+		return entity.Wallet{}, err
+	}
+	return wallet, nil
+}
+
+// How a posting checks the wallet
+type postingRule struct {
+	// The wallet can be frozen, for the money which is the user's anyway (e.g. returned or released)
+	allowFrozen bool
+	// Only a user wallet can be posted
+	userWalletOnly bool
+}
+
+// Credit or debit the amount to the wallet against a system wallet, its ledger counterparty, and return the new wallet balance
+// The wallet row is locked until the end of the transaction, and a debit can't make the balance negative.
+// The system wallet isn't locked nor updated, its balance is derived from its postings,
+// so the transactions of different wallets never wait for each other on a system wallet.
+// Lock order: the user wallets are the only rows locked, in the order of wallet ID when a transaction locks several (see LockWallets)
+func postWallet(tx *gorm.DB, walletID string, credit bool, amount decimal.Decimal, systemWalletID string, rule postingRule) (decimal.Decimal, error) {
+	// Ensure transaction amount > 0
+	if amount.IsNegative() || amount.IsZero() {
+		return decimal.Zero, errors.New(ErrNegativeOrZeroAmount)
+	}
+	// Fetch wallet balance
+	lock := lockWallet
+	if rule.allowFrozen {
+		lock = lockWalletRow
+	}
+	wallet, err := lock(tx, walletID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if rule.userWalletOnly && wallet.WalletType != constant.WalletTypeUser {
+		return decimal.Zero, errors.New(ErrWalletNotFound)
+	}
+	// Check balance sufficiency
+	postingAmount := amount
+	if !credit {
+		postingAmount = amount.Neg()
+		if wallet.Balance.Cmp(amount) < 0 {
+			return decimal.Zero, errors.New(ErrInsufficientBalance)
+		}
+	}
+	// The counterparty must exist, so the posting is rolled back rather than left unbalanced
+	if err := checkSystemWallet(tx, systemWalletID); err != nil {
+		return decimal.Zero, err
+	}
+	// Modify wallet balance (+/- amount)
+	newWalletBalance := wallet.Balance.Add(postingAmount)
+	if err := tx.Table("wallet").Where("wallet_id = ?", walletID).Update("balance", newWalletBalance).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return newWalletBalance, nil
+}
+
+// Check the system wallet exists, without locking it
+// If the system wallet is missing, return ErrSystemWalletNotFound
+func checkSystemWallet(tx *gorm.DB, systemWalletID string) error {
+	var count int64
+	if err := tx.Table("wallet").Where("wallet_id = ? and wallet_type = ?", systemWalletID, constant.WalletTypeSystem).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != 1 {
+		return fmt.Errorf("%s: %s", ErrSystemWalletNotFound, systemWalletID)
+	}
+	return nil
+}
+
+// Return the distinct wallet IDs in ascending order
+func sortedWalletIDs(walletIDs ...string) []string {
+	result := make([]string, 0, len(walletIDs))
+	for _, walletID := range walletIDs {
+		if !slices.Contains(result, walletID) {
+			result = append(result, walletID)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"wallet-app-server/app/constant"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestCheckSystemWalletNotFound(t *testing.T) {
+	// The statement is only built, so no row is counted, as if the system wallet were missing
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Equal(t, err, nil)
+	err = checkSystemWallet(db, constant.SystemWalletCashIn)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, strings.HasPrefix(err.Error(), ErrSystemWalletNotFound), true)
+}
+
+func TestSortedWalletIDs(t *testing.T) {
+	assert.Equal(t, sortedWalletIDs("w2", "w1", "w2"), []string{"w1", "w2"})
+}
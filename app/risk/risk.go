@@ -0,0 +1,218 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"wallet-app-server/app/config"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shopspring/decimal"
+)
+
+// Decisions
+const (
+	DecisionAllow  = "allow"
+	DecisionReview = "review"
+	DecisionDeny   = "deny"
+)
+
+// The risk policy, loaded from the rules file
+// The matched rules' scores are summed up, and the sum decides review or deny by the thresholds,
+// unless a matched rule forces the decision by its action
+type Policy struct {
+	ReviewScore int          `toml:"review-score"`
+	DenyScore   int          `toml:"deny-score"`
+	Rules       []RuleConfig `toml:"rules"`
+}
+
+// A declarative rule, the type specific fields are only used by the rules of that type
+type RuleConfig struct {
+	Name     string   `toml:"name"`
+	Type     string   `toml:"type"`
+	TxnTypes []string `toml:"txn-types"`
+	Score    int      `toml:"score"`
+	Action   string   `toml:"action"`
+	// velocity
+	WindowInSecs int64 `toml:"window-in-secs"`
+	MaxCount     int64 `toml:"max-count"`
+	// amount_anomaly
+	Multiplier  decimal.Decimal `toml:"multiplier"`
+	MinHistory  int64           `toml:"min-history"`
+	HistorySize int             `toml:"history-size"`
+	// time_of_day
+	FromHour int `toml:"from-hour"`
+	ToHour   int `toml:"to-hour"`
+}
+
+// Facts about the user and the transaction, only queried by the rules which need them
+type Facts interface {
+	// Number of the user's transactions of the same type since the time
+	CountTransactionsSince(since time.Time) (int64, error)
+	// Whether the user has made a transaction from the device before
+	IsKnownDevice() (bool, error)
+	// Whether the user has transferred to the recipient before, always true if there is no recipient
+	IsKnownRecipient() (bool, error)
+	// Average amount and number of the user's latest transactions of the same type
+	AverageAmount(historySize int) (decimal.Decimal, int64, error)
+}
+
+// The transaction to be evaluated
+type Input struct {
+	TxnType string
+	Amount  decimal.Decimal
+	Time    time.Time
+	Facts   Facts
+}
+
+// Result of an evaluation
+type Decision struct {
+	Decision     string
+	Score        int
+	MatchedRules []string
+}
+
+// A rule matching risky transactions
+type Rule interface {
+	Match(input Input) (bool, error)
+}
+
+// Build a rule from its configuration
+type RuleFactory func(cfg RuleConfig) (Rule, error)
+
+var ruleFactories = map[string]RuleFactory{
+	RuleTypeVelocity:           newVelocityRule,
+	RuleTypeNewDevice:          newNewDeviceRule,
+	RuleTypeFirstTimeRecipient: newFirstTimeRecipientRule,
+	RuleTypeAmountAnomaly:      newAmountAnomalyRule,
+	RuleTypeTimeOfDay:          newTimeOfDayRule,
+}
+
+// Register a rule type, so that it can be used in the rules file
+// Should be called before the engine is created
+func RegisterRule(ruleType string, factory RuleFactory) {
+	ruleFactories[ruleType] = factory
+}
+
+// The risk engine evaluating the transactions by the policy
+type Engine struct {
+	reviewScore int
+	denyScore   int
+	rules       []engineRule
+}
+
+type engineRule struct {
+	name     string
+	txnTypes []string
+	score    int
+	action   string
+	rule     Rule
+}
+
+// The risk engine instance
+// By default there is no rule, and every transaction is allowed
+var DefaultEngine = &Engine{}
+
+// Init the risk engine from the rules file in the configuration
+// If no rules file is configured, the risk engine allows every transaction
+func Init() error {
+	if config.Cfg.Risk.RulesFile == "" {
+		return nil
+	}
+	engine, err := LoadEngine(config.Cfg.Risk.RulesFile)
+	if err != nil {
+		return err
+	}
+	DefaultEngine = engine
+	return nil
+}
+
+// Load the policy from a TOML rules file and create the engine
+func LoadEngine(path string) (*Engine, error) {
+	var policy Policy
+	if _, err := toml.DecodeFile(path, &policy); err != nil {
+		return nil, err
+	}
+	return NewEngine(policy)
+}
+
+// Create the engine of the policy, all the problems of the policy are reported together
+func NewEngine(policy Policy) (*Engine, error) {
+	var errs []error
+	if policy.ReviewScore <= 0 || policy.DenyScore <= 0 {
+		errs = append(errs, errors.New("review-score and deny-score must be positive"))
+	} else if policy.DenyScore < policy.ReviewScore {
+		errs = append(errs, errors.New("deny-score is less than review-score"))
+	}
+	engine := &Engine{reviewScore: policy.ReviewScore, denyScore: policy.DenyScore}
+	seen := map[string]bool{}
+	for i, cfg := range policy.Rules {
+		name := fmt.Sprintf("risk rule #%d (%s)", i+1, cfg.Name)
+		if cfg.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", name))
+		} else if seen[cfg.Name] {
+			errs = append(errs, fmt.Errorf("%s: duplicated name", name))
+		}
+		seen[cfg.Name] = true
+		switch cfg.Action {
+		case "", DecisionReview, DecisionDeny:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown action %q", name, cfg.Action))
+		}
+		if cfg.Score < 0 {
+			errs = append(errs, fmt.Errorf("%s: score can't be negative", name))
+		}
+		factory, found := ruleFactories[cfg.Type]
+		if !found {
+			errs = append(errs, fmt.Errorf("%s: unknown type %q", name, cfg.Type))
+			continue
+		}
+		rule, err := factory(cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		engine.rules = append(engine.rules, engineRule{
+			name:     cfg.Name,
+			txnTypes: cfg.TxnTypes,
+			score:    cfg.Score,
+			action:   cfg.Action,
+			rule:     rule,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Evaluate the transaction by the rules of its transaction type
+func (e *Engine) Evaluate(input Input) (Decision, error) {
+	result := Decision{Decision: DecisionAllow, MatchedRules: []string{}}
+	forced := ""
+	for _, r := range e.rules {
+		if len(r.txnTypes) > 0 && !slices.Contains(r.txnTypes, input.TxnType) {
+			continue
+		}
+		matched, err := r.rule.Match(input)
+		if err != nil {
+			return Decision{}, err
+		}
+		if !matched {
+			continue
+		}
+		result.Score += r.score
+		result.MatchedRules = append(result.MatchedRules, r.name)
+		if r.action == DecisionDeny || (r.action == DecisionReview && forced == "") {
+			forced = r.action
+		}
+	}
+	switch {
+	case forced == DecisionDeny || (e.denyScore > 0 && result.Score >= e.denyScore):
+		result.Decision = DecisionDeny
+	case forced == DecisionReview || (e.reviewScore > 0 && result.Score >= e.reviewScore):
+		result.Decision = DecisionReview
+	}
+	return result, nil
+}
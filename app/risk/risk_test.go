@@ -0,0 +1,177 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+type fakeFacts struct {
+	count          int64
+	knownDevice    bool
+	knownRecipient bool
+	average        decimal.Decimal
+	historyCount   int64
+	err            error
+}
+
+func (ff *fakeFacts) CountTransactionsSince(since time.Time) (int64, error) {
+	return ff.count, ff.err
+}
+
+func (ff *fakeFacts) IsKnownDevice() (bool, error) {
+	return ff.knownDevice, ff.err
+}
+
+func (ff *fakeFacts) IsKnownRecipient() (bool, error) {
+	return ff.knownRecipient, ff.err
+}
+
+func (ff *fakeFacts) AverageAmount(historySize int) (decimal.Decimal, int64, error) {
+	return ff.average, ff.historyCount, ff.err
+}
+
+// Facts of a regular user, matching no rule
+func regularFacts() *fakeFacts {
+	return &fakeFacts{count: 1, knownDevice: true, knownRecipient: true, average: decimal.NewFromInt(100), historyCount: 10}
+}
+
+func newInput(txnType string, amount int64, hour int, facts Facts) Input {
+	return Input{
+		TxnType: txnType,
+		Amount:  decimal.NewFromInt(amount),
+		Time:    time.Date(2025, 6, 15, hour, 30, 0, 0, time.UTC),
+		Facts:   facts,
+	}
+}
+
+func loadTestEngine(t *testing.T) *Engine {
+	engine, err := LoadEngine("testdata/rules.toml")
+	assert.Equal(t, nil, err)
+	return engine
+}
+
+func TestEvaluateAllow(t *testing.T) {
+	decision, err := loadTestEngine(t).Evaluate(newInput("transfer", 200, 12, regularFacts()))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionAllow, decision.Decision)
+	assert.Equal(t, 0, decision.Score)
+	assert.Equal(t, []string{}, decision.MatchedRules)
+}
+
+func TestEvaluateReview(t *testing.T) {
+	facts := regularFacts()
+	facts.knownDevice = false
+	facts.knownRecipient = false
+	// new device + first time recipient + night time
+	decision, err := loadTestEngine(t).Evaluate(newInput("transfer", 200, 3, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionAllow, decision.Decision)
+	assert.Equal(t, 50, decision.Score)
+	assert.Equal(t, []string{"new-device", "first-time-recipient", "night-time"}, decision.MatchedRules)
+	// + amount anomaly
+	decision, err = loadTestEngine(t).Evaluate(newInput("transfer", 501, 3, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionReview, decision.Decision)
+	assert.Equal(t, 90, decision.Score)
+}
+
+func TestEvaluateDeny(t *testing.T) {
+	facts := regularFacts()
+	facts.count = 10
+	facts.knownDevice = false
+	decision, err := loadTestEngine(t).Evaluate(newInput("withdraw", 1000, 12, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionDeny, decision.Decision)
+	assert.Equal(t, []string{"velocity-1h", "new-device", "amount-anomaly"}, decision.MatchedRules)
+}
+
+func TestEvaluateTxnTypes(t *testing.T) {
+	facts := regularFacts()
+	facts.knownRecipient = false
+	// first-time-recipient only applies to transfers
+	decision, err := loadTestEngine(t).Evaluate(newInput("withdraw", 100, 12, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, decision.Score)
+}
+
+func TestEvaluateForcedAction(t *testing.T) {
+	engine, err := NewEngine(Policy{
+		ReviewScore: 50,
+		DenyScore:   100,
+		Rules: []RuleConfig{
+			{Name: "new-device", Type: RuleTypeNewDevice, Score: 1, Action: DecisionReview},
+			{Name: "night-time", Type: RuleTypeTimeOfDay, Score: 1, Action: DecisionDeny, FromHour: 22, ToHour: 6},
+		},
+	})
+	assert.Equal(t, nil, err)
+	facts := regularFacts()
+	facts.knownDevice = false
+	decision, err := engine.Evaluate(newInput("transfer", 100, 12, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionReview, decision.Decision)
+	// time of day wraps around midnight
+	decision, err = engine.Evaluate(newInput("transfer", 100, 23, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionDeny, decision.Decision)
+}
+
+func TestEvaluateAmountAnomalyMinHistory(t *testing.T) {
+	facts := regularFacts()
+	facts.historyCount = 4
+	decision, err := loadTestEngine(t).Evaluate(newInput("transfer", 100000, 12, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionAllow, decision.Decision)
+}
+
+func TestEvaluateFactsError(t *testing.T) {
+	facts := regularFacts()
+	facts.err = errors.New("db error")
+	_, err := loadTestEngine(t).Evaluate(newInput("transfer", 100, 12, facts))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestEmptyEngine(t *testing.T) {
+	decision, err := (&Engine{}).Evaluate(newInput("transfer", 100, 12, regularFacts()))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionAllow, decision.Decision)
+}
+
+func TestNewEngineInvalid(t *testing.T) {
+	_, err := NewEngine(Policy{
+		ReviewScore: 100,
+		DenyScore:   50,
+		Rules: []RuleConfig{
+			{Name: "a", Type: "unknown"},
+			{Name: "a", Type: RuleTypeVelocity},
+			{Name: "b", Type: RuleTypeNewDevice, Action: "block"},
+			{Name: "c", Type: RuleTypeAmountAnomaly, Multiplier: decimal.NewFromInt(1), MinHistory: 1, HistorySize: 1},
+			{Name: "d", Type: RuleTypeTimeOfDay, FromHour: 3, ToHour: 3},
+		},
+	})
+	assert.NotEqual(t, nil, err)
+	assert.MatchRegex(t, err.Error(), "deny-score is less than review-score")
+	assert.MatchRegex(t, err.Error(), `unknown type "unknown"`)
+	assert.MatchRegex(t, err.Error(), "duplicated name")
+	assert.MatchRegex(t, err.Error(), "window-in-secs and max-count must be positive")
+	assert.MatchRegex(t, err.Error(), `unknown action "block"`)
+	assert.MatchRegex(t, err.Error(), "multiplier must be greater than 1")
+	assert.MatchRegex(t, err.Error(), "from-hour and to-hour must be different")
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("always", func(cfg RuleConfig) (Rule, error) {
+		return &newDeviceRule{}, nil
+	})
+	defer delete(ruleFactories, "always")
+	engine, err := NewEngine(Policy{ReviewScore: 10, DenyScore: 20, Rules: []RuleConfig{{Name: "always", Type: "always", Score: 10}}})
+	assert.Equal(t, nil, err)
+	facts := regularFacts()
+	facts.knownDevice = false
+	decision, err := engine.Evaluate(newInput("transfer", 100, 12, facts))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, DecisionReview, decision.Decision)
+}
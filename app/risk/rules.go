@@ -0,0 +1,113 @@
+package risk
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rule types
+const (
+	RuleTypeVelocity           = "velocity"
+	RuleTypeNewDevice          = "new_device"
+	RuleTypeFirstTimeRecipient = "first_time_recipient"
+	RuleTypeAmountAnomaly      = "amount_anomaly"
+	RuleTypeTimeOfDay          = "time_of_day"
+)
+
+// Match if the user has already made max-count transactions of the type in the last window-in-secs
+type velocityRule struct {
+	window   time.Duration
+	maxCount int64
+}
+
+func newVelocityRule(cfg RuleConfig) (Rule, error) {
+	if cfg.WindowInSecs <= 0 || cfg.MaxCount <= 0 {
+		return nil, errors.New("window-in-secs and max-count must be positive")
+	}
+	return &velocityRule{window: time.Duration(cfg.WindowInSecs) * time.Second, maxCount: cfg.MaxCount}, nil
+}
+
+func (vr *velocityRule) Match(input Input) (bool, error) {
+	count, err := input.Facts.CountTransactionsSince(input.Time.Add(-vr.window))
+	if err != nil {
+		return false, err
+	}
+	return count >= vr.maxCount, nil
+}
+
+// Match if the transaction is made from a device the user has never used
+type newDeviceRule struct{}
+
+func newNewDeviceRule(cfg RuleConfig) (Rule, error) {
+	return &newDeviceRule{}, nil
+}
+
+func (ndr *newDeviceRule) Match(input Input) (bool, error) {
+	known, err := input.Facts.IsKnownDevice()
+	return !known, err
+}
+
+// Match if the user has never transferred to the recipient
+type firstTimeRecipientRule struct{}
+
+func newFirstTimeRecipientRule(cfg RuleConfig) (Rule, error) {
+	return &firstTimeRecipientRule{}, nil
+}
+
+func (ftr *firstTimeRecipientRule) Match(input Input) (bool, error) {
+	known, err := input.Facts.IsKnownRecipient()
+	return !known, err
+}
+
+// Match if the amount is more than multiplier times the average amount of the user's latest history-size transactions of the type
+// The rule doesn't match until the user has min-history transactions of the type
+type amountAnomalyRule struct {
+	multiplier  decimal.Decimal
+	minHistory  int64
+	historySize int
+}
+
+func newAmountAnomalyRule(cfg RuleConfig) (Rule, error) {
+	if !cfg.Multiplier.GreaterThan(decimal.NewFromInt(1)) {
+		return nil, errors.New("multiplier must be greater than 1")
+	}
+	if cfg.MinHistory <= 0 || cfg.HistorySize <= 0 || int64(cfg.HistorySize) < cfg.MinHistory {
+		return nil, errors.New("min-history and history-size must be positive, and history-size can't be less than min-history")
+	}
+	return &amountAnomalyRule{multiplier: cfg.Multiplier, minHistory: cfg.MinHistory, historySize: cfg.HistorySize}, nil
+}
+
+func (aar *amountAnomalyRule) Match(input Input) (bool, error) {
+	average, count, err := input.Facts.AverageAmount(aar.historySize)
+	if err != nil {
+		return false, err
+	}
+	if count < aar.minHistory {
+		return false, nil
+	}
+	return input.Amount.GreaterThan(average.Mul(aar.multiplier)), nil
+}
+
+// Match if the transaction is made in the hours [from-hour, to-hour) of the server time zone
+// The range wraps around midnight if from-hour is greater than to-hour (e.g. 22 to 6)
+type timeOfDayRule struct {
+	fromHour int
+	toHour   int
+}
+
+func newTimeOfDayRule(cfg RuleConfig) (Rule, error) {
+	if cfg.FromHour < 0 || cfg.FromHour > 23 || cfg.ToHour < 0 || cfg.ToHour > 24 || cfg.FromHour == cfg.ToHour {
+		return nil, errors.New("from-hour and to-hour must be different hours of the day")
+	}
+	return &timeOfDayRule{fromHour: cfg.FromHour, toHour: cfg.ToHour}, nil
+}
+
+func (tdr *timeOfDayRule) Match(input Input) (bool, error) {
+	hour := input.Time.Hour()
+	if tdr.fromHour < tdr.toHour {
+		return hour >= tdr.fromHour && hour < tdr.toHour, nil
+	}
+	return hour >= tdr.fromHour || hour < tdr.toHour, nil
+}
@@ -3,16 +3,24 @@ package app
 import (
 	"wallet-app-server/app/controller"
 	"wallet-app-server/app/middleware"
+	"wallet-app-server/app/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
 func configRoutes(g *gin.Engine) {
+	// Health endpoints for the load balancer (no authentication)
+	g.GET("/healthz", controller.Healthz)
+	g.GET("/readyz", controller.Readyz)
+
 	apiGroup := g.Group("/api/v1")
 
 	// User endpoints
 	userGroup := apiGroup.Group("/user")
 	userGroup.POST("/login", controller.Login)
+	userGroup.POST("/register", controller.Register)
+	userGroup.POST("/profile", middleware.Authentication, controller.UpdateProfile)
+	userGroup.GET("/limits", middleware.Authentication, controller.GetUserLimits)
 
 	// Wallet endpoints (need authentication)
 	walletGroup := apiGroup.Group("/wallet", middleware.Authentication)
@@ -20,9 +28,63 @@ func configRoutes(g *gin.Engine) {
 	walletGroup.POST("/checkBalance", controller.CheckWalletBalance)
 	walletGroup.POST("/deposit", controller.Deposit)
 	walletGroup.POST("/withdraw", controller.Withdraw)
+	walletGroup.GET("/:wallet_id/balance", controller.GetWalletBalance)
+	walletGroup.GET("/:wallet_id/statement", controller.Statement)
+	walletGroup.GET("/:wallet_id/export", controller.Export)
 
 	// Transaction endpoints (need authentication)
 	transactionGroup := apiGroup.Group("/transaction", middleware.Authentication)
 	transactionGroup.POST("/transfer", controller.Transfer)
 	transactionGroup.POST("/history", controller.History)
+	transactionGroup.POST("/quote", controller.Quote)
+
+	// KYC endpoints (need authentication)
+	kycGroup := apiGroup.Group("/kyc", middleware.Authentication)
+	kycGroup.POST("/document", controller.UploadKYCDocument)
+	kycGroup.POST("/submit", controller.SubmitKYC)
+	kycGroup.GET("/status", controller.GetKYCStatus)
+
+	// Payout endpoints (need authentication)
+	payoutGroup := apiGroup.Group("/payout", middleware.Authentication)
+	payoutGroup.POST("/destination", controller.CreatePayoutDestination)
+	payoutGroup.GET("/destination/list", controller.ListPayoutDestinations)
+	payoutGroup.POST("/withdraw", controller.RequestPayout)
+	payoutGroup.GET("/list", controller.ListPayouts)
+
+	// Admin endpoints (need authentication and a staff role, each endpoint needs its own permission)
+	// Every request is recorded in the admin audit log, including the denied ones
+	adminGroup := apiGroup.Group("/admin", middleware.Authentication, middleware.AdminAudit, middleware.CurrentRole, middleware.Authorization(rbac.PermissionAdminAPI))
+	adminGroup.GET("/user/search", middleware.Authorization(rbac.PermissionUserRead), controller.SearchUsers)
+	adminGroup.GET("/user/:user_id/wallets", middleware.Authorization(rbac.PermissionUserRead), controller.ListUserWalletsByAdmin)
+	adminGroup.GET("/user/:user_id/activities", middleware.Authorization(rbac.PermissionUserRead), controller.ListUserActivities)
+	adminGroup.POST("/user/role", middleware.Authorization(rbac.PermissionUserManage), controller.SetUserRole)
+	adminGroup.GET("/wallet/:wallet_id", middleware.Authorization(rbac.PermissionWalletRead), controller.GetWalletByAdmin)
+	adminGroup.GET("/wallet/:wallet_id/history", middleware.Authorization(rbac.PermissionWalletRead), controller.ListWalletHistoryByAdmin)
+	adminGroup.POST("/wallet/adjust", middleware.Authorization(rbac.PermissionWalletAdjust), controller.AdjustWallet)
+	adminGroup.POST("/wallet/unfreeze", middleware.Authorization(rbac.PermissionWalletManage), controller.UnfreezeWallet)
+	adminGroup.POST("/reconcile", middleware.Authorization(rbac.PermissionWalletManage), controller.Reconcile)
+	adminGroup.POST("/bank-import", middleware.Authorization(rbac.PermissionBankImport), controller.ImportBankStatement)
+	adminGroup.GET("/bank-import/unmatched", middleware.Authorization(rbac.PermissionBankImport), controller.ListUnmatchedBankLines)
+	adminGroup.POST("/bank-import/assign", middleware.Authorization(rbac.PermissionBankImport), controller.AssignBankLine)
+	adminGroup.POST("/bank-import/ignore", middleware.Authorization(rbac.PermissionBankImport), controller.IgnoreBankLine)
+	adminGroup.POST("/payout/destination/verify", middleware.Authorization(rbac.PermissionPayoutManage), controller.VerifyPayoutDestination)
+	adminGroup.POST("/payout/batch", middleware.Authorization(rbac.PermissionPayoutManage), controller.SubmitPayoutBatch)
+	adminGroup.POST("/payout/settle", middleware.Authorization(rbac.PermissionPayoutManage), controller.SettlePayout)
+	adminGroup.POST("/payout/return", middleware.Authorization(rbac.PermissionPayoutManage), controller.ReturnPayout)
+	adminGroup.POST("/limit/user", middleware.Authorization(rbac.PermissionLimitManage), controller.SetUserLimit)
+	adminGroup.POST("/limit/user/remove", middleware.Authorization(rbac.PermissionLimitManage), controller.RemoveUserLimit)
+	adminGroup.GET("/risk/review/pending", middleware.Authorization(rbac.PermissionRiskReview), controller.ListPendingRiskReviews)
+	adminGroup.POST("/risk/review/approve", middleware.Authorization(rbac.PermissionRiskReview), controller.ApproveRiskReview)
+	adminGroup.POST("/risk/review/reject", middleware.Authorization(rbac.PermissionRiskReview), controller.RejectRiskReview)
+	adminGroup.POST("/screening/reload", middleware.Authorization(rbac.PermissionScreeningManage), controller.ReloadScreeningLists)
+	adminGroup.GET("/screening/case/open", middleware.Authorization(rbac.PermissionScreeningManage), controller.ListOpenScreeningCases)
+	adminGroup.POST("/screening/case/close", middleware.Authorization(rbac.PermissionScreeningManage), controller.CloseScreeningCase)
+	adminGroup.GET("/kyc/submission/pending", middleware.Authorization(rbac.PermissionKYCReview), controller.ListPendingKYCSubmissions)
+	adminGroup.GET("/kyc/document/:document_id", middleware.Authorization(rbac.PermissionKYCReview), controller.DownloadKYCDocument)
+	adminGroup.POST("/kyc/submission/approve", middleware.Authorization(rbac.PermissionKYCReview), controller.ApproveKYCSubmission)
+	adminGroup.POST("/kyc/submission/reject", middleware.Authorization(rbac.PermissionKYCReview), controller.RejectKYCSubmission)
+	adminGroup.GET("/approval/pending", middleware.Authorization(rbac.PermissionApprovalReview), controller.ListPendingOperations)
+	adminGroup.GET("/approval/:operation_id", middleware.Authorization(rbac.PermissionApprovalReview), controller.GetPendingOperation)
+	adminGroup.POST("/approval/approve", middleware.Authorization(rbac.PermissionApprovalReview), controller.ApproveOperation)
+	adminGroup.POST("/approval/reject", middleware.Authorization(rbac.PermissionApprovalReview), controller.RejectOperation)
 }
@@ -0,0 +1,184 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Sources of the sanctions lists
+const (
+	SourceOFACSDN        = "ofac_sdn"
+	SourceEUConsolidated = "eu_consolidated"
+)
+
+// Subject types of the list entries
+const (
+	SubjectTypePerson = "person"
+	SubjectTypeEntity = "entity"
+)
+
+// The null value of the OFAC CSV files
+const ofacNull = "-0-"
+
+// An entry of a sanctions list
+type Entry struct {
+	Source      string
+	EntryID     string
+	Name        string
+	Aliases     []string
+	SubjectType string
+	Programs    string
+}
+
+// Parse the OFAC SDN list in the CSV format (sdn.csv), with the optional alternate names (alt.csv)
+// The files have no header row:
+//   - sdn.csv: ent_num, SDN_Name, SDN_Type, Program, Title, Call_Sign, Vess_type, Tonnage, GRT, Vess_flag, Vess_owner, Remarks
+//   - alt.csv: ent_num, alt_num, alt_type, alt_name, alt_remarks
+func ParseOFACSDN(sdn io.Reader, alt io.Reader) ([]Entry, error) {
+	records, err := readOFACCSV(sdn, 4)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(records))
+	index := make(map[string]int, len(records))
+	for _, record := range records {
+		if record[0] == "" || record[1] == "" {
+			continue
+		}
+		subjectType := SubjectTypeEntity
+		if strings.EqualFold(record[2], "individual") {
+			subjectType = SubjectTypePerson
+		}
+		index[record[0]] = len(entries)
+		entries = append(entries, Entry{
+			Source:      SourceOFACSDN,
+			EntryID:     record[0],
+			Name:        record[1],
+			SubjectType: subjectType,
+			Programs:    record[3],
+		})
+	}
+	if alt == nil {
+		return entries, nil
+	}
+	altRecords, err := readOFACCSV(alt, 4)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range altRecords {
+		i, found := index[record[0]]
+		if !found || record[3] == "" {
+			continue
+		}
+		entries[i].Aliases = append(entries[i].Aliases, record[3])
+	}
+	return entries, nil
+}
+
+// Read the OFAC CSV records with at least minFields fields, the null values are replaced with empty strings
+func readOFACCSV(r io.Reader, minFields int) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var result [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The file ends with a SUB (0x1A) character
+		if len(record) < minFields {
+			continue
+		}
+		for i, field := range record {
+			field = strings.TrimSpace(field)
+			if field == ofacNull {
+				field = ""
+			}
+			record[i] = field
+		}
+		result = append(result, record)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no OFAC record found")
+	}
+	return result, nil
+}
+
+// Elements of the EU consolidated financial sanctions list (XML format 1.1)
+type euSanctionEntity struct {
+	LogicalID   string `xml:"logicalId,attr"`
+	SubjectType struct {
+		Code string `xml:"code,attr"`
+	} `xml:"subjectType"`
+	Regulations []struct {
+		Programme string `xml:"programme,attr"`
+	} `xml:"regulation"`
+	NameAliases []struct {
+		WholeName string `xml:"wholeName,attr"`
+	} `xml:"nameAlias"`
+}
+
+// Parse the EU consolidated financial sanctions list in the XML format
+// The file is read element by element, so that the whole document is never loaded into memory
+func ParseEUConsolidated(r io.Reader) ([]Entry, error) {
+	decoder := xml.NewDecoder(r)
+	var entries []Entry
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sanctionEntity" {
+			continue
+		}
+		var entity euSanctionEntity
+		if err := decoder.DecodeElement(&entity, &start); err != nil {
+			return nil, err
+		}
+		entry := Entry{
+			Source:      SourceEUConsolidated,
+			EntryID:     entity.LogicalID,
+			SubjectType: SubjectTypeEntity,
+		}
+		if entity.SubjectType.Code == SubjectTypePerson {
+			entry.SubjectType = SubjectTypePerson
+		}
+		var programmes []string
+		for _, regulation := range entity.Regulations {
+			if regulation.Programme != "" {
+				programmes = append(programmes, regulation.Programme)
+			}
+		}
+		entry.Programs = strings.Join(programmes, ";")
+		for _, alias := range entity.NameAliases {
+			name := strings.TrimSpace(alias.WholeName)
+			if name == "" {
+				continue
+			}
+			if entry.Name == "" {
+				entry.Name = name
+			} else {
+				entry.Aliases = append(entry.Aliases, name)
+			}
+		}
+		if entry.EntryID == "" || entry.Name == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no EU sanction entity found")
+	}
+	return entries, nil
+}
@@ -0,0 +1,98 @@
+package screening
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize a name for matching: strip the diacritics, lower case, and keep only letters and digits separated by single spaces
+// e.g. "AL-ZAWAHIRI, Aymán" -> "al zawahiri ayman"
+func Normalize(name string) string {
+	var builder strings.Builder
+	space := true
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(unicode.ToLower(r))
+			space = false
+		case !space:
+			builder.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// Sort the tokens of a normalized name, so that the order of the names doesn't matter
+// e.g. "al zawahiri ayman" -> "al ayman zawahiri"
+func sortTokens(name string) string {
+	tokens := strings.Fields(name)
+	slices.Sort(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// Similarity of two normalized names between 0 and 1, the best of comparing them as they are and with sorted tokens
+func Similarity(a string, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	return max(JaroWinkler(a, b), JaroWinkler(sortTokens(a), sortTokens(b)))
+}
+
+// Jaro-Winkler similarity of two strings between 0 and 1
+func JaroWinkler(a string, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+	// Characters match if they're equal and not farther than the window
+	window := max(len(s1), len(s2))/2 - 1
+	window = max(window, 0)
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		for j := max(0, i-window); j < min(len(s2), i+window+1); j++ {
+			if matched2[j] || s1[i] != s2[j] {
+				continue
+			}
+			matched1[i], matched2[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	// Count the transpositions
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+	// Boost the common prefix, up to 4 characters
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
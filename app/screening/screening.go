@@ -0,0 +1,223 @@
+package screening
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+	"wallet-app-server/app/config"
+)
+
+// The list files to load, an empty path is skipped
+type ListFiles struct {
+	OFACSDN        string
+	OFACAlt        string
+	EUConsolidated string
+}
+
+// A name matching a list entry
+type Match struct {
+	Source      string  `json:"source"`
+	EntryID     string  `json:"entry_id"`
+	EntryName   string  `json:"entry_name"`
+	MatchedName string  `json:"matched_name"`
+	SubjectType string  `json:"subject_type"`
+	Programs    string  `json:"programs,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// The minimum similarity of a hit by the subject type of the list entry
+type Thresholds struct {
+	Person float64
+	Entity float64
+}
+
+// A loaded version of the sanctions lists
+// A list set is never modified after it's loaded, a reload creates a new list set
+type ListSet struct {
+	Version  string
+	LoadTime time.Time
+	Entries  []Entry
+	names    []indexedName
+}
+
+type indexedName struct {
+	entry int
+	name  string
+	value string
+}
+
+// Load the list files into a new list set
+// The version is the hash of the file contents, so the same files always have the same version
+func LoadListSet(files ListFiles, loadTime time.Time) (*ListSet, error) {
+	hash := sha256.New()
+	var entries []Entry
+	readFile := func(path string) ([]byte, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		hash.Write([]byte(path))
+		hash.Write(content)
+		return content, nil
+	}
+	if files.OFACSDN != "" {
+		sdn, err := readFile(files.OFACSDN)
+		if err != nil {
+			return nil, err
+		}
+		// alt.csv is optional
+		var alt io.Reader
+		if files.OFACAlt != "" {
+			content, err := readFile(files.OFACAlt)
+			if err != nil {
+				return nil, err
+			}
+			alt = bytes.NewReader(content)
+		}
+		ofacEntries, err := ParseOFACSDN(bytes.NewReader(sdn), alt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ofacEntries...)
+	}
+	if files.EUConsolidated != "" {
+		content, err := readFile(files.EUConsolidated)
+		if err != nil {
+			return nil, err
+		}
+		euEntries, err := ParseEUConsolidated(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, euEntries...)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no sanctions list configured")
+	}
+	return NewListSet(hex.EncodeToString(hash.Sum(nil))[:16], loadTime, entries), nil
+}
+
+// Create a list set of the entries, with the normalized names indexed for matching
+func NewListSet(version string, loadTime time.Time, entries []Entry) *ListSet {
+	listSet := &ListSet{Version: version, LoadTime: loadTime, Entries: entries}
+	for i, entry := range entries {
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if normalized := Normalize(name); normalized != "" {
+				listSet.names = append(listSet.names, indexedName{entry: i, name: name, value: normalized})
+			}
+		}
+	}
+	return listSet
+}
+
+// Screen the name against the list entries
+// Return the best matching name of every entry reaching the threshold of its subject type, the best match first
+func (ls *ListSet) Screen(name string, thresholds Thresholds) []Match {
+	normalized := Normalize(name)
+	best := map[int]Match{}
+	for _, indexed := range ls.names {
+		entry := ls.Entries[indexed.entry]
+		threshold := thresholds.Entity
+		if entry.SubjectType == SubjectTypePerson {
+			threshold = thresholds.Person
+		}
+		score := Similarity(normalized, indexed.value)
+		if score < threshold || score <= best[indexed.entry].Score {
+			continue
+		}
+		best[indexed.entry] = Match{
+			Source:      entry.Source,
+			EntryID:     entry.EntryID,
+			EntryName:   entry.Name,
+			MatchedName: indexed.name,
+			SubjectType: entry.SubjectType,
+			Programs:    entry.Programs,
+			Score:       score,
+		}
+	}
+	result := make([]Match, 0, len(best))
+	for _, match := range best {
+		result = append(result, match)
+	}
+	slices.SortFunc(result, func(a, b Match) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Source+a.EntryID, b.Source+b.EntryID)
+	})
+	return result
+}
+
+// Screener holding the current list set
+// A reload loads the new list set completely before replacing the current one,
+// so a screening always uses one consistent version of the lists
+type Screener struct {
+	current atomic.Pointer[ListSet]
+}
+
+// Screener instance
+// By default there is no list, and every name passes
+var DefaultScreener = &Screener{}
+
+// Init the screener with the list files in the configuration
+// If no list file is configured, every name passes the screening
+func Init() error {
+	files := ConfiguredListFiles()
+	if files == (ListFiles{}) {
+		return nil
+	}
+	if err := ValidateThresholds(ConfiguredThresholds()); err != nil {
+		return err
+	}
+	_, err := DefaultScreener.Reload(files)
+	return err
+}
+
+// Validate the thresholds, they must be greater than 0 and at most 1
+func ValidateThresholds(thresholds Thresholds) error {
+	if thresholds.Person <= 0 || thresholds.Person > 1 {
+		return fmt.Errorf("invalid person threshold %v, must be greater than 0 and at most 1", thresholds.Person)
+	}
+	if thresholds.Entity <= 0 || thresholds.Entity > 1 {
+		return fmt.Errorf("invalid entity threshold %v, must be greater than 0 and at most 1", thresholds.Entity)
+	}
+	return nil
+}
+
+// The list files in the configuration
+func ConfiguredListFiles() ListFiles {
+	return ListFiles{
+		OFACSDN:        config.Cfg.Screening.OFACSDNFile,
+		OFACAlt:        config.Cfg.Screening.OFACAltFile,
+		EUConsolidated: config.Cfg.Screening.EUConsolidatedFile,
+	}
+}
+
+// The thresholds in the configuration
+func ConfiguredThresholds() Thresholds {
+	return Thresholds{Person: config.Cfg.Screening.PersonThreshold, Entity: config.Cfg.Screening.EntityThreshold}
+}
+
+// Load the list files and replace the current list set
+// If the files can't be loaded, the current list set is kept
+func (s *Screener) Reload(files ListFiles) (*ListSet, error) {
+	listSet, err := LoadListSet(files, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.current.Store(listSet)
+	return listSet, nil
+}
+
+// The current list set, nil if no list has been loaded
+func (s *Screener) Current() *ListSet {
+	return s.current.Load()
+}
@@ -0,0 +1,132 @@
+package screening
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var testThresholds = Thresholds{Person: 0.9, Entity: 0.93}
+
+var testFiles = ListFiles{
+	OFACSDN:        "testdata/sdn.csv",
+	OFACAlt:        "testdata/alt.csv",
+	EUConsolidated: "testdata/eu.xml",
+}
+
+func TestParseOFACSDN(t *testing.T) {
+	sdn, err := os.Open("testdata/sdn.csv")
+	assert.Equal(t, nil, err)
+	defer sdn.Close()
+	alt, err := os.Open("testdata/alt.csv")
+	assert.Equal(t, nil, err)
+	defer alt.Close()
+	entries, err := ParseOFACSDN(sdn, alt)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "AEROCARIBBEAN AIRLINES", entries[0].Name)
+	assert.Equal(t, SubjectTypeEntity, entries[0].SubjectType)
+	assert.Equal(t, []string{"AERO-CARIBBEAN"}, entries[0].Aliases)
+	assert.Equal(t, "2674", entries[2].EntryID)
+	assert.Equal(t, SubjectTypePerson, entries[2].SubjectType)
+	assert.Equal(t, "SDGT", entries[2].Programs)
+	assert.Equal(t, 2, len(entries[2].Aliases))
+}
+
+func TestParseEUConsolidated(t *testing.T) {
+	file, err := os.Open("testdata/eu.xml")
+	assert.Equal(t, nil, err)
+	defer file.Close()
+	entries, err := ParseEUConsolidated(file)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "13", entries[0].EntryID)
+	assert.Equal(t, "Saddam Hussein Al-Tikriti", entries[0].Name)
+	assert.Equal(t, []string{"Abu Ali"}, entries[0].Aliases)
+	assert.Equal(t, SubjectTypePerson, entries[0].SubjectType)
+	assert.Equal(t, "IRQ", entries[0].Programs)
+	assert.Equal(t, SubjectTypeEntity, entries[1].SubjectType)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "al zawahiri ayman", Normalize("AL-ZAWAHIRI, Aymán"))
+	assert.Equal(t, "muller hans jurgen", Normalize("  MÜLLER,  Hans Jürgen "))
+	assert.Equal(t, "", Normalize("--"))
+}
+
+func TestJaroWinkler(t *testing.T) {
+	assert.Equal(t, 1.0, JaroWinkler("martha", "martha"))
+	assert.Equal(t, "0.961", formatScore(JaroWinkler("martha", "marhta")))
+	assert.Equal(t, "0.840", formatScore(JaroWinkler("dwayne", "duane")))
+	assert.Equal(t, 0.0, JaroWinkler("abc", "xyz"))
+	assert.Equal(t, 0.0, JaroWinkler("", "xyz"))
+}
+
+func TestScreen(t *testing.T) {
+	listSet, err := LoadListSet(testFiles, time.Now())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 16, len(listSet.Version))
+
+	// Name order, case and punctuation don't matter
+	matches := listSet.Screen("Ayman al-Zawahiri", testThresholds)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "2674", matches[0].EntryID)
+	assert.Equal(t, 1.0, matches[0].Score)
+
+	// Diacritics and small spelling differences
+	matches = listSet.Screen("Hans Jurgen Muller", testThresholds)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "MÜLLER, Hans Jürgen", matches[0].EntryName)
+	matches = listSet.Screen("Saddam Husein Al Tikriti", testThresholds)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, SourceEUConsolidated, matches[0].Source)
+
+	// Aliases
+	matches = listSet.Screen("Aero Caribbean", testThresholds)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "AERO-CARIBBEAN", matches[0].MatchedName)
+
+	// No hit
+	assert.Equal(t, 0, len(listSet.Screen("Vence Lin", testThresholds)))
+	assert.Equal(t, 0, len(listSet.Screen("Hans Miller", testThresholds)))
+}
+
+func TestLoadListSetVersion(t *testing.T) {
+	first, err := LoadListSet(testFiles, time.Now())
+	assert.Equal(t, nil, err)
+	second, err := LoadListSet(testFiles, time.Now())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, first.Version, second.Version)
+	ofacOnly, err := LoadListSet(ListFiles{OFACSDN: testFiles.OFACSDN}, time.Now())
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, first.Version, ofacOnly.Version)
+	assert.Equal(t, 4, len(ofacOnly.Entries))
+}
+
+func TestScreenerReload(t *testing.T) {
+	screener := &Screener{}
+	assert.Equal(t, (*ListSet)(nil), screener.Current())
+	listSet, err := screener.Reload(testFiles)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, listSet, screener.Current())
+	// A failed reload keeps the current list set
+	_, err = screener.Reload(ListFiles{OFACSDN: "testdata/missing.csv"})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, listSet, screener.Current())
+	_, err = screener.Reload(ListFiles{})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestValidateThresholds(t *testing.T) {
+	assert.Equal(t, nil, ValidateThresholds(testThresholds))
+	assert.Equal(t, nil, ValidateThresholds(Thresholds{Person: 1, Entity: 1}))
+	assert.NotEqual(t, nil, ValidateThresholds(Thresholds{Person: 0, Entity: 0.9}))
+	assert.NotEqual(t, nil, ValidateThresholds(Thresholds{Person: 0.9, Entity: 1.1}))
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 3, 64)
+}
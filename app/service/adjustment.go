@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"gorm.io/gorm"
+)
+
+// Max number of characters of the justification of an adjustment
+const maxJustificationLength = 300
+
+// Admin audit action of a manual balance adjustment
+const auditActionWalletAdjustment = "wallet_adjustment"
+
+// The reason codes accepted for the manual balance adjustments
+var adjustmentReasonCodes = []string{
+	constant.AdjustmentReasonIncident,
+	constant.AdjustmentReasonDuplicatePosting,
+	constant.AdjustmentReasonMissingPosting,
+	constant.AdjustmentReasonGoodwill,
+	constant.AdjustmentReasonFeeRefund,
+	constant.AdjustmentReasonCorrection,
+}
+
+// Adjustment service interface
+type IAdjustmentService interface {
+	AdjustWallet(ctx context.Context, operatorID string, adjustment model.WalletAdjustment) (model.PendingOperation, int, error)
+}
+
+// Adjustment service instance
+var AdjustmentService IAdjustmentService = &adjustmentServiceImpl{}
+
+// Adjustment service implementation
+type adjustmentServiceImpl struct{}
+
+// Request a credit or debit of a user wallet to fix an incident
+// The adjustment is only posted after a second person approves it, see executeAdjustWalletOperation
+func (as *adjustmentServiceImpl) AdjustWallet(ctx context.Context, operatorID string, adjustment model.WalletAdjustment) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "AdjustmentService.AdjustWallet")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if statusCode, err := validateAdjustment(&adjustment); err != nil {
+		return model.PendingOperation{}, statusCode, err
+	}
+	// Only a user wallet can be adjusted
+	if _, err := repository.WalletRepository.GetWalletOwnerID(conn, adjustment.WalletID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+		}
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	summary := fmt.Sprintf("Balance adjustment %s amount %s to wallet %s, reason %s",
+		adjustment.Direction, adjustment.Amount.StringFixed(2), adjustment.WalletID, adjustment.ReasonCode)
+	return requestOperationForApproval(conn, operatorID, constant.OperationTypeAdjustWallet, adjustment, summary, "")
+}
+
+// Post the approved adjustment against the system adjustment wallet, without limits, fees or KYC checks
+// The customer sees it in the user activities, and the requester and the approver are recorded in the admin audit log
+// Return the transaction ID
+func executeAdjustWalletOperation(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error) {
+	var adjustment model.WalletAdjustment
+	if err := decodeOperationPayload(operation, &adjustment); err != nil {
+		return "", err
+	}
+	// The owner is notified in the user activities, a system wallet has no owner
+	ownerID, err := repository.WalletRepository.GetWalletOwnerID(tx, adjustment.WalletID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+		}
+		return "", err
+	}
+	// Adjust
+	if _, err := repository.WalletRepository.Adjust(tx, adjustment.WalletID, adjustment.Direction, adjustment.Amount); err != nil {
+		return "", err
+	}
+	// Create transaction history
+	fromWalletID, toWalletID := constant.SystemWalletAdjustment, adjustment.WalletID
+	if adjustment.Direction == constant.AdjustmentDirectionDebit {
+		fromWalletID, toWalletID = adjustment.WalletID, constant.SystemWalletAdjustment
+	}
+	txnID, err := repository.TransactionRepository.CreateTransactionHistory(tx, fromWalletID, toWalletID, constant.TxnTypeAdjustment, adjustment.Amount, currTime)
+	if err != nil {
+		return "", err
+	}
+	// Create user activity, the justification is internal and only kept in the admin audit log
+	activityDetail := fmt.Sprintf("Balance adjustment %s amount %s to wallet %s, reason %s",
+		adjustment.Direction, adjustment.Amount.StringFixed(2), adjustment.WalletID, adjustment.ReasonCode)
+	if err := repository.UserRepository.CreateUserActivity(tx, ownerID, constant.UserActTypeAdjustment, activityDetail, adjustment.WalletID, currTime); err != nil {
+		return "", err
+	}
+	// Create admin audit of the requester
+	requester, err := repository.UserRepository.GetUserByID(tx, operation.RequestBy)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", err
+	}
+	auditDetail := fmt.Sprintf("txnID: %s, walletID: %s, ownerID: %s, %s %s, reason: %s, approverID: %s, justification: %s",
+		txnID, adjustment.WalletID, ownerID, adjustment.Direction, adjustment.Amount.StringFixed(2), adjustment.ReasonCode, approverID, adjustment.Justification)
+	if err := recordAdminAudit(tx, model.AdminAudit{
+		OperatorID:   operation.RequestBy,
+		OperatorRole: requester.UserRole,
+		AuditAction:  auditActionWalletAdjustment,
+		AuditDetail:  auditDetail,
+	}, currTime); err != nil {
+		return "", err
+	}
+	logger.InfofContext(tx.Statement.Context, "Wallet adjusted, txnID: %s, walletID: %s, direction: %s, amount: %s, reason: %s, requestBy: %s, approverID: %s",
+		txnID, adjustment.WalletID, adjustment.Direction, adjustment.Amount.StringFixed(2), adjustment.ReasonCode, operation.RequestBy, approverID)
+	return txnID, nil
+}
+
+// Validate the adjustment, the justification is trimmed
+func validateAdjustment(adjustment *model.WalletAdjustment) (int, error) {
+	if adjustment.Direction != constant.AdjustmentDirectionCredit && adjustment.Direction != constant.AdjustmentDirectionDebit {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidDirection, nil)
+	}
+	if !adjustment.Amount.IsPositive() {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+	}
+	if !slices.Contains(adjustmentReasonCodes, adjustment.ReasonCode) {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidReasonCode, nil)
+	}
+	adjustment.Justification = strings.TrimSpace(adjustment.Justification)
+	if adjustment.Justification == "" {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageJustificationRequired, nil)
+	}
+	if utf8.RuneCountInString(adjustment.Justification) > maxJustificationLength {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageJustificationTooLong, nil)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/rbac"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"gorm.io/gorm"
+)
+
+// Max number of the users returned by a search
+const maxUserSearchResults = 50
+
+// Max number of the activities returned for a user
+const maxUserActivities = 200
+
+// Admin service interface
+// The staff operations on any user or wallet, the permissions are checked by the Authorization middleware
+type IAdminService interface {
+	SearchUsers(ctx context.Context, keyword string) ([]model.UserProfile, int, error)
+	ListUserWallets(ctx context.Context, userID string) ([]model.WalletInfo, int, error)
+	ListUserActivities(ctx context.Context, userID string) ([]model.UserActivity, int, error)
+	SetUserRole(ctx context.Context, operatorID string, userID string, userRole string) (model.UserProfile, int, error)
+	GetWallet(ctx context.Context, walletID string) (model.WalletDetail, int, error)
+	ListWalletHistory(ctx context.Context, walletID string) ([]model.TransactionHistory, int, error)
+}
+
+// Admin service instance
+var AdminService IAdminService = &adminServiceImpl{}
+
+// Admin service implementation
+type adminServiceImpl struct{}
+
+// Search users by the user ID, or a part of the user name or the full name
+func (as *adminServiceImpl) SearchUsers(ctx context.Context, keyword string) ([]model.UserProfile, int, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.SearchUsers")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return []model.UserProfile{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageKeywordRequired, nil)
+	}
+	users, err := repository.UserRepository.SearchUsers(conn, keyword, maxUserSearchResults)
+	if err != nil {
+		return []model.UserProfile{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.UserProfile
+	result := make([]model.UserProfile, 0, len(users))
+	for _, user := range users {
+		result = append(result, toUserProfileModel(user))
+	}
+	return result, http.StatusOK, nil
+}
+
+func (as *adminServiceImpl) ListUserWallets(ctx context.Context, userID string) ([]model.WalletInfo, int, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListUserWallets")
+	defer span.End()
+	if statusCode, err := checkUserExists(db.DB.WithContext(ctx), userID); err != nil {
+		return []model.WalletInfo{}, statusCode, err
+	}
+	return WalletService.ListUserWallets(ctx, userID)
+}
+
+// List the user's latest activities, the latest first
+func (as *adminServiceImpl) ListUserActivities(ctx context.Context, userID string) ([]model.UserActivity, int, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListUserActivities")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if statusCode, err := checkUserExists(conn, userID); err != nil {
+		return []model.UserActivity{}, statusCode, err
+	}
+	activities, err := repository.UserRepository.ListUserActivities(conn, userID, maxUserActivities)
+	if err != nil {
+		return []model.UserActivity{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.UserActivity
+	result := make([]model.UserActivity, 0, len(activities))
+	for _, activity := range activities {
+		result = append(result, model.UserActivity{
+			UserActID:     activity.UserActID,
+			UserActType:   activity.UserActType,
+			UserActDetail: activity.UserActDetail,
+			UserWalletID:  activity.UserWalletID.String,
+			UserActTime:   activity.UserActTime,
+		})
+	}
+	return result, http.StatusOK, nil
+}
+
+// Give the user a role, the new role applies at once to the user's sessions
+// Nobody can change their own role, so that a staff user can't raise or lose their own permissions
+func (as *adminServiceImpl) SetUserRole(ctx context.Context, operatorID string, userID string, userRole string) (model.UserProfile, int, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.SetUserRole")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if !rbac.IsRole(userRole) {
+		return model.UserProfile{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidRole, nil)
+	}
+	if userID == operatorID {
+		return model.UserProfile{}, http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageSelfRoleChange, nil)
+	}
+	var previousRole string
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		user, err := repository.UserRepository.LockUser(tx, userID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return newServiceError(ErrTypeInvalidRequestBody, ErrMessageUserNotFound, nil)
+			}
+			return err
+		}
+		previousRole = user.UserRole
+		if err := repository.UserRepository.UpdateUserRole(tx, userID, userRole, currTime); err != nil {
+			return err
+		}
+		activityDetail := fmt.Sprintf("User role changed from %s to %s by %s", previousRole, userRole, operatorID)
+		return repository.UserRepository.CreateUserActivity(tx, userID, constant.UserActTypeRole, activityDetail, "", currTime)
+	}); err != nil {
+		var serviceErr ServiceError
+		if errors.As(err, &serviceErr) {
+			return model.UserProfile{}, http.StatusBadRequest, serviceErr
+		}
+		return model.UserProfile{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.InfofContext(ctx, "User role changed, userID: %s, from: %s, to: %s, operatorID: %s", userID, previousRole, userRole, operatorID)
+	user, err := repository.UserRepository.GetUserByID(conn, userID)
+	if err != nil {
+		return model.UserProfile{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toUserProfileModel(user), http.StatusOK, nil
+}
+
+// Get any wallet with its status, balance and owner
+func (as *adminServiceImpl) GetWallet(ctx context.Context, walletID string) (model.WalletDetail, int, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetWallet")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	wallet, err := repository.WalletRepository.GetWalletByID(conn, walletID)
+	if err != nil {
+		return model.WalletDetail{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if wallet.WalletID == "" {
+		return model.WalletDetail{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	// System wallets have no owner
+	ownerID, err := repository.WalletRepository.GetWalletOwnerID(conn, walletID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return model.WalletDetail{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return model.WalletDetail{
+		WalletID:      wallet.WalletID,
+		WalletName:    wallet.WalletName,
+		WalletType:    wallet.WalletType,
+		WalletStatus:  wallet.WalletStatus,
+		ReferenceCode: wallet.ReferenceCode.String,
+		OwnerID:       ownerID,
+		Balance:       wallet.Balance,
+	}, http.StatusOK, nil
+}
+
+// List the transaction history of any wallet
+func (as *adminServiceImpl) ListWalletHistory(ctx context.Context, walletID string) ([]model.TransactionHistory, int, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListWalletHistory")
+	defer span.End()
+	reader := db.Reader().WithContext(ctx)
+	// The history can be read from the replica
+	wallet, err := repository.WalletRepository.GetWalletByID(reader, walletID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if wallet.WalletID == "" {
+		return nil, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	txnHistoryList, err := repository.TransactionRepository.ListTransactionHistory(reader, walletID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toTransactionHistoryModels(txnHistoryList), http.StatusOK, nil
+}
+
+// Check the user exists, return bad request status code if not
+func checkUserExists(db *gorm.DB, userID string) (int, error) {
+	if _, err := repository.UserRepository.GetUserByID(db, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageUserNotFound, nil)
+		}
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/rbac"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Approval service interface
+type IApprovalService interface {
+	ListPendingOperations(ctx context.Context) ([]model.PendingOperation, int, error)
+	GetOperation(ctx context.Context, operationID string) (model.PendingOperation, int, error)
+	ApproveOperation(ctx context.Context, approverID string, operationID string, note string) (model.PendingOperation, int, error)
+	RejectOperation(ctx context.Context, approverID string, operationID string, note string) (model.PendingOperation, int, error)
+	ExpireOperations(ctx context.Context, now time.Time) (int, int, error)
+}
+
+// Approval service instance
+var ApprovalService IApprovalService = &approvalServiceImpl{}
+
+// Approval service implementation
+type approvalServiceImpl struct{}
+
+// Execute the approved operation inside the approval transaction
+// Return the reference of the result if any, e.g. the transaction ID
+type operationExecutor func(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error)
+
+// The executors of the operation types, each one decodes the payload it's requested with
+var operationExecutors = map[string]operationExecutor{
+	constant.OperationTypeTransfer:        executeTransferOperation,
+	constant.OperationTypeSetUserLimit:    executeSetUserLimitOperation,
+	constant.OperationTypeRemoveUserLimit: executeRemoveUserLimitOperation,
+	constant.OperationTypeUnfreezeWallet:  executeUnfreezeWalletOperation,
+	constant.OperationTypeAdjustWallet:    executeAdjustWalletOperation,
+}
+
+// Close the operation which is never executed, since it's rejected or expired
+// The outcome is the risk decision outcome of the closing, e.g. rejected
+type operationCloser func(tx *gorm.DB, operation entity.PendingOperation, outcome string, currTime time.Time) error
+
+// The closers of the operation types which have something to undo when they're never executed, e.g. release a hold
+var operationClosers = map[string]operationCloser{
+	constant.OperationTypeTransfer: closeTransferOperation,
+}
+
+// Max length of the request and review notes, and of the note in the audit trail
+const maxAuditNoteLength = 255
+
+func (as *approvalServiceImpl) ListPendingOperations(ctx context.Context) ([]model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "ApprovalService.ListPendingOperations")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	operations, err := repository.ApprovalRepository.ListOperationsByStatus(conn, constant.OperationStatusPending)
+	if err != nil {
+		return []model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.PendingOperation
+	result := make([]model.PendingOperation, 0, len(operations))
+	for _, operation := range operations {
+		result = append(result, toPendingOperationModel(operation, nil))
+	}
+	return result, http.StatusOK, nil
+}
+
+// Get the pending operation with its audit trail
+func (as *approvalServiceImpl) GetOperation(ctx context.Context, operationID string) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "ApprovalService.GetOperation")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	operation, err := repository.ApprovalRepository.GetOperation(conn, operationID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageOperationNotFound, nil)
+		}
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	audits, err := repository.ApprovalRepository.ListAudits(conn, operationID)
+	if err != nil {
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toPendingOperationModel(operation, audits), http.StatusOK, nil
+}
+
+// Approve the pending operation, and execute it in the same transaction
+// If the execution fails (e.g. insufficient balance), the operation stays pending and the failure is audited
+func (as *approvalServiceImpl) ApproveOperation(ctx context.Context, approverID string, operationID string, note string) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "ApprovalService.ApproveOperation")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if statusCode, err := validateOperationNote(note); err != nil {
+		return model.PendingOperation{}, statusCode, err
+	}
+	executed := false
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Lock the operation, so that it can't be reviewed twice concurrently
+		operation, err := lockPendingOperation(tx, approverID, operationID, currTime)
+		if err != nil {
+			return err
+		}
+		execute, found := operationExecutors[operation.OperationType]
+		if !found {
+			return fmt.Errorf("unknown type of pending operation: %s", operation.OperationType)
+		}
+		executed = true
+		resultRef, err := execute(tx, operation, approverID, currTime)
+		if err != nil {
+			return err
+		}
+		if err := repository.ApprovalRepository.UpdateOperation(tx, operationID, constant.OperationStatusApproved, approverID, note, resultRef, currTime); err != nil {
+			return err
+		}
+		return createOperationAudit(tx, operationID, constant.OperationActionApproved, approverID, note, currTime)
+	}); err != nil {
+		if executed {
+			auditNote := err.Error()
+			var serviceErr ServiceError
+			if errors.As(err, &serviceErr) {
+				auditNote = serviceErr.ErrMessage
+			}
+			if auditErr := createOperationAudit(conn, operationID, constant.OperationActionFailed, approverID, auditNote, time.Now()); auditErr != nil {
+				logger.ErrorfContext(ctx, "Failed to audit the failed approval, operationID: %s, err: %s", operationID, auditErr.Error())
+			}
+			logger.WarnfContext(ctx, "Approved operation failed, operationID: %s, approverID: %s, err: %s", operationID, approverID, err.Error())
+		}
+		statusCode, serviceErr := mapApprovalError(err)
+		return model.PendingOperation{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Operation approved, operationID: %s, approverID: %s", operationID, approverID)
+	return as.GetOperation(ctx, operationID)
+}
+
+// Reject the pending operation, it's never executed
+func (as *approvalServiceImpl) RejectOperation(ctx context.Context, approverID string, operationID string, note string) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "ApprovalService.RejectOperation")
+	defer span.End()
+	if statusCode, err := validateOperationNote(note); err != nil {
+		return model.PendingOperation{}, statusCode, err
+	}
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Lock the operation, so that it can't be reviewed twice concurrently
+		operation, err := lockPendingOperation(tx, approverID, operationID, currTime)
+		if err != nil {
+			return err
+		}
+		if err := closeOperation(tx, operation, constant.RiskDecisionOutcomeRejected, currTime); err != nil {
+			return err
+		}
+		if err := repository.ApprovalRepository.UpdateOperation(tx, operationID, constant.OperationStatusRejected, approverID, note, "", currTime); err != nil {
+			return err
+		}
+		return createOperationAudit(tx, operationID, constant.OperationActionRejected, approverID, note, currTime)
+	}); err != nil {
+		statusCode, serviceErr := mapApprovalError(err)
+		return model.PendingOperation{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Operation rejected, operationID: %s, approverID: %s", operationID, approverID)
+	return as.GetOperation(ctx, operationID)
+}
+
+// Expire the pending operations which haven't been approved or rejected in time
+// Return the number of the operations expired
+func (as *approvalServiceImpl) ExpireOperations(ctx context.Context, now time.Time) (int, int, error) {
+	ctx, span := tracing.Start(ctx, "ApprovalService.ExpireOperations")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	operationIDs, err := repository.ApprovalRepository.ListExpiredOperationIDs(conn, constant.OperationStatusPending, now)
+	if err != nil {
+		logger.ErrorfContext(ctx, "Failed to list expired operations, err: %s", err.Error())
+		return 0, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	expiredCount := 0
+	for _, operationID := range operationIDs {
+		expired := false
+		if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+			operation, err := repository.ApprovalRepository.LockOperation(tx, operationID)
+			if err != nil {
+				return err
+			}
+			// The operation may have been reviewed since it's listed
+			if operation.OperationStatus != constant.OperationStatusPending {
+				return nil
+			}
+			if err := closeOperation(tx, operation, constant.RiskDecisionOutcomeExpired, now); err != nil {
+				return err
+			}
+			if err := repository.ApprovalRepository.UpdateOperation(tx, operationID, constant.OperationStatusExpired, "", "", "", now); err != nil {
+				return err
+			}
+			expired = true
+			return createOperationAudit(tx, operationID, constant.OperationActionExpired, constant.ActorSystem, "", now)
+		}); err != nil {
+			logger.ErrorfContext(ctx, "Failed to expire operation, operationID: %s, err: %s", operationID, err.Error())
+			continue
+		}
+		if expired {
+			expiredCount++
+		}
+	}
+	if expiredCount > 0 {
+		logger.InfofContext(ctx, "Pending operations expired, count: %d", expiredCount)
+	}
+	return expiredCount, http.StatusOK, nil
+}
+
+// Store the operation to be executed after a second person approves it, and audit the request
+// The payload is stored as JSON, and decoded by the executor of the operation type
+func requestOperation(db *gorm.DB, requestBy string, operationType string, payload any, summary string, note string) (entity.PendingOperation, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return entity.PendingOperation{}, err
+	}
+	currTime := time.Now()
+	operation := entity.PendingOperation{
+		OperationID:     uuid.New().String(),
+		OperationType:   operationType,
+		Payload:         string(payloadJSON),
+		Summary:         summary,
+		OperationStatus: constant.OperationStatusPending,
+		RequestBy:       requestBy,
+		RequestNote:     sql.NullString{String: note, Valid: note != ""},
+		ExpireTime:      currTime.Add(time.Duration(config.Cfg.Approval.ExpireTimeInSecs) * time.Second),
+		CreateTime:      currTime,
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := repository.ApprovalRepository.CreateOperation(tx, operation); err != nil {
+			return err
+		}
+		return createOperationAudit(tx, operation.OperationID, constant.OperationActionRequested, requestBy, note, currTime)
+	}); err != nil {
+		return entity.PendingOperation{}, err
+	}
+	logger.InfofContext(db.Statement.Context, "Operation requested for approval, operationID: %s, type: %s, requestBy: %s", operation.OperationID, operationType, requestBy)
+	return operation, nil
+}
+
+// Request the operation for approval, and return the pending operation
+func requestOperationForApproval(db *gorm.DB, requestBy string, operationType string, payload any, summary string, note string) (model.PendingOperation, int, error) {
+	if statusCode, err := validateOperationNote(note); err != nil {
+		return model.PendingOperation{}, statusCode, err
+	}
+	operation, err := requestOperation(db, requestBy, operationType, payload, summary, note)
+	if err != nil {
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toPendingOperationModel(operation, nil), http.StatusOK, nil
+}
+
+// Lock an operation which is waiting for approval, and check that the user can review it
+// The reviewer's current role must have the approval permission, and the reviewer can't be the one who requested the operation
+func lockPendingOperation(tx *gorm.DB, approverID string, operationID string, currTime time.Time) (entity.PendingOperation, error) {
+	operation, err := repository.ApprovalRepository.LockOperation(tx, operationID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.PendingOperation{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageOperationNotFound, nil)
+		}
+		return entity.PendingOperation{}, err
+	}
+	if operation.OperationStatus != constant.OperationStatusPending {
+		return entity.PendingOperation{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageOperationClosed, nil)
+	}
+	if !currTime.Before(operation.ExpireTime) {
+		return entity.PendingOperation{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageOperationExpired, nil)
+	}
+	if operation.RequestBy == approverID {
+		return entity.PendingOperation{}, newServiceError(ErrTypePermissionDenied, ErrMessageSelfReview, nil)
+	}
+	approver, err := repository.UserRepository.GetUserByID(tx, approverID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return entity.PendingOperation{}, err
+	}
+	if !rbac.HasPermission(approver.UserRole, rbac.PermissionApprovalReview) {
+		return entity.PendingOperation{}, newServiceError(ErrTypePermissionDenied, ErrMessageApproverOnly, nil)
+	}
+	return operation, nil
+}
+
+// Undo what the operation holds if it has a closer, when it's rejected or expired
+func closeOperation(tx *gorm.DB, operation entity.PendingOperation, outcome string, currTime time.Time) error {
+	closer, found := operationClosers[operation.OperationType]
+	if !found {
+		return nil
+	}
+	return closer(tx, operation, outcome, currTime)
+}
+
+// Check the request or review note fits in the operation
+func validateOperationNote(note string) (int, error) {
+	if utf8.RuneCountInString(note) > maxAuditNoteLength {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNoteTooLong, nil)
+	}
+	return http.StatusOK, nil
+}
+
+// Create an entry of the audit trail of the operation
+func createOperationAudit(db *gorm.DB, operationID string, auditAction string, actorID string, note string, currTime time.Time) error {
+	if len(note) > maxAuditNoteLength {
+		note = strings.ToValidUTF8(note[:maxAuditNoteLength], "")
+	}
+	return repository.ApprovalRepository.CreateAudit(db, entity.PendingOperationAudit{
+		AuditID:     uuid.New().String(),
+		OperationID: operationID,
+		AuditAction: auditAction,
+		ActorID:     actorID,
+		AuditNote:   sql.NullString{String: note, Valid: note != ""},
+		CreateTime:  currTime,
+	})
+}
+
+// Decode the payload of the operation
+func decodeOperationPayload(operation entity.PendingOperation, payload any) error {
+	if err := json.Unmarshal([]byte(operation.Payload), payload); err != nil {
+		return fmt.Errorf("invalid payload of pending operation %s: %w", operation.OperationID, err)
+	}
+	return nil
+}
+
+// Map the error of an approval to the status code and the service error
+// The errors of the executed operation are mapped as the errors of a transfer
+func mapApprovalError(err error) (int, error) {
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		if serviceErr.ErrType == ErrTypePermissionDenied {
+			return http.StatusForbidden, serviceErr
+		}
+		return http.StatusBadRequest, serviceErr
+	}
+	return mapTransferError(err)
+}
+
+func toPendingOperationModel(operation entity.PendingOperation, audits []entity.PendingOperationAudit) model.PendingOperation {
+	result := model.PendingOperation{
+		OperationID:     operation.OperationID,
+		OperationType:   operation.OperationType,
+		Payload:         json.RawMessage(operation.Payload),
+		Summary:         operation.Summary,
+		OperationStatus: operation.OperationStatus,
+		RequestBy:       operation.RequestBy,
+		RequestNote:     operation.RequestNote.String,
+		ReviewBy:        operation.ReviewBy.String,
+		ReviewNote:      operation.ReviewNote.String,
+		ResultRef:       operation.ResultRef.String,
+		ExpireTime:      operation.ExpireTime,
+		CreateTime:      operation.CreateTime,
+	}
+	for _, audit := range audits {
+		result.AuditTrail = append(result.AuditTrail, model.PendingOperationAudit{
+			AuditAction: audit.AuditAction,
+			ActorID:     audit.ActorID,
+			AuditNote:   audit.AuditNote.String,
+			CreateTime:  audit.CreateTime,
+		})
+	}
+	return result
+}
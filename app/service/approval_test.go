@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/repository"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Operation type executed by a test executor
+const testOperationType = "test"
+
+// Approval repository keeping the operations and the audit trail in memory
+type fakeApprovalRepository struct {
+	repository.IApprovalRepository
+	operations map[string]entity.PendingOperation
+	audits     []entity.PendingOperationAudit
+}
+
+func (r *fakeApprovalRepository) GetOperation(db *gorm.DB, operationID string) (entity.PendingOperation, error) {
+	operation, found := r.operations[operationID]
+	if !found {
+		return entity.PendingOperation{}, gorm.ErrRecordNotFound
+	}
+	return operation, nil
+}
+
+func (r *fakeApprovalRepository) LockOperation(db *gorm.DB, operationID string) (entity.PendingOperation, error) {
+	return r.GetOperation(db, operationID)
+}
+
+func (r *fakeApprovalRepository) ListExpiredOperationIDs(db *gorm.DB, operationStatus string, currTime time.Time) ([]string, error) {
+	var operationIDs []string
+	for _, operation := range r.operations {
+		if operation.OperationStatus == operationStatus && !currTime.Before(operation.ExpireTime) {
+			operationIDs = append(operationIDs, operation.OperationID)
+		}
+	}
+	return operationIDs, nil
+}
+
+func (r *fakeApprovalRepository) UpdateOperation(db *gorm.DB, operationID string, operationStatus string, reviewBy string, reviewNote string, resultRef string, updateTime time.Time) error {
+	operation := r.operations[operationID]
+	operation.OperationStatus = operationStatus
+	operation.ReviewBy = sql.NullString{String: reviewBy, Valid: reviewBy != ""}
+	operation.ReviewNote = sql.NullString{String: reviewNote, Valid: reviewNote != ""}
+	operation.ResultRef = sql.NullString{String: resultRef, Valid: resultRef != ""}
+	r.operations[operationID] = operation
+	return nil
+}
+
+func (r *fakeApprovalRepository) CreateAudit(db *gorm.DB, audit entity.PendingOperationAudit) error {
+	r.audits = append(r.audits, audit)
+	return nil
+}
+
+func (r *fakeApprovalRepository) ListAudits(db *gorm.DB, operationID string) ([]entity.PendingOperationAudit, error) {
+	var audits []entity.PendingOperationAudit
+	for _, audit := range r.audits {
+		if audit.OperationID == operationID {
+			audits = append(audits, audit)
+		}
+	}
+	return audits, nil
+}
+
+// The actions of the audit trail of the operation
+func (r *fakeApprovalRepository) auditActions(operationID string) []string {
+	var actions []string
+	for _, audit := range r.audits {
+		if audit.OperationID == operationID {
+			actions = append(actions, audit.AuditAction)
+		}
+	}
+	return actions
+}
+
+// User repository returning the users with their roles
+type fakeApprovalUserRepository struct {
+	repository.IUserRepository
+	roles map[string]string
+}
+
+func (r *fakeApprovalUserRepository) GetUserByID(db *gorm.DB, userID string) (entity.User, error) {
+	role, found := r.roles[userID]
+	if !found {
+		return entity.User{}, gorm.ErrRecordNotFound
+	}
+	return entity.User{UserID: userID, UserRole: role}, nil
+}
+
+func (r *fakeApprovalUserRepository) CreateUserActivity(db *gorm.DB, userID string, userActType string, userActDetail string, userWalletID string, userActTime time.Time) error {
+	return nil
+}
+
+// Wallet repository recording the released holds and the adjustments
+type fakeHoldWalletRepository struct {
+	repository.IWalletRepository
+	released []decimal.Decimal
+	adjusted []decimal.Decimal
+}
+
+func (r *fakeHoldWalletRepository) GetWalletOwnerID(db *gorm.DB, walletID string) (string, error) {
+	return "owner", nil
+}
+
+func (r *fakeHoldWalletRepository) Adjust(db *gorm.DB, walletID string, direction string, amount decimal.Decimal) (decimal.Decimal, error) {
+	r.adjusted = append(r.adjusted, amount)
+	return amount, nil
+}
+
+func (r *fakeHoldWalletRepository) ReleaseHold(db *gorm.DB, walletID string, amount decimal.Decimal) (decimal.Decimal, error) {
+	r.released = append(r.released, amount)
+	return amount, nil
+}
+
+// Transaction repository recording the linked transaction types
+type fakeHoldTransactionRepository struct {
+	repository.ITransactionRepository
+	txnTypes []string
+}
+
+func (r *fakeHoldTransactionRepository) CreateTransactionHistory(db *gorm.DB, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error) {
+	r.txnTypes = append(r.txnTypes, txnType)
+	return "txn-" + txnType, nil
+}
+
+func (r *fakeHoldTransactionRepository) CreateLinkedTransactionHistory(db *gorm.DB, parentTxnID string, fromWalletID string, toWalletID string, txnType string, txnAmount decimal.Decimal, txnTime time.Time) (string, error) {
+	r.txnTypes = append(r.txnTypes, txnType)
+	return "release-" + parentTxnID, nil
+}
+
+// Audit repository keeping the admin audits in memory
+type fakeAdminAuditRepository struct {
+	repository.IAuditRepository
+	audits []entity.AdminAuditLog
+}
+
+func (r *fakeAdminAuditRepository) CreateAdminAudit(db *gorm.DB, audit entity.AdminAuditLog) error {
+	r.audits = append(r.audits, audit)
+	return nil
+}
+
+// Risk repository recording the decision outcomes
+type fakeOutcomeRiskRepository struct {
+	repository.IRiskRepository
+	outcomes map[string]string
+}
+
+func (r *fakeOutcomeRiskRepository) UpdateDecisionOutcome(db *gorm.DB, decisionID string, outcome string) error {
+	r.outcomes[decisionID] = outcome
+	return nil
+}
+
+type fakeApproval struct {
+	approvals    *fakeApprovalRepository
+	wallets      *fakeHoldWalletRepository
+	transactions *fakeHoldTransactionRepository
+	risks        *fakeOutcomeRiskRepository
+	// The number of the test operations executed, and the error they fail with
+	executed   int
+	executeErr error
+}
+
+// Replace the repositories with the fakes, and register the executor of the test operations
+// The operation "test-1" is requested by the support user "requester", and "reviewer" is an admin
+func fakeApprovals(t *testing.T) *fakeApproval {
+	f := &fakeApproval{
+		approvals: &fakeApprovalRepository{operations: map[string]entity.PendingOperation{
+			"test-1": pendingOperation("test-1", testOperationType, "{}", time.Now().Add(time.Hour)),
+		}},
+		wallets:      &fakeHoldWalletRepository{},
+		transactions: &fakeHoldTransactionRepository{},
+		risks:        &fakeOutcomeRiskRepository{outcomes: map[string]string{}},
+	}
+	users := &fakeApprovalUserRepository{roles: map[string]string{
+		"requester": constant.UserRoleSupport,
+		"reviewer":  constant.UserRoleAdmin,
+		"customer":  constant.UserRoleCustomer,
+	}}
+	originalApprovals, originalUsers := repository.ApprovalRepository, repository.UserRepository
+	originalWallets, originalTransactions, originalRisks := repository.WalletRepository, repository.TransactionRepository, repository.RiskRepository
+	repository.ApprovalRepository, repository.UserRepository = f.approvals, users
+	repository.WalletRepository, repository.TransactionRepository, repository.RiskRepository = f.wallets, f.transactions, f.risks
+	operationExecutors[testOperationType] = func(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error) {
+		f.executed++
+		return "result-ref", f.executeErr
+	}
+	t.Cleanup(func() {
+		repository.ApprovalRepository, repository.UserRepository = originalApprovals, originalUsers
+		repository.WalletRepository, repository.TransactionRepository, repository.RiskRepository = originalWallets, originalTransactions, originalRisks
+		delete(operationExecutors, testOperationType)
+	})
+	return f
+}
+
+func pendingOperation(operationID string, operationType string, payload string, expireTime time.Time) entity.PendingOperation {
+	return entity.PendingOperation{
+		OperationID:     operationID,
+		OperationType:   operationType,
+		Payload:         payload,
+		OperationStatus: constant.OperationStatusPending,
+		RequestBy:       "requester",
+		ExpireTime:      expireTime,
+		CreateTime:      expireTime.Add(-time.Hour),
+	}
+}
+
+func serviceErrMessage(err error) string {
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.ErrMessage
+	}
+	return ""
+}
+
+func TestApproveOperation(t *testing.T) {
+	f := fakeApprovals(t)
+	operation, statusCode, err := ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", "checked")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, f.executed, 1)
+	assert.Equal(t, operation.OperationStatus, constant.OperationStatusApproved)
+	assert.Equal(t, operation.ReviewBy, "reviewer")
+	assert.Equal(t, operation.ResultRef, "result-ref")
+	assert.Equal(t, len(operation.AuditTrail), 1)
+	assert.Equal(t, operation.AuditTrail[0].AuditAction, constant.OperationActionApproved)
+	// The operation is executed once, approving it again is refused
+	_, statusCode, err = ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", "")
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageOperationClosed)
+	assert.Equal(t, f.executed, 1)
+}
+
+func TestReviewOwnOperation(t *testing.T) {
+	f := fakeApprovals(t)
+	// Even an admin can't review the operation they requested
+	operation := pendingOperation("test-1", testOperationType, "{}", time.Now().Add(time.Hour))
+	operation.RequestBy = "reviewer"
+	f.approvals.operations["test-1"] = operation
+	_, statusCode, err := ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", "")
+	assert.Equal(t, statusCode, http.StatusForbidden)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageSelfReview)
+	_, statusCode, err = ApprovalService.RejectOperation(context.Background(), "reviewer", "test-1", "no")
+	assert.Equal(t, statusCode, http.StatusForbidden)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageSelfReview)
+	// A user without the approval permission can't review it either
+	_, statusCode, err = ApprovalService.ApproveOperation(context.Background(), "customer", "test-1", "")
+	assert.Equal(t, statusCode, http.StatusForbidden)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageApproverOnly)
+	assert.Equal(t, f.executed, 0)
+	assert.Equal(t, f.approvals.operations["test-1"].OperationStatus, constant.OperationStatusPending)
+}
+
+func TestApproveFailedOperation(t *testing.T) {
+	f := fakeApprovals(t)
+	f.executeErr = errors.New(repository.ErrInsufficientBalance)
+	_, statusCode, err := ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", "")
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageInsufficientBalance)
+	// The operation stays pending, and the failure is audited
+	assert.Equal(t, f.approvals.operations["test-1"].OperationStatus, constant.OperationStatusPending)
+	assert.Equal(t, f.approvals.auditActions("test-1"), []string{constant.OperationActionFailed})
+	assert.Equal(t, f.approvals.audits[0].ActorID, "reviewer")
+	assert.Equal(t, f.approvals.audits[0].AuditNote.String, repository.ErrInsufficientBalance)
+	// It can be approved once the problem is solved
+	f.executeErr = nil
+	_, statusCode, _ = ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", "")
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, f.executed, 2)
+}
+
+func TestExpireOperations(t *testing.T) {
+	f := fakeApprovals(t)
+	now := time.Now()
+	f.approvals.operations["transfer-1"] = pendingOperation("transfer-1", constant.OperationTypeTransfer,
+		`{"user_id":"customer","from_wallet_id":"wallet-1","to_wallet_id":"wallet-2","amount":"20000","decision_id":"decision-1","hold_txn_id":"hold-1"}`, now)
+	count, statusCode, err := ApprovalService.ExpireOperations(context.Background(), now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, count, 1)
+	assert.Equal(t, f.approvals.operations["transfer-1"].OperationStatus, constant.OperationStatusExpired)
+	assert.Equal(t, f.approvals.auditActions("transfer-1"), []string{constant.OperationActionExpired})
+	assert.Equal(t, f.approvals.audits[0].ActorID, constant.ActorSystem)
+	// The held amount is released, and the risk decision is resolved
+	assert.Equal(t, len(f.wallets.released), 1)
+	assert.Equal(t, f.wallets.released[0].String(), "20000")
+	assert.Equal(t, f.transactions.txnTypes, []string{constant.TxnTypeHoldRelease})
+	assert.Equal(t, f.risks.outcomes["decision-1"], constant.RiskDecisionOutcomeExpired)
+	// The operation which hasn't expired yet is left pending
+	assert.Equal(t, f.approvals.operations["test-1"].OperationStatus, constant.OperationStatusPending)
+	// The expired operation can't be approved anymore
+	_, statusCode, err = ApprovalService.ApproveOperation(context.Background(), "reviewer", "transfer-1", "")
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageOperationClosed)
+}
+
+func TestApproveExpiredOperation(t *testing.T) {
+	f := fakeApprovals(t)
+	// Expired, but not closed by the expiry job yet
+	f.approvals.operations["test-1"] = pendingOperation("test-1", testOperationType, "{}", time.Now().Add(-time.Second))
+	_, statusCode, err := ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", "")
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageOperationExpired)
+	assert.Equal(t, f.executed, 0)
+}
+
+func TestRejectTransferOperation(t *testing.T) {
+	f := fakeApprovals(t)
+	f.approvals.operations["transfer-1"] = pendingOperation("transfer-1", constant.OperationTypeTransfer,
+		`{"user_id":"customer","from_wallet_id":"wallet-1","to_wallet_id":"wallet-2","amount":"20000","decision_id":"decision-1","hold_txn_id":"hold-1"}`, time.Now().Add(time.Hour))
+	operation, statusCode, err := ApprovalService.RejectOperation(context.Background(), "reviewer", "transfer-1", "unknown recipient")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, operation.OperationStatus, constant.OperationStatusRejected)
+	assert.Equal(t, f.transactions.txnTypes, []string{constant.TxnTypeHoldRelease})
+	assert.Equal(t, f.risks.outcomes["decision-1"], constant.RiskDecisionOutcomeRejected)
+}
+
+func TestOperationNoteTooLong(t *testing.T) {
+	f := fakeApprovals(t)
+	_, statusCode, err := ApprovalService.RejectOperation(context.Background(), "reviewer", "test-1", strings.Repeat("x", maxAuditNoteLength+1))
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageNoteTooLong)
+	_, statusCode, err = ApprovalService.ApproveOperation(context.Background(), "reviewer", "test-1", strings.Repeat("x", maxAuditNoteLength+1))
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageNoteTooLong)
+	_, statusCode, err = requestOperationForApproval(nil, "requester", testOperationType, struct{}{}, "test", strings.Repeat("x", maxAuditNoteLength+1))
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageNoteTooLong)
+	// The length is counted in characters, as the VARCHAR of the DB
+	_, statusCode, err = ApprovalService.RejectOperation(context.Background(), "reviewer", "test-1", strings.Repeat("é", maxAuditNoteLength))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, f.executed, 0)
+}
+
+// An adjustment is only posted when approved, and the admin audit records the requester with the approver
+func TestApproveAdjustWalletOperation(t *testing.T) {
+	f := fakeApprovals(t)
+	audits := &fakeAdminAuditRepository{}
+	originalAudits := repository.AuditRepository
+	repository.AuditRepository = audits
+	t.Cleanup(func() { repository.AuditRepository = originalAudits })
+	f.approvals.operations["adjust-1"] = pendingOperation("adjust-1", constant.OperationTypeAdjustWallet,
+		`{"wallet_id":"wallet","direction":"credit","amount":"12.5","reason_code":"incident","justification":"missing deposit"}`, time.Now().Add(time.Hour))
+	assert.Equal(t, len(f.wallets.adjusted), 0)
+	operation, statusCode, err := ApprovalService.ApproveOperation(context.Background(), "reviewer", "adjust-1", "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, operation.ResultRef, "txn-"+constant.TxnTypeAdjustment)
+	assert.Equal(t, len(f.wallets.adjusted), 1)
+	assert.Equal(t, f.wallets.adjusted[0].String(), "12.5")
+	assert.Equal(t, len(audits.audits), 1)
+	assert.Equal(t, audits.audits[0].OperatorID, "requester")
+	assert.Equal(t, audits.audits[0].OperatorRole, constant.UserRoleSupport)
+	assert.Equal(t, strings.Contains(audits.audits[0].AuditDetail.String, "approverID: reviewer"), true)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Max length of the detail kept in the admin audit log
+const maxAdminAuditDetailLength = 500
+
+// Audit service interface
+type IAuditService interface {
+	RecordAdminRequest(ctx context.Context, audit model.AdminAudit) (int, error)
+}
+
+// Audit service instance
+var AuditService IAuditService = &auditServiceImpl{}
+
+// Audit service implementation
+type auditServiceImpl struct{}
+
+// Record the request to the admin endpoints in the admin audit log
+// The request has been handled, so a failure is only logged, and the audit is recorded even if the client has gone
+func (as *auditServiceImpl) RecordAdminRequest(ctx context.Context, audit model.AdminAudit) (int, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.RecordAdminRequest")
+	defer span.End()
+	if err := recordAdminAudit(db.Writer(ctx), audit, time.Now()); err != nil {
+		logger.ErrorfContext(ctx, "Failed to record admin audit, operatorID: %s, action: %s, err: %s", audit.OperatorID, audit.AuditAction, err.Error())
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return http.StatusOK, nil
+}
+
+// Create an entry of the admin audit log, e.g. inside the transaction of an admin operation
+func recordAdminAudit(db *gorm.DB, audit model.AdminAudit, currTime time.Time) error {
+	if len(audit.AuditDetail) > maxAdminAuditDetailLength {
+		audit.AuditDetail = strings.ToValidUTF8(audit.AuditDetail[:maxAdminAuditDetailLength], "")
+	}
+	return repository.AuditRepository.CreateAdminAudit(db, entity.AdminAuditLog{
+		AuditID:      uuid.New().String(),
+		OperatorID:   audit.OperatorID,
+		OperatorRole: audit.OperatorRole,
+		AuditAction:  audit.AuditAction,
+		RequestPath:  sql.NullString{String: audit.RequestPath, Valid: audit.RequestPath != ""},
+		StatusCode:   sql.NullInt64{Int64: int64(audit.StatusCode), Valid: audit.StatusCode != 0},
+		ClientIP:     sql.NullString{String: audit.ClientIP, Valid: audit.ClientIP != ""},
+		AuditDetail:  sql.NullString{String: audit.AuditDetail, Valid: audit.AuditDetail != ""},
+		CreateTime:   currTime,
+	})
+}
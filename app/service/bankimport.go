@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-app-server/app/bankimport"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/statement"
+	"wallet-app-server/app/tracing"
+	"wallet-app-server/app/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Bank import service interface
+type IBankImportService interface {
+	ImportStatement(ctx context.Context, operatorID string, fileName string, format string, account string, content []byte) (model.BankImportResult, int, error)
+	ListUnmatchedLines(ctx context.Context) ([]model.BankStatementLine, int, error)
+	AssignLine(ctx context.Context, operatorID string, lineID string, walletID string, note string) (model.BankStatementLine, int, error)
+	IgnoreLine(ctx context.Context, operatorID string, lineID string, note string) (model.BankStatementLine, int, error)
+}
+
+// Bank import service instance
+var BankImportService IBankImportService = &bankImportServiceImpl{}
+
+// Bank import service implementation
+type bankImportServiceImpl struct{}
+
+// Import a bank statement file
+// Every credit line is matched to a wallet by the reference code in its remittance information, and deposited to the wallet.
+// The lines which can't be matched are kept in the review queue.
+// Each line is recorded with a unique hash in the same DB transaction as its deposit,
+// so a line which has already been imported (even concurrently) is skipped and never credited twice
+// The account is the one of the statement if the file doesn't give it
+func (bis *bankImportServiceImpl) ImportStatement(ctx context.Context, operatorID string, fileName string, format string, account string, content []byte) (model.BankImportResult, int, error) {
+	ctx, span := tracing.Start(ctx, "BankImportService.ImportStatement")
+	defer span.End()
+	// Parse the statement
+	entries, err := bankimport.Parse(format, bytes.NewReader(content))
+	if err != nil {
+		if err.Error() == bankimport.ErrUnsupportedFormat {
+			return model.BankImportResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidFormat, nil)
+		}
+		return model.BankImportResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidBankStatement, err)
+	}
+	if err := bankimport.SetAccount(entries, account); err != nil {
+		if err.Error() == bankimport.ErrMissingAccount {
+			return model.BankImportResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageBankAccountRequired, nil)
+		}
+		return model.BankImportResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidBankStatement, err)
+	}
+	lineKeys := bankimport.LineKeysOf(entries)
+	fileHash := sha256.Sum256(content)
+
+	result := model.BankImportResult{ImportID: uuid.New().String()}
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Create import record
+		bankImport := entity.BankStatementImport{
+			ImportID:   result.ImportID,
+			FileName:   fileName,
+			FileFormat: format,
+			FileHash:   hex.EncodeToString(fileHash[:]),
+			ImportedBy: operatorID,
+			ImportTime: currTime,
+		}
+		if err := repository.BankImportRepository.CreateImport(tx, bankImport); err != nil {
+			return err
+		}
+		for i, entry := range entries {
+			// Only incoming credits are deposits
+			if !entry.Credit {
+				result.SkippedCount++
+				continue
+			}
+			// A line which can't be recorded is skipped, so it can't fail the whole import
+			if err := bankimport.Validate(entry); err != nil {
+				logger.WarnfContext(ctx, "Bank statement line skipped, importID: %s, bankRef: %s, err: %s", result.ImportID, entry.BankRef, err.Error())
+				result.SkippedCount++
+				continue
+			}
+			result.LineCount++
+			// A line recorded under another line hash is a duplicate too, e.g. a line imported in a format without bank reference
+			// The lines of the same content are checked one at a time, so that the same line imported concurrently is found
+			if err := repository.BankImportRepository.LockLineContent(tx, lineKeys[i].ContentHash); err != nil {
+				return err
+			}
+			duplicate, err := repository.BankImportRepository.ExistsLineOfContent(tx, lineKeys[i].ContentHash, entry.BankRef, lineKeys[i].LegacyHash)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				result.DuplicateCount++
+				continue
+			}
+			// Match the line to a wallet
+			walletID, err := matchBankStatementEntry(tx, entry)
+			if err != nil {
+				return err
+			}
+			// Record the line, the line hash is unique
+			// The too long text fields are cut to fit, so one line can't fail the whole import
+			recorded, truncated := bankimport.Truncate(entry)
+			if truncated {
+				logger.WarnfContext(ctx, "Bank statement line text cut to fit, importID: %s, bankRef: %s", result.ImportID, recorded.BankRef)
+			}
+			line := entity.BankStatementLine{
+				LineID:      uuid.New().String(),
+				ImportID:    result.ImportID,
+				LineHash:    lineKeys[i].LineHash,
+				Account:     entry.Account,
+				ContentHash: sql.NullString{String: lineKeys[i].ContentHash, Valid: true},
+				BankRef:     recorded.BankRef,
+				BookingTime: entry.BookingTime,
+				Amount:      entry.Amount,
+				Currency:    entry.Currency,
+				Reference:   recorded.Reference,
+				DebtorName:  recorded.DebtorName,
+				LineStatus:  constant.BankLineStatusUnmatched,
+				CreateTime:  currTime,
+			}
+			created, err := repository.BankImportRepository.CreateLine(tx, line)
+			if err != nil {
+				return err
+			}
+			if !created {
+				result.DuplicateCount++
+				continue
+			}
+			if walletID == "" {
+				result.UnmatchedCount++
+				continue
+			}
+			// Deposit to the matched wallet
+			txnID, err := depositBankStatementLine(tx, line, walletID, currTime)
+			if err != nil {
+				// The wallet can't receive the deposit (e.g. frozen), leave the line to the review queue
+				if isDepositBusinessError(err) {
+					logger.WarnfContext(ctx, "Bank statement line can't be deposited, lineID: %s, walletID: %s, err: %s", line.LineID, walletID, err.Error())
+					result.UnmatchedCount++
+					continue
+				}
+				return err
+			}
+			if err := repository.BankImportRepository.UpdateLineReview(tx, line.LineID, constant.BankLineStatusMatched, walletID, txnID, "", "", currTime); err != nil {
+				return err
+			}
+			result.MatchedCount++
+		}
+		// Update the counts of the import record
+		bankImport.LineCount = result.LineCount
+		bankImport.MatchedCount = result.MatchedCount
+		bankImport.UnmatchedCount = result.UnmatchedCount
+		bankImport.DuplicateCount = result.DuplicateCount
+		return repository.BankImportRepository.UpdateImportCounts(tx, bankImport)
+	}); err != nil {
+		return model.BankImportResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.InfofContext(ctx, "Bank statement imported, importID: %s, fileName: %s, line_count=%d matched_count=%d unmatched_count=%d duplicate_count=%d",
+		result.ImportID, fileName, result.LineCount, result.MatchedCount, result.UnmatchedCount, result.DuplicateCount)
+	return result, http.StatusOK, nil
+}
+
+func (bis *bankImportServiceImpl) ListUnmatchedLines(ctx context.Context) ([]model.BankStatementLine, int, error) {
+	ctx, span := tracing.Start(ctx, "BankImportService.ListUnmatchedLines")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	lines, err := repository.BankImportRepository.ListLinesByStatus(conn, constant.BankLineStatusUnmatched)
+	if err != nil {
+		return []model.BankStatementLine{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.BankStatementLine
+	result := make([]model.BankStatementLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, toBankStatementLineModel(line))
+	}
+	return result, http.StatusOK, nil
+}
+
+// Manually assign an unmatched line to a wallet, and deposit the line amount to the wallet
+func (bis *bankImportServiceImpl) AssignLine(ctx context.Context, operatorID string, lineID string, walletID string, note string) (model.BankStatementLine, int, error) {
+	ctx, span := tracing.Start(ctx, "BankImportService.AssignLine")
+	defer span.End()
+	var result entity.BankStatementLine
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Lock the line, so that it can't be reviewed twice concurrently
+		line, err := lockUnmatchedBankStatementLine(tx, lineID)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(line.Currency, statement.Currency()) {
+			return newServiceError(ErrTypeInvalidRequestBody, ErrMessageCurrencyMismatch, nil)
+		}
+		// Deposit to the wallet
+		txnID, err := depositBankStatementLine(tx, line, walletID, currTime)
+		if err != nil {
+			return err
+		}
+		if err := repository.BankImportRepository.UpdateLineReview(tx, lineID, constant.BankLineStatusAssigned, walletID, txnID, operatorID, note, currTime); err != nil {
+			return err
+		}
+		result, err = repository.BankImportRepository.LockLine(tx, lineID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapBankLineReviewError(err)
+		return model.BankStatementLine{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Bank statement line assigned, lineID: %s, walletID: %s, operatorID: %s", lineID, walletID, operatorID)
+	return toBankStatementLineModel(result), http.StatusOK, nil
+}
+
+// Mark an unmatched line as ignored (e.g. the money has been returned to the sender), nothing is deposited
+func (bis *bankImportServiceImpl) IgnoreLine(ctx context.Context, operatorID string, lineID string, note string) (model.BankStatementLine, int, error) {
+	ctx, span := tracing.Start(ctx, "BankImportService.IgnoreLine")
+	defer span.End()
+	var result entity.BankStatementLine
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the line, so that it can't be reviewed twice concurrently
+		if _, err := lockUnmatchedBankStatementLine(tx, lineID); err != nil {
+			return err
+		}
+		if err := repository.BankImportRepository.UpdateLineReview(tx, lineID, constant.BankLineStatusIgnored, "", "", operatorID, note, time.Now()); err != nil {
+			return err
+		}
+		var err error
+		result, err = repository.BankImportRepository.LockLine(tx, lineID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapBankLineReviewError(err)
+		return model.BankStatementLine{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Bank statement line ignored, lineID: %s, operatorID: %s", lineID, operatorID)
+	return toBankStatementLineModel(result), http.StatusOK, nil
+}
+
+// Find the wallet of a bank statement entry by the reference codes in its remittance information
+// Return an empty wallet ID if the entry doesn't match exactly one wallet, or isn't in the wallet currency
+func matchBankStatementEntry(tx *gorm.DB, entry model.BankStatementEntry) (string, error) {
+	if !strings.EqualFold(entry.Currency, statement.Currency()) {
+		return "", nil
+	}
+	walletID := ""
+	for _, referenceCode := range util.FindReferenceCodes(entry.Reference) {
+		wallet, err := repository.WalletRepository.GetWalletByReferenceCode(tx, referenceCode)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				continue
+			}
+			return "", err
+		}
+		// Several different wallets are ambiguous
+		if walletID != "" && walletID != wallet.WalletID {
+			return "", nil
+		}
+		walletID = wallet.WalletID
+	}
+	return walletID, nil
+}
+
+// Deposit the amount of a bank statement line to the wallet on behalf of the wallet owner
+// Return the transaction ID
+func depositBankStatementLine(tx *gorm.DB, line entity.BankStatementLine, walletID string, currTime time.Time) (string, error) {
+	userID, err := repository.WalletRepository.GetWalletOwnerID(tx, walletID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", errors.New(repository.ErrWalletNotFound)
+		}
+		return "", err
+	}
+	activityDetail := fmt.Sprintf("Bank transfer deposit amount %s to wallet %s, bank reference %s", line.Amount.StringFixed(2), walletID, line.BankRef)
+	_, txnID, err := deposit(tx, userID, walletID, line.Amount, activityDetail, currTime)
+	return txnID, err
+}
+
+// Lock a bank statement line which is waiting for review
+func lockUnmatchedBankStatementLine(tx *gorm.DB, lineID string) (entity.BankStatementLine, error) {
+	line, err := repository.BankImportRepository.LockLine(tx, lineID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.BankStatementLine{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageBankLineNotFound, nil)
+		}
+		return entity.BankStatementLine{}, err
+	}
+	if line.LineStatus != constant.BankLineStatusUnmatched {
+		return entity.BankStatementLine{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageBankLineReviewed, nil)
+	}
+	return line, nil
+}
+
+// Check if the deposit error is a business logic related error
+func isDepositBusinessError(err error) bool {
+	switch err.Error() {
+	case repository.ErrNegativeOrZeroAmount, repository.ErrWalletNotFound, repository.ErrWalletFrozen:
+		return true
+	}
+	return false
+}
+
+// Map the error of a line review to the status code and the service error
+func mapBankLineReviewError(err error) (int, error) {
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		return http.StatusBadRequest, serviceErr
+	}
+	return mapWalletError(err)
+}
+
+func toBankStatementLineModel(line entity.BankStatementLine) model.BankStatementLine {
+	return model.BankStatementLine{
+		LineID:      line.LineID,
+		ImportID:    line.ImportID,
+		Account:     line.Account,
+		BankRef:     line.BankRef,
+		BookingTime: line.BookingTime,
+		Amount:      line.Amount,
+		Currency:    line.Currency,
+		Reference:   line.Reference,
+		DebtorName:  line.DebtorName,
+		LineStatus:  line.LineStatus,
+		WalletID:    line.WalletID.String,
+		TxnID:       line.TxnID.String,
+		ReviewBy:    line.ReviewBy.String,
+		ReviewNote:  line.ReviewNote.String,
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/repository"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+// Bank import repository keeping the lines in memory
+type fakeBankImportRepository struct {
+	repository.IBankImportRepository
+	lines []entity.BankStatementLine
+}
+
+func (r *fakeBankImportRepository) CreateImport(db *gorm.DB, bankImport entity.BankStatementImport) error {
+	return nil
+}
+
+func (r *fakeBankImportRepository) UpdateImportCounts(db *gorm.DB, bankImport entity.BankStatementImport) error {
+	return nil
+}
+
+func (r *fakeBankImportRepository) CreateLine(db *gorm.DB, line entity.BankStatementLine) (bool, error) {
+	for _, recorded := range r.lines {
+		if recorded.LineHash == line.LineHash {
+			return false, nil
+		}
+	}
+	r.lines = append(r.lines, line)
+	return true, nil
+}
+
+func (r *fakeBankImportRepository) LockLineContent(tx *gorm.DB, contentHash string) error {
+	return nil
+}
+
+func (r *fakeBankImportRepository) ExistsLineOfContent(db *gorm.DB, contentHash string, bankRef string, legacyHash string) (bool, error) {
+	for _, recorded := range r.lines {
+		if recorded.ContentHash.String == contentHash && (recorded.BankRef == "" || bankRef == "") {
+			return true, nil
+		}
+		if recorded.Account == "" && recorded.LineHash == legacyHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func fakeBankImports(t *testing.T) *fakeBankImportRepository {
+	bankImports := &fakeBankImportRepository{}
+	original := repository.BankImportRepository
+	repository.BankImportRepository = bankImports
+	t.Cleanup(func() { repository.BankImportRepository = original })
+	return bankImports
+}
+
+func TestImportStatementOtherAccount(t *testing.T) {
+	bankImports := fakeBankImports(t)
+	content := []byte("bank_ref,booking_date,amount,currency,reference\nREF1,2025-06-17,10.00,USD,gift\n")
+	result, statusCode, err := BankImportService.ImportStatement(context.Background(), "operator", "a.csv", "csv", "GB33BUKB20201555555555", content)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, result.UnmatchedCount, 1)
+	// The same bank reference in the statement of another account is another line
+	result, _, err = BankImportService.ImportStatement(context.Background(), "operator", "b.csv", "csv", "DE89370400440532013000", content)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.UnmatchedCount, 1)
+	assert.Equal(t, result.DuplicateCount, 0)
+	// The same statement of the first account again is a duplicate
+	result, _, err = BankImportService.ImportStatement(context.Background(), "operator", "a.csv", "csv", "GB33BUKB20201555555555", content)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.DuplicateCount, 1)
+	assert.Equal(t, len(bankImports.lines), 2)
+	// The account is required if the file doesn't give it
+	_, statusCode, err = BankImportService.ImportStatement(context.Background(), "operator", "a.csv", "csv", "", content)
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageBankAccountRequired)
+}
+
+func TestImportStatementWithoutBankRef(t *testing.T) {
+	bankImports := fakeBankImports(t)
+	withRef := []byte("account,bank_ref,booking_date,amount,currency,reference\nGB33BUKB20201555555555,REF1,2025-06-17,10.00,USD,gift\n")
+	withoutRef := []byte("booking_date,amount,currency,reference\n2025-06-17,10.00,USD,gift\n")
+	result, _, err := BankImportService.ImportStatement(context.Background(), "operator", "a.csv", "csv", "", withRef)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.UnmatchedCount, 1)
+	// The same line from a statement without the bank reference is a duplicate
+	result, _, err = BankImportService.ImportStatement(context.Background(), "operator", "b.csv", "csv", "GB33BUKB20201555555555", withoutRef)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.DuplicateCount, 1)
+	assert.Equal(t, result.UnmatchedCount, 0)
+	// And the other way round
+	bankImports.lines = nil
+	result, _, _ = BankImportService.ImportStatement(context.Background(), "operator", "b.csv", "csv", "GB33BUKB20201555555555", withoutRef)
+	assert.Equal(t, result.UnmatchedCount, 1)
+	result, _, _ = BankImportService.ImportStatement(context.Background(), "operator", "a.csv", "csv", "", withRef)
+	assert.Equal(t, result.DuplicateCount, 1)
+	assert.Equal(t, len(bankImports.lines), 1)
+}
@@ -21,14 +21,74 @@ const (
 	ErrTypeInvalidRequestBody   = "invalid request body"
 	ErrTypeInternalServerError  = "internal server error"
 	ErrTypeAuthenticationFailed = "authentication failed"
+	ErrTypePermissionDenied     = "permission denied"
 )
 
 const (
-	ErrMessageUserNotFound         = "user not found"
-	ErrMessageDBError              = "database error"
-	ErrMessagePasswordNotValid     = "password is not valid"
-	ErrMessageNegativeOrZeroAmount = "amount must be positive"
-	ErrMessageInsufficientBalance  = "insufficient balance"
-	ErrMessageWalletIDInvalid      = "invalid wallet ID"
-	ErrMessageInvalidAccessToken   = "please login first"
+	ErrMessageUserNotFound           = "user not found"
+	ErrMessageDBError                = "database error"
+	ErrMessagePasswordNotValid       = "password is not valid"
+	ErrMessageNegativeOrZeroAmount   = "amount must be positive"
+	ErrMessageInsufficientBalance    = "insufficient balance"
+	ErrMessageWalletIDInvalid        = "invalid wallet ID"
+	ErrMessageSameWalletTransfer     = "cannot transfer to the same wallet"
+	ErrMessageInvalidAccessToken     = "please login first"
+	ErrMessageWalletFrozen           = "wallet is frozen"
+	ErrMessageNoPermission           = "the user's role doesn't have the permission"
+	ErrMessageInvalidTimestamp       = "invalid timestamp, expected RFC3339 format"
+	ErrMessageInvalidTimeRange       = "invalid time range, from must be before to"
+	ErrMessageInvalidFormat          = "invalid format"
+	ErrMessageStatementWriteError    = "failed to write statement"
+	ErrMessageInvalidBankStatement   = "invalid bank statement"
+	ErrMessageBankAccountRequired    = "bank account of the statement is required, in the file or in the account field"
+	ErrMessageBankLineNotFound       = "bank statement line not found"
+	ErrMessageBankLineReviewed       = "bank statement line has already been reviewed"
+	ErrMessageCurrencyMismatch       = "currency doesn't match the wallet currency"
+	ErrMessageInvalidIBAN            = "invalid IBAN"
+	ErrMessageInvalidBIC             = "invalid BIC"
+	ErrMessageDestinationInvalid     = "invalid payout destination"
+	ErrMessageDestinationNotVerified = "payout destination is not verified"
+	ErrMessagePayoutNotFound         = "payout not found"
+	ErrMessagePayoutStatusInvalid    = "operation not allowed in the current payout status"
+	ErrMessagePayoutNotConfigured    = "payout debtor account is not configured"
+	ErrMessagePayoutFileError        = "failed to write payout file"
+	ErrMessageInvalidTxnType         = "invalid transaction type"
+	ErrMessageLimitExceeded          = "transaction limit exceeded"
+	ErrMessageInvalidLimit           = "invalid limit"
+	ErrMessageLimitNotFound          = "user has no own limit of the transaction type"
+	ErrMessageRiskDenied             = "transaction denied by the risk check"
+	ErrMessageRiskReviewNotFound     = "risk review not found"
+	ErrMessageRiskReviewClosed       = "risk review has already been closed"
+	ErrMessageUserNameTaken          = "user name has already been taken"
+	ErrMessagePasswordTooShort       = "password must be at least 8 characters"
+	ErrMessageFullNameRequired       = "full name is required"
+	ErrMessageScreeningHit           = "blocked by the sanctions screening"
+	ErrMessageScreeningListError     = "failed to load sanctions lists"
+	ErrMessageScreeningCaseNotFound  = "screening case not found"
+	ErrMessageScreeningCaseClosed    = "screening case has already been closed"
+	ErrMessageInvalidCaseResolution  = "invalid case resolution, expected cleared or confirmed"
+	ErrMessageKYCLevelInsufficient   = "transaction not allowed at the user's KYC level"
+	ErrMessageInvalidKYCLevel        = "invalid KYC level"
+	ErrMessageInvalidDocumentType    = "invalid document type"
+	ErrMessageInvalidDocument        = "invalid document, expected a JPEG, PNG or PDF file"
+	ErrMessageDocumentNotFound       = "document not found"
+	ErrMessageDocumentRequired       = "at least one unsubmitted document is required"
+	ErrMessageDocumentStoreError     = "failed to store document"
+	ErrMessageKYCSubmissionPending   = "a KYC submission is already waiting for review"
+	ErrMessageKYCSubmissionNotFound  = "KYC submission not found"
+	ErrMessageKYCSubmissionClosed    = "KYC submission has already been reviewed"
+	ErrMessageSelfReview             = "cannot review your own request"
+	ErrMessageOperationNotFound      = "pending operation not found"
+	ErrMessageOperationClosed        = "pending operation has already been closed"
+	ErrMessageOperationExpired       = "pending operation has expired"
+	ErrMessageApproverOnly           = "not allowed to review the operations"
+	ErrMessageWalletNotFrozen        = "wallet is not frozen"
+	ErrMessageInvalidRole            = "invalid user role"
+	ErrMessageSelfRoleChange         = "cannot change your own role"
+	ErrMessageKeywordRequired        = "search keyword is required"
+	ErrMessageInvalidDirection       = "invalid adjustment direction, expected credit or debit"
+	ErrMessageInvalidReasonCode      = "invalid adjustment reason code"
+	ErrMessageJustificationRequired  = "justification is required"
+	ErrMessageJustificationTooLong   = "justification must be at most 300 characters"
+	ErrMessageNoteTooLong            = "note must be at most 255 characters"
 )
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/fee"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Fee service interface
+type IFeeService interface {
+	Quote(ctx context.Context, currentUserID string, txnType string, amount decimal.Decimal) (model.FeeQuote, int, error)
+}
+
+// Fee service instance
+var FeeService IFeeService = &feeServiceImpl{}
+
+// Fee service implementation
+type feeServiceImpl struct{}
+
+// Quote the fee of a transaction before making it
+func (fs *feeServiceImpl) Quote(ctx context.Context, currentUserID string, txnType string, amount decimal.Decimal) (model.FeeQuote, int, error) {
+	ctx, span := tracing.Start(ctx, "FeeService.Quote")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	switch txnType {
+	case constant.TxnTypeWithdraw, constant.TxnTypeTransfer, constant.TxnTypeFX:
+	default:
+		return model.FeeQuote{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidTxnType, nil)
+	}
+	if amount.IsNegative() || amount.IsZero() {
+		return model.FeeQuote{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+	}
+	feeAmount, err := calculateFee(conn, config.Current(), currentUserID, txnType, amount)
+	if err != nil {
+		return model.FeeQuote{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return model.FeeQuote{
+		TxnType:    txnType,
+		Amount:     amount,
+		Fee:        feeAmount,
+		TotalDebit: amount.Add(feeAmount),
+	}, http.StatusOK, nil
+}
+
+// Calculate the fee of a transaction by the fee schedule of the transaction type and the user tier
+// The configuration is read once by the operation, so that a reload can't change the fee schedules halfway
+func calculateFee(db *gorm.DB, cfg *config.Config, userID string, txnType string, amount decimal.Decimal) (decimal.Decimal, error) {
+	user, err := repository.UserRepository.GetUserByID(db, userID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	schedule, found := fee.FindSchedule(cfg.Fee.Schedules, txnType, user.UserTier)
+	if !found {
+		return decimal.Zero, nil
+	}
+	return fee.Calculate(schedule, amount), nil
+}
+
+// Charge the fee of a transaction from the wallet to the system fee wallet, in the transaction of the charged operation
+// The fee transaction history is linked to the parent transaction
+// Return the fee and the latest wallet balance, the balance is zero if no fee is charged
+func chargeFee(tx *gorm.DB, cfg *config.Config, userID string, walletID string, parentTxnID string, txnType string, amount decimal.Decimal, currTime time.Time) (decimal.Decimal, decimal.Decimal, error) {
+	feeAmount, err := calculateFee(tx, cfg, userID, txnType, amount)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if !feeAmount.IsPositive() {
+		return decimal.Zero, decimal.Zero, nil
+	}
+	latestBalance, err := repository.WalletRepository.ChargeFee(tx, walletID, feeAmount)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if _, err := repository.TransactionRepository.CreateLinkedTransactionHistory(tx, parentTxnID, walletID, constant.SystemWalletFee, constant.TxnTypeFee, feeAmount, currTime); err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return feeAmount, latestBalance, nil
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+	"wallet-app-server/app/blob"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/kyc"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Content types accepted for the KYC documents
+var kycDocumentContentTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+// KYC service interface
+type IKYCService interface {
+	UploadDocument(ctx context.Context, currentUserID string, documentType string, fileName string, content []byte) (model.KYCDocument, int, error)
+	Submit(ctx context.Context, currentUserID string, targetLevel string, documentIDs []string) (model.KYCSubmission, int, error)
+	GetStatus(ctx context.Context, currentUserID string) (model.KYCStatus, int, error)
+	ListPendingSubmissions(ctx context.Context) ([]model.KYCSubmission, int, error)
+	OpenDocument(ctx context.Context, documentID string) (model.KYCDocument, io.ReadCloser, int, error)
+	ApproveSubmission(ctx context.Context, operatorID string, submissionID string, reason string) (model.KYCSubmission, int, error)
+	RejectSubmission(ctx context.Context, operatorID string, submissionID string, reason string) (model.KYCSubmission, int, error)
+}
+
+// KYC service instance
+var KYCService IKYCService = &kycServiceImpl{}
+
+// KYC service implementation
+type kycServiceImpl struct{}
+
+// Store an identity document of the user on the blob store
+// The document is submitted for review later together with the other documents
+func (ks *kycServiceImpl) UploadDocument(ctx context.Context, currentUserID string, documentType string, fileName string, content []byte) (model.KYCDocument, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.UploadDocument")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if !slices.Contains(kyc.DocumentTypes, documentType) {
+		return model.KYCDocument{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidDocumentType, nil)
+	}
+	// The content type is detected from the content, the one claimed by the client isn't trusted
+	contentType := http.DetectContentType(content)
+	if !slices.Contains(kycDocumentContentTypes, contentType) {
+		return model.KYCDocument{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidDocument, nil)
+	}
+	if blob.DefaultStore == nil {
+		return model.KYCDocument{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDocumentStoreError, errors.New("blob store is not configured"))
+	}
+	hash := sha256.Sum256(content)
+	document := entity.KYCDocument{
+		DocumentID:   uuid.New().String(),
+		UserID:       currentUserID,
+		DocumentType: documentType,
+		FileName:     filepath.Base(fileName),
+		ContentType:  contentType,
+		FileSize:     int64(len(content)),
+		FileHash:     hex.EncodeToString(hash[:]),
+		CreateTime:   time.Now(),
+	}
+	document.BlobKey = fmt.Sprintf("kyc/%s/%s", currentUserID, document.DocumentID)
+	if err := blob.DefaultStore.Put(document.BlobKey, bytes.NewReader(content)); err != nil {
+		logger.ErrorfContext(ctx, "Failed to store KYC document, err: %s", err.Error())
+		return model.KYCDocument{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDocumentStoreError, err)
+	}
+	if err := repository.KYCRepository.CreateDocument(conn, document); err != nil {
+		// Don't leave a blob without its record
+		if err := blob.DefaultStore.Delete(document.BlobKey); err != nil {
+			logger.ErrorfContext(ctx, "Failed to delete KYC document %s, err: %s", document.BlobKey, err.Error())
+		}
+		return model.KYCDocument{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toKYCDocumentModel(document), http.StatusOK, nil
+}
+
+// Submit the uploaded documents for the review of a higher KYC level
+// A user can only have one submission waiting for review
+func (ks *kycServiceImpl) Submit(ctx context.Context, currentUserID string, targetLevel string, documentIDs []string) (model.KYCSubmission, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.Submit")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if len(documentIDs) == 0 {
+		return model.KYCSubmission{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageDocumentRequired, nil)
+	}
+	submission := entity.KYCSubmission{
+		SubmissionID:     uuid.New().String(),
+		UserID:           currentUserID,
+		TargetLevel:      targetLevel,
+		SubmissionStatus: constant.KYCSubmissionStatusPending,
+		CreateTime:       time.Now(),
+	}
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the user, so that the user's submissions are made one after another
+		user, err := repository.UserRepository.LockUser(tx, currentUserID)
+		if err != nil {
+			return err
+		}
+		if kyc.Rank(targetLevel) <= kyc.Rank(user.KYCLevel) {
+			return newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidKYCLevel, nil)
+		}
+		pending, err := repository.KYCRepository.HasPendingSubmission(tx, currentUserID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return newServiceError(ErrTypeInvalidRequestBody, ErrMessageKYCSubmissionPending, nil)
+		}
+		if err := repository.KYCRepository.CreateSubmission(tx, submission); err != nil {
+			return err
+		}
+		// Every document must be the user's own and not submitted before
+		attached, err := repository.KYCRepository.AttachDocuments(tx, currentUserID, submission.SubmissionID, documentIDs)
+		if err != nil {
+			return err
+		}
+		if attached != int64(len(documentIDs)) {
+			return newServiceError(ErrTypeInvalidRequestBody, ErrMessageDocumentRequired, nil)
+		}
+		activityDetail := fmt.Sprintf("User submit %d documents for KYC level %s", len(documentIDs), targetLevel)
+		return repository.UserRepository.CreateUserActivity(tx, currentUserID, constant.UserActTypeKYC, activityDetail, "", submission.CreateTime)
+	}); err != nil {
+		statusCode, serviceErr := mapKYCError(err)
+		return model.KYCSubmission{}, statusCode, serviceErr
+	}
+	return toKYCSubmissionModel(conn, submission)
+}
+
+func (ks *kycServiceImpl) GetStatus(ctx context.Context, currentUserID string) (model.KYCStatus, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.GetStatus")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	user, err := repository.UserRepository.GetUserByID(conn, currentUserID)
+	if err != nil {
+		return model.KYCStatus{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	result := model.KYCStatus{KYCLevel: user.KYCLevel}
+	if kycLevel, found := kyc.FindLevel(config.Cfg.KYC.Levels, user.KYCLevel); found {
+		result.Restricted = true
+		result.Capabilities = make([]model.KYCCapability, 0, len(kycLevel.Capabilities))
+		for _, capability := range kycLevel.Capabilities {
+			var maxAmount *decimal.Decimal
+			if capability.MaxAmount.IsPositive() {
+				maxAmount = &capability.MaxAmount
+			}
+			result.Capabilities = append(result.Capabilities, model.KYCCapability{TxnType: capability.TxnType, MaxAmount: maxAmount})
+		}
+	}
+	submission, err := repository.KYCRepository.GetLatestSubmission(conn, currentUserID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return model.KYCStatus{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if err == nil {
+		latestSubmission, statusCode, err := toKYCSubmissionModel(conn, submission)
+		if err != nil {
+			return model.KYCStatus{}, statusCode, err
+		}
+		result.LatestSubmission = &latestSubmission
+	}
+	return result, http.StatusOK, nil
+}
+
+func (ks *kycServiceImpl) ListPendingSubmissions(ctx context.Context) ([]model.KYCSubmission, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.ListPendingSubmissions")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	submissions, err := repository.KYCRepository.ListSubmissionsByStatus(conn, constant.KYCSubmissionStatusPending)
+	if err != nil {
+		return []model.KYCSubmission{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toKYCSubmissionModels(conn, submissions)
+}
+
+// Open a KYC document for the reviewer, the caller should close the returned reader
+func (ks *kycServiceImpl) OpenDocument(ctx context.Context, documentID string) (model.KYCDocument, io.ReadCloser, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.OpenDocument")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	document, err := repository.KYCRepository.GetDocumentByID(conn, documentID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.KYCDocument{}, nil, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageDocumentNotFound, nil)
+		}
+		return model.KYCDocument{}, nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if blob.DefaultStore == nil {
+		return model.KYCDocument{}, nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDocumentStoreError, errors.New("blob store is not configured"))
+	}
+	reader, err := blob.DefaultStore.Open(document.BlobKey)
+	if err != nil {
+		logger.ErrorfContext(ctx, "Failed to open KYC document %s, err: %s", document.BlobKey, err.Error())
+		return model.KYCDocument{}, nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDocumentStoreError, err)
+	}
+	return toKYCDocumentModel(document), reader, http.StatusOK, nil
+}
+
+// Approve the submission, and raise the user to the target KYC level
+func (ks *kycServiceImpl) ApproveSubmission(ctx context.Context, operatorID string, submissionID string, reason string) (model.KYCSubmission, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.ApproveSubmission")
+	defer span.End()
+	return reviewKYCSubmission(ctx, operatorID, submissionID, constant.KYCSubmissionStatusApproved, reason)
+}
+
+// Reject the submission with the reason, the user's KYC level isn't changed
+func (ks *kycServiceImpl) RejectSubmission(ctx context.Context, operatorID string, submissionID string, reason string) (model.KYCSubmission, int, error) {
+	ctx, span := tracing.Start(ctx, "KYCService.RejectSubmission")
+	defer span.End()
+	return reviewKYCSubmission(ctx, operatorID, submissionID, constant.KYCSubmissionStatusRejected, reason)
+}
+
+// Close a pending submission as approved or rejected
+// The reviewer can't review the own submission
+func reviewKYCSubmission(ctx context.Context, operatorID string, submissionID string, submissionStatus string, reason string) (model.KYCSubmission, int, error) {
+	var result entity.KYCSubmission
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Lock the submission, so that it can't be reviewed twice concurrently
+		submission, err := repository.KYCRepository.LockSubmission(tx, submissionID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return newServiceError(ErrTypeInvalidRequestBody, ErrMessageKYCSubmissionNotFound, nil)
+			}
+			return err
+		}
+		if submission.SubmissionStatus != constant.KYCSubmissionStatusPending {
+			return newServiceError(ErrTypeInvalidRequestBody, ErrMessageKYCSubmissionClosed, nil)
+		}
+		if submission.UserID == operatorID {
+			return newServiceError(ErrTypePermissionDenied, ErrMessageSelfReview, nil)
+		}
+		if err := repository.KYCRepository.UpdateSubmission(tx, submissionID, submissionStatus, operatorID, reason, currTime); err != nil {
+			return err
+		}
+		activityDetail := fmt.Sprintf("KYC submission for level %s %s by %s", submission.TargetLevel, submissionStatus, operatorID)
+		if submissionStatus == constant.KYCSubmissionStatusApproved {
+			// Never lower the user's level, in case the user has been raised higher since the submission
+			user, err := repository.UserRepository.LockUser(tx, submission.UserID)
+			if err != nil {
+				return err
+			}
+			if kyc.Rank(submission.TargetLevel) > kyc.Rank(user.KYCLevel) {
+				if err := repository.UserRepository.UpdateKYCLevel(tx, submission.UserID, submission.TargetLevel, currTime); err != nil {
+					return err
+				}
+			}
+		}
+		if err := repository.UserRepository.CreateUserActivity(tx, submission.UserID, constant.UserActTypeKYC, activityDetail, "", currTime); err != nil {
+			return err
+		}
+		result, err = repository.KYCRepository.LockSubmission(tx, submissionID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapKYCError(err)
+		return model.KYCSubmission{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "KYC submission %s, submissionID: %s, operatorID: %s", submissionStatus, submissionID, operatorID)
+	return toKYCSubmissionModel(db.DB.WithContext(ctx), result)
+}
+
+// Check the capabilities of the user's KYC level before the transaction is requested, so that a transaction
+// not allowed at the level is never held for review
+// The capabilities are checked again when the transaction is made
+func checkKYCCapability(db *gorm.DB, userID string, txnType string, amount decimal.Decimal) (int, error) {
+	user, err := repository.UserRepository.GetUserByID(db, userID)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if err := kyc.Check(config.Cfg.KYC.Levels, user.KYCLevel, txnType, amount); err != nil {
+		return http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageKYCLevelInsufficient, err)
+	}
+	return http.StatusOK, nil
+}
+
+// Map the error of a KYC operation to the status code and the service error
+func mapKYCError(err error) (int, error) {
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		if serviceErr.ErrType == ErrTypePermissionDenied {
+			return http.StatusForbidden, serviceErr
+		}
+		return http.StatusBadRequest, serviceErr
+	}
+	return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+}
+
+func toKYCDocumentModel(document entity.KYCDocument) model.KYCDocument {
+	return model.KYCDocument{
+		DocumentID:   document.DocumentID,
+		UserID:       document.UserID,
+		DocumentType: document.DocumentType,
+		FileName:     document.FileName,
+		ContentType:  document.ContentType,
+		FileSize:     document.FileSize,
+		FileHash:     document.FileHash,
+		SubmissionID: document.SubmissionID.String,
+		CreateTime:   document.CreateTime,
+	}
+}
+
+// Convert the submission to model, with its documents
+func toKYCSubmissionModel(db *gorm.DB, submission entity.KYCSubmission) (model.KYCSubmission, int, error) {
+	result, statusCode, err := toKYCSubmissionModels(db, []entity.KYCSubmission{submission})
+	if err != nil {
+		return model.KYCSubmission{}, statusCode, err
+	}
+	return result[0], http.StatusOK, nil
+}
+
+// Convert the submissions to models, with their documents
+func toKYCSubmissionModels(db *gorm.DB, submissions []entity.KYCSubmission) ([]model.KYCSubmission, int, error) {
+	submissionIDs := make([]string, 0, len(submissions))
+	for _, submission := range submissions {
+		submissionIDs = append(submissionIDs, submission.SubmissionID)
+	}
+	documents := map[string][]model.KYCDocument{}
+	if len(submissionIDs) > 0 {
+		documentList, err := repository.KYCRepository.ListSubmissionDocuments(db, submissionIDs)
+		if err != nil {
+			return []model.KYCSubmission{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		}
+		for _, document := range documentList {
+			documents[document.SubmissionID.String] = append(documents[document.SubmissionID.String], toKYCDocumentModel(document))
+		}
+	}
+	result := make([]model.KYCSubmission, 0, len(submissions))
+	for _, submission := range submissions {
+		item := model.KYCSubmission{
+			SubmissionID:     submission.SubmissionID,
+			UserID:           submission.UserID,
+			TargetLevel:      submission.TargetLevel,
+			SubmissionStatus: submission.SubmissionStatus,
+			Documents:        documents[submission.SubmissionID],
+			ReviewBy:         submission.ReviewBy.String,
+			ReviewReason:     submission.ReviewReason.String,
+			CreateTime:       submission.CreateTime,
+		}
+		if item.Documents == nil {
+			item.Documents = []model.KYCDocument{}
+		}
+		if submission.UpdateTime.Valid {
+			item.UpdateTime = &submission.UpdateTime.Time
+		}
+		result = append(result, item)
+	}
+	return result, http.StatusOK, nil
+}
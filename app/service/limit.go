@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/kyc"
+	"wallet-app-server/app/limit"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// The transaction types which have limits
+var limitedTxnTypes = []string{constant.TxnTypeWithdraw, constant.TxnTypeTransfer}
+
+// Limit service interface
+type ILimitService interface {
+	GetUserLimits(ctx context.Context, currentUserID string) ([]model.LimitStatus, int, error)
+	SetUserLimit(ctx context.Context, operatorID string, userLimit model.UserLimit, note string) (model.PendingOperation, int, error)
+	RemoveUserLimit(ctx context.Context, operatorID string, userID string, txnType string, note string) (model.PendingOperation, int, error)
+}
+
+// Limit service instance
+var LimitService ILimitService = &limitServiceImpl{}
+
+// Limit service implementation
+type limitServiceImpl struct{}
+
+// Get the user's limits of every limited transaction type, with the amount and count used in the current day and month
+func (ls *limitServiceImpl) GetUserLimits(ctx context.Context, currentUserID string) ([]model.LimitStatus, int, error) {
+	ctx, span := tracing.Start(ctx, "LimitService.GetUserLimits")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	user, err := repository.UserRepository.GetUserByID(conn, currentUserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageUserNotFound, nil)
+		}
+		return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	currTime := time.Now()
+	cfg := config.Current()
+	result := make([]model.LimitStatus, 0, len(limitedTxnTypes))
+	for _, txnType := range limitedTxnTypes {
+		rule, source, err := findLimitRule(conn, cfg, user, txnType)
+		if err != nil {
+			return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		}
+		usage, err := getLimitUsage(conn, user.UserID, txnType, currTime)
+		if err != nil {
+			return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		}
+		result = append(result, model.LimitStatus{
+			TxnType:      txnType,
+			Source:       source,
+			PerTxnAmount: optionalAmount(rule.PerTxnAmount),
+			Daily:        toLimitPeriodStatus(rule.DailyAmount, usage.DailyAmount, rule.DailyCount, usage.DailyCount),
+			Monthly:      toLimitPeriodStatus(rule.MonthlyAmount, usage.MonthlyAmount, rule.MonthlyCount, usage.MonthlyCount),
+		})
+	}
+	return result, http.StatusOK, nil
+}
+
+// Request to set the user's own limits of the transaction type, overriding the limits of the user tier
+// The limits are set after a second person approves the request
+func (ls *limitServiceImpl) SetUserLimit(ctx context.Context, operatorID string, userLimit model.UserLimit, note string) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "LimitService.SetUserLimit")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if !slices.Contains(limitedTxnTypes, userLimit.TxnType) {
+		return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidTxnType, nil)
+	}
+	rule := config.LimitRule{
+		TxnType:       userLimit.TxnType,
+		PerTxnAmount:  userLimit.PerTxnAmount,
+		DailyAmount:   userLimit.DailyAmount,
+		DailyCount:    userLimit.DailyCount,
+		MonthlyAmount: userLimit.MonthlyAmount,
+		MonthlyCount:  userLimit.MonthlyCount,
+	}
+	if err := limit.ValidateRule(rule); err != nil {
+		return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidLimit, err)
+	}
+	if _, err := repository.UserRepository.GetUserByID(conn, userLimit.UserID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageUserNotFound, nil)
+		}
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	summary := fmt.Sprintf("Set %s limits of user %s", userLimit.TxnType, userLimit.UserID)
+	return requestOperationForApproval(conn, operatorID, constant.OperationTypeSetUserLimit, userLimit, summary, note)
+}
+
+// Request to remove the user's own limits of the transaction type, so that the limits of the user tier apply again
+// The limits are removed after a second person approves the request
+func (ls *limitServiceImpl) RemoveUserLimit(ctx context.Context, operatorID string, userID string, txnType string, note string) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "LimitService.RemoveUserLimit")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	if _, err := repository.LimitRepository.GetUserLimit(conn, userID, txnType); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageLimitNotFound, nil)
+		}
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	summary := fmt.Sprintf("Remove %s limits of user %s", txnType, userID)
+	return requestOperationForApproval(conn, operatorID, constant.OperationTypeRemoveUserLimit, removeUserLimitOperation{UserID: userID, TxnType: txnType}, summary, note)
+}
+
+// A removal of the user's own limits held for approval
+type removeUserLimitOperation struct {
+	UserID  string `json:"user_id"`
+	TxnType string `json:"txn_type"`
+}
+
+// Set the user's own limits approved in the pending operation
+func executeSetUserLimitOperation(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error) {
+	var userLimit model.UserLimit
+	if err := decodeOperationPayload(operation, &userLimit); err != nil {
+		return "", err
+	}
+	if err := repository.LimitRepository.SaveUserLimit(tx, entity.UserLimit{
+		UserID:        userLimit.UserID,
+		TxnType:       userLimit.TxnType,
+		PerTxnAmount:  userLimit.PerTxnAmount,
+		DailyAmount:   userLimit.DailyAmount,
+		DailyCount:    userLimit.DailyCount,
+		MonthlyAmount: userLimit.MonthlyAmount,
+		MonthlyCount:  userLimit.MonthlyCount,
+		UpdateBy:      approverID,
+		UpdateTime:    currTime,
+	}); err != nil {
+		return "", err
+	}
+	logger.InfofContext(tx.Statement.Context, "User limit set, userID: %s, txnType: %s, requestBy: %s, approverID: %s", userLimit.UserID, userLimit.TxnType, operation.RequestBy, approverID)
+	return "", nil
+}
+
+// Remove the user's own limits approved in the pending operation
+func executeRemoveUserLimitOperation(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error) {
+	var payload removeUserLimitOperation
+	if err := decodeOperationPayload(operation, &payload); err != nil {
+		return "", err
+	}
+	deleted, err := repository.LimitRepository.DeleteUserLimit(tx, payload.UserID, payload.TxnType)
+	if err != nil {
+		return "", err
+	}
+	if !deleted {
+		return "", newServiceError(ErrTypeInvalidRequestBody, ErrMessageLimitNotFound, nil)
+	}
+	logger.InfofContext(tx.Statement.Context, "User limit removed, userID: %s, txnType: %s, requestBy: %s, approverID: %s", payload.UserID, payload.TxnType, operation.RequestBy, approverID)
+	return "", nil
+}
+
+// Check the user's limits of the transaction type before the balance change, in the same transaction
+// The user row is locked until the end of the transaction, so that the concurrent transactions of the user
+// are checked one after another, and can't exceed the limits together
+// The capabilities of the user's KYC level are checked first
+// The configuration is read once by the operation, so that a reload can't change the limit rules halfway
+func enforceLimits(tx *gorm.DB, cfg *config.Config, userID string, txnType string, amount decimal.Decimal, currTime time.Time) error {
+	user, err := repository.UserRepository.LockUser(tx, userID)
+	if err != nil {
+		return err
+	}
+	if err := kyc.Check(cfg.KYC.Levels, user.KYCLevel, txnType, amount); err != nil {
+		return err
+	}
+	rule, source, err := findLimitRule(tx, cfg, user, txnType)
+	if err != nil {
+		return err
+	}
+	if source == constant.LimitSourceNone {
+		return nil
+	}
+	usage, err := getLimitUsage(tx, userID, txnType, currTime)
+	if err != nil {
+		return err
+	}
+	return limit.Check(rule, usage, amount)
+}
+
+// Find the limits of the transaction type applying to the user
+// The user's own limits take precedence over the limits of the user tier
+// Return the limits and where they come from
+func findLimitRule(db *gorm.DB, cfg *config.Config, user entity.User, txnType string) (config.LimitRule, string, error) {
+	userLimit, err := repository.LimitRepository.GetUserLimit(db, user.UserID, txnType)
+	if err == nil {
+		return config.LimitRule{
+			TxnType:       userLimit.TxnType,
+			PerTxnAmount:  userLimit.PerTxnAmount,
+			DailyAmount:   userLimit.DailyAmount,
+			DailyCount:    userLimit.DailyCount,
+			MonthlyAmount: userLimit.MonthlyAmount,
+			MonthlyCount:  userLimit.MonthlyCount,
+		}, constant.LimitSourceUser, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return config.LimitRule{}, "", err
+	}
+	if rule, found := limit.FindRule(cfg.Limit.Rules, txnType, user.UserTier); found {
+		return rule, constant.LimitSourceTier, nil
+	}
+	return config.LimitRule{}, constant.LimitSourceNone, nil
+}
+
+// Get the amount and count of the user's transactions of the type in the current day and month
+func getLimitUsage(db *gorm.DB, userID string, txnType string, currTime time.Time) (limit.Usage, error) {
+	dayStart, monthStart := limit.PeriodStart(currTime)
+	var usage limit.Usage
+	var err error
+	if usage.DailyAmount, usage.DailyCount, err = repository.LimitRepository.SumUserTransactionsSince(db, userID, txnType, dayStart); err != nil {
+		return limit.Usage{}, err
+	}
+	if usage.MonthlyAmount, usage.MonthlyCount, err = repository.LimitRepository.SumUserTransactionsSince(db, userID, txnType, monthStart); err != nil {
+		return limit.Usage{}, err
+	}
+	return usage, nil
+}
+
+func toLimitPeriodStatus(amountLimit decimal.Decimal, amountUsed decimal.Decimal, countLimit int64, countUsed int64) model.LimitPeriodStatus {
+	result := model.LimitPeriodStatus{
+		AmountUsed: amountUsed,
+		CountUsed:  countUsed,
+	}
+	if amountLimit.IsPositive() {
+		amountRemaining := decimal.Max(amountLimit.Sub(amountUsed), decimal.Zero)
+		result.AmountLimit = &amountLimit
+		result.AmountRemaining = &amountRemaining
+	}
+	if countLimit > 0 {
+		countRemaining := max(countLimit-countUsed, 0)
+		result.CountLimit = &countLimit
+		result.CountRemaining = &countRemaining
+	}
+	return result
+}
+
+// Return nil for zero amount, which means no limit
+func optionalAmount(amount decimal.Decimal) *decimal.Decimal {
+	if amount.IsPositive() {
+		return &amount
+	}
+	return nil
+}
@@ -0,0 +1,560 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/payout"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/screening"
+	"wallet-app-server/app/statement"
+	"wallet-app-server/app/tracing"
+	"wallet-app-server/app/util"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Default maximum number of payouts in a pain.001 file
+const defaultPayoutMaxBatchSize = 1000
+
+// Payout service interface
+type IPayoutService interface {
+	CreateDestination(ctx context.Context, currentUserID string, iban string, holderName string, bic string) (model.PayoutDestination, int, error)
+	ListDestinations(ctx context.Context, currentUserID string) ([]model.PayoutDestination, int, error)
+	VerifyDestination(ctx context.Context, operatorID string, destinationID string) (model.PayoutDestination, int, error)
+	RequestPayout(ctx context.Context, currentUserID string, walletID string, destinationID string, amount decimal.Decimal, deviceID string) (model.PayoutResult, int, error)
+	ListPayouts(ctx context.Context, currentUserID string) ([]model.PayoutInfo, int, error)
+	SubmitBatch(ctx context.Context, now time.Time) (model.PayoutBatchResult, int, error)
+	SettlePayout(ctx context.Context, operatorID string, payoutID string) (model.PayoutInfo, int, error)
+	ReturnPayout(ctx context.Context, operatorID string, payoutID string, returnReason string) (model.PayoutInfo, int, error)
+}
+
+// Payout service instance
+var PayoutService IPayoutService = &payoutServiceImpl{}
+
+// Payout service implementation
+type payoutServiceImpl struct{}
+
+// Register an external bank account as a payout destination of the user
+// The destination can only receive payouts after it's verified by an operator
+func (ps *payoutServiceImpl) CreateDestination(ctx context.Context, currentUserID string, iban string, holderName string, bic string) (model.PayoutDestination, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.CreateDestination")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	// Validate the bank account
+	iban = util.NormalizeIBAN(iban)
+	if err := util.ValidateIBAN(iban); err != nil {
+		return model.PayoutDestination{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidIBAN, nil)
+	}
+	bic = strings.ToUpper(strings.TrimSpace(bic))
+	if bic != "" && !util.IsValidBIC(bic) {
+		return model.PayoutDestination{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidBIC, nil)
+	}
+	holderName = strings.TrimSpace(holderName)
+	// Screen the account holder against the sanctions lists
+	if statusCode, err := screenName(conn, screeningSubject{
+		UserID:     currentUserID,
+		SubjectRef: iban,
+		Name:       holderName,
+		Context:    constant.ScreeningContextPayoutDestination,
+	}); err != nil {
+		return model.PayoutDestination{}, statusCode, err
+	}
+	destination := entity.PayoutDestination{
+		DestinationID:     uuid.New().String(),
+		UserID:            currentUserID,
+		IBAN:              iban,
+		HolderName:        holderName,
+		BIC:               sql.NullString{String: bic, Valid: bic != ""},
+		DestinationStatus: constant.PayoutDestinationStatusPending,
+		CreateTime:        time.Now(),
+	}
+	if err := repository.PayoutRepository.CreateDestination(conn, destination); err != nil {
+		return model.PayoutDestination{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toPayoutDestinationModel(destination), http.StatusOK, nil
+}
+
+func (ps *payoutServiceImpl) ListDestinations(ctx context.Context, currentUserID string) ([]model.PayoutDestination, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.ListDestinations")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	destinations, err := repository.PayoutRepository.ListUserDestinations(conn, currentUserID)
+	if err != nil {
+		return []model.PayoutDestination{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.PayoutDestination
+	result := make([]model.PayoutDestination, 0, len(destinations))
+	for _, destination := range destinations {
+		result = append(result, toPayoutDestinationModel(destination))
+	}
+	return result, http.StatusOK, nil
+}
+
+// Mark a pending payout destination as verified (e.g. after the account holder name has been checked)
+func (ps *payoutServiceImpl) VerifyDestination(ctx context.Context, operatorID string, destinationID string) (model.PayoutDestination, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.VerifyDestination")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	verified, err := repository.PayoutRepository.VerifyDestination(conn, destinationID, operatorID, time.Now())
+	if err != nil {
+		return model.PayoutDestination{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if !verified {
+		return model.PayoutDestination{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageDestinationInvalid, nil)
+	}
+	destination, err := repository.PayoutRepository.GetDestinationByID(conn, destinationID)
+	if err != nil {
+		return model.PayoutDestination{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.InfofContext(ctx, "Payout destination verified, destinationID: %s, operatorID: %s", destinationID, operatorID)
+	return toPayoutDestinationModel(destination), http.StatusOK, nil
+}
+
+// Withdraw from the wallet to a verified payout destination
+// The money leaves the wallet immediately, and the payout is queued until the next pain.001 batch
+// The payout is assessed by the risk engine as a withdraw, a held payout is queued once its risk review is approved
+func (ps *payoutServiceImpl) RequestPayout(ctx context.Context, currentUserID string, walletID string, destinationID string, amount decimal.Decimal, deviceID string) (model.PayoutResult, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.RequestPayout")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	// The fee schedules and the limit rules as of the request, even if reloaded meanwhile
+	cfg := config.Current()
+	// Verify from wallet is belong to the current user
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, walletID)
+	if err != nil {
+		return model.PayoutResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if !valid {
+		return model.PayoutResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	// Reject the invalid payout before the risk assessment, so that it's never held for review
+	if amount.IsNegative() || amount.IsZero() {
+		return model.PayoutResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+	}
+	// Verify the destination is belong to the current user and verified
+	destination, statusCode, err := getPayoutDestination(conn, currentUserID, destinationID)
+	if err != nil {
+		return model.PayoutResult{}, statusCode, err
+	}
+	// Screen the account holder again, the lists may have changed since the destination was registered
+	listSet := screening.DefaultScreener.Current()
+	if statusCode, err := screenNameAgainst(conn, listSet, screeningSubject{
+		UserID:     currentUserID,
+		SubjectRef: destination.IBAN,
+		Name:       destination.HolderName,
+		Context:    constant.ScreeningContextPayout,
+	}); err != nil {
+		return model.PayoutResult{}, statusCode, err
+	}
+	var result model.PayoutResult
+	var denied bool
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Lock the user, so that the concurrent transactions of the user are assessed one after another
+		if _, err := repository.UserRepository.LockUser(tx, currentUserID); err != nil {
+			return err
+		}
+		// Assess the risk of the payout as a withdraw, the decision of a denied or held payout is committed without the payout
+		assessment, err := assessRisk(tx, riskRequest{
+			UserID:              currentUserID,
+			TxnType:             constant.TxnTypeWithdraw,
+			FromWalletID:        walletID,
+			Amount:              amount,
+			DeviceID:            deviceID,
+			PayoutDestinationID: destinationID,
+		}, currTime)
+		if err != nil {
+			return err
+		}
+		if assessment.Denied {
+			denied = true
+			return nil
+		}
+		if assessment.ReviewID != "" {
+			result = model.PayoutResult{Status: constant.TxnStatusPendingReview, ReviewID: assessment.ReviewID}
+			return nil
+		}
+		// Withdraw and queue the payout, with the version the holder is screened against above
+		activityDetail := fmt.Sprintf("User payout amount %s from wallet %s to bank account %s", amount.StringFixed(2), walletID, maskIBAN(destination.IBAN))
+		queued, err := queuePayout(tx, cfg, currentUserID, walletID, destination, amount, screeningListVersion(listSet), activityDetail, currTime)
+		if err != nil {
+			return err
+		}
+		result = model.PayoutResult{Payout: toPayoutInfoModel(queued), Status: constant.TxnStatusCompleted}
+		// Link the risk decision to the transaction
+		return completeRiskDecision(tx, assessment.DecisionID, currentUserID, deviceID, queued.TxnID, currTime)
+	}); err != nil {
+		statusCode, serviceErr := mapWalletError(err)
+		return model.PayoutResult{}, statusCode, serviceErr
+	}
+	if denied {
+		return model.PayoutResult{}, http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageRiskDenied, nil)
+	}
+	return result, http.StatusOK, nil
+}
+
+func (ps *payoutServiceImpl) ListPayouts(ctx context.Context, currentUserID string) ([]model.PayoutInfo, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.ListPayouts")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	payouts, err := repository.PayoutRepository.ListUserPayouts(conn, currentUserID)
+	if err != nil {
+		return []model.PayoutInfo{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.PayoutInfo
+	result := make([]model.PayoutInfo, 0, len(payouts))
+	for _, payout := range payouts {
+		result = append(result, toPayoutInfoModel(payout))
+	}
+	return result, http.StatusOK, nil
+}
+
+// Write the queued payouts to a pain.001 file in the output directory, and mark them as submitted
+// The file is written under a temporary name, and only renamed to its final name after the payouts are marked as submitted,
+// so that a file picked up for the bank always matches the committed payout statuses
+// The account holders are screened again against the current lists, a blocked payout stays queued out of the batch
+// until its screening case is cleared
+func (ps *payoutServiceImpl) SubmitBatch(ctx context.Context, now time.Time) (model.PayoutBatchResult, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.SubmitBatch")
+	defer span.End()
+	if config.Cfg.Payout.DebtorIBAN == "" {
+		logger.ErrorfContext(ctx, "Payout debtor account is not configured, skip the payout batch")
+		return model.PayoutBatchResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessagePayoutNotConfigured, nil)
+	}
+	maxBatchSize := config.Cfg.Payout.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultPayoutMaxBatchSize
+	}
+	batchID := uuid.New().String()
+	listSet := screening.DefaultScreener.Current()
+	result := model.PayoutBatchResult{TotalAmount: decimal.Zero}
+	var tmpPath, filePath string
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the queued payouts
+		payouts, err := repository.PayoutRepository.LockQueuedPayouts(tx, maxBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(payouts) == 0 {
+			return nil
+		}
+		// Build the batch
+		batch := model.PayoutBatch{
+			MsgID:         strings.ReplaceAll(batchID, "-", ""),
+			CreateTime:    now,
+			ExecutionDate: now,
+			Currency:      statement.Currency(),
+			DebtorName:    config.Cfg.Payout.DebtorName,
+			DebtorIBAN:    config.Cfg.Payout.DebtorIBAN,
+			DebtorBIC:     config.Cfg.Payout.DebtorBIC,
+		}
+		payoutIDs := make([]string, 0, len(payouts))
+		for _, p := range payouts {
+			destination, err := repository.PayoutRepository.GetDestinationByID(tx, p.DestinationID)
+			if err != nil {
+				return err
+			}
+			// Screen the account holder again, a hit opens a case (committed with the batch) and leaves the payout queued
+			if statusCode, err := screenNameAgainst(tx, listSet, screeningSubject{
+				UserID:     p.UserID,
+				SubjectRef: destination.IBAN,
+				Name:       destination.HolderName,
+				Context:    constant.ScreeningContextPayoutBatch,
+			}); err != nil {
+				if statusCode != http.StatusForbidden {
+					return err
+				}
+				result.BlockedCount++
+				continue
+			}
+			batch.Transfers = append(batch.Transfers, model.PayoutTransfer{
+				EndToEndID:     strings.ReplaceAll(p.PayoutID, "-", ""),
+				Amount:         p.Amount,
+				CreditorName:   destination.HolderName,
+				CreditorIBAN:   destination.IBAN,
+				CreditorBIC:    destination.BIC.String,
+				RemittanceInfo: fmt.Sprintf("Wallet payout %s", p.PayoutID),
+			})
+			payoutIDs = append(payoutIDs, p.PayoutID)
+		}
+		if len(payoutIDs) == 0 {
+			return nil
+		}
+		// Write the file under a temporary name
+		fileName := fmt.Sprintf("pain001-%s-%s.xml", now.UTC().Format("20060102150405"), batch.MsgID[:8])
+		filePath = filepath.Join(config.Cfg.Payout.OutputDir, fileName)
+		tmpPath = filePath + ".tmp"
+		if err := writePain001File(tmpPath, batch); err != nil {
+			return newServiceError(ErrTypeInternalServerError, ErrMessagePayoutFileError, err)
+		}
+		// Record the batch and mark the payouts as submitted
+		result = model.PayoutBatchResult{
+			BatchID:      batchID,
+			MsgID:        batch.MsgID,
+			PayoutCount:  len(payoutIDs),
+			TotalAmount:  payout.ControlSum(batch.Transfers),
+			FileName:     fileName,
+			BlockedCount: result.BlockedCount,
+		}
+		if err := repository.PayoutRepository.CreateBatch(tx, entity.PayoutBatch{
+			BatchID:     batchID,
+			MsgID:       result.MsgID,
+			PayoutCount: result.PayoutCount,
+			TotalAmount: result.TotalAmount,
+			FileName:    fileName,
+			CreateTime:  now,
+		}); err != nil {
+			return err
+		}
+		return repository.PayoutRepository.MarkPayoutsSubmitted(tx, payoutIDs, batchID, screeningListVersion(listSet), now)
+	}); err != nil {
+		if tmpPath != "" {
+			os.Remove(tmpPath)
+		}
+		logger.ErrorfContext(ctx, "Failed to submit payout batch, err: %s", err.Error())
+		var serviceErr ServiceError
+		if errors.As(err, &serviceErr) {
+			return model.PayoutBatchResult{}, http.StatusInternalServerError, serviceErr
+		}
+		return model.PayoutBatchResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if result.PayoutCount == 0 {
+		return result, http.StatusOK, nil
+	}
+	// Publish the file
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		logger.ErrorfContext(ctx, "Failed to rename payout file, the payouts are submitted, rename the file manually, file: %s, err: %s", tmpPath, err.Error())
+		return result, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessagePayoutFileError, err)
+	}
+	logger.InfofContext(ctx, "Payout batch submitted, batchID: %s, file: %s, payout_count=%d total_amount=%s blocked_count=%d",
+		result.BatchID, filePath, result.PayoutCount, result.TotalAmount.StringFixed(2), result.BlockedCount)
+	return result, http.StatusOK, nil
+}
+
+// Mark a submitted payout as settled by the bank
+func (ps *payoutServiceImpl) SettlePayout(ctx context.Context, operatorID string, payoutID string) (model.PayoutInfo, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.SettlePayout")
+	defer span.End()
+	var result entity.Payout
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		p, err := lockPayoutInStatus(tx, payoutID, constant.PayoutStatusSubmitted)
+		if err != nil {
+			return err
+		}
+		if err := repository.PayoutRepository.MarkPayoutSettled(tx, p.PayoutID, time.Now()); err != nil {
+			return err
+		}
+		result, err = repository.PayoutRepository.GetPayoutByID(tx, payoutID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapPayoutError(err)
+		return model.PayoutInfo{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Payout settled, payoutID: %s, operatorID: %s", payoutID, operatorID)
+	return toPayoutInfoModel(result), http.StatusOK, nil
+}
+
+// Mark a payout as returned by the bank (e.g. closed account), and re-credit the wallet
+// The re-credit is a payout_return transaction linked to the withdrawal of the payout
+func (ps *payoutServiceImpl) ReturnPayout(ctx context.Context, operatorID string, payoutID string, returnReason string) (model.PayoutInfo, int, error) {
+	ctx, span := tracing.Start(ctx, "PayoutService.ReturnPayout")
+	defer span.End()
+	var result entity.Payout
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// A payout can be returned after it's submitted, even after it's settled
+		p, err := lockPayoutInStatus(tx, payoutID, constant.PayoutStatusSubmitted, constant.PayoutStatusSettled)
+		if err != nil {
+			return err
+		}
+		// Re-credit the wallet
+		if _, err := repository.WalletRepository.ReturnWithdrawal(tx, p.WalletID, p.Amount); err != nil {
+			return err
+		}
+		// Create transaction history linked to the withdrawal
+		returnTxnID, err := repository.TransactionRepository.CreateLinkedTransactionHistory(tx, p.TxnID, constant.SystemWalletCashOut, p.WalletID, constant.TxnTypePayoutReturn, p.Amount, currTime)
+		if err != nil {
+			return err
+		}
+		// Refund the withdrawal fee, linked to the withdrawal as well
+		activityDetail := fmt.Sprintf("Payout %s returned, amount %s re-credited to wallet %s", p.PayoutID, p.Amount.StringFixed(2), p.WalletID)
+		feeTxn, err := repository.TransactionRepository.GetLinkedTransactionHistory(tx, p.TxnID, constant.TxnTypeFee)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err == nil {
+			if _, err := repository.WalletRepository.RefundFee(tx, p.WalletID, feeTxn.TxnAmount); err != nil {
+				return err
+			}
+			if _, err := repository.TransactionRepository.CreateLinkedTransactionHistory(tx, p.TxnID, constant.SystemWalletFee, p.WalletID, constant.TxnTypeFeeRefund, feeTxn.TxnAmount, currTime); err != nil {
+				return err
+			}
+			activityDetail = fmt.Sprintf("%s, fee %s refunded", activityDetail, feeTxn.TxnAmount.StringFixed(2))
+		}
+		// Create user activity
+		if err := repository.UserRepository.CreateUserActivity(tx, p.UserID, constant.UserActTypePayoutReturn, activityDetail, p.WalletID, currTime); err != nil {
+			return err
+		}
+		if err := repository.PayoutRepository.MarkPayoutReturned(tx, p.PayoutID, returnReason, returnTxnID, currTime); err != nil {
+			return err
+		}
+		result, err = repository.PayoutRepository.GetPayoutByID(tx, payoutID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapPayoutError(err)
+		return model.PayoutInfo{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Payout returned, payoutID: %s, operatorID: %s, returnTxnID: %s", payoutID, operatorID, result.ReturnTxnID.String)
+	return toPayoutInfoModel(result), http.StatusOK, nil
+}
+
+// Lock the payout and check it's in one of the given statuses
+func lockPayoutInStatus(tx *gorm.DB, payoutID string, payoutStatuses ...string) (entity.Payout, error) {
+	p, err := repository.PayoutRepository.LockPayout(tx, payoutID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.Payout{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessagePayoutNotFound, nil)
+		}
+		return entity.Payout{}, err
+	}
+	for _, payoutStatus := range payoutStatuses {
+		if p.PayoutStatus == payoutStatus {
+			return p, nil
+		}
+	}
+	return entity.Payout{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessagePayoutStatusInvalid, nil)
+}
+
+// Map the error of a payout operation to the status code and the service error
+func mapPayoutError(err error) (int, error) {
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		return http.StatusBadRequest, serviceErr
+	}
+	return mapWalletError(err)
+}
+
+// Write the pain.001 file, creating the output directory if needed
+func writePain001File(path string, batch model.PayoutBatch) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	if err := payout.WritePain001(file, batch); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Mask an IBAN for display, only the country code and the last 4 characters are kept
+func maskIBAN(iban string) string {
+	if len(iban) <= 8 {
+		return iban
+	}
+	return iban[:2] + strings.Repeat("*", len(iban)-6) + iban[len(iban)-4:]
+}
+
+func toPayoutDestinationModel(destination entity.PayoutDestination) model.PayoutDestination {
+	return model.PayoutDestination{
+		DestinationID:     destination.DestinationID,
+		IBAN:              destination.IBAN,
+		HolderName:        destination.HolderName,
+		BIC:               destination.BIC.String,
+		DestinationStatus: destination.DestinationStatus,
+		CreateTime:        destination.CreateTime,
+	}
+}
+
+// Get the payout destination of the user, which has to be verified
+func getPayoutDestination(db *gorm.DB, userID string, destinationID string) (entity.PayoutDestination, int, error) {
+	destination, err := repository.PayoutRepository.GetDestinationByID(db, destinationID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.PayoutDestination{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageDestinationInvalid, nil)
+		}
+		return entity.PayoutDestination{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if destination.UserID != userID {
+		return entity.PayoutDestination{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageDestinationInvalid, nil)
+	}
+	if destination.DestinationStatus != constant.PayoutDestinationStatusVerified {
+		return entity.PayoutDestination{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageDestinationNotVerified, nil)
+	}
+	return destination, http.StatusOK, nil
+}
+
+// Withdraw from the wallet and queue the payout to the destination
+// Should call this function inside a transaction
+func queuePayout(tx *gorm.DB, cfg *config.Config, userID string, walletID string, destination entity.PayoutDestination, amount decimal.Decimal, listVersion sql.NullString, activityDetail string, currTime time.Time) (entity.Payout, error) {
+	_, txnID, err := withdraw(tx, cfg, userID, walletID, amount, constant.UserActTypePayout, activityDetail, currTime)
+	if err != nil {
+		return entity.Payout{}, err
+	}
+	queued := entity.Payout{
+		PayoutID:             uuid.New().String(),
+		UserID:               userID,
+		WalletID:             walletID,
+		DestinationID:        destination.DestinationID,
+		Amount:               amount,
+		Currency:             statement.Currency(),
+		TxnID:                txnID,
+		PayoutStatus:         constant.PayoutStatusQueued,
+		ScreeningListVersion: listVersion,
+		CreateTime:           currTime,
+	}
+	return queued, repository.PayoutRepository.CreatePayout(tx, queued)
+}
+
+// Queue the payout held for the risk review once approved, if its destination is still verified
+// The account holder isn't screened again here but when the payout is batched, so no list version is recorded
+// Should call this function inside a transaction
+func approvePayout(tx *gorm.DB, cfg *config.Config, review entity.RiskReview, currTime time.Time) (string, error) {
+	destination, _, err := getPayoutDestination(tx, review.UserID, review.PayoutDestinationID.String)
+	if err != nil {
+		return "", err
+	}
+	activityDetail := fmt.Sprintf("User payout amount %s from wallet %s to bank account %s, approved in risk review %s",
+		review.Amount.StringFixed(2), review.FromWalletID, maskIBAN(destination.IBAN), review.ReviewID)
+	queued, err := queuePayout(tx, cfg, review.UserID, review.FromWalletID, destination, review.Amount, sql.NullString{}, activityDetail, currTime)
+	if err != nil {
+		return "", err
+	}
+	return queued.TxnID, nil
+}
+
+func toPayoutInfoModel(p entity.Payout) model.PayoutInfo {
+	return model.PayoutInfo{
+		PayoutID:      p.PayoutID,
+		WalletID:      p.WalletID,
+		DestinationID: p.DestinationID,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		TxnID:         p.TxnID,
+		PayoutStatus:  p.PayoutStatus,
+		BatchID:       p.BatchID.String,
+		ReturnReason:  p.ReturnReason.String,
+		ReturnTxnID:   p.ReturnTxnID.String,
+		CreateTime:    p.CreateTime,
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"wallet-app-server/app/alert"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"github.com/shopspring/decimal"
+)
+
+// Reconcile service interface
+type IReconcileService interface {
+	Reconcile(ctx context.Context, freezeOnMismatch bool) (model.ReconcileReport, int, error)
+}
+
+// Reconcile service instance
+var ReconcileService IReconcileService = &reconcileServiceImpl{}
+
+// Reconcile service implementation
+type reconcileServiceImpl struct{}
+
+// Recompute every wallet's balance from txn_history and report the drift against wallet.balance
+// If freezeOnMismatch is true, the mismatched user wallets will be frozen
+func (rs *reconcileServiceImpl) Reconcile(ctx context.Context, freezeOnMismatch bool) (model.ReconcileReport, int, error) {
+	ctx, span := tracing.Start(ctx, "ReconcileService.Reconcile")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	report := model.ReconcileReport{ReconcileTime: time.Now(), Mismatches: []model.WalletDrift{}, TotalDrift: decimal.Zero}
+	// Recompute balances from history, in one statement over the whole history, so it runs without the statement timeout
+	balances, err := repository.WalletRepository.ListWalletHistoryBalances(db.DB.WithContext(db.WithoutStatementTimeout(ctx)))
+	if err != nil {
+		logger.ErrorfContext(ctx, "Failed to list wallet history balances, err: %s", err.Error())
+		return report, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	report.WalletCount = len(balances)
+	// Compare with the wallet balances
+	for _, balance := range balances {
+		drift := balance.Balance.Sub(balance.HistoryBalance)
+		if drift.IsZero() {
+			continue
+		}
+		walletDrift := model.WalletDrift{
+			WalletID:       balance.WalletID,
+			Balance:        balance.Balance,
+			HistoryBalance: balance.HistoryBalance,
+			Drift:          drift,
+			Frozen:         balance.WalletStatus == constant.WalletStatusFrozen,
+		}
+		// Freeze the user wallet, system wallets are never frozen
+		if freezeOnMismatch && !walletDrift.Frozen && balance.WalletType == constant.WalletTypeUser {
+			if err := repository.WalletRepository.UpdateWalletStatus(conn, balance.WalletID, constant.WalletStatusFrozen); err != nil {
+				logger.ErrorfContext(ctx, "Failed to freeze wallet, walletID: %s, err: %s", balance.WalletID, err.Error())
+			} else {
+				walletDrift.Frozen = true
+			}
+		}
+		report.Mismatches = append(report.Mismatches, walletDrift)
+		report.TotalDrift = report.TotalDrift.Add(drift.Abs())
+		alert.Send(alert.AlertTypeReconcileMismatch, "Wallet balance doesn't match the transaction history", map[string]any{
+			"wallet_id":       walletDrift.WalletID,
+			"balance":         walletDrift.Balance.StringFixed(2),
+			"history_balance": walletDrift.HistoryBalance.StringFixed(2),
+			"drift":           walletDrift.Drift.StringFixed(2),
+			"frozen":          walletDrift.Frozen,
+		})
+	}
+	report.MismatchCount = len(report.Mismatches)
+	logger.InfofContext(ctx, "Reconciliation finished, wallet_count=%d mismatch_count=%d total_drift=%s",
+		report.WalletCount, report.MismatchCount, report.TotalDrift.StringFixed(2))
+	return report, http.StatusOK, nil
+}
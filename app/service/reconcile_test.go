@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"wallet-app-server/app/alert"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/repository"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Wallet repository returning the given history balances, and recording the status updates
+type fakeReconcileWalletRepository struct {
+	repository.IWalletRepository
+	balances []repository.WalletHistoryBalance
+	frozen   []string
+}
+
+func (r *fakeReconcileWalletRepository) ListWalletHistoryBalances(db *gorm.DB) ([]repository.WalletHistoryBalance, error) {
+	if r.balances == nil {
+		return nil, errors.New("connection refused")
+	}
+	return r.balances, nil
+}
+
+func (r *fakeReconcileWalletRepository) UpdateWalletStatus(db *gorm.DB, walletID string, walletStatus string) error {
+	r.frozen = append(r.frozen, walletID)
+	return nil
+}
+
+// Alerter recording the alerts
+type fakeAlerter struct {
+	alerts []alert.Alert
+}
+
+func (a *fakeAlerter) Send(alert alert.Alert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+func historyBalance(walletID string, walletType string, walletStatus string, balance string, historyBalance string) repository.WalletHistoryBalance {
+	return repository.WalletHistoryBalance{
+		WalletID:       walletID,
+		WalletType:     walletType,
+		WalletStatus:   walletStatus,
+		Balance:        decimal.RequireFromString(balance),
+		HistoryBalance: decimal.RequireFromString(historyBalance),
+	}
+}
+
+func fakeReconcile(t *testing.T, freezeOnMismatch bool) (*fakeReconcileWalletRepository, *fakeAlerter) {
+	walletRepository := &fakeReconcileWalletRepository{balances: []repository.WalletHistoryBalance{
+		historyBalance("matched", constant.WalletTypeUser, constant.WalletStatusActive, "100", "100"),
+		historyBalance("drifted", constant.WalletTypeUser, constant.WalletStatusActive, "100", "90.5"),
+		historyBalance("frozen", constant.WalletTypeUser, constant.WalletStatusFrozen, "10", "20"),
+		historyBalance(constant.SystemWalletCashIn, constant.WalletTypeSystem, constant.WalletStatusActive, "-100", "-101"),
+	}}
+	alerter := &fakeAlerter{}
+	originalRepository, originalAlerter := repository.WalletRepository, alert.Alerter
+	repository.WalletRepository, alert.Alerter = walletRepository, alerter
+	t.Cleanup(func() { repository.WalletRepository, alert.Alerter = originalRepository, originalAlerter })
+	return walletRepository, alerter
+}
+
+func TestReconcileDrift(t *testing.T) {
+	walletRepository, alerter := fakeReconcile(t, false)
+	report, statusCode, err := ReconcileService.Reconcile(context.Background(), false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, report.WalletCount, 4)
+	assert.Equal(t, report.MismatchCount, 3)
+	assert.Equal(t, report.TotalDrift.String(), "20.5")
+	assert.Equal(t, report.Mismatches[0].WalletID, "drifted")
+	assert.Equal(t, report.Mismatches[0].Drift.String(), "9.5")
+	assert.Equal(t, report.Mismatches[0].Frozen, false)
+	assert.Equal(t, report.Mismatches[1].Drift.String(), "-10")
+	assert.Equal(t, report.Mismatches[1].Frozen, true)
+	// Nothing is frozen, every mismatch is alerted
+	assert.Equal(t, len(walletRepository.frozen), 0)
+	assert.Equal(t, len(alerter.alerts), 3)
+	assert.Equal(t, alerter.alerts[0].AlertType, alert.AlertTypeReconcileMismatch)
+	assert.Equal(t, alerter.alerts[0].Fields["drift"], "9.50")
+}
+
+func TestReconcileFreeze(t *testing.T) {
+	walletRepository, _ := fakeReconcile(t, true)
+	report, _, err := ReconcileService.Reconcile(context.Background(), true)
+	assert.Equal(t, err, nil)
+	// Only the active user wallet is frozen, the system wallets never are
+	assert.Equal(t, walletRepository.frozen, []string{"drifted"})
+	assert.Equal(t, report.Mismatches[0].Frozen, true)
+	assert.Equal(t, report.Mismatches[2].WalletID, constant.SystemWalletCashIn)
+	assert.Equal(t, report.Mismatches[2].Frozen, false)
+}
+
+func TestReconcileDBError(t *testing.T) {
+	walletRepository, _ := fakeReconcile(t, false)
+	walletRepository.balances = nil
+	_, statusCode, err := ReconcileService.Reconcile(context.Background(), false)
+	assert.Equal(t, statusCode, http.StatusInternalServerError)
+	assert.NotEqual(t, err, nil)
+}
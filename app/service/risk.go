@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/risk"
+	"wallet-app-server/app/tracing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Risk service interface
+type IRiskService interface {
+	ListPendingReviews(ctx context.Context) ([]model.RiskReview, int, error)
+	ApproveReview(ctx context.Context, operatorID string, reviewID string, note string) (model.RiskReview, int, error)
+	RejectReview(ctx context.Context, operatorID string, reviewID string, note string) (model.RiskReview, int, error)
+}
+
+// Risk service instance
+var RiskService IRiskService = &riskServiceImpl{}
+
+// Risk service implementation
+type riskServiceImpl struct{}
+
+func (rs *riskServiceImpl) ListPendingReviews(ctx context.Context) ([]model.RiskReview, int, error) {
+	ctx, span := tracing.Start(ctx, "RiskService.ListPendingReviews")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	reviews, err := repository.RiskRepository.ListReviewsByStatus(conn, constant.RiskReviewStatusPending)
+	if err != nil {
+		return []model.RiskReview{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.RiskReview
+	result := make([]model.RiskReview, 0, len(reviews))
+	for _, review := range reviews {
+		result = append(result, toRiskReviewModel(review))
+	}
+	return result, http.StatusOK, nil
+}
+
+// Approve a transaction held for the risk review, and make the transaction
+// The transaction is made as the user requested it, except that the risk isn't assessed again.
+// If the transaction fails (e.g. insufficient balance), the review stays pending
+func (rs *riskServiceImpl) ApproveReview(ctx context.Context, operatorID string, reviewID string, note string) (model.RiskReview, int, error) {
+	ctx, span := tracing.Start(ctx, "RiskService.ApproveReview")
+	defer span.End()
+	var result entity.RiskReview
+	cfg := config.Current()
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Lock the review, so that it can't be reviewed twice concurrently
+		review, err := lockPendingRiskReview(tx, operatorID, reviewID)
+		if err != nil {
+			return err
+		}
+		// Make the transaction
+		var txnID string
+		switch review.TxnType {
+		case constant.TxnTypeTransfer:
+			txnID, err = transfer(tx, cfg, review.UserID, review.FromWalletID, review.ToWalletID.String, review.Amount, currTime)
+		case constant.TxnTypeWithdraw:
+			if review.PayoutDestinationID.Valid {
+				txnID, err = approvePayout(tx, cfg, review, currTime)
+				break
+			}
+			activityDetail := fmt.Sprintf("User withdraw amount %s to wallet %s, approved in risk review %s", review.Amount.StringFixed(2), review.FromWalletID, reviewID)
+			_, txnID, err = withdraw(tx, cfg, review.UserID, review.FromWalletID, review.Amount, constant.UserActTypeWithdraw, activityDetail, currTime)
+		default:
+			err = fmt.Errorf("unknown transaction type of risk review: %s", review.TxnType)
+		}
+		if err != nil {
+			return err
+		}
+		if review.DeviceID.Valid {
+			if err := repository.RiskRepository.RememberDevice(tx, review.UserID, review.DeviceID.String, currTime); err != nil {
+				return err
+			}
+		}
+		if err := repository.RiskRepository.UpdateReview(tx, reviewID, constant.RiskReviewStatusApproved, txnID, operatorID, note, currTime); err != nil {
+			return err
+		}
+		result, err = repository.RiskRepository.LockReview(tx, reviewID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapRiskReviewError(err)
+		return model.RiskReview{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Risk review approved, reviewID: %s, txnID: %s, operatorID: %s", reviewID, result.TxnID.String, operatorID)
+	return toRiskReviewModel(result), http.StatusOK, nil
+}
+
+// Reject a transaction held for the risk review, nothing is changed in the wallets
+func (rs *riskServiceImpl) RejectReview(ctx context.Context, operatorID string, reviewID string, note string) (model.RiskReview, int, error) {
+	ctx, span := tracing.Start(ctx, "RiskService.RejectReview")
+	defer span.End()
+	var result entity.RiskReview
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the review, so that it can't be reviewed twice concurrently
+		if _, err := lockPendingRiskReview(tx, operatorID, reviewID); err != nil {
+			return err
+		}
+		if err := repository.RiskRepository.UpdateReview(tx, reviewID, constant.RiskReviewStatusRejected, "", operatorID, note, time.Now()); err != nil {
+			return err
+		}
+		var err error
+		result, err = repository.RiskRepository.LockReview(tx, reviewID)
+		return err
+	}); err != nil {
+		statusCode, serviceErr := mapRiskReviewError(err)
+		return model.RiskReview{}, statusCode, serviceErr
+	}
+	logger.InfofContext(ctx, "Risk review rejected, reviewID: %s, operatorID: %s", reviewID, operatorID)
+	return toRiskReviewModel(result), http.StatusOK, nil
+}
+
+// A transaction requested by the user, to be assessed by the risk engine
+type riskRequest struct {
+	UserID       string
+	TxnType      string
+	FromWalletID string
+	ToWalletID   string
+	Amount       decimal.Decimal
+	DeviceID     string
+	// The destination of a payout, empty for the other transactions
+	PayoutDestinationID string
+}
+
+// Result of the risk assessment
+// DecisionID is the persisted decision, ReviewID is set if the transaction is held for review
+type riskAssessment struct {
+	DecisionID string
+	ReviewID   string
+	Denied     bool
+}
+
+// Assess the risk of the requested transaction by the risk engine, and persist the decision
+// A transaction to be reviewed is held in a pending risk review
+// Should call this function inside the transaction of the requested transaction, after the user is locked with LockUser,
+// so that the concurrent transactions of the user are assessed one after another, and the velocity rules count each other
+func assessRisk(tx *gorm.DB, req riskRequest, currTime time.Time) (riskAssessment, error) {
+	decision, err := risk.DefaultEngine.Evaluate(risk.Input{
+		TxnType: req.TxnType,
+		Amount:  req.Amount,
+		Time:    currTime,
+		Facts:   &riskFacts{db: tx, req: req},
+	})
+	if err != nil {
+		return riskAssessment{}, err
+	}
+	result := riskAssessment{DecisionID: uuid.New().String(), Denied: decision.Decision == risk.DecisionDeny}
+	riskDecision := entity.RiskDecision{
+		DecisionID:   result.DecisionID,
+		UserID:       req.UserID,
+		TxnType:      req.TxnType,
+		FromWalletID: req.FromWalletID,
+		ToWalletID:   sql.NullString{String: req.ToWalletID, Valid: req.ToWalletID != ""},
+		Amount:       req.Amount,
+		DeviceID:     sql.NullString{String: req.DeviceID, Valid: req.DeviceID != ""},
+		RiskScore:    decision.Score,
+		Decision:     decision.Decision,
+		MatchedRules: strings.Join(decision.MatchedRules, ","),
+		CreateTime:   currTime,
+	}
+	// Hold the transaction for review
+	if decision.Decision == risk.DecisionReview {
+		result.ReviewID = uuid.New().String()
+		riskDecision.ReviewID = sql.NullString{String: result.ReviewID, Valid: true}
+		if err := repository.RiskRepository.CreateReview(tx, entity.RiskReview{
+			ReviewID:            result.ReviewID,
+			UserID:              req.UserID,
+			TxnType:             req.TxnType,
+			FromWalletID:        req.FromWalletID,
+			ToWalletID:          riskDecision.ToWalletID,
+			Amount:              req.Amount,
+			DeviceID:            riskDecision.DeviceID,
+			RiskScore:           decision.Score,
+			MatchedRules:        riskDecision.MatchedRules,
+			ReviewStatus:        constant.RiskReviewStatusPending,
+			PayoutDestinationID: sql.NullString{String: req.PayoutDestinationID, Valid: req.PayoutDestinationID != ""},
+			CreateTime:          currTime,
+		}); err != nil {
+			return riskAssessment{}, err
+		}
+	}
+	if err := repository.RiskRepository.CreateDecision(tx, riskDecision); err != nil {
+		return riskAssessment{}, err
+	}
+	if decision.Decision != risk.DecisionAllow {
+		logger.WarnfContext(tx.Statement.Context, "Risk check %s, userID: %s, txnType: %s, amount: %s, score: %d, matched rules: %s",
+			decision.Decision, req.UserID, req.TxnType, req.Amount.StringFixed(2), decision.Score, riskDecision.MatchedRules)
+	}
+	return result, nil
+}
+
+// Link the allowed risk decision to the transaction, and remember the device the transaction is made from
+// Should call this function inside the transaction
+func completeRiskDecision(tx *gorm.DB, decisionID string, userID string, deviceID string, txnID string, currTime time.Time) error {
+	if err := repository.RiskRepository.UpdateDecisionTxnID(tx, decisionID, txnID); err != nil {
+		return err
+	}
+	if deviceID == "" {
+		return nil
+	}
+	return repository.RiskRepository.RememberDevice(tx, userID, deviceID, currTime)
+}
+
+// Facts of the requested transaction, queried from the DB by the risk rules
+type riskFacts struct {
+	db  *gorm.DB
+	req riskRequest
+}
+
+func (rf *riskFacts) CountTransactionsSince(since time.Time) (int64, error) {
+	_, count, err := repository.LimitRepository.SumUserTransactionsSince(rf.db, rf.req.UserID, rf.req.TxnType, since)
+	return count, err
+}
+
+// A request without device ID is from an unknown device
+func (rf *riskFacts) IsKnownDevice() (bool, error) {
+	if rf.req.DeviceID == "" {
+		return false, nil
+	}
+	return repository.RiskRepository.IsKnownDevice(rf.db, rf.req.UserID, rf.req.DeviceID)
+}
+
+// The user's own wallets are always known recipients
+func (rf *riskFacts) IsKnownRecipient() (bool, error) {
+	if rf.req.ToWalletID == "" {
+		return true, nil
+	}
+	own, err := repository.WalletRepository.VerifyUserWalletPossession(rf.db, rf.req.UserID, rf.req.ToWalletID)
+	if err != nil || own {
+		return own, err
+	}
+	return repository.RiskRepository.HasTransferredTo(rf.db, rf.req.UserID, rf.req.ToWalletID)
+}
+
+func (rf *riskFacts) AverageAmount(historySize int) (decimal.Decimal, int64, error) {
+	return repository.RiskRepository.AverageRecentAmount(rf.db, rf.req.UserID, rf.req.TxnType, historySize)
+}
+
+// Lock a risk review which is waiting for review, the operator can't review their own transaction
+func lockPendingRiskReview(tx *gorm.DB, operatorID string, reviewID string) (entity.RiskReview, error) {
+	review, err := repository.RiskRepository.LockReview(tx, reviewID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.RiskReview{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageRiskReviewNotFound, nil)
+		}
+		return entity.RiskReview{}, err
+	}
+	if review.ReviewStatus != constant.RiskReviewStatusPending {
+		return entity.RiskReview{}, newServiceError(ErrTypeInvalidRequestBody, ErrMessageRiskReviewClosed, nil)
+	}
+	if review.UserID == operatorID {
+		return entity.RiskReview{}, newServiceError(ErrTypePermissionDenied, ErrMessageSelfReview, nil)
+	}
+	return review, nil
+}
+
+// Map the error of a risk review to the status code and the service error
+func mapRiskReviewError(err error) (int, error) {
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		if serviceErr.ErrType == ErrTypePermissionDenied {
+			return http.StatusForbidden, serviceErr
+		}
+		return http.StatusBadRequest, serviceErr
+	}
+	return mapTransferError(err)
+}
+
+func toRiskReviewModel(review entity.RiskReview) model.RiskReview {
+	matchedRules := []string{}
+	if review.MatchedRules != "" {
+		matchedRules = strings.Split(review.MatchedRules, ",")
+	}
+	return model.RiskReview{
+		ReviewID:            review.ReviewID,
+		UserID:              review.UserID,
+		TxnType:             review.TxnType,
+		FromWalletID:        review.FromWalletID,
+		ToWalletID:          review.ToWalletID.String,
+		Amount:              review.Amount,
+		DeviceID:            review.DeviceID.String,
+		RiskScore:           review.RiskScore,
+		MatchedRules:        matchedRules,
+		ReviewStatus:        review.ReviewStatus,
+		TxnID:               review.TxnID.String,
+		ReviewBy:            review.ReviewBy.String,
+		ReviewNote:          review.ReviewNote.String,
+		PayoutDestinationID: review.PayoutDestinationID.String,
+		CreateTime:          review.CreateTime,
+	}
+}
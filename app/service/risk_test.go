@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/repository"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Risk repository keeping the reviews in memory
+type fakeReviewRiskRepository struct {
+	repository.IRiskRepository
+	reviews map[string]entity.RiskReview
+}
+
+func (r *fakeReviewRiskRepository) LockReview(db *gorm.DB, reviewID string) (entity.RiskReview, error) {
+	review, found := r.reviews[reviewID]
+	if !found {
+		return entity.RiskReview{}, gorm.ErrRecordNotFound
+	}
+	return review, nil
+}
+
+func TestReviewOwnRiskReview(t *testing.T) {
+	risks := &fakeReviewRiskRepository{reviews: map[string]entity.RiskReview{
+		"review-1": {
+			ReviewID:     "review-1",
+			UserID:       "staff",
+			TxnType:      constant.TxnTypeWithdraw,
+			FromWalletID: "wallet-1",
+			Amount:       decimal.NewFromInt(5000),
+			ReviewStatus: constant.RiskReviewStatusPending,
+			CreateTime:   time.Now(),
+		},
+	}}
+	original := repository.RiskRepository
+	repository.RiskRepository = risks
+	t.Cleanup(func() { repository.RiskRepository = original })
+
+	// The staff user can't release or reject the hold on their own withdraw
+	_, statusCode, err := RiskService.ApproveReview(context.Background(), "staff", "review-1", "")
+	assert.Equal(t, statusCode, http.StatusForbidden)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageSelfReview)
+	_, statusCode, err = RiskService.RejectReview(context.Background(), "staff", "review-1", "")
+	assert.Equal(t, statusCode, http.StatusForbidden)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageSelfReview)
+	assert.Equal(t, risks.reviews["review-1"].ReviewStatus, constant.RiskReviewStatusPending)
+}
+
+// Payout repository keeping the destinations in memory
+type fakeDestinationPayoutRepository struct {
+	repository.IPayoutRepository
+	destinations map[string]entity.PayoutDestination
+}
+
+func (r *fakeDestinationPayoutRepository) GetDestinationByID(db *gorm.DB, destinationID string) (entity.PayoutDestination, error) {
+	destination, found := r.destinations[destinationID]
+	if !found {
+		return entity.PayoutDestination{}, gorm.ErrRecordNotFound
+	}
+	return destination, nil
+}
+
+func TestApprovePayoutReviewUnverifiedDestination(t *testing.T) {
+	risks := &fakeReviewRiskRepository{reviews: map[string]entity.RiskReview{
+		"review-1": {
+			ReviewID:            "review-1",
+			UserID:              "customer",
+			TxnType:             constant.TxnTypeWithdraw,
+			FromWalletID:        "wallet-1",
+			Amount:              decimal.NewFromInt(5000),
+			ReviewStatus:        constant.RiskReviewStatusPending,
+			PayoutDestinationID: sql.NullString{String: "destination-1", Valid: true},
+			CreateTime:          time.Now(),
+		},
+	}}
+	payouts := &fakeDestinationPayoutRepository{destinations: map[string]entity.PayoutDestination{
+		"destination-1": {DestinationID: "destination-1", UserID: "customer", DestinationStatus: constant.PayoutDestinationStatusPending},
+	}}
+	originalRisks, originalPayouts := repository.RiskRepository, repository.PayoutRepository
+	repository.RiskRepository, repository.PayoutRepository = risks, payouts
+	t.Cleanup(func() { repository.RiskRepository, repository.PayoutRepository = originalRisks, originalPayouts })
+
+	// The held payout is only queued to a destination which is still verified, the review stays pending otherwise
+	_, statusCode, err := RiskService.ApproveReview(context.Background(), "reviewer", "review-1", "")
+	assert.Equal(t, statusCode, http.StatusBadRequest)
+	assert.Equal(t, serviceErrMessage(err), ErrMessageDestinationNotVerified)
+	assert.Equal(t, risks.reviews["review-1"].ReviewStatus, constant.RiskReviewStatusPending)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/screening"
+	"wallet-app-server/app/tracing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Screening service interface
+type IScreeningService interface {
+	ReloadLists(ctx context.Context, operatorID string) (model.ScreeningListVersion, int, error)
+	ListOpenCases(ctx context.Context) ([]model.ScreeningCase, int, error)
+	CloseCase(ctx context.Context, operatorID string, caseID string, resolution string, note string) (model.ScreeningCase, int, error)
+}
+
+// Screening service instance
+var ScreeningService IScreeningService = &screeningServiceImpl{}
+
+// Screening service implementation
+type screeningServiceImpl struct{}
+
+// Reload the sanctions list files in the configuration, and record the loaded version
+// The screenings keep using the current lists until the new lists are completely loaded,
+// and if the files can't be loaded, the current lists are kept
+func (ss *screeningServiceImpl) ReloadLists(ctx context.Context, operatorID string) (model.ScreeningListVersion, int, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.ReloadLists")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	listSet, err := screening.DefaultScreener.Reload(screening.ConfiguredListFiles())
+	if err != nil {
+		logger.ErrorfContext(ctx, "Failed to reload sanctions lists, err: %s", err.Error())
+		return model.ScreeningListVersion{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageScreeningListError, err)
+	}
+	if err := repository.ScreeningRepository.CreateListVersion(conn, entity.ScreeningListVersion{
+		ListVersion: listSet.Version,
+		EntryCount:  len(listSet.Entries),
+		LoadBy:      operatorID,
+		LoadTime:    listSet.LoadTime,
+	}); err != nil {
+		return model.ScreeningListVersion{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.InfofContext(ctx, "Sanctions lists reloaded, version: %s, entries: %d, operatorID: %s", listSet.Version, len(listSet.Entries), operatorID)
+	return model.ScreeningListVersion{ListVersion: listSet.Version, EntryCount: len(listSet.Entries), LoadTime: listSet.LoadTime}, http.StatusOK, nil
+}
+
+func (ss *screeningServiceImpl) ListOpenCases(ctx context.Context) ([]model.ScreeningCase, int, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.ListOpenCases")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	screeningCases, err := repository.ScreeningRepository.ListCasesByStatus(conn, constant.ScreeningCaseStatusOpen)
+	if err != nil {
+		return []model.ScreeningCase{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Construct result list of model.ScreeningCase
+	result := make([]model.ScreeningCase, 0, len(screeningCases))
+	for _, screeningCase := range screeningCases {
+		result = append(result, toScreeningCaseModel(screeningCase))
+	}
+	return result, http.StatusOK, nil
+}
+
+// Close an open screening case as cleared (a false positive) or confirmed (a true hit)
+// After the case is cleared, the same name of the same subject passes the screening against the same list version
+func (ss *screeningServiceImpl) CloseCase(ctx context.Context, operatorID string, caseID string, resolution string, note string) (model.ScreeningCase, int, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.CloseCase")
+	defer span.End()
+	if resolution != constant.ScreeningCaseStatusCleared && resolution != constant.ScreeningCaseStatusConfirmed {
+		return model.ScreeningCase{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidCaseResolution, nil)
+	}
+	var result entity.ScreeningCase
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the case, so that it can't be closed twice concurrently
+		screeningCase, err := repository.ScreeningRepository.LockCase(tx, caseID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return newServiceError(ErrTypeInvalidRequestBody, ErrMessageScreeningCaseNotFound, nil)
+			}
+			return err
+		}
+		if screeningCase.CaseStatus != constant.ScreeningCaseStatusOpen {
+			return newServiceError(ErrTypeInvalidRequestBody, ErrMessageScreeningCaseClosed, nil)
+		}
+		if err := repository.ScreeningRepository.UpdateCase(tx, caseID, resolution, operatorID, note, time.Now()); err != nil {
+			return err
+		}
+		result, err = repository.ScreeningRepository.LockCase(tx, caseID)
+		return err
+	}); err != nil {
+		var serviceErr ServiceError
+		if errors.As(err, &serviceErr) {
+			return model.ScreeningCase{}, http.StatusBadRequest, serviceErr
+		}
+		return model.ScreeningCase{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.InfofContext(ctx, "Screening case %s, caseID: %s, operatorID: %s", resolution, caseID, operatorID)
+	return toScreeningCaseModel(result), http.StatusOK, nil
+}
+
+// A name to be screened against the sanctions lists
+// SubjectRef identifies whom the name belongs to, e.g. the user ID, or the IBAN of a payout destination
+type screeningSubject struct {
+	UserID     string
+	SubjectRef string
+	Name       string
+	Context    string
+}
+
+// Screen the name against the current sanctions lists
+// A hit blocks the action and opens a screening case for the compliance review. While the case is open or
+// confirmed, the name stays blocked without opening another case, and after the case is cleared, the name passes
+// until the lists change
+func screenName(db *gorm.DB, subject screeningSubject) (int, error) {
+	return screenNameAgainst(db, screening.DefaultScreener.Current(), subject)
+}
+
+// Screen the name against the given sanctions lists, nil if no list is loaded
+// Used when the version of the lists the name is screened against has to be recorded
+func screenNameAgainst(db *gorm.DB, listSet *screening.ListSet, subject screeningSubject) (int, error) {
+	if listSet == nil {
+		return http.StatusOK, nil
+	}
+	matches := listSet.Screen(subject.Name, screening.ConfiguredThresholds())
+	if len(matches) == 0 {
+		return http.StatusOK, nil
+	}
+	// Check the previous case of the name
+	latestCase, err := repository.ScreeningRepository.GetLatestCase(db, subject.SubjectRef, subject.Name, listSet.Version)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if err == nil {
+		if latestCase.CaseStatus == constant.ScreeningCaseStatusCleared {
+			return http.StatusOK, nil
+		}
+		logger.WarnfContext(db.Statement.Context, "Screening hit, %s blocked by case %s, subjectRef: %s", subject.Context, latestCase.CaseID, subject.SubjectRef)
+		return http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageScreeningHit, nil)
+	}
+	// Open a case
+	matchesJSON, err := json.Marshal(matches)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	screeningCase := entity.ScreeningCase{
+		CaseID:           uuid.New().String(),
+		UserID:           sql.NullString{String: subject.UserID, Valid: subject.UserID != ""},
+		SubjectRef:       subject.SubjectRef,
+		ScreenedName:     subject.Name,
+		ScreeningContext: subject.Context,
+		ListVersion:      listSet.Version,
+		MatchScore:       decimal.NewFromFloat(matches[0].Score).Round(4),
+		Matches:          string(matchesJSON),
+		CaseStatus:       constant.ScreeningCaseStatusOpen,
+		CreateTime:       time.Now(),
+	}
+	if err := repository.ScreeningRepository.CreateCase(db, screeningCase); err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.WarnfContext(db.Statement.Context, "Screening hit, %s blocked and case %s opened, subjectRef: %s, best match: %s %s (%s), score: %s",
+		subject.Context, screeningCase.CaseID, subject.SubjectRef, matches[0].Source, matches[0].EntryID, matches[0].EntryName, screeningCase.MatchScore.String())
+	return http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageScreeningHit, nil)
+}
+
+// Screen the owner of the wallet, if the user has never transferred to it
+// The user's own wallets and unknown wallets are not screened, the transfer to an unknown wallet fails anyway
+func screenCounterparty(db *gorm.DB, userID string, toWalletID string) (int, error) {
+	own, err := repository.WalletRepository.VerifyUserWalletPossession(db, userID, toWalletID)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if own {
+		return http.StatusOK, nil
+	}
+	transferred, err := repository.RiskRepository.HasTransferredTo(db, userID, toWalletID)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if transferred {
+		return http.StatusOK, nil
+	}
+	ownerID, err := repository.WalletRepository.GetWalletOwnerID(db, toWalletID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return http.StatusOK, nil
+		}
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	owner, err := repository.UserRepository.GetUserByID(db, ownerID)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Screen the full name, or the user name if the owner has no full name
+	name := owner.UserName
+	if owner.FullName.Valid && strings.TrimSpace(owner.FullName.String) != "" {
+		name = owner.FullName.String
+	}
+	return screenName(db, screeningSubject{UserID: userID, SubjectRef: ownerID, Name: name, Context: constant.ScreeningContextTransfer})
+}
+
+// The version of the lists, NULL if no list is loaded
+func screeningListVersion(listSet *screening.ListSet) sql.NullString {
+	if listSet == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: listSet.Version, Valid: true}
+}
+
+func toScreeningCaseModel(screeningCase entity.ScreeningCase) model.ScreeningCase {
+	matches := []model.ScreeningMatch{}
+	if err := json.Unmarshal([]byte(screeningCase.Matches), &matches); err != nil {
+		logger.Errorf("Failed to parse matches of screening case %s, err: %s", screeningCase.CaseID, err.Error())
+	}
+	return model.ScreeningCase{
+		CaseID:           screeningCase.CaseID,
+		UserID:           screeningCase.UserID.String,
+		SubjectRef:       screeningCase.SubjectRef,
+		ScreenedName:     screeningCase.ScreenedName,
+		ScreeningContext: screeningCase.ScreeningContext,
+		ListVersion:      screeningCase.ListVersion,
+		MatchScore:       screeningCase.MatchScore,
+		Matches:          matches,
+		CaseStatus:       screeningCase.CaseStatus,
+		ReviewBy:         screeningCase.ReviewBy.String,
+		ReviewNote:       screeningCase.ReviewNote.String,
+		CreateTime:       screeningCase.CreateTime,
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Connection pool beginning the transactions without a DB, the statements are never run in the dry run mode
+type fakeConnPool struct {
+	gorm.ConnPool
+}
+
+func (p *fakeConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &fakeTx{}, nil
+}
+
+// Transaction committed and rolled back without a DB, the fake repositories don't roll back what they recorded
+type fakeTx struct {
+	gorm.ConnPool
+}
+
+func (tx *fakeTx) Commit() error {
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	return nil
+}
+
+// The services run against fake repositories, so the DB only has to build the sessions and the transactions passed to them
+func TestMain(m *testing.M) {
+	logDir, _ := os.MkdirTemp("", "service")
+	config.Cfg.Logging.LogLevel = "critical"
+	config.Cfg.Logging.LogFormat = "text"
+	config.Cfg.Logging.LogFilePath = filepath.Join(logDir, "server.log")
+	logger.Init()
+	conn, err := gorm.Open(postgres.New(postgres.Config{Conn: &fakeConnPool{}}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		panic(err)
+	}
+	db.DB = conn
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
+
+	"gorm.io/gorm"
+)
+
+// Balance snapshot service interface
+type IBalanceSnapshotService interface {
+	TakeDailySnapshot(ctx context.Context, now time.Time) (int64, int, error)
+}
+
+// Balance snapshot service instance
+var BalanceSnapshotService IBalanceSnapshotService = &balanceSnapshotServiceImpl{}
+
+// Balance snapshot service implementation
+type balanceSnapshotServiceImpl struct{}
+
+// Take the balance snapshot of every wallet at the last midnight before now, once the lag after it has passed
+// Nothing is done if the snapshot has been taken already
+// The previous snapshot is computed again first, so that a transaction stamped before its midnight which committed
+// after it was taken is counted in the previous snapshot and in the new one
+func (bss *balanceSnapshotServiceImpl) TakeDailySnapshot(ctx context.Context, now time.Time) (int64, int, error) {
+	ctx, span := tracing.Start(ctx, "BalanceSnapshotService.TakeDailySnapshot")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	lagged := now.Add(-time.Duration(config.Cfg.Snapshot.LagInSecs) * time.Second)
+	snapshotTime := time.Date(lagged.Year(), lagged.Month(), lagged.Day(), 0, 0, 0, 0, lagged.Location())
+	exists, err := repository.BalanceSnapshotRepository.ExistsSnapshot(conn, snapshotTime)
+	if err != nil {
+		logger.ErrorfContext(ctx, "Failed to check balance snapshot, err: %s", err.Error())
+		return 0, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if exists {
+		return 0, http.StatusOK, nil
+	}
+	// The snapshot of every wallet is a single statement, which may take longer than the statement timeout
+	var count int64
+	err = db.Writer(db.WithoutStatementTimeout(ctx)).Transaction(func(tx *gorm.DB) error {
+		previousTime, err := repository.BalanceSnapshotRepository.GetPreviousSnapshotTime(tx, snapshotTime)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err == nil {
+			corrected, err := repository.BalanceSnapshotRepository.RecomputeSnapshots(tx, previousTime)
+			if err != nil {
+				return err
+			}
+			if corrected > 0 {
+				logger.WarnfContext(ctx, "Balance snapshot corrected for the transactions committed after it was taken, snapshotTime: %s, walletCount: %d", previousTime.Format(time.RFC3339), corrected)
+			}
+		}
+		count, err = repository.BalanceSnapshotRepository.CreateSnapshots(tx, snapshotTime)
+		return err
+	})
+	if err != nil {
+		logger.ErrorfContext(ctx, "Failed to create balance snapshot, err: %s", err.Error())
+		return 0, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	logger.InfofContext(ctx, "Balance snapshot taken, snapshotTime: %s, walletCount: %d", snapshotTime.Format(time.RFC3339), count)
+	return count, http.StatusOK, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/repository"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Snapshot repository holding the snapshots of one wallet in memory, computed from the postings as in the DB
+type fakeSnapshotRepository struct {
+	snapshots []entity.WalletBalanceSnapshot
+	created   []time.Time
+	postings  *fakePostingRepository
+}
+
+func (r *fakeSnapshotRepository) GetLatestSnapshot(db *gorm.DB, walletID string, asOf time.Time) (entity.WalletBalanceSnapshot, error) {
+	var latest entity.WalletBalanceSnapshot
+	for _, snapshot := range r.snapshots {
+		if !snapshot.SnapshotTime.After(asOf) && snapshot.SnapshotTime.After(latest.SnapshotTime) {
+			latest = snapshot
+		}
+	}
+	if latest.SnapshotTime.IsZero() {
+		return latest, gorm.ErrRecordNotFound
+	}
+	return latest, nil
+}
+
+func (r *fakeSnapshotRepository) ExistsSnapshot(db *gorm.DB, snapshotTime time.Time) (bool, error) {
+	for _, snapshot := range r.snapshots {
+		if snapshot.SnapshotTime.Equal(snapshotTime) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeSnapshotRepository) GetPreviousSnapshotTime(db *gorm.DB, snapshotTime time.Time) (time.Time, error) {
+	previous, err := r.GetLatestSnapshot(db, "wallet-a", snapshotTime.Add(-time.Nanosecond))
+	return previous.SnapshotTime, err
+}
+
+func (r *fakeSnapshotRepository) CreateSnapshots(db *gorm.DB, snapshotTime time.Time) (int64, error) {
+	r.created = append(r.created, snapshotTime)
+	r.snapshots = append(r.snapshots, entity.WalletBalanceSnapshot{WalletID: "wallet-a", SnapshotTime: snapshotTime, Balance: r.compute(snapshotTime)})
+	return 1, nil
+}
+
+func (r *fakeSnapshotRepository) RecomputeSnapshots(db *gorm.DB, snapshotTime time.Time) (int64, error) {
+	balance := r.compute(snapshotTime)
+	for i, snapshot := range r.snapshots {
+		if snapshot.SnapshotTime.Equal(snapshotTime) && !snapshot.Balance.Equal(balance) {
+			r.snapshots[i].Balance = balance
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// The previous snapshot plus the postings after it
+func (r *fakeSnapshotRepository) compute(snapshotTime time.Time) decimal.Decimal {
+	previous, _ := r.GetLatestSnapshot(nil, "wallet-a", snapshotTime.Add(-time.Nanosecond))
+	delta, _ := r.postings.SumWalletPostingsBetween(nil, "wallet-a", previous.SnapshotTime, snapshotTime)
+	return previous.Balance.Add(delta)
+}
+
+// Transaction repository summing the postings of one wallet in memory
+type fakePostingRepository struct {
+	repository.ITransactionRepository
+	postings map[time.Time]decimal.Decimal
+	sums     [][2]time.Time
+}
+
+func (r *fakePostingRepository) SumWalletPostingsBetween(db *gorm.DB, walletID string, after time.Time, until time.Time) (decimal.Decimal, error) {
+	r.sums = append(r.sums, [2]time.Time{after, until})
+	sum := decimal.Zero
+	for postingTime, amount := range r.postings {
+		if postingTime.After(after) && !postingTime.After(until) {
+			sum = sum.Add(amount)
+		}
+	}
+	return sum, nil
+}
+
+func day(d int, hour int) time.Time {
+	return time.Date(2025, time.June, d, hour, 0, 0, 0, time.UTC)
+}
+
+func fakeSnapshots(t *testing.T) (*fakeSnapshotRepository, *fakePostingRepository) {
+	snapshotRepository := &fakeSnapshotRepository{snapshots: []entity.WalletBalanceSnapshot{
+		{WalletID: "wallet-a", SnapshotTime: day(2, 0), Balance: decimal.RequireFromString("100")},
+	}}
+	postingRepository := &fakePostingRepository{postings: map[time.Time]decimal.Decimal{
+		day(1, 12): decimal.RequireFromString("100"),
+		day(2, 12): decimal.RequireFromString("-30"),
+		day(3, 12): decimal.RequireFromString("50"),
+	}}
+	snapshotRepository.postings = postingRepository
+	originalSnapshotRepository, originalTransactionRepository := repository.BalanceSnapshotRepository, repository.TransactionRepository
+	repository.BalanceSnapshotRepository, repository.TransactionRepository = snapshotRepository, postingRepository
+	t.Cleanup(func() {
+		repository.BalanceSnapshotRepository, repository.TransactionRepository = originalSnapshotRepository, originalTransactionRepository
+	})
+	return snapshotRepository, postingRepository
+}
+
+func TestGetWalletBalanceAsOf(t *testing.T) {
+	_, postingRepository := fakeSnapshots(t)
+	// From the snapshot, only the postings after it are summed
+	balance, err := getWalletBalanceAsOf(nil, "wallet-a", day(3, 0))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, balance.String(), "70")
+	assert.Equal(t, postingRepository.sums[0], [2]time.Time{day(2, 0), day(3, 0)})
+	balance, _ = getWalletBalanceAsOf(nil, "wallet-a", day(4, 0))
+	assert.Equal(t, balance.String(), "120")
+
+	// Before the first snapshot, all the postings are summed
+	balance, err = getWalletBalanceAsOf(nil, "wallet-a", day(1, 18))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, balance.String(), "100")
+	assert.Equal(t, postingRepository.sums[2][0].IsZero(), true)
+}
+
+func TestTakeDailySnapshot(t *testing.T) {
+	snapshotRepository, _ := fakeSnapshots(t)
+	// Taken at the last midnight
+	count, _, err := BalanceSnapshotService.TakeDailySnapshot(context.Background(), day(3, 8))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, snapshotRepository.created, []time.Time{day(3, 0)})
+	// Not taken twice
+	count, _, err = BalanceSnapshotService.TakeDailySnapshot(context.Background(), day(3, 20))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(0))
+	assert.Equal(t, len(snapshotRepository.created), 1)
+}
+
+func TestTakeDailySnapshotLag(t *testing.T) {
+	snapshotRepository, _ := fakeSnapshots(t)
+	lag := config.Cfg.Snapshot.LagInSecs
+	config.Cfg.Snapshot.LagInSecs = 3600
+	t.Cleanup(func() { config.Cfg.Snapshot.LagInSecs = lag })
+	// Within the lag after midnight, the snapshot of the day before is the latest, and it's taken already
+	count, _, err := BalanceSnapshotService.TakeDailySnapshot(context.Background(), day(3, 0).Add(30*time.Minute))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(0))
+	// Taken once the lag has passed
+	count, _, err = BalanceSnapshotService.TakeDailySnapshot(context.Background(), day(3, 1))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, snapshotRepository.created, []time.Time{day(3, 0)})
+}
+
+func TestTakeDailySnapshotLateCommit(t *testing.T) {
+	snapshotRepository, postingRepository := fakeSnapshots(t)
+	_, _, err := BalanceSnapshotService.TakeDailySnapshot(context.Background(), day(3, 8))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, snapshotRepository.snapshots[1].Balance.String(), "70")
+
+	// A transaction stamped before midnight commits after the snapshot was taken
+	postingRepository.postings[day(2, 23)] = decimal.RequireFromString("-20")
+
+	// The next snapshot counts it, and corrects the previous one
+	_, _, err = BalanceSnapshotService.TakeDailySnapshot(context.Background(), day(4, 8))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, snapshotRepository.snapshots[1].Balance.String(), "50")
+	assert.Equal(t, snapshotRepository.snapshots[2].Balance.String(), "100")
+	balance, _ := getWalletBalanceAsOf(nil, "wallet-a", day(3, 6))
+	assert.Equal(t, balance.String(), "50")
+	balance, _ = getWalletBalanceAsOf(nil, "wallet-a", day(4, 6))
+	assert.Equal(t, balance.String(), "100")
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
+	"wallet-app-server/app/repository"
+	"wallet-app-server/app/statement"
+	"wallet-app-server/app/tracing"
+
+	"gorm.io/gorm"
+)
+
+// Statement service interface
+type IStatementService interface {
+	ExportStatement(ctx context.Context, currentUserID string, walletID string, fromTime time.Time, toTime time.Time, writer statement.Writer) (int, error)
+	ExportWalletStatement(ctx context.Context, walletID string, fromTime time.Time, toTime time.Time, writer statement.Writer) (int, error)
+}
+
+// Statement service instance
+var StatementService IStatementService = &statementServiceImpl{}
+
+// Statement service implementation
+type statementServiceImpl struct{}
+
+// Stream the statement of the current user's wallet in the time range (fromTime, toTime] to the writer
+// The statement has the opening balance, every transaction with its running balance, and the closing balance
+// Nothing is written to the writer if the request is not valid
+func (ss *statementServiceImpl) ExportStatement(ctx context.Context, currentUserID string, walletID string, fromTime time.Time, toTime time.Time, writer statement.Writer) (int, error) {
+	ctx, span := tracing.Start(ctx, "StatementService.ExportStatement")
+	defer span.End()
+	reader := db.Reader().WithContext(ctx)
+	// Verify from wallet is belong to the current user
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(reader, currentUserID, walletID)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if !valid {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	return ss.ExportWalletStatement(ctx, walletID, fromTime, toTime, writer)
+}
+
+// Stream the statement of any wallet, without checking the wallet possession
+// This is for the operators (e.g. the statement export tool)
+func (ss *statementServiceImpl) ExportWalletStatement(ctx context.Context, walletID string, fromTime time.Time, toTime time.Time, writer statement.Writer) (int, error) {
+	ctx, span := tracing.Start(ctx, "StatementService.ExportWalletStatement")
+	defer span.End()
+	reader := db.Reader().WithContext(ctx)
+	// Verify the time range
+	if !fromTime.Before(toTime) {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInvalidTimeRange, nil)
+	}
+	// The statement can be read from the replica
+	// Verify the wallet exists
+	wallet, err := repository.WalletRepository.GetWalletByID(reader, walletID)
+	if err != nil {
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if wallet.WalletID == "" {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	// Read everything in one repeatable read transaction,
+	// so that the balances and the transactions are from the same snapshot even if new transactions are committed
+	started := false
+	if err := reader.Transaction(func(tx *gorm.DB) error {
+		// Opening and closing balances
+		// The closing balance is needed before the transactions by some formats (e.g. camt.053)
+		openingBalance, err := getWalletBalanceAsOf(tx, walletID, fromTime)
+		if err != nil {
+			return err
+		}
+		closingBalance, err := getWalletBalanceAsOf(tx, walletID, toTime)
+		if err != nil {
+			return err
+		}
+		summary := model.StatementSummary{
+			WalletID:       walletID,
+			FromTime:       fromTime,
+			ToTime:         toTime,
+			OpeningBalance: openingBalance,
+			ClosingBalance: closingBalance,
+		}
+		started = true
+		if err := writer.WriteOpening(summary); err != nil {
+			return err
+		}
+		// Transactions with the running balance
+		runningBalance := openingBalance
+		if err := repository.TransactionRepository.IterateWalletStatement(tx, walletID, fromTime, toTime, func(row repository.WalletStatementRow) error {
+			runningBalance = runningBalance.Add(row.PostingAmount)
+			summary.TxnCount++
+			return writer.WriteLine(model.StatementLine{
+				TxnID:          row.TxnID,
+				FromWalletID:   row.FromWalletID,
+				ToWalletID:     row.ToWalletID,
+				TxnAmount:      row.TxnAmount,
+				TxnTypeDesc:    row.TxnType,
+				TxnTime:        row.TxnTime,
+				PostingAmount:  row.PostingAmount,
+				RunningBalance: runningBalance,
+			})
+		}); err != nil {
+			return err
+		}
+		// Closing balance
+		if !runningBalance.Equal(closingBalance) {
+			logger.ErrorfContext(ctx, "Statement running balance doesn't match the closing balance, walletID: %s, runningBalance: %s, closingBalance: %s",
+				walletID, runningBalance.StringFixed(2), closingBalance.StringFixed(2))
+		}
+		return writer.WriteClosing(summary)
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}); err != nil {
+		logger.ErrorfContext(ctx, "Failed to export statement, walletID: %s, err: %s", walletID, err.Error())
+		if !started {
+			return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		}
+		return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageStatementWriteError, err)
+	}
+	return http.StatusOK, nil
+}
@@ -1,13 +1,17 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
+	"wallet-app-server/app/config"
 	"wallet-app-server/app/constant"
 	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
 	"wallet-app-server/app/model"
 	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
 
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
@@ -15,8 +19,8 @@ import (
 
 // Transaction service interface
 type ITransactionService interface {
-	Transfer(currentUserID string, fromWalletID string, toWalletID string, amount decimal.Decimal) (string, int, error)
-	ListHistory(currentUserID string, walletID string) ([]model.TransactionHistory, int, error)
+	Transfer(ctx context.Context, currentUserID string, fromWalletID string, toWalletID string, amount decimal.Decimal, deviceID string) (model.TransferResult, int, error)
+	ListHistory(ctx context.Context, currentUserID string, walletID string) ([]model.TransactionHistory, int, error)
 }
 
 // Transaction service instance
@@ -25,54 +29,110 @@ var TransactionService ITransactionService = &transactionServiceImpl{}
 // Transaction service implementation
 type transactionServiceImpl struct{}
 
-func (ts *transactionServiceImpl) Transfer(currentUserID string, fromWalletID string, toWalletID string, amount decimal.Decimal) (string, int, error) {
+func (ts *transactionServiceImpl) Transfer(ctx context.Context, currentUserID string, fromWalletID string, toWalletID string, amount decimal.Decimal, deviceID string) (model.TransferResult, int, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Transfer")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	// The fee schedules and the limit rules as of the request, even if reloaded meanwhile
+	cfg := config.Current()
 	// Verify from wallet is belong to the current user
-	valid, err := repository.WalletRepository.VerifyUserWalletPossession(db.DB, currentUserID, fromWalletID)
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, fromWalletID)
 	if err != nil {
-		return "", http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		return model.TransferResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
 	if !valid {
-		return "", http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+		return model.TransferResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
 	}
-	var result string
-	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+	// Reject the invalid transfer before the risk assessment, so that it's never held for review
+	if amount.IsNegative() || amount.IsZero() {
+		return model.TransferResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+	}
+	if fromWalletID == toWalletID {
+		return model.TransferResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageSameWalletTransfer, nil)
+	}
+	if statusCode, err := checkKYCCapability(conn, currentUserID, constant.TxnTypeTransfer, amount); err != nil {
+		return model.TransferResult{}, statusCode, err
+	}
+	// Screen the recipient against the sanctions lists, if the user has never transferred to the wallet
+	if statusCode, err := screenCounterparty(conn, currentUserID, toWalletID); err != nil {
+		return model.TransferResult{}, statusCode, err
+	}
+	var result model.TransferResult
+	var denied bool
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
 		// Record current time
 		currTime := time.Now()
-		// Transfer money
-		if err := repository.WalletRepository.Transfer(tx, currentUserID, fromWalletID, toWalletID, amount); err != nil {
+		// Lock the user, so that the concurrent transactions of the user are assessed one after another
+		if _, err := repository.UserRepository.LockUser(tx, currentUserID); err != nil {
 			return err
 		}
-		// Create transaction history
-		txnID, err := repository.TransactionRepository.CreateTransactionHistory(tx, fromWalletID, toWalletID, constant.TxnTypeTransfer, amount, currTime)
+		// Assess the risk of the transfer, the decision of a denied or held transfer is committed without the transfer
+		assessment, err := assessRisk(tx, riskRequest{
+			UserID:       currentUserID,
+			TxnType:      constant.TxnTypeTransfer,
+			FromWalletID: fromWalletID,
+			ToWalletID:   toWalletID,
+			Amount:       amount,
+			DeviceID:     deviceID,
+		}, currTime)
 		if err != nil {
 			return err
 		}
-		result = txnID
-		// Create user activity
-		activityDetail := fmt.Sprintf("User transfer amount %s from wallet %s to wallet %s", amount.StringFixed(2), fromWalletID, toWalletID)
-		if err := repository.UserRepository.CreateUserActivity(tx, currentUserID, constant.UserActTypeTransfer, activityDetail, fromWalletID, currTime); err != nil {
-			return err
+		if assessment.Denied {
+			denied = true
+			return nil
 		}
-		return nil
-	}); err != nil {
-		// If the underlying error is business logic related error
-		// return bad request status code
-		// otherwise return internal server error status code
-		if err.Error() == repository.ErrNegativeOrZeroAmount {
-			return "", http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+		if assessment.ReviewID != "" {
+			result = model.TransferResult{Status: constant.TxnStatusPendingReview, ReviewID: assessment.ReviewID}
+			return nil
 		}
-		if err.Error() == repository.ErrInsufficientBalance {
-			return "", http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInsufficientBalance, nil)
+		// Hold the large transfer for approval, the amount is held until the approval is closed
+		if isLargeTransfer(cfg, amount) {
+			holdTxnID, err := holdTransfer(tx, fromWalletID, amount, currTime)
+			if err != nil {
+				return err
+			}
+			summary := fmt.Sprintf("Transfer amount %s from wallet %s to wallet %s", amount.StringFixed(2), fromWalletID, toWalletID)
+			operation, err := requestOperation(tx, currentUserID, constant.OperationTypeTransfer, transferOperation{
+				UserID:       currentUserID,
+				FromWalletID: fromWalletID,
+				ToWalletID:   toWalletID,
+				Amount:       amount,
+				DeviceID:     deviceID,
+				DecisionID:   assessment.DecisionID,
+				HoldTxnID:    holdTxnID,
+			}, summary, "")
+			if err != nil {
+				return err
+			}
+			result = model.TransferResult{Status: constant.TxnStatusPendingApproval, OperationID: operation.OperationID}
+			return nil
 		}
-		return "", http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		// Transfer
+		txnID, err := transfer(tx, cfg, currentUserID, fromWalletID, toWalletID, amount, currTime)
+		if err != nil {
+			return err
+		}
+		result = model.TransferResult{TxnID: txnID, Status: constant.TxnStatusCompleted}
+		// Link the risk decision to the transaction
+		return completeRiskDecision(tx, assessment.DecisionID, currentUserID, deviceID, txnID, currTime)
+	}); err != nil {
+		statusCode, serviceErr := mapTransferError(err)
+		return model.TransferResult{}, statusCode, serviceErr
+	}
+	if denied {
+		return model.TransferResult{}, http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageRiskDenied, nil)
 	}
-	// Return txnID as result, and success status code
 	return result, http.StatusOK, nil
 }
 
-func (ts *transactionServiceImpl) ListHistory(currentUserID string, walletID string) ([]model.TransactionHistory, int, error) {
+func (ts *transactionServiceImpl) ListHistory(ctx context.Context, currentUserID string, walletID string) ([]model.TransactionHistory, int, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.ListHistory")
+	defer span.End()
+	// The history can be read from the replica
+	conn := db.Reader().WithContext(ctx)
 	// Verify from wallet is belong to the current user
-	valid, err := repository.WalletRepository.VerifyUserWalletPossession(db.DB, currentUserID, walletID)
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, walletID)
 	if err != nil {
 		return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
@@ -80,12 +140,122 @@ func (ts *transactionServiceImpl) ListHistory(currentUserID string, walletID str
 		return nil, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
 	}
 	// List transaction history
-	txnHistoryList, err := repository.TransactionRepository.ListTransactionHistory(db.DB, walletID)
+	txnHistoryList, err := repository.TransactionRepository.ListTransactionHistory(conn, walletID)
 	if err != nil {
 		return nil, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
-	// Construct result list of model.TransactionHistory
-	var result = make([]model.TransactionHistory, 0, len(txnHistoryList))
+	return toTransactionHistoryModels(txnHistoryList), http.StatusOK, nil
+}
+
+// Transfer from the user's wallet to another wallet, and record the transaction history, the fee and the user activity
+// Should call this function inside a transaction
+// Return the transaction ID
+func transfer(tx *gorm.DB, cfg *config.Config, userID string, fromWalletID string, toWalletID string, amount decimal.Decimal, currTime time.Time) (string, error) {
+	// Check transfer limits
+	if err := enforceLimits(tx, cfg, userID, constant.TxnTypeTransfer, amount, currTime); err != nil {
+		return "", err
+	}
+	// Transfer money
+	if err := repository.WalletRepository.Transfer(tx, userID, fromWalletID, toWalletID, amount); err != nil {
+		return "", err
+	}
+	// Create transaction history
+	txnID, err := repository.TransactionRepository.CreateTransactionHistory(tx, fromWalletID, toWalletID, constant.TxnTypeTransfer, amount, currTime)
+	if err != nil {
+		return "", err
+	}
+	// Charge transfer fee
+	feeAmount, _, err := chargeFee(tx, cfg, userID, fromWalletID, txnID, constant.TxnTypeTransfer, amount, currTime)
+	if err != nil {
+		return "", err
+	}
+	// Create user activity
+	activityDetail := fmt.Sprintf("User transfer amount %s from wallet %s to wallet %s", amount.StringFixed(2), fromWalletID, toWalletID)
+	if feeAmount.IsPositive() {
+		activityDetail = fmt.Sprintf("%s, fee %s", activityDetail, feeAmount.StringFixed(2))
+	}
+	if err := repository.UserRepository.CreateUserActivity(tx, userID, constant.UserActTypeTransfer, activityDetail, fromWalletID, currTime); err != nil {
+		return "", err
+	}
+	return txnID, nil
+}
+
+// A transfer held for approval, executed as the user requested it
+type transferOperation struct {
+	UserID       string          `json:"user_id"`
+	FromWalletID string          `json:"from_wallet_id"`
+	ToWalletID   string          `json:"to_wallet_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	DeviceID     string          `json:"device_id,omitempty"`
+	DecisionID   string          `json:"decision_id"`
+	// The hold of the amount, empty for the transfers requested before the holds
+	HoldTxnID string `json:"hold_txn_id,omitempty"`
+}
+
+// Check if the transfer amount needs approval, no transfer needs approval if the large transfer amount is zero
+func isLargeTransfer(cfg *config.Config, amount decimal.Decimal) bool {
+	largeTransferAmount := cfg.Approval.LargeTransferAmount
+	return largeTransferAmount.IsPositive() && amount.GreaterThanOrEqual(largeTransferAmount)
+}
+
+// Make the approved transfer, the risk isn't assessed again, but the limits are checked at the time of the approval
+// The held amount is released first, and transferred with the fee in the same transaction
+func executeTransferOperation(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error) {
+	var payload transferOperation
+	if err := decodeOperationPayload(operation, &payload); err != nil {
+		return "", err
+	}
+	// Lock both wallets in the wallet ID order before the release, which would lock the from wallet alone first
+	if err := repository.WalletRepository.LockWallets(tx, payload.FromWalletID, payload.ToWalletID); err != nil {
+		return "", err
+	}
+	if err := releaseTransferHold(tx, payload, currTime); err != nil {
+		return "", err
+	}
+	txnID, err := transfer(tx, config.Current(), payload.UserID, payload.FromWalletID, payload.ToWalletID, payload.Amount, currTime)
+	if err != nil {
+		return "", err
+	}
+	return txnID, completeRiskDecision(tx, payload.DecisionID, payload.UserID, payload.DeviceID, txnID, currTime)
+}
+
+// Close the transfer which is never made, since its approval is rejected or expired
+// The held amount goes back to the wallet, and the outcome is recorded on the risk decision
+func closeTransferOperation(tx *gorm.DB, operation entity.PendingOperation, outcome string, currTime time.Time) error {
+	var payload transferOperation
+	if err := decodeOperationPayload(operation, &payload); err != nil {
+		return err
+	}
+	if err := releaseTransferHold(tx, payload, currTime); err != nil {
+		return err
+	}
+	return repository.RiskRepository.UpdateDecisionOutcome(tx, payload.DecisionID, outcome)
+}
+
+// Hold the amount of the transfer waiting for approval, so that the wallet can't spend it meanwhile
+// Return the transaction ID of the hold
+func holdTransfer(tx *gorm.DB, fromWalletID string, amount decimal.Decimal, currTime time.Time) (string, error) {
+	if _, err := repository.WalletRepository.Hold(tx, fromWalletID, amount); err != nil {
+		return "", err
+	}
+	return repository.TransactionRepository.CreateTransactionHistory(tx, fromWalletID, constant.SystemWalletHold, constant.TxnTypeHold, amount, currTime)
+}
+
+// Release the held amount of the transfer back to the wallet, linked to the hold
+func releaseTransferHold(tx *gorm.DB, payload transferOperation, currTime time.Time) error {
+	if payload.HoldTxnID == "" {
+		return nil
+	}
+	if _, err := repository.WalletRepository.ReleaseHold(tx, payload.FromWalletID, payload.Amount); err != nil {
+		return err
+	}
+	_, err := repository.TransactionRepository.CreateLinkedTransactionHistory(tx, payload.HoldTxnID, constant.SystemWalletHold, payload.FromWalletID, constant.TxnTypeHoldRelease, payload.Amount, currTime)
+	return err
+}
+
+// Construct result list of model.TransactionHistory
+func toTransactionHistoryModels(txnHistoryList []entity.TxnHistory) []model.TransactionHistory {
+	result := make([]model.TransactionHistory, 0, len(txnHistoryList))
 	for _, txnHistory := range txnHistoryList {
 		result = append(result, model.TransactionHistory{
 			TxnID:        txnHistory.TxnID,
@@ -94,7 +264,18 @@ func (ts *transactionServiceImpl) ListHistory(currentUserID string, walletID str
 			TxnAmount:    txnHistory.TxnAmount,
 			TxnTypeDesc:  txnHistory.TxnType,
 			TxnTime:      txnHistory.TxnTime,
+			ParentTxnID:  txnHistory.ParentTxnID.String,
 		})
 	}
-	return result, http.StatusOK, nil
+	return result
+}
+
+// Map the error of a transfer to the status code and the service error
+// If the underlying error is business logic related error, return bad request (or forbidden) status code
+// otherwise return internal server error status code
+func mapTransferError(err error) (int, error) {
+	if err.Error() == repository.ErrSameWalletTransfer {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageSameWalletTransfer, nil)
+	}
+	return mapWalletError(err)
 }
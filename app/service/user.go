@@ -1,14 +1,21 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 	"wallet-app-server/app/config"
 	"wallet-app-server/app/constant"
 	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
 	"wallet-app-server/app/logger"
+	"wallet-app-server/app/model"
 	"wallet-app-server/app/redis"
 	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
 	"wallet-app-server/app/util"
 
 	"github.com/google/uuid"
@@ -17,41 +24,180 @@ import (
 
 // User service interface
 type IUserService interface {
-	Login(username string, password string) (string, int, error)
+	Login(ctx context.Context, username string, password string) (string, int, error)
+	Register(ctx context.Context, username string, password string, fullName string) (model.RegisterResult, int, error)
+	UpdateProfile(ctx context.Context, currentUserID string, fullName string) (model.UserProfile, int, error)
+	GetUserRole(ctx context.Context, currentUserID string) (string, int, error)
 }
 
+// Minimum length of a password
+const minPasswordLength = 8
+
 // User service instance
 var UserService IUserService = &userServiceImpl{}
 
 // User service implementation
 type userServiceImpl struct{}
 
-func (us *userServiceImpl) Login(username string, password string) (string, int, error) {
+func (us *userServiceImpl) Login(ctx context.Context, username string, password string) (string, int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
 	// Try to fetch user record by user name in DB
-	user, err := repository.UserRepository.GetUserByName(db.DB, username)
+	user, err := repository.UserRepository.GetUserByName(conn, username)
 	if err != nil {
 		// If user not found,
 		if err == gorm.ErrRecordNotFound {
 			return "", http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageUserNotFound, nil)
 		}
 		// Other repository error
-		logger.Errorf("Failed to get user from DB, err: %s", err.Error())
+		logger.ErrorfContext(ctx, "Failed to get user from DB, err: %s", err.Error())
 		return "", http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
 	// Validate user password
 	inputPassHash := util.HashPassword(password)
-	logger.Debugf("inputPassHash: %s, user.UserHash: %s", inputPassHash, user.UserHash)
 	if user.UserHash != inputPassHash {
+		// Never log the hashes, they can be brute forced offline
+		logger.DebugfContext(ctx, "Password not valid, userID: %s", user.UserID)
 		return "", http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessagePasswordNotValid, nil)
 	}
 	// Generate access token
 	accessToken := uuid.New().String()
 	// Insert access token into Redis
-	if err := redis.Client.Set(accessToken, user.UserID, time.Duration(config.Cfg.Server.SessionExpireTimeInSecs)*time.Second); err != nil {
-		logger.Errorf("Failed to insert access token to Redis, err: %s", err.Error())
+	session, err := json.Marshal(model.Session{UserID: user.UserID})
+	if err != nil {
+		return "", http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if err := redis.Client.Set(ctx, accessToken, string(session), time.Duration(config.Current().Server.SessionExpireTimeInSecs)*time.Second); err != nil {
+		logger.ErrorfContext(ctx, "Failed to insert access token to Redis, err: %s", err.Error())
 		return "", http.StatusBadRequest, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, nil)
 	}
 	// Create user activity
-	repository.UserRepository.CreateUserActivity(db.DB, user.UserID, constant.UserActTypeLogin, "User login", "", time.Now())
+	repository.UserRepository.CreateUserActivity(conn, user.UserID, constant.UserActTypeLogin, "User login", "", time.Now())
 	return accessToken, http.StatusOK, nil
 }
+
+// Register a user with a default wallet
+// The full name is screened against the sanctions lists before the user is created
+func (us *userServiceImpl) Register(ctx context.Context, username string, password string, fullName string) (model.RegisterResult, int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	username = strings.TrimSpace(username)
+	fullName = strings.TrimSpace(fullName)
+	if fullName == "" {
+		return model.RegisterResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageFullNameRequired, nil)
+	}
+	if len(password) < minPasswordLength {
+		return model.RegisterResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessagePasswordTooShort, nil)
+	}
+	// Check if the user name has been taken
+	if _, err := repository.UserRepository.GetUserByName(conn, username); err != gorm.ErrRecordNotFound {
+		if err == nil {
+			return model.RegisterResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageUserNameTaken, nil)
+		}
+		return model.RegisterResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	// Screen the full name, the user doesn't exist yet so the case refers to the user name
+	if statusCode, err := screenName(conn, screeningSubject{SubjectRef: username, Name: fullName, Context: constant.ScreeningContextRegister}); err != nil {
+		return model.RegisterResult{}, statusCode, err
+	}
+	user := entity.User{
+		UserID:     uuid.New().String(),
+		UserName:   username,
+		UserHash:   util.HashPassword(password),
+		UserTier:   constant.UserTierStandard,
+		KYCLevel:   constant.KYCLevelUnverified,
+		UserRole:   constant.UserRoleCustomer,
+		FullName:   sql.NullString{String: fullName, Valid: true},
+		CreateTime: time.Now(),
+	}
+	var wallet entity.Wallet
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Create user
+		if err := repository.UserRepository.CreateUser(tx, user); err != nil {
+			return err
+		}
+		// Create the default wallet
+		var err error
+		wallet, err = repository.WalletRepository.CreateUserWallet(tx, user.UserID, "default wallet", user.CreateTime)
+		if err != nil {
+			return err
+		}
+		// Create user activity
+		return repository.UserRepository.CreateUserActivity(tx, user.UserID, constant.UserActTypeRegister, "User register", wallet.WalletID, user.CreateTime)
+	}); err != nil {
+		logger.ErrorfContext(ctx, "Failed to create user, err: %s", err.Error())
+		return model.RegisterResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return model.RegisterResult{
+		User: toUserProfileModel(user),
+		Wallet: model.WalletInfo{
+			WalletID:      wallet.WalletID,
+			WalletName:    wallet.WalletName,
+			ReferenceCode: wallet.ReferenceCode.String,
+		},
+	}, http.StatusOK, nil
+}
+
+// Update the user's full name
+// The new full name is screened against the sanctions lists before it's saved
+func (us *userServiceImpl) UpdateProfile(ctx context.Context, currentUserID string, fullName string) (model.UserProfile, int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	fullName = strings.TrimSpace(fullName)
+	if fullName == "" {
+		return model.UserProfile{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageFullNameRequired, nil)
+	}
+	// Screen the full name
+	if statusCode, err := screenName(conn, screeningSubject{UserID: currentUserID, SubjectRef: currentUserID, Name: fullName, Context: constant.ScreeningContextProfile}); err != nil {
+		return model.UserProfile{}, statusCode, err
+	}
+	var user entity.User
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		// Record current time
+		currTime := time.Now()
+		// Update full name
+		if err := repository.UserRepository.UpdateFullName(tx, currentUserID, fullName, currTime); err != nil {
+			return err
+		}
+		// Create user activity
+		if err := repository.UserRepository.CreateUserActivity(tx, currentUserID, constant.UserActTypeProfile, "User update full name", "", currTime); err != nil {
+			return err
+		}
+		var err error
+		user, err = repository.UserRepository.GetUserByID(tx, currentUserID)
+		return err
+	}); err != nil {
+		return model.UserProfile{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return toUserProfileModel(user), http.StatusOK, nil
+}
+
+func toUserProfileModel(user entity.User) model.UserProfile {
+	return model.UserProfile{
+		UserID:     user.UserID,
+		UserName:   user.UserName,
+		FullName:   user.FullName.String,
+		UserTier:   user.UserTier,
+		KYCLevel:   user.KYCLevel,
+		UserRole:   user.UserRole,
+		CreateTime: user.CreateTime,
+	}
+}
+
+// Get the user's current role, so that a role change applies to the user's open sessions at once
+func (us *userServiceImpl) GetUserRole(ctx context.Context, currentUserID string) (string, int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserRole")
+	defer span.End()
+	user, err := repository.UserRepository.GetUserByID(db.DB.WithContext(ctx), currentUserID)
+	if err != nil {
+		// The user of the session doesn't exist anymore
+		if err == gorm.ErrRecordNotFound {
+			return "", http.StatusUnauthorized, newServiceError(ErrTypeAuthenticationFailed, ErrMessageInvalidAccessToken, nil)
+		}
+		return "", http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return user.UserRole, http.StatusOK, nil
+}
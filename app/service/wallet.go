@@ -1,13 +1,20 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
+	"wallet-app-server/app/config"
 	"wallet-app-server/app/constant"
 	"wallet-app-server/app/db"
+	"wallet-app-server/app/entity"
+	"wallet-app-server/app/kyc"
+	"wallet-app-server/app/limit"
+	"wallet-app-server/app/logger"
 	"wallet-app-server/app/model"
 	"wallet-app-server/app/repository"
+	"wallet-app-server/app/tracing"
 
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
@@ -15,10 +22,12 @@ import (
 
 // Wallet service interface
 type IWalletService interface {
-	ListUserWallets(currentUserID string) ([]model.WalletInfo, int, error)
-	CheckWalletBallance(currentUserID string, walletID string) (decimal.Decimal, int, error)
-	Deposit(currentUserID string, walletID string, amount decimal.Decimal) (decimal.Decimal, int, error)
-	Withdraw(currentUserID string, walletID string, amount decimal.Decimal) (decimal.Decimal, int, error)
+	ListUserWallets(ctx context.Context, currentUserID string) ([]model.WalletInfo, int, error)
+	CheckWalletBallance(ctx context.Context, currentUserID string, walletID string) (decimal.Decimal, int, error)
+	Deposit(ctx context.Context, currentUserID string, walletID string, amount decimal.Decimal) (decimal.Decimal, int, error)
+	Withdraw(ctx context.Context, currentUserID string, walletID string, amount decimal.Decimal, deviceID string) (model.WithdrawResult, int, error)
+	GetWalletBalanceAsOf(ctx context.Context, currentUserID string, walletID string, asOf time.Time) (decimal.Decimal, int, error)
+	UnfreezeWallet(ctx context.Context, operatorID string, walletID string, note string) (model.PendingOperation, int, error)
 }
 
 // Wallet service instance
@@ -27,8 +36,11 @@ var WalletService IWalletService = &walletServiceImpl{}
 // Wallet service implementation
 type walletServiceImpl struct{}
 
-func (ws *walletServiceImpl) ListUserWallets(currentUserID string) ([]model.WalletInfo, int, error) {
-	wallets, err := repository.WalletRepository.ListUserWallets(db.DB, currentUserID)
+func (ws *walletServiceImpl) ListUserWallets(ctx context.Context, currentUserID string) ([]model.WalletInfo, int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.ListUserWallets")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	wallets, err := repository.WalletRepository.ListUserWallets(conn, currentUserID)
 	if err != nil {
 		// If record not found, return empty OK response
 		if err == gorm.ErrRecordNotFound {
@@ -41,23 +53,28 @@ func (ws *walletServiceImpl) ListUserWallets(currentUserID string) ([]model.Wall
 	result := make([]model.WalletInfo, 0, len(wallets))
 	for _, wallet := range wallets {
 		result = append(result, model.WalletInfo{
-			WalletID:   wallet.WalletID,
-			WalletName: wallet.WalletName,
+			WalletID:      wallet.WalletID,
+			WalletName:    wallet.WalletName,
+			ReferenceCode: wallet.ReferenceCode.String,
 		})
 	}
 	return result, http.StatusOK, nil
 }
 
-func (ws *walletServiceImpl) CheckWalletBallance(currentUserID string, walletID string) (decimal.Decimal, int, error) {
+func (ws *walletServiceImpl) CheckWalletBallance(ctx context.Context, currentUserID string, walletID string) (decimal.Decimal, int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.CheckWalletBallance")
+	defer span.End()
+	// The balance can be read from the replica
+	conn := db.Reader().WithContext(ctx)
 	// Verify from wallet is belong to the current user
-	valid, err := repository.WalletRepository.VerifyUserWalletPossession(db.DB, currentUserID, walletID)
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, walletID)
 	if err != nil {
 		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
 	if !valid {
 		return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
 	}
-	wallet, err := repository.WalletRepository.GetWalletByID(db.DB, walletID)
+	wallet, err := repository.WalletRepository.GetWalletByID(conn, walletID)
 	if err != nil {
 		// Wallet not found
 		if err == gorm.ErrRecordNotFound {
@@ -66,13 +83,16 @@ func (ws *walletServiceImpl) CheckWalletBallance(currentUserID string, walletID
 		// Other repository error
 		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
-	// Return wallet.Balance
+	// Return wallet.Balance, the drift from the ledger is detected by the reconciliation job
 	return wallet.Balance, http.StatusOK, nil
 }
 
-func (ws *walletServiceImpl) Deposit(currentUserID string, walletID string, amount decimal.Decimal) (decimal.Decimal, int, error) {
+func (ws *walletServiceImpl) Deposit(ctx context.Context, currentUserID string, walletID string, amount decimal.Decimal) (decimal.Decimal, int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.Deposit")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
 	// Verify from wallet is belong to the current user
-	valid, err := repository.WalletRepository.VerifyUserWalletPossession(db.DB, currentUserID, walletID)
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, walletID)
 	if err != nil {
 		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
@@ -80,77 +100,252 @@ func (ws *walletServiceImpl) Deposit(currentUserID string, walletID string, amou
 		return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
 	}
 	var result decimal.Decimal
-	if err := db.DB.Transaction(func(tx *gorm.DB) error {
-		// Record current time
-		currTime := time.Now()
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
 		// Deposit
-		latestBalance, err := repository.WalletRepository.Deposit(tx, walletID, amount)
+		activityDetail := fmt.Sprintf("User deposit amount %s to wallet %s", amount.StringFixed(2), walletID)
+		latestBalance, _, err := deposit(tx, currentUserID, walletID, amount, activityDetail, time.Now())
 		if err != nil {
 			return err
 		}
 		result = latestBalance
-		// Create transaction history
-		if _, err := repository.TransactionRepository.CreateTransactionHistory(tx, walletID, walletID, constant.TxnTypeDeposit, amount, currTime); err != nil {
-			return err
-		}
-		// Create user activity
-		activityDetail := fmt.Sprintf("User deposit amount %s to wallet %s", amount.StringFixed(2), walletID)
-		if err := repository.UserRepository.CreateUserActivity(tx, currentUserID, constant.UserActTypeDeposit, activityDetail, walletID, currTime); err != nil {
-			return err
-		}
 		return nil
 	}); err != nil {
-		// If the underlying error is business logic related error
-		// return bad request status code
-		// otherwise return internal server error status code
-		if err.Error() == repository.ErrNegativeOrZeroAmount {
-			return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
-		}
-		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		statusCode, serviceErr := mapWalletError(err)
+		return decimal.Zero, statusCode, serviceErr
 	}
 	return result, http.StatusOK, nil
 }
 
-func (ws *walletServiceImpl) Withdraw(currentUserID string, walletID string, amount decimal.Decimal) (decimal.Decimal, int, error) {
+func (ws *walletServiceImpl) Withdraw(ctx context.Context, currentUserID string, walletID string, amount decimal.Decimal, deviceID string) (model.WithdrawResult, int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.Withdraw")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	// The fee schedules and the limit rules as of the request, even if reloaded meanwhile
+	cfg := config.Current()
 	// Verify from wallet is belong to the current user
-	valid, err := repository.WalletRepository.VerifyUserWalletPossession(db.DB, currentUserID, walletID)
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, walletID)
 	if err != nil {
-		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		return model.WithdrawResult{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
 	}
 	if !valid {
-		return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+		return model.WithdrawResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
 	}
-	var result decimal.Decimal
-	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+	// Reject the invalid withdraw before the risk assessment, so that it's never held for review
+	if amount.IsNegative() || amount.IsZero() {
+		return model.WithdrawResult{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+	}
+	if statusCode, err := checkKYCCapability(conn, currentUserID, constant.TxnTypeWithdraw, amount); err != nil {
+		return model.WithdrawResult{}, statusCode, err
+	}
+	var result model.WithdrawResult
+	var denied bool
+	if err := db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
 		// Record current time
 		currTime := time.Now()
-		// Withdraw
-		latestBalance, err := repository.WalletRepository.Withdraw(tx, walletID, amount)
-		if err != nil {
+		// Lock the user, so that the concurrent transactions of the user are assessed one after another
+		if _, err := repository.UserRepository.LockUser(tx, currentUserID); err != nil {
 			return err
 		}
-		result = latestBalance
-		// Create transaction history
-		if _, err := repository.TransactionRepository.CreateTransactionHistory(tx, walletID, walletID, constant.TxnTypeWithdraw, amount, currTime); err != nil {
+		// Assess the risk of the withdraw, the decision of a denied or held withdraw is committed without the withdraw
+		assessment, err := assessRisk(tx, riskRequest{
+			UserID:       currentUserID,
+			TxnType:      constant.TxnTypeWithdraw,
+			FromWalletID: walletID,
+			Amount:       amount,
+			DeviceID:     deviceID,
+		}, currTime)
+		if err != nil {
 			return err
 		}
-		// Create user activity
+		if assessment.Denied {
+			denied = true
+			return nil
+		}
+		if assessment.ReviewID != "" {
+			result = model.WithdrawResult{Status: constant.TxnStatusPendingReview, ReviewID: assessment.ReviewID}
+			return nil
+		}
+		// Withdraw
 		activityDetail := fmt.Sprintf("User withdraw amount %s to wallet %s", amount.StringFixed(2), walletID)
-		if err := repository.UserRepository.CreateUserActivity(tx, currentUserID, constant.UserActTypeWithdraw, activityDetail, walletID, currTime); err != nil {
+		latestBalance, txnID, err := withdraw(tx, cfg, currentUserID, walletID, amount, constant.UserActTypeWithdraw, activityDetail, currTime)
+		if err != nil {
 			return err
 		}
-		return nil
+		result = model.WithdrawResult{Balance: latestBalance, Status: constant.TxnStatusCompleted}
+		// Link the risk decision to the transaction
+		return completeRiskDecision(tx, assessment.DecisionID, currentUserID, deviceID, txnID, currTime)
 	}); err != nil {
-		// If the underlying error is business logic related error
-		// return bad request status code
-		// otherwise return internal server error status code
-		if err.Error() == repository.ErrNegativeOrZeroAmount {
-			return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
-		}
-		if err.Error() == repository.ErrInsufficientBalance {
-			return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInsufficientBalance, nil)
-		}
-		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+		statusCode, serviceErr := mapWalletError(err)
+		return model.WithdrawResult{}, statusCode, serviceErr
+	}
+	if denied {
+		return model.WithdrawResult{}, http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageRiskDenied, nil)
 	}
 	return result, http.StatusOK, nil
 }
+
+func (ws *walletServiceImpl) GetWalletBalanceAsOf(ctx context.Context, currentUserID string, walletID string, asOf time.Time) (decimal.Decimal, int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetWalletBalanceAsOf")
+	defer span.End()
+	// The balance can be read from the replica
+	conn := db.Reader().WithContext(ctx)
+	// Verify from wallet is belong to the current user
+	valid, err := repository.WalletRepository.VerifyUserWalletPossession(conn, currentUserID, walletID)
+	if err != nil {
+		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if !valid {
+		return decimal.Zero, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	balance, err := getWalletBalanceAsOf(conn, walletID, asOf)
+	if err != nil {
+		return decimal.Zero, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	return balance, http.StatusOK, nil
+}
+
+// Request to unfreeze the frozen wallet, e.g. after the reconciliation mismatch is resolved
+// The wallet is unfrozen after a second person approves the request
+func (ws *walletServiceImpl) UnfreezeWallet(ctx context.Context, operatorID string, walletID string, note string) (model.PendingOperation, int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.UnfreezeWallet")
+	defer span.End()
+	conn := db.DB.WithContext(ctx)
+	wallet, err := repository.WalletRepository.GetWalletByID(conn, walletID)
+	if err != nil {
+		return model.PendingOperation{}, http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+	}
+	if wallet.WalletID == "" {
+		return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	if wallet.WalletStatus != constant.WalletStatusFrozen {
+		return model.PendingOperation{}, http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletNotFrozen, nil)
+	}
+	summary := fmt.Sprintf("Unfreeze wallet %s", walletID)
+	return requestOperationForApproval(conn, operatorID, constant.OperationTypeUnfreezeWallet, unfreezeWalletOperation{WalletID: walletID}, summary, note)
+}
+
+// An unfreeze of the wallet held for approval
+type unfreezeWalletOperation struct {
+	WalletID string `json:"wallet_id"`
+}
+
+// Unfreeze the wallet approved in the pending operation
+func executeUnfreezeWalletOperation(tx *gorm.DB, operation entity.PendingOperation, approverID string, currTime time.Time) (string, error) {
+	var payload unfreezeWalletOperation
+	if err := decodeOperationPayload(operation, &payload); err != nil {
+		return "", err
+	}
+	wallet, err := repository.WalletRepository.GetWalletByID(tx, payload.WalletID)
+	if err != nil {
+		return "", err
+	}
+	if wallet.WalletStatus != constant.WalletStatusFrozen {
+		return "", newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletNotFrozen, nil)
+	}
+	if err := repository.WalletRepository.UpdateWalletStatus(tx, payload.WalletID, constant.WalletStatusActive); err != nil {
+		return "", err
+	}
+	logger.InfofContext(tx.Statement.Context, "Wallet unfrozen, walletID: %s, requestBy: %s, approverID: %s", payload.WalletID, operation.RequestBy, approverID)
+	return "", nil
+}
+
+// Deposit to the wallet from the system cash-in wallet, and record the transaction history and the user activity
+// Should call this function inside a transaction
+// Return the latest wallet balance and the transaction ID
+func deposit(tx *gorm.DB, userID string, walletID string, amount decimal.Decimal, activityDetail string, currTime time.Time) (decimal.Decimal, string, error) {
+	// Deposit
+	latestBalance, err := repository.WalletRepository.Deposit(tx, walletID, amount)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	// Create transaction history
+	txnID, err := repository.TransactionRepository.CreateTransactionHistory(tx, constant.SystemWalletCashIn, walletID, constant.TxnTypeDeposit, amount, currTime)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	// Create user activity
+	if err := repository.UserRepository.CreateUserActivity(tx, userID, constant.UserActTypeDeposit, activityDetail, walletID, currTime); err != nil {
+		return decimal.Zero, "", err
+	}
+	return latestBalance, txnID, nil
+}
+
+// Map the error of a wallet balance change (deposit, withdraw) to the status code and the service error
+// If the underlying error is business logic related error
+// return bad request status code
+// otherwise return internal server error status code
+func mapWalletError(err error) (int, error) {
+	if err.Error() == repository.ErrNegativeOrZeroAmount {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageNegativeOrZeroAmount, nil)
+	}
+	if err.Error() == repository.ErrWalletNotFound {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageWalletIDInvalid, nil)
+	}
+	if err.Error() == repository.ErrWalletFrozen {
+		return http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageWalletFrozen, nil)
+	}
+	if err.Error() == repository.ErrInsufficientBalance {
+		return http.StatusBadRequest, newServiceError(ErrTypeInvalidRequestBody, ErrMessageInsufficientBalance, nil)
+	}
+	if limit.IsLimitError(err) {
+		return http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageLimitExceeded, err)
+	}
+	if kyc.IsKYCError(err) {
+		return http.StatusForbidden, newServiceError(ErrTypePermissionDenied, ErrMessageKYCLevelInsufficient, err)
+	}
+	return http.StatusInternalServerError, newServiceError(ErrTypeInternalServerError, ErrMessageDBError, err)
+}
+
+// Withdraw from the wallet to the system cash-out wallet, and record the transaction history and the user activity
+// Should call this function inside a transaction
+// Return the latest wallet balance and the transaction ID
+func withdraw(tx *gorm.DB, cfg *config.Config, userID string, walletID string, amount decimal.Decimal, userActType string, activityDetail string, currTime time.Time) (decimal.Decimal, string, error) {
+	// Check withdrawal limits
+	if err := enforceLimits(tx, cfg, userID, constant.TxnTypeWithdraw, amount, currTime); err != nil {
+		return decimal.Zero, "", err
+	}
+	// Withdraw
+	latestBalance, err := repository.WalletRepository.Withdraw(tx, walletID, amount)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	// Create transaction history
+	txnID, err := repository.TransactionRepository.CreateTransactionHistory(tx, walletID, constant.SystemWalletCashOut, constant.TxnTypeWithdraw, amount, currTime)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	// Charge withdrawal fee
+	feeAmount, feeBalance, err := chargeFee(tx, cfg, userID, walletID, txnID, constant.TxnTypeWithdraw, amount, currTime)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	if feeAmount.IsPositive() {
+		latestBalance = feeBalance
+		activityDetail = fmt.Sprintf("%s, fee %s", activityDetail, feeAmount.StringFixed(2))
+	}
+	// Create user activity
+	if err := repository.UserRepository.CreateUserActivity(tx, userID, userActType, activityDetail, walletID, currTime); err != nil {
+		return decimal.Zero, "", err
+	}
+	return latestBalance, txnID, nil
+}
+
+// Compute the historical balance of a wallet at the given time
+// Start from the latest balance snapshot before the time, and only replay the postings after the snapshot
+func getWalletBalanceAsOf(db *gorm.DB, walletID string, asOf time.Time) (decimal.Decimal, error) {
+	balance := decimal.Zero
+	var snapshotTime time.Time
+	snapshot, err := repository.BalanceSnapshotRepository.GetLatestSnapshot(db, walletID, asOf)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return decimal.Zero, err
+	}
+	if err == nil {
+		balance = snapshot.Balance
+		snapshotTime = snapshot.SnapshotTime
+	}
+	delta, err := repository.TransactionRepository.SumWalletPostingsBetween(db, walletID, snapshotTime, asOf)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return balance.Add(delta), nil
+}
@@ -0,0 +1,168 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ISO 20022 bank-to-customer statement (camt.053.001.08) namespace
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+// ISO 20022 code values
+const (
+	camtCredit         = "CRDT"
+	camtDebit          = "DBIT"
+	camtOpeningBooked  = "OPBD"
+	camtClosingBooked  = "CLBD"
+	camtEntryBooked    = "BOOK"
+	camtDateTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+type camtGroupHeader struct {
+	XMLName xml.Name `xml:"GrpHdr"`
+	MsgId   string   `xml:"MsgId"`
+	CreDtTm string   `xml:"CreDtTm"`
+}
+
+type camtAccount struct {
+	XMLName xml.Name `xml:"Acct"`
+	Id      string   `xml:"Id>Othr>Id"`
+	Ccy     string   `xml:"Ccy"`
+}
+
+type camtPeriod struct {
+	XMLName xml.Name `xml:"FrToDt"`
+	FrDtTm  string   `xml:"FrDtTm"`
+	ToDtTm  string   `xml:"ToDtTm"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	XMLName   xml.Name   `xml:"Bal"`
+	Cd        string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	DtTm      string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	XMLName      xml.Name   `xml:"Ntry"`
+	NtryRef      string     `xml:"NtryRef"`
+	Amt          camtAmount `xml:"Amt"`
+	CdtDbtInd    string     `xml:"CdtDbtInd"`
+	Sts          string     `xml:"Sts>Cd"`
+	BookgDtTm    string     `xml:"BookgDt>DtTm"`
+	AcctSvcrRef  string     `xml:"AcctSvcrRef"`
+	BkTxCd       string     `xml:"BkTxCd>Prtry>Cd"`
+	EndToEndId   string     `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	AddtlNtryInf string     `xml:"AddtlNtryInf,omitempty"`
+}
+
+// camt.053 statement writer
+// The document is written with an XML token encoder, entries are flushed one by one
+// The closing balance is written before the entries (as required by the schema),
+// so it must be given in the summary of WriteOpening
+type camt053Writer struct {
+	enc      *xml.Encoder
+	currency string
+}
+
+func newCamt053Writer(w io.Writer) *camt053Writer {
+	return &camt053Writer{enc: xml.NewEncoder(w), currency: Currency()}
+}
+
+func (cw *camt053Writer) WriteOpening(summary model.StatementSummary) error {
+	now := time.Now()
+	if err := cw.enc.EncodeToken(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)}); err != nil {
+		return err
+	}
+	if err := cw.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace}); err != nil {
+		return err
+	}
+	if err := cw.start("BkToCstmrStmt"); err != nil {
+		return err
+	}
+	if err := cw.enc.Encode(camtGroupHeader{MsgId: compactID(uuid.New().String()), CreDtTm: now.Format(camtDateTimeFormat)}); err != nil {
+		return err
+	}
+	if err := cw.start("Stmt"); err != nil {
+		return err
+	}
+	statementID := compactID(fmt.Sprintf("%s-%s", summary.WalletID, summary.ToTime.Format("20060102")))
+	if err := cw.enc.EncodeElement(statementID, xml.StartElement{Name: xml.Name{Local: "Id"}}); err != nil {
+		return err
+	}
+	if err := cw.enc.EncodeElement(now.Format(camtDateTimeFormat), xml.StartElement{Name: xml.Name{Local: "CreDtTm"}}); err != nil {
+		return err
+	}
+	if err := cw.enc.Encode(camtPeriod{FrDtTm: summary.FromTime.Format(camtDateTimeFormat), ToDtTm: summary.ToTime.Format(camtDateTimeFormat)}); err != nil {
+		return err
+	}
+	if err := cw.enc.Encode(camtAccount{Id: compactID(summary.WalletID), Ccy: cw.currency}); err != nil {
+		return err
+	}
+	if err := cw.enc.Encode(cw.balance(camtOpeningBooked, summary.OpeningBalance, summary.FromTime)); err != nil {
+		return err
+	}
+	if err := cw.enc.Encode(cw.balance(camtClosingBooked, summary.ClosingBalance, summary.ToTime)); err != nil {
+		return err
+	}
+	return cw.enc.Flush()
+}
+
+func (cw *camt053Writer) WriteLine(line model.StatementLine) error {
+	creditDebit, counterparty := camtCredit, line.FromWalletID
+	if line.PostingAmount.IsNegative() {
+		creditDebit, counterparty = camtDebit, line.ToWalletID
+	}
+	if err := cw.enc.Encode(camtEntry{
+		NtryRef:      compactID(line.TxnID),
+		Amt:          camtAmount{Ccy: cw.currency, Value: line.PostingAmount.Abs().StringFixed(2)},
+		CdtDbtInd:    creditDebit,
+		Sts:          camtEntryBooked,
+		BookgDtTm:    line.TxnTime.Format(camtDateTimeFormat),
+		AcctSvcrRef:  compactID(line.TxnID),
+		BkTxCd:       line.TxnTypeDesc,
+		EndToEndId:   compactID(line.TxnID),
+		AddtlNtryInf: fmt.Sprintf("%s, counterparty wallet %s", line.TxnTypeDesc, counterparty),
+	}); err != nil {
+		return err
+	}
+	return cw.enc.Flush()
+}
+
+func (cw *camt053Writer) WriteClosing(summary model.StatementSummary) error {
+	for _, name := range []string{"Stmt", "BkToCstmrStmt", "Document"} {
+		if err := cw.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return cw.enc.Flush()
+}
+
+func (cw *camt053Writer) start(name string, attrs ...xml.Attr) error {
+	return cw.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+}
+
+func (cw *camt053Writer) balance(code string, amount decimal.Decimal, balanceTime time.Time) camtBalance {
+	creditDebit := camtCredit
+	if amount.IsNegative() {
+		creditDebit = camtDebit
+	}
+	return camtBalance{
+		Cd:        code,
+		Amt:       camtAmount{Ccy: cw.currency, Value: amount.Abs().StringFixed(2)},
+		CdtDbtInd: creditDebit,
+		DtTm:      balanceTime.Format(camtDateTimeFormat),
+	}
+}
@@ -0,0 +1,50 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+	"wallet-app-server/app/model"
+)
+
+// Record types of the CSV and NDJSON statements
+const (
+	recordTypeOpening = "opening"
+	recordTypeTxn     = "txn"
+	recordTypeClosing = "closing"
+)
+
+// CSV statement writer
+// Every record has the same columns, the record_type column tells the opening, transaction and closing records apart
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) WriteOpening(summary model.StatementSummary) error {
+	if err := cw.w.Write([]string{"record_type", "wallet_id", "txn_id", "txn_time", "txn_type", "from_wallet_id", "to_wallet_id", "amount", "balance"}); err != nil {
+		return err
+	}
+	return cw.writeRecord([]string{recordTypeOpening, summary.WalletID, "", summary.FromTime.Format(time.RFC3339), "", "", "", "", summary.OpeningBalance.StringFixed(2)})
+}
+
+func (cw *csvWriter) WriteLine(line model.StatementLine) error {
+	return cw.writeRecord([]string{recordTypeTxn, "", line.TxnID, line.TxnTime.Format(time.RFC3339), line.TxnTypeDesc, line.FromWalletID, line.ToWalletID,
+		line.PostingAmount.StringFixed(2), line.RunningBalance.StringFixed(2)})
+}
+
+func (cw *csvWriter) WriteClosing(summary model.StatementSummary) error {
+	return cw.writeRecord([]string{recordTypeClosing, summary.WalletID, "", summary.ToTime.Format(time.RFC3339), "", "", "", "", summary.ClosingBalance.StringFixed(2)})
+}
+
+// Write the record and flush it, so that nothing is accumulated in memory
+func (cw *csvWriter) writeRecord(record []string) error {
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,137 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/model"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Validate the camt.053 statement against the published ISO 20022 schema (testdata/camt.053.001.08.xsd)
+// xmllint is used as the schema validator, the test is skipped if it's not installed
+func TestCamt053StatementSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed, skip schema validation")
+	}
+	content := writeTestStatement(t, FormatCamt053, 3)
+	path := filepath.Join(t.TempDir(), "camt053.xml")
+	assert.Equal(t, nil, os.WriteFile(path, []byte(content), 0644))
+	output, err := exec.Command(xmllint, "--noout", "--schema", "testdata/camt.053.001.08.xsd", path).CombinedOutput()
+	t.Log(string(output))
+	assert.Equal(t, nil, err)
+}
+
+func TestCamt053StatementContent(t *testing.T) {
+	content := writeTestStatement(t, FormatCamt053, 2)
+	var doc struct {
+		Stmt struct {
+			Bal []struct {
+				Cd  string `xml:"Tp>CdOrPrtry>Cd"`
+				Amt string `xml:"Amt"`
+			} `xml:"Bal"`
+			Ntry []struct {
+				NtryRef   string `xml:"NtryRef"`
+				CdtDbtInd string `xml:"CdtDbtInd"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	assert.Equal(t, nil, xml.Unmarshal([]byte(content), &doc))
+	assert.Equal(t, 2, len(doc.Stmt.Bal))
+	assert.Equal(t, "OPBD", doc.Stmt.Bal[0].Cd)
+	assert.Equal(t, "100.00", doc.Stmt.Bal[0].Amt)
+	assert.Equal(t, "CLBD", doc.Stmt.Bal[1].Cd)
+	assert.Equal(t, "121.00", doc.Stmt.Bal[1].Amt)
+	assert.Equal(t, 2, len(doc.Stmt.Ntry))
+	assert.Equal(t, "txn0", doc.Stmt.Ntry[0].NtryRef)
+	assert.Equal(t, "CRDT", doc.Stmt.Ntry[0].CdtDbtInd)
+}
+
+// Validate the OFX statement against the reduced OFX 2.1.1 schema of the bank statement response (testdata/ofx-2.1.1-bank-statement.xsd)
+// xmllint is used as the schema validator, the test is skipped if it's not installed
+func TestOFXStatementSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed, skip schema validation")
+	}
+	// A UUID wallet ID, whose account ID is the longest allowed
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatOFX, &buf)
+	assert.Equal(t, nil, err)
+	fromTime := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	summary := model.StatementSummary{WalletID: "a5344dde-a6a2-4c7a-8b9d-78841ef0ab3d", FromTime: fromTime, ToTime: fromTime.AddDate(0, 1, 0)}
+	assert.Equal(t, nil, w.WriteOpening(summary))
+	lines := []model.StatementLine{
+		{TxnID: "txn-0", FromWalletID: "system-cash-in", ToWalletID: summary.WalletID, TxnTypeDesc: constant.TxnTypeDeposit, PostingAmount: decimal.RequireFromString("100")},
+		{TxnID: "txn-1", FromWalletID: summary.WalletID, ToWalletID: "f0e1d2c3-b4a5-4968-8776-655443322110", TxnTypeDesc: constant.TxnTypeTransfer, PostingAmount: decimal.RequireFromString("-20.5")},
+		{TxnID: "txn-2", FromWalletID: summary.WalletID, ToWalletID: "system-fee", TxnTypeDesc: constant.TxnTypeFee, PostingAmount: decimal.RequireFromString("-0.1")},
+		{TxnID: "txn-3", FromWalletID: summary.WalletID, ToWalletID: "system-cash-out", TxnTypeDesc: constant.TxnTypeWithdraw, PostingAmount: decimal.RequireFromString("-50")},
+	}
+	for i, line := range lines {
+		line.TxnTime = fromTime.Add(time.Duration(i) * time.Hour)
+		assert.Equal(t, nil, w.WriteLine(line))
+	}
+	summary.ClosingBalance = decimal.RequireFromString("29.4")
+	assert.Equal(t, nil, w.WriteClosing(summary))
+
+	path := filepath.Join(t.TempDir(), "statement.ofx")
+	assert.Equal(t, nil, os.WriteFile(path, buf.Bytes(), 0644))
+	output, err := exec.Command(xmllint, "--noout", "--schema", "testdata/ofx-2.1.1-bank-statement.xsd", path).CombinedOutput()
+	t.Log(string(output))
+	assert.Equal(t, nil, err)
+}
+
+// Check the OFX statement has the aggregates required by the OFX 2.1.1 specification,
+// in the required order and within the field length limits
+func TestOFXStatementStructure(t *testing.T) {
+	content := writeTestStatement(t, FormatOFX, 2)
+	assert.Equal(t, true, strings.HasPrefix(content, `<?xml version="1.0" encoding="UTF-8" standalone="no"?><?OFX OFXHEADER="200" VERSION="211"`))
+
+	// Element order of the statement response
+	order := []string{"<OFX>", "<SIGNONMSGSRSV1>", "<SONRS>", "<STATUS>", "<DTSERVER>", "<LANGUAGE>", "</SIGNONMSGSRSV1>",
+		"<BANKMSGSRSV1>", "<STMTTRNRS>", "<TRNUID>", "<STATUS>", "<STMTRS>", "<CURDEF>", "<BANKACCTFROM>", "<BANKID>", "<ACCTID>", "<ACCTTYPE>",
+		"<BANKTRANLIST>", "<DTSTART>", "<DTEND>", "<STMTTRN>", "<TRNTYPE>", "<DTPOSTED>", "<TRNAMT>", "<FITID>", "<NAME>", "<MEMO>",
+		"</BANKTRANLIST>", "<LEDGERBAL>", "<BALAMT>", "<DTASOF>", "</STMTRS>", "</STMTTRNRS>", "</BANKMSGSRSV1>", "</OFX>"}
+	position := 0
+	for _, tag := range order {
+		index := strings.Index(content[position:], tag)
+		assert.NotEqual(t, -1, index)
+		if index < 0 {
+			t.Fatalf("%s not found in order", tag)
+		}
+		position += index + len(tag)
+	}
+
+	var doc struct {
+		AcctID  string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>ACCTID"`
+		Balance string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+		Txns    []struct {
+			TrnType  string `xml:"TRNTYPE"`
+			DtPosted string `xml:"DTPOSTED"`
+			TrnAmt   string `xml:"TRNAMT"`
+			Name     string `xml:"NAME"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+	}
+	assert.Equal(t, nil, xml.Unmarshal([]byte(content), &doc))
+	assert.Equal(t, true, len(doc.AcctID) <= ofxMaxAcctIDLen)
+	assert.Equal(t, "121.00", doc.Balance)
+	assert.Equal(t, 2, len(doc.Txns))
+	assert.Equal(t, "DEP", doc.Txns[0].TrnType)
+	assert.Equal(t, "20250601000000.000[0:GMT]", doc.Txns[0].DtPosted)
+	assert.Equal(t, "10.50", doc.Txns[0].TrnAmt)
+	assert.Equal(t, true, len(doc.Txns[0].Name) <= ofxMaxNameLen)
+}
+
+func TestOFXAccountID(t *testing.T) {
+	assert.Equal(t, "pTRN3qaiTHqLnXiEHvCrPQ", ofxAccountID("a5344dde-a6a2-4c7a-8b9d-78841ef0ab3d"))
+	assert.Equal(t, "system-cash-in", ofxAccountID("system-cash-in"))
+}
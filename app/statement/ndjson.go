@@ -0,0 +1,45 @@
+package statement
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// NDJSON (JSON Lines) statement writer
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+type ndjsonBalanceRecord struct {
+	RecordType string          `json:"record_type"`
+	WalletID   string          `json:"wallet_id"`
+	Time       time.Time       `json:"time"`
+	Balance    decimal.Decimal `json:"balance"`
+	TxnCount   *int            `json:"txn_count,omitempty"`
+}
+
+type ndjsonTxnRecord struct {
+	RecordType string `json:"record_type"`
+	model.StatementLine
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (nw *ndjsonWriter) WriteOpening(summary model.StatementSummary) error {
+	return nw.enc.Encode(ndjsonBalanceRecord{RecordType: recordTypeOpening, WalletID: summary.WalletID, Time: summary.FromTime, Balance: summary.OpeningBalance})
+}
+
+func (nw *ndjsonWriter) WriteLine(line model.StatementLine) error {
+	return nw.enc.Encode(ndjsonTxnRecord{RecordType: recordTypeTxn, StatementLine: line})
+}
+
+func (nw *ndjsonWriter) WriteClosing(summary model.StatementSummary) error {
+	txnCount := summary.TxnCount
+	return nw.enc.Encode(ndjsonBalanceRecord{RecordType: recordTypeClosing, WalletID: summary.WalletID, Time: summary.ToTime, Balance: summary.ClosingBalance, TxnCount: &txnCount})
+}
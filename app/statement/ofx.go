@@ -0,0 +1,206 @@
+package statement
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+	"wallet-app-server/app/constant"
+	"wallet-app-server/app/model"
+
+	"github.com/google/uuid"
+)
+
+// OFX 2.1.1 header
+const ofxHeader = `OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`
+
+// OFX code values
+const (
+	ofxDateTimeFormat = "20060102150405.000"
+	ofxAccountType    = "CHECKING"
+	ofxLanguage       = "ENG"
+	ofxMaxAcctIDLen   = 22
+	ofxMaxNameLen     = 32
+)
+
+type ofxStatus struct {
+	XMLName  xml.Name `xml:"STATUS"`
+	Code     int      `xml:"CODE"`
+	Severity string   `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	XMLName  xml.Name  `xml:"SIGNONMSGSRSV1"`
+	Status   ofxStatus `xml:"SONRS>STATUS"`
+	DtServer string    `xml:"SONRS>DTSERVER"`
+	Language string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxBankAccount struct {
+	XMLName  xml.Name `xml:"BANKACCTFROM"`
+	BankID   string   `xml:"BANKID"`
+	AcctID   string   `xml:"ACCTID"`
+	AcctType string   `xml:"ACCTTYPE"`
+}
+
+type ofxTransaction struct {
+	XMLName  xml.Name `xml:"STMTTRN"`
+	TrnType  string   `xml:"TRNTYPE"`
+	DtPosted string   `xml:"DTPOSTED"`
+	TrnAmt   string   `xml:"TRNAMT"`
+	FitID    string   `xml:"FITID"`
+	Name     string   `xml:"NAME,omitempty"`
+	Memo     string   `xml:"MEMO,omitempty"`
+}
+
+type ofxLedgerBalance struct {
+	XMLName xml.Name `xml:"LEDGERBAL"`
+	BalAmt  string   `xml:"BALAMT"`
+	DtAsOf  string   `xml:"DTASOF"`
+}
+
+// OFX 2.x bank statement writer
+// The document is written with an XML token encoder, transactions are flushed one by one
+type ofxWriter struct {
+	enc      *xml.Encoder
+	currency string
+	bankID   string
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{enc: xml.NewEncoder(w), currency: Currency(), bankID: bankID()}
+}
+
+func (ow *ofxWriter) WriteOpening(summary model.StatementSummary) error {
+	if err := ow.enc.EncodeToken(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8" standalone="no"`)}); err != nil {
+		return err
+	}
+	if err := ow.enc.EncodeToken(xml.ProcInst{Target: "OFX", Inst: []byte(ofxHeader)}); err != nil {
+		return err
+	}
+	if err := ow.start("OFX"); err != nil {
+		return err
+	}
+	if err := ow.enc.Encode(ofxSignOn{
+		Status:   ofxStatus{Code: 0, Severity: "INFO"},
+		DtServer: ofxDateTime(time.Now()),
+		Language: ofxLanguage,
+	}); err != nil {
+		return err
+	}
+	for _, name := range []string{"BANKMSGSRSV1", "STMTTRNRS"} {
+		if err := ow.start(name); err != nil {
+			return err
+		}
+	}
+	if err := ow.element("TRNUID", uuid.New().String()); err != nil {
+		return err
+	}
+	if err := ow.enc.Encode(ofxStatus{Code: 0, Severity: "INFO"}); err != nil {
+		return err
+	}
+	if err := ow.start("STMTRS"); err != nil {
+		return err
+	}
+	if err := ow.element("CURDEF", ow.currency); err != nil {
+		return err
+	}
+	if err := ow.enc.Encode(ofxBankAccount{BankID: ow.bankID, AcctID: ofxAccountID(summary.WalletID), AcctType: ofxAccountType}); err != nil {
+		return err
+	}
+	if err := ow.start("BANKTRANLIST"); err != nil {
+		return err
+	}
+	if err := ow.element("DTSTART", ofxDateTime(summary.FromTime)); err != nil {
+		return err
+	}
+	if err := ow.element("DTEND", ofxDateTime(summary.ToTime)); err != nil {
+		return err
+	}
+	return ow.enc.Flush()
+}
+
+func (ow *ofxWriter) WriteLine(line model.StatementLine) error {
+	counterparty := line.FromWalletID
+	if line.PostingAmount.IsNegative() {
+		counterparty = line.ToWalletID
+	}
+	name := counterparty
+	if len(name) > ofxMaxNameLen {
+		name = name[:ofxMaxNameLen]
+	}
+	if err := ow.enc.Encode(ofxTransaction{
+		TrnType:  ofxTransactionType(line),
+		DtPosted: ofxDateTime(line.TxnTime),
+		TrnAmt:   line.PostingAmount.StringFixed(2),
+		FitID:    line.TxnID,
+		Name:     name,
+		Memo:     fmt.Sprintf("%s, counterparty wallet %s", line.TxnTypeDesc, counterparty),
+	}); err != nil {
+		return err
+	}
+	return ow.enc.Flush()
+}
+
+func (ow *ofxWriter) WriteClosing(summary model.StatementSummary) error {
+	if err := ow.end("BANKTRANLIST"); err != nil {
+		return err
+	}
+	if err := ow.enc.Encode(ofxLedgerBalance{BalAmt: summary.ClosingBalance.StringFixed(2), DtAsOf: ofxDateTime(summary.ToTime)}); err != nil {
+		return err
+	}
+	for _, name := range []string{"STMTRS", "STMTTRNRS", "BANKMSGSRSV1", "OFX"} {
+		if err := ow.end(name); err != nil {
+			return err
+		}
+	}
+	return ow.enc.Flush()
+}
+
+func (ow *ofxWriter) start(name string) error {
+	return ow.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func (ow *ofxWriter) end(name string) error {
+	return ow.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func (ow *ofxWriter) element(name string, value string) error {
+	return ow.enc.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// Format the time in OFX datetime format, always in GMT
+func ofxDateTime(t time.Time) string {
+	return t.UTC().Format(ofxDateTimeFormat) + "[0:GMT]"
+}
+
+// Map the transaction type to the OFX transaction type
+func ofxTransactionType(line model.StatementLine) string {
+	switch line.TxnTypeDesc {
+	case constant.TxnTypeDeposit:
+		return "DEP"
+	case constant.TxnTypeWithdraw:
+		return "CASH"
+	case constant.TxnTypeTransfer:
+		return "XFER"
+	case constant.TxnTypeFee:
+		return "FEE"
+	}
+	if line.PostingAmount.IsNegative() {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// OFX limits ACCTID to 22 characters, which is shorter than a UUID wallet ID
+// A UUID is encoded in URL-safe base64 (exactly 22 characters), so that the account ID is still unique and reversible
+func ofxAccountID(walletID string) string {
+	if id, err := uuid.Parse(walletID); err == nil {
+		return base64.RawURLEncoding.EncodeToString(id[:])
+	}
+	if len(walletID) > ofxMaxAcctIDLen {
+		return walletID[:ofxMaxAcctIDLen]
+	}
+	return walletID
+}
@@ -0,0 +1,195 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"wallet-app-server/app/model"
+)
+
+// Page layout of the PDF statement (A4 landscape, in points)
+const (
+	pdfPageWidth   = 842
+	pdfPageHeight  = 595
+	pdfMargin      = 36
+	pdfFontSize    = 8
+	pdfLineHeight  = 11
+	pdfLinesOnPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// Fixed object numbers, page objects start after them
+const (
+	pdfCatalogObj = 1
+	pdfPagesObj   = 2
+	pdfFontObj    = 3
+)
+
+// PDF statement writer
+// A minimal PDF 1.4 document in a monospaced font. Pages are written out as soon as they're full,
+// and the page tree and the cross-reference table are written at the end, so only one page is kept in memory
+type pdfWriter struct {
+	w        *countingWriter
+	offsets  map[int]int64
+	nextObj  int
+	pageObjs []int
+	title    string
+	lines    []string
+}
+
+// An io.Writer which counts the written bytes, to record the object offsets for the cross-reference table
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.count += int64(n)
+	return n, err
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	return &pdfWriter{
+		w:       &countingWriter{w: w},
+		offsets: map[int]int64{},
+		nextObj: pdfFontObj + 1,
+	}
+}
+
+func (pw *pdfWriter) WriteOpening(summary model.StatementSummary) error {
+	// File header, catalog and font
+	if _, err := io.WriteString(pw.w, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return err
+	}
+	if err := pw.writeObject(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj)); err != nil {
+		return err
+	}
+	if err := pw.writeObject(pdfFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"); err != nil {
+		return err
+	}
+	// Statement heading
+	pw.title = fmt.Sprintf("Account Statement - Wallet %s - %s to %s", summary.WalletID,
+		summary.FromTime.Format(time.RFC3339), summary.ToTime.Format(time.RFC3339))
+	if err := pw.addLine(fmt.Sprintf("Opening balance: %s", summary.OpeningBalance.StringFixed(2))); err != nil {
+		return err
+	}
+	if err := pw.addLine(""); err != nil {
+		return err
+	}
+	return pw.addLine(pdfTableHeader())
+}
+
+func (pw *pdfWriter) WriteLine(line model.StatementLine) error {
+	counterparty := line.ToWalletID
+	if line.PostingAmount.IsPositive() {
+		counterparty = line.FromWalletID
+	}
+	return pw.addLine(fmt.Sprintf("%-20s %-12s %-36s %-36s %15s %15s",
+		line.TxnTime.Format("2006-01-02 15:04:05"), line.TxnTypeDesc, line.TxnID, counterparty,
+		line.PostingAmount.StringFixed(2), line.RunningBalance.StringFixed(2)))
+}
+
+func (pw *pdfWriter) WriteClosing(summary model.StatementSummary) error {
+	if err := pw.addLine(""); err != nil {
+		return err
+	}
+	if err := pw.addLine(fmt.Sprintf("Closing balance: %s", summary.ClosingBalance.StringFixed(2))); err != nil {
+		return err
+	}
+	if err := pw.addLine(fmt.Sprintf("Number of transactions: %d", summary.TxnCount)); err != nil {
+		return err
+	}
+	if err := pw.flushPage(); err != nil {
+		return err
+	}
+	// Page tree
+	kids := make([]string, 0, len(pw.pageObjs))
+	for _, pageObj := range pw.pageObjs {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+	}
+	if err := pw.writeObject(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pw.pageObjs))); err != nil {
+		return err
+	}
+	// Cross-reference table and trailer
+	xrefOffset := pw.w.count
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", pw.nextObj)
+	for obj := 1; obj < pw.nextObj; obj++ {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", pw.offsets[obj])
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", pw.nextObj, pdfCatalogObj, xrefOffset)
+	_, err := pw.w.Write(buf.Bytes())
+	return err
+}
+
+// Add a text line to the current page, the page is written out when it's full
+func (pw *pdfWriter) addLine(line string) error {
+	// Repeat the table header on the following pages
+	if len(pw.lines) == 0 && len(pw.pageObjs) > 0 {
+		pw.lines = append(pw.lines, pdfTableHeader())
+	}
+	pw.lines = append(pw.lines, line)
+	if len(pw.lines) >= pdfLinesOnPage-2 {
+		return pw.flushPage()
+	}
+	return nil
+}
+
+// Write the current page: its content stream and the page object
+func (pw *pdfWriter) flushPage() error {
+	if len(pw.lines) == 0 && len(pw.pageObjs) > 0 {
+		return nil
+	}
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+	fmt.Fprintf(&content, "(%s) Tj\n", pdfEscape(fmt.Sprintf("%s - Page %d", pw.title, len(pw.pageObjs)+1)))
+	content.WriteString("T*\n")
+	for _, line := range pw.lines {
+		fmt.Fprintf(&content, "T* (%s) Tj\n", pdfEscape(line))
+	}
+	content.WriteString("ET\n")
+
+	contentObj := pw.nextObj
+	pageObj := pw.nextObj + 1
+	pw.nextObj += 2
+	if err := pw.writeObject(contentObj, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String())); err != nil {
+		return err
+	}
+	if err := pw.writeObject(pageObj, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, contentObj)); err != nil {
+		return err
+	}
+	pw.pageObjs = append(pw.pageObjs, pageObj)
+	pw.lines = pw.lines[:0]
+	return nil
+}
+
+// Write an indirect object and record its offset
+func (pw *pdfWriter) writeObject(obj int, body string) error {
+	pw.offsets[obj] = pw.w.count
+	_, err := fmt.Fprintf(pw.w, "%d 0 obj\n%s\nendobj\n", obj, body)
+	return err
+}
+
+func pdfTableHeader() string {
+	return fmt.Sprintf("%-20s %-12s %-36s %-36s %15s %15s", "Time", "Type", "Transaction ID", "Counterparty", "Amount", "Balance")
+}
+
+// Escape a string for a PDF literal string, non-ASCII characters are replaced by '?'
+func pdfEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r < 32 || r > 126:
+			sb.WriteRune('?')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package statement
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/model"
+)
+
+// Statement formats
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatPDF     = "pdf"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+// Default values if not configured in the Export section
+const (
+	defaultCurrency = "USD"
+	defaultBankID   = "WALLETAPP"
+)
+
+const ErrUnsupportedFormat = "unsupported statement format"
+
+// Statement writer interface
+// The methods are called in order: WriteOpening once, WriteLine for each transaction, and WriteClosing once
+// Implementations must not keep the lines in memory, so that a large statement can be streamed
+type Writer interface {
+	WriteOpening(summary model.StatementSummary) error
+	WriteLine(line model.StatementLine) error
+	WriteClosing(summary model.StatementSummary) error
+}
+
+// Create a statement writer of the given format
+// If the format is not supported, return ErrUnsupportedFormat
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatPDF:
+		return newPDFWriter(w), nil
+	case FormatOFX:
+		return newOFXWriter(w), nil
+	case FormatCamt053:
+		return newCamt053Writer(w), nil
+	default:
+		return nil, errors.New(ErrUnsupportedFormat)
+	}
+}
+
+// Get the HTTP content type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatPDF:
+		return "application/pdf"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatCamt053:
+		return "application/xml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Get the file extension of the format
+func FileExtension(format string) string {
+	switch format {
+	case FormatCamt053:
+		return "xml"
+	default:
+		return format
+	}
+}
+
+// Check if the format is a banking-standard format (OFX or camt.053)
+func IsBankingFormat(format string) bool {
+	return format == FormatOFX || format == FormatCamt053
+}
+
+// Remove the hyphens from an ID (e.g. UUID), and cut it to 35 characters,
+// which is the maximum length of most ISO 20022 identifiers
+func compactID(id string) string {
+	result := strings.ReplaceAll(id, "-", "")
+	if len(result) > 35 {
+		result = result[:35]
+	}
+	return result
+}
+
+// The currency of the wallets, as configured in the Export section
+func Currency() string {
+	if config.Cfg.Export.Currency != "" {
+		return config.Cfg.Export.Currency
+	}
+	return defaultCurrency
+}
+
+func bankID() string {
+	if config.Cfg.Export.BankID != "" {
+		return config.Cfg.Export.BankID
+	}
+	return defaultBankID
+}
@@ -0,0 +1,96 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"wallet-app-server/app/model"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/shopspring/decimal"
+)
+
+func writeTestStatement(t *testing.T, format string, lineCount int) string {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	assert.Equal(t, nil, err)
+	fromTime := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	// The closing balance is known before the lines, as the statement service does
+	summary := model.StatementSummary{WalletID: "wallet-a", FromTime: fromTime, ToTime: fromTime.AddDate(0, 1, 0), OpeningBalance: decimal.RequireFromString("100")}
+	summary.ClosingBalance = summary.OpeningBalance.Add(decimal.RequireFromString("10.5").Mul(decimal.NewFromInt(int64(lineCount))))
+	assert.Equal(t, nil, w.WriteOpening(summary))
+	balance := summary.OpeningBalance
+	for i := 0; i < lineCount; i++ {
+		balance = balance.Add(decimal.RequireFromString("10.5"))
+		assert.Equal(t, nil, w.WriteLine(model.StatementLine{
+			TxnID:          fmt.Sprintf("txn-%d", i),
+			FromWalletID:   "system-cash-in",
+			ToWalletID:     "wallet-a",
+			TxnAmount:      decimal.RequireFromString("10.5"),
+			TxnTypeDesc:    "deposit",
+			TxnTime:        fromTime.Add(time.Duration(i) * time.Minute),
+			PostingAmount:  decimal.RequireFromString("10.5"),
+			RunningBalance: balance,
+		}))
+	}
+	summary.TxnCount = lineCount
+	assert.Equal(t, nil, w.WriteClosing(summary))
+	return buf.String()
+}
+
+func TestCSVStatement(t *testing.T) {
+	content := writeTestStatement(t, FormatCSV, 2)
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.Equal(t, "record_type,wallet_id,txn_id,txn_time,txn_type,from_wallet_id,to_wallet_id,amount,balance", lines[0])
+	assert.Equal(t, "opening,wallet-a,,2025-06-01T00:00:00Z,,,,,100.00", lines[1])
+	assert.Equal(t, "txn,,txn-1,2025-06-01T00:01:00Z,deposit,system-cash-in,wallet-a,10.50,121.00", lines[3])
+	assert.Equal(t, "closing,wallet-a,,2025-07-01T00:00:00Z,,,,,121.00", lines[4])
+}
+
+func TestNDJSONStatement(t *testing.T) {
+	content := writeTestStatement(t, FormatNDJSON, 1)
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, true, strings.HasPrefix(lines[0], `{"record_type":"opening","wallet_id":"wallet-a"`))
+	assert.Equal(t, true, strings.HasPrefix(lines[1], `{"record_type":"txn","txn_id":"txn-0"`))
+	assert.Equal(t, true, strings.Contains(lines[2], `"txn_count":1`))
+}
+
+func TestPDFStatementCrossReference(t *testing.T) {
+	// Enough lines to span several pages
+	content := writeTestStatement(t, FormatPDF, 200)
+	assert.Equal(t, true, strings.HasPrefix(content, "%PDF-1.4"))
+	assert.Equal(t, true, strings.HasSuffix(content, "%%EOF\n"))
+
+	// startxref points to the xref table
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(content)
+	xrefOffset, _ := strconv.Atoi(startxref[1])
+	assert.Equal(t, true, strings.HasPrefix(content[xrefOffset:], "xref\n"))
+
+	// Every xref entry points to its object
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(content[xrefOffset:], -1)
+	assert.NotEqual(t, 0, len(entries))
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.Equal(t, true, strings.HasPrefix(content[offset:], fmt.Sprintf("%d 0 obj\n", i+1)))
+	}
+
+	// The page tree has all the pages
+	pageCount := strings.Count(content, "/Type /Page ")
+	assert.Equal(t, true, pageCount > 1)
+	assert.Equal(t, true, strings.Contains(content, fmt.Sprintf("/Count %d", pageCount)))
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("xls", &bytes.Buffer{})
+	assert.Equal(t, ErrUnsupportedFormat, err.Error())
+}
+
+func TestPDFEscape(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\c?`, pdfEscape(`a(b)\c€`))
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Key of the query span in the GORM statement
+const spanKey = "tracing:span"
+
+// Register the GORM callbacks tracing every DB query
+// A query is only traced if its context has a span, i.e. the session is created with db.WithContext(ctx),
+// so that the queries of the background jobs don't start a trace each
+func InstrumentGORM(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("tracing:before_create", beforeQuery("create")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", afterQuery),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", beforeQuery("query")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", afterQuery),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", beforeQuery("update")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", afterQuery),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", beforeQuery("delete")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", afterQuery),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", beforeQuery("row")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", afterQuery),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", beforeQuery("raw")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", afterQuery),
+	)
+}
+
+func beforeQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		_, span := tracer.Start(ctx, "db."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+		))
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// End the query span, a record not found is not a failed query
+// The statement is recorded with the placeholders, the values are never recorded
+func afterQuery(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+	span.SetAttributes(
+		semconv.DBCollectionName(db.Statement.Table),
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(span, db.Error)
+	}
+}
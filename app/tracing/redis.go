@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redis hook tracing every command, a pipeline is traced as a single "pipeline" span
+// A command is only traced if its context has a span, the arguments are never recorded
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		span, ok := startRedisSpan(ctx, cmd.Name())
+		if !ok {
+			return next(ctx, cmd)
+		}
+		defer span.End()
+		err := next(ctx, cmd)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		span, ok := startRedisSpan(ctx, "pipeline")
+		if !ok {
+			return next(ctx, cmds)
+		}
+		defer span.End()
+		span.SetAttributes(attribute.Int("db.redis.num_cmd", len(cmds)))
+		err := next(ctx, cmds)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+func startRedisSpan(ctx context.Context, command string) (trace.Span, bool) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil, false
+	}
+	_, span := tracer.Start(ctx, "redis."+command, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemRedis,
+		semconv.DBOperationName(command),
+	))
+	return span, true
+}
+
+// A missing key is not a failed command
+func endRedisSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		RecordError(span, err)
+	}
+}
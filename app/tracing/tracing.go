@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters of the spans
+// The spans are still recorded without an exporter, so that the log lines have trace IDs
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Name of the tracer of the server, and the service name if not configured
+const instrumentationName = "wallet-app-server"
+
+// Timeout of flushing the remaining spans on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// The tracer of the server
+// It delegates to the global tracer provider, so the spans started before Init are not recorded
+var tracer = otel.Tracer(instrumentationName)
+
+var provider *sdktrace.TracerProvider
+
+// Init the tracer provider and the W3C trace context propagation, as configured in the Tracing section
+// Must be called before the server starts
+func Init() error {
+	tracingConf := config.Cfg.Tracing
+	var opts []sdktrace.TracerProviderOption
+	switch tracingConf.Exporter {
+	case ExporterOTLP:
+		var exporterOpts []otlptracehttp.Option
+		if tracingConf.Endpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(tracingConf.Endpoint))
+		}
+		exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterNone, "":
+	default:
+		return fmt.Errorf("unknown exporter %q", tracingConf.Exporter)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(ServiceName()),
+		semconv.ServiceVersion(version.Version),
+	))
+	if err != nil {
+		return err
+	}
+	// The new traces are sampled with the ratio, the traces started by the caller follow the caller's decision
+	opts = append(opts, sdktrace.WithResource(res), sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tracingConf.SampleRatio))))
+	provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return nil
+}
+
+// Flush the remaining spans and stop the tracer provider, the spans ended afterwards are dropped
+func Shutdown() error {
+	if provider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return provider.Shutdown(ctx)
+}
+
+// The configured service name of the spans
+func ServiceName() string {
+	if serviceName := config.Cfg.Tracing.ServiceName; serviceName != "" {
+		return serviceName
+	}
+	return instrumentationName
+}
+
+// Start a span as the child of the span in the context, e.g. around a service method
+func Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, spanName, opts...)
+}
+
+// Mark the span as failed with the error
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	os.Exit(m.Run())
+}
+
+// Find the ended span by name
+func endedSpan(name string) (sdktrace.ReadOnlySpan, bool) {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span, true
+		}
+	}
+	return nil, false
+}
+
+func TestInstrumentGORM(t *testing.T) {
+	// The statements are only built, no DB is needed
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, InstrumentGORM(db), nil)
+
+	// A query without a span in the context is not traced
+	var balances []string
+	db.Table("wallet").Where("user_id = ?", "secret-user").Pluck("balance", &balances)
+	_, ok := endedSpan("db.query")
+	assert.Equal(t, ok, false)
+
+	// A query of a traced request is a child span
+	ctx, parent := Start(context.Background(), "WalletService.ListUserWallets")
+	db.WithContext(ctx).Table("wallet").Where("user_id = ?", "secret-user").Pluck("balance", &balances)
+	parent.End()
+	span, ok := endedSpan("db.query")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, span.Parent().SpanID(), parent.SpanContext().SpanID())
+	assert.Equal(t, span.SpanContext().TraceID(), parent.SpanContext().TraceID())
+	attrs := map[string]string{}
+	for _, attr := range span.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	assert.Equal(t, attrs["db.collection.name"], "wallet")
+	// The values are not recorded
+	assert.Equal(t, attrs["db.query.text"], `SELECT "balance" FROM "wallet" WHERE user_id = $1`)
+}
+
+func TestRedisHook(t *testing.T) {
+	rdb := goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer rdb.Close()
+	rdb.AddHook(RedisHook{})
+
+	ctx, parent := Start(context.Background(), "Authentication")
+	err := rdb.Get(ctx, "secret-token").Err()
+	parent.End()
+	assert.NotEqual(t, err, nil)
+	span, ok := endedSpan("redis.get")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, span.Parent().SpanID(), parent.SpanContext().SpanID())
+	assert.Equal(t, span.Status().Code, codes.Error)
+	for _, attr := range span.Attributes() {
+		assert.NotEqual(t, attr.Value.Emit(), "secret-token")
+	}
+}
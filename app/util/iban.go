@@ -0,0 +1,48 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const ErrInvalidIBAN = "invalid IBAN"
+
+// Pattern of an IBAN in electronic format: country code, check digits and up to 30 alphanumeric characters
+var ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{1,30}$`)
+
+// Pattern of a BIC (ISO 9362), with or without the branch code
+var bicPattern = regexp.MustCompile(`^[A-Z0-9]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// Convert an IBAN to the electronic format (upper case without spaces)
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// Validate an IBAN in electronic format, including the ISO 7064 mod 97-10 check digits
+func ValidateIBAN(iban string) error {
+	if !ibanPattern.MatchString(iban) {
+		return errors.New(ErrInvalidIBAN)
+	}
+	// Move the first 4 characters to the end, and convert the letters to numbers (A = 10, ..., Z = 35)
+	var sb strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	number, _ := new(big.Int).SetString(sb.String(), 10)
+	if new(big.Int).Mod(number, big.NewInt(97)).Int64() != 1 {
+		return errors.New(ErrInvalidIBAN)
+	}
+	return nil
+}
+
+// Check if the BIC is valid
+func IsValidBIC(bic string) bool {
+	return bicPattern.MatchString(bic)
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestNormalizeIBAN(t *testing.T) {
+	assert.Equal(t, "GB82WEST12345698765432", NormalizeIBAN(" gb82 west 1234 5698 7654 32 "))
+}
+
+func TestValidateIBAN(t *testing.T) {
+	assert.Equal(t, nil, ValidateIBAN("GB82WEST12345698765432"))
+	assert.Equal(t, nil, ValidateIBAN("DE89370400440532013000"))
+	assert.Equal(t, nil, ValidateIBAN("FR1420041010050500013M02606"))
+}
+
+func TestValidateIBANInvalid(t *testing.T) {
+	// Wrong check digits
+	assert.NotEqual(t, nil, ValidateIBAN("GB83WEST12345698765432"))
+	// Not in electronic format
+	assert.NotEqual(t, nil, ValidateIBAN("GB82 WEST 1234 5698 7654 32"))
+	assert.NotEqual(t, nil, ValidateIBAN(""))
+}
+
+func TestIsValidBIC(t *testing.T) {
+	assert.Equal(t, true, IsValidBIC("DEUTDEFF"))
+	assert.Equal(t, true, IsValidBIC("DEUTDEFF500"))
+	assert.Equal(t, false, IsValidBIC("DEUTDEFF5"))
+	assert.Equal(t, false, IsValidBIC("deutdeff"))
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"crypto/rand"
+	"regexp"
+	"strings"
+)
+
+// Characters of the wallet reference code, without the ambiguous ones (0/O, 1/I/L)
+const referenceCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// Pattern of a wallet reference code inside a free text (e.g. bank transfer remittance information)
+var referenceCodePattern = regexp.MustCompile(`(?i)\bWA[0-9A-Z]{10}\b`)
+
+// Generate a random wallet reference code, "WA" followed by 10 characters
+// Users put it in the bank transfer reference so that the deposit can be matched to the wallet
+func GenerateReferenceCode() string {
+	randomBytes := make([]byte, 10)
+	rand.Read(randomBytes)
+	code := make([]byte, 0, 12)
+	code = append(code, 'W', 'A')
+	for _, b := range randomBytes {
+		code = append(code, referenceCodeAlphabet[int(b)%len(referenceCodeAlphabet)])
+	}
+	return string(code)
+}
+
+// Find the wallet reference codes in a free text, in upper case
+func FindReferenceCodes(text string) []string {
+	matches := referenceCodePattern.FindAllString(text, -1)
+	for i := range matches {
+		matches[i] = strings.ToUpper(matches[i])
+	}
+	return matches
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestGenerateReferenceCode(t *testing.T) {
+	code := GenerateReferenceCode()
+	assert.Equal(t, 12, len(code))
+	assert.Equal(t, []string{code}, FindReferenceCodes("Top up "+code))
+}
+
+func TestFindReferenceCodes(t *testing.T) {
+	assert.Equal(t, []string{"WAA5344DDEA6"}, FindReferenceCodes("deposit ref waa5344ddea6 thanks"))
+	assert.Equal(t, []string{"WAA5344DDEA6", "WA34FAD4741D"}, FindReferenceCodes("WAA5344DDEA6/WA34FAD4741D"))
+	assert.Equal(t, 0, len(FindReferenceCodes("XWAA5344DDEA6 WA123")))
+}
@@ -0,0 +1,24 @@
+package version
+
+import "runtime"
+
+// Build information, set by the build scripts with ldflags, e.g.
+// go build -ldflags "-X wallet-app-server/app/version.Version=v1.2.0" ...
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Build information of the running server
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get the build information of the running server
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+}
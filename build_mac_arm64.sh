@@ -1 +1,4 @@
-GOOS=darwin GOARCH=arm64 go build -o dist/wallet-app-server cmd/*
\ No newline at end of file
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+GOOS=darwin GOARCH=arm64 go build -ldflags "-X wallet-app-server/app/version.Version=$VERSION -X wallet-app-server/app/version.Commit=$COMMIT -X wallet-app-server/app/version.BuildTime=$BUILD_TIME" -o dist/wallet-app-server cmd/*
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"os"
 	"wallet-app-server/app"
 )
 
@@ -9,8 +12,35 @@ func main() {
 	// Parse commandline flags
 	var configPath string
 	flag.StringVar(&configPath, "c", "config.toml", "Configutation file path")
+	flag.Usage = func() {
+		fmt.Println("Usage: wallet-app-server [-c <config_path>] [migrate <command>]")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	// Init & start application
-	app.InitAndStart(configPath)
+	// Run the subcommand, if any
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "migrate":
+			// Exit with code -1 if the migration fails, or 2 if the arguments are invalid
+			if err := app.Migrate(configPath, flag.Args()[1:]); err != nil {
+				if errors.Is(err, app.ErrMigrateUsage) {
+					os.Exit(2)
+				}
+				fmt.Println("[FATAL] " + err.Error())
+				os.Exit(-1)
+			}
+			return
+		default:
+			fmt.Printf("Unknown command %q\n", flag.Arg(0))
+			flag.Usage()
+			os.Exit(2)
+		}
+	}
+
+	// Init & start application, exit with code -1 if the server can't start or can't shut down cleanly
+	if err := app.InitAndStart(configPath); err != nil {
+		fmt.Println("[FATAL] " + err.Error())
+		os.Exit(-1)
+	}
 }
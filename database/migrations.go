@@ -0,0 +1,10 @@
+package database
+
+import "embed"
+
+// The versioned migrations of the schema, embedded in the server binary
+// Each version has an up file and a down file, e.g. 002-wallet-note.up.sql and 002-wallet-note.down.sql
+// An applied migration must never be changed, add a new version instead
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,25 @@
+/* Drop all the tables of the baseline, ALL THE DATA IS LOST */
+/* The schema is kept, it holds the schema_migrations table */
+DROP TABLE IF EXISTS wallet_app.admin_audit_log;
+DROP TABLE IF EXISTS wallet_app.pending_operation_audit;
+DROP TABLE IF EXISTS wallet_app.pending_operation;
+DROP TABLE IF EXISTS wallet_app.kyc_document;
+DROP TABLE IF EXISTS wallet_app.kyc_submission;
+DROP TABLE IF EXISTS wallet_app.screening_case;
+DROP TABLE IF EXISTS wallet_app.screening_list_version;
+DROP TABLE IF EXISTS wallet_app.risk_decision;
+DROP TABLE IF EXISTS wallet_app.risk_review;
+DROP TABLE IF EXISTS wallet_app.user_device;
+DROP TABLE IF EXISTS wallet_app.user_limit;
+DROP TABLE IF EXISTS wallet_app.payout;
+DROP TABLE IF EXISTS wallet_app.payout_batch;
+DROP TABLE IF EXISTS wallet_app.payout_destination;
+DROP TABLE IF EXISTS wallet_app.bank_statement_line;
+DROP TABLE IF EXISTS wallet_app.bank_statement_import;
+DROP TABLE IF EXISTS wallet_app.wallet_balance_snapshot;
+DROP TABLE IF EXISTS wallet_app.txn_posting;
+DROP TABLE IF EXISTS wallet_app.txn_history;
+DROP TABLE IF EXISTS wallet_app.user_activity;
+DROP TABLE IF EXISTS wallet_app.user_wallet_bridge;
+DROP TABLE IF EXISTS wallet_app.wallet;
+DROP TABLE IF EXISTS wallet_app.user;
@@ -0,0 +1,3 @@
+/* The matched rules longer than 255 characters are cut */
+ALTER TABLE wallet_app.risk_review ALTER COLUMN matched_rules TYPE VARCHAR(255) USING LEFT(matched_rules, 255);
+ALTER TABLE wallet_app.risk_decision ALTER COLUMN matched_rules TYPE VARCHAR(255) USING LEFT(matched_rules, 255);
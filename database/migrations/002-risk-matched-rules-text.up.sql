@@ -0,0 +1,3 @@
+/* The matched rules of a decision can be longer than 255 characters */
+ALTER TABLE wallet_app.risk_review ALTER COLUMN matched_rules TYPE TEXT;
+ALTER TABLE wallet_app.risk_decision ALTER COLUMN matched_rules TYPE TEXT;
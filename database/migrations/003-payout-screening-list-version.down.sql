@@ -0,0 +1 @@
+ALTER TABLE wallet_app.payout DROP COLUMN screening_list_version;
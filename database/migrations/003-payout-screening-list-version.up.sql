@@ -0,0 +1,2 @@
+/* The version of the sanctions lists the account holder of a payout was last screened against, NULL for the existing payouts */
+ALTER TABLE wallet_app.payout ADD COLUMN screening_list_version VARCHAR(16);
@@ -0,0 +1,4 @@
+ALTER TABLE wallet_app.risk_decision DROP COLUMN outcome;
+
+/* The hold wallet is kept while an amount is held, close the transfers waiting for approval before rolling back */
+DELETE FROM wallet_app.wallet WHERE wallet_id = 'system-hold' AND balance = 0;
@@ -0,0 +1,7 @@
+/* The amount of a transfer waiting for approval is held in the system hold wallet */
+INSERT INTO wallet_app.wallet (wallet_id, wallet_name, wallet_type, balance, create_time)
+VALUES ('system-hold', 'system hold account', 'system', 0, NOW());
+
+/* What became of the assessed transaction: completed, or rejected or expired in the approval */
+ALTER TABLE wallet_app.risk_decision ADD COLUMN outcome VARCHAR(10);
+UPDATE wallet_app.risk_decision SET outcome = 'completed' WHERE txn_id IS NOT NULL;
@@ -0,0 +1,4 @@
+/* Approving a held payout without its destination would only withdraw, so reject the pending ones before rolling back */
+UPDATE wallet_app.risk_review SET review_status = 'rejected', review_note = 'Rolled back migration 005', update_time = NOW()
+WHERE payout_destination_id IS NOT NULL AND review_status = 'pending';
+ALTER TABLE wallet_app.risk_review DROP COLUMN payout_destination_id;
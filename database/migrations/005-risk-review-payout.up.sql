@@ -0,0 +1,2 @@
+/* The payout destination of a payout held for the risk review, NULL for the other transactions */
+ALTER TABLE wallet_app.risk_review ADD COLUMN payout_destination_id VARCHAR(60);
@@ -0,0 +1,4 @@
+/* The line hashes recorded since keep their account, so a line imported since is credited again if its statement is imported after rolling back */
+DROP INDEX IF EXISTS wallet_app.idx_bank_statement_line_content_hash;
+ALTER TABLE wallet_app.bank_statement_line DROP COLUMN content_hash;
+ALTER TABLE wallet_app.bank_statement_line DROP COLUMN account;
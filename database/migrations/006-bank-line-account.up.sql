@@ -0,0 +1,5 @@
+/* The bank account of the statement of a line, empty for the lines imported before, whose line hash has no account */
+ALTER TABLE wallet_app.bank_statement_line ADD COLUMN account VARCHAR(34) NOT NULL DEFAULT '';
+/* The hash of the account and the content of a line, NULL for the lines imported before */
+ALTER TABLE wallet_app.bank_statement_line ADD COLUMN content_hash VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_bank_statement_line_content_hash ON wallet_app.bank_statement_line(content_hash);
@@ -0,0 +1,3 @@
+/* The former server keeps the balance of the system wallets in the column, starting from the sum of their postings */
+UPDATE wallet_app.wallet w SET balance = COALESCE((SELECT SUM(p.posting_amount) FROM wallet_app.txn_posting p WHERE p.wallet_id = w.wallet_id), 0)
+WHERE w.wallet_type = 'system';
@@ -0,0 +1,2 @@
+/* The balance of a system wallet is derived from its postings, so that no transaction locks its row, the column is left at 0 */
+UPDATE wallet_app.wallet SET balance = 0 WHERE wallet_type = 'system';
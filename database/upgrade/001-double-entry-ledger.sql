@@ -0,0 +1,40 @@
+/* Upgrade an existing database to the double-entry ledger */
+
+/* Add wallet type, existing wallets are all user wallets */
+ALTER TABLE wallet_app.wallet ADD COLUMN wallet_type VARCHAR(10) NOT NULL DEFAULT 'user';
+
+/* Create posting table */
+CREATE TABLE wallet_app.txn_posting (
+    posting_id VARCHAR(60) NOT NULL,
+    txn_id VARCHAR(60) NOT NULL,
+    wallet_id VARCHAR(60) NOT NULL,
+    posting_amount NUMERIC(15, 2) NOT NULL,
+    posting_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_txn_posting PRIMARY KEY(posting_id)
+);
+
+CREATE INDEX idx_txn_posting_txn_id ON wallet_app.txn_posting(txn_id);
+CREATE INDEX idx_txn_posting_wallet_id ON wallet_app.txn_posting(wallet_id, posting_time);
+
+/* Create system wallets */
+INSERT INTO wallet_app.wallet (wallet_id, wallet_name, wallet_type, balance, create_time)
+VALUES
+('system-cash-in', 'system cash-in account', 'system', 0, NOW()),
+('system-cash-out', 'system cash-out account', 'system', 0, NOW()),
+('system-fee', 'system fee account', 'system', 0, NOW()),
+('system-fx', 'system FX account', 'system', 0, NOW());
+
+/* Legacy deposits and withdrawals were recorded as wallet-to-itself, point them to the system wallets */
+UPDATE wallet_app.txn_history SET from_wallet_id = 'system-cash-in' WHERE txn_type = 'deposit' AND from_wallet_id = to_wallet_id;
+UPDATE wallet_app.txn_history SET to_wallet_id = 'system-cash-out' WHERE txn_type = 'withdraw' AND from_wallet_id = to_wallet_id;
+
+/* Back-fill the postings of the existing transactions (debit the from wallet, credit the to wallet) */
+INSERT INTO wallet_app.txn_posting (posting_id, txn_id, wallet_id, posting_amount, posting_time)
+SELECT txn_id || '-D', txn_id, from_wallet_id, -txn_amount, COALESCE(txn_time, NOW()) FROM wallet_app.txn_history;
+INSERT INTO wallet_app.txn_posting (posting_id, txn_id, wallet_id, posting_amount, posting_time)
+SELECT txn_id || '-C', txn_id, to_wallet_id, txn_amount, COALESCE(txn_time, NOW()) FROM wallet_app.txn_history;
+
+/* Derive the system wallet balances from the postings */
+UPDATE wallet_app.wallet w SET balance = p.total
+FROM (SELECT wallet_id, SUM(posting_amount) AS total FROM wallet_app.txn_posting GROUP BY wallet_id) p
+WHERE w.wallet_id = p.wallet_id AND w.wallet_type = 'system';
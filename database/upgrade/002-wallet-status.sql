@@ -0,0 +1,2 @@
+/* Upgrade an existing database to support frozen wallets */
+ALTER TABLE wallet_app.wallet ADD COLUMN wallet_status VARCHAR(10) NOT NULL DEFAULT 'active';
@@ -0,0 +1,8 @@
+/* Upgrade an existing database to support point-in-time balance queries */
+CREATE TABLE wallet_app.wallet_balance_snapshot (
+    wallet_id VARCHAR(60) NOT NULL,
+    snapshot_time TIMESTAMP NOT NULL,
+    balance NUMERIC(15, 2) NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_wallet_balance_snapshot PRIMARY KEY(wallet_id, snapshot_time)
+);
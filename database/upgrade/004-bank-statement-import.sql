@@ -0,0 +1,41 @@
+/* Upgrade an existing database to support bank statement import */
+
+/* Add the reference code used by the bank transfers to identify the wallet, and back-fill the user wallets */
+ALTER TABLE wallet_app.wallet ADD COLUMN reference_code VARCHAR(20) UNIQUE;
+UPDATE wallet_app.wallet SET reference_code = 'WA' || UPPER(SUBSTRING(REPLACE(wallet_id, '-', ''), 1, 10)) WHERE wallet_type = 'user';
+
+CREATE TABLE wallet_app.bank_statement_import (
+    import_id VARCHAR(60) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_format VARCHAR(10) NOT NULL,
+    file_hash VARCHAR(64) NOT NULL,
+    line_count INT NOT NULL,
+    matched_count INT NOT NULL,
+    unmatched_count INT NOT NULL,
+    duplicate_count INT NOT NULL,
+    imported_by VARCHAR(60) NOT NULL,
+    import_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_bank_statement_import PRIMARY KEY(import_id)
+);
+
+CREATE TABLE wallet_app.bank_statement_line (
+    line_id VARCHAR(60) NOT NULL,
+    import_id VARCHAR(60) NOT NULL,
+    line_hash VARCHAR(64) UNIQUE NOT NULL,
+    bank_ref VARCHAR(100) NOT NULL,
+    booking_time TIMESTAMP NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reference VARCHAR(255) NOT NULL,
+    debtor_name VARCHAR(140) NOT NULL,
+    line_status VARCHAR(10) NOT NULL,
+    wallet_id VARCHAR(60),
+    txn_id VARCHAR(60),
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    review_time TIMESTAMP,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_bank_statement_line PRIMARY KEY(line_id)
+);
+
+CREATE INDEX idx_bank_statement_line_status ON wallet_app.bank_statement_line(line_status, booking_time);
@@ -0,0 +1,54 @@
+/* Upgrade an existing database to support bank payouts */
+
+/* Widen the type columns for the longer types (e.g. payout_return) */
+ALTER TABLE wallet_app.txn_history ALTER COLUMN txn_type TYPE VARCHAR(20);
+ALTER TABLE wallet_app.user_activity ALTER COLUMN user_act_type TYPE VARCHAR(20);
+
+/* Link a transaction to its parent transaction (e.g. a payout return to the withdrawal) */
+ALTER TABLE wallet_app.txn_history ADD COLUMN parent_txn_id VARCHAR(60);
+CREATE INDEX idx_txn_history_parent_txn_id ON wallet_app.txn_history(parent_txn_id);
+
+CREATE TABLE wallet_app.payout_destination (
+    destination_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    iban VARCHAR(34) NOT NULL,
+    holder_name VARCHAR(140) NOT NULL,
+    bic VARCHAR(11),
+    destination_status VARCHAR(10) NOT NULL,
+    verified_by VARCHAR(60),
+    verified_time TIMESTAMP,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_payout_destination PRIMARY KEY(destination_id)
+);
+
+CREATE INDEX idx_payout_destination_user_id ON wallet_app.payout_destination(user_id);
+
+CREATE TABLE wallet_app.payout_batch (
+    batch_id VARCHAR(60) NOT NULL,
+    msg_id VARCHAR(35) NOT NULL,
+    payout_count INT NOT NULL,
+    total_amount NUMERIC(15, 2) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_payout_batch PRIMARY KEY(batch_id)
+);
+
+CREATE TABLE wallet_app.payout (
+    payout_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    wallet_id VARCHAR(60) NOT NULL,
+    destination_id VARCHAR(60) NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    txn_id VARCHAR(60) NOT NULL,
+    payout_status VARCHAR(10) NOT NULL,
+    batch_id VARCHAR(60),
+    return_reason VARCHAR(255),
+    return_txn_id VARCHAR(60),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_payout PRIMARY KEY(payout_id)
+);
+
+CREATE INDEX idx_payout_status ON wallet_app.payout(payout_status, create_time);
+CREATE INDEX idx_payout_wallet_id ON wallet_app.payout(wallet_id);
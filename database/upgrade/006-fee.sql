@@ -0,0 +1,4 @@
+/* Upgrade an existing database to support the fee engine */
+
+/* Add user tier, the fee schedules can vary by user tier */
+ALTER TABLE wallet_app.user ADD COLUMN user_tier VARCHAR(20) NOT NULL DEFAULT 'standard';
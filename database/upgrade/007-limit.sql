@@ -0,0 +1,18 @@
+/* Upgrade an existing database to support transaction limits */
+
+/* Sum the user's transactions of a type in the current day and month */
+CREATE INDEX idx_txn_history_from_wallet_id ON wallet_app.txn_history(from_wallet_id, txn_type, txn_time);
+
+/* Per user limits, overriding the limits of the user tier */
+CREATE TABLE wallet_app.user_limit (
+    user_id VARCHAR(60) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
+    per_txn_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    daily_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    daily_count INTEGER NOT NULL DEFAULT 0,
+    monthly_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    monthly_count INTEGER NOT NULL DEFAULT 0,
+    update_by VARCHAR(60) NOT NULL,
+    update_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_user_limit PRIMARY KEY(user_id, txn_type)
+);
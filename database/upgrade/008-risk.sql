@@ -0,0 +1,49 @@
+/* Upgrade an existing database to support the risk engine */
+
+CREATE TABLE wallet_app.user_device (
+    user_id VARCHAR(60) NOT NULL,
+    device_id VARCHAR(100) NOT NULL,
+    first_seen_time TIMESTAMP NOT NULL,
+    last_seen_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_user_device PRIMARY KEY(user_id, device_id)
+);
+
+CREATE TABLE wallet_app.risk_review (
+    review_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
+    from_wallet_id VARCHAR(60) NOT NULL,
+    to_wallet_id VARCHAR(60),
+    amount NUMERIC(15, 2) NOT NULL,
+    device_id VARCHAR(100),
+    risk_score INTEGER NOT NULL,
+    matched_rules VARCHAR(255) NOT NULL,
+    review_status VARCHAR(10) NOT NULL,
+    txn_id VARCHAR(60),
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_risk_review PRIMARY KEY(review_id)
+);
+
+CREATE INDEX idx_risk_review_status ON wallet_app.risk_review(review_status, create_time);
+
+CREATE TABLE wallet_app.risk_decision (
+    decision_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
+    from_wallet_id VARCHAR(60) NOT NULL,
+    to_wallet_id VARCHAR(60),
+    amount NUMERIC(15, 2) NOT NULL,
+    device_id VARCHAR(100),
+    risk_score INTEGER NOT NULL,
+    decision VARCHAR(10) NOT NULL,
+    matched_rules VARCHAR(255) NOT NULL,
+    txn_id VARCHAR(60),
+    review_id VARCHAR(60),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_risk_decision PRIMARY KEY(decision_id)
+);
+
+CREATE INDEX idx_risk_decision_user_id ON wallet_app.risk_decision(user_id, create_time);
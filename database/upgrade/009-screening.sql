@@ -0,0 +1,32 @@
+/* Upgrade an existing database to support the sanctions screening */
+
+/* Add user full name, it is screened against the sanctions lists */
+ALTER TABLE wallet_app.user ADD COLUMN full_name VARCHAR(140);
+
+CREATE TABLE wallet_app.screening_list_version (
+    list_version VARCHAR(16) NOT NULL,
+    entry_count INTEGER NOT NULL,
+    load_by VARCHAR(60) NOT NULL,
+    load_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_screening_list_version PRIMARY KEY(list_version, load_time)
+);
+
+CREATE TABLE wallet_app.screening_case (
+    case_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60),
+    subject_ref VARCHAR(60) NOT NULL,
+    screened_name VARCHAR(140) NOT NULL,
+    screening_context VARCHAR(20) NOT NULL,
+    list_version VARCHAR(16) NOT NULL,
+    match_score NUMERIC(5, 4) NOT NULL,
+    matches TEXT NOT NULL,
+    case_status VARCHAR(10) NOT NULL,
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_screening_case PRIMARY KEY(case_id)
+);
+
+CREATE INDEX idx_screening_case_status ON wallet_app.screening_case(case_status, create_time);
+CREATE INDEX idx_screening_case_subject_ref ON wallet_app.screening_case(subject_ref, list_version);
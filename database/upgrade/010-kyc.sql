@@ -0,0 +1,39 @@
+/* Upgrade an existing database to support the KYC levels */
+
+/* Add user KYC level, the transactions allowed can vary by KYC level */
+/* The existing users are grandfathered, they keep making every transaction unless the grandfathered level is configured */
+ALTER TABLE wallet_app.user ADD COLUMN kyc_level VARCHAR(20) NOT NULL DEFAULT 'grandfathered';
+/* The new users start unverified */
+ALTER TABLE wallet_app.user ALTER COLUMN kyc_level SET DEFAULT 'unverified';
+
+CREATE TABLE wallet_app.kyc_submission (
+    submission_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    target_level VARCHAR(20) NOT NULL,
+    submission_status VARCHAR(10) NOT NULL,
+    review_by VARCHAR(60),
+    review_reason VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_kyc_submission PRIMARY KEY(submission_id)
+);
+
+CREATE INDEX idx_kyc_submission_status ON wallet_app.kyc_submission(submission_status, create_time);
+CREATE INDEX idx_kyc_submission_user_id ON wallet_app.kyc_submission(user_id, create_time);
+
+CREATE TABLE wallet_app.kyc_document (
+    document_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    document_type VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    file_size BIGINT NOT NULL,
+    file_hash VARCHAR(64) NOT NULL,
+    blob_key VARCHAR(255) NOT NULL,
+    submission_id VARCHAR(60),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_kyc_document PRIMARY KEY(document_id)
+);
+
+CREATE INDEX idx_kyc_document_user_id ON wallet_app.kyc_document(user_id, create_time);
+CREATE INDEX idx_kyc_document_submission_id ON wallet_app.kyc_document(submission_id);
@@ -0,0 +1,32 @@
+/* Upgrade an existing database to support the approval of the sensitive operations by a second person */
+
+CREATE TABLE wallet_app.pending_operation (
+    operation_id VARCHAR(60) NOT NULL,
+    operation_type VARCHAR(30) NOT NULL,
+    payload TEXT NOT NULL,
+    summary VARCHAR(255) NOT NULL,
+    operation_status VARCHAR(10) NOT NULL,
+    request_by VARCHAR(60) NOT NULL,
+    request_note VARCHAR(255),
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    result_ref VARCHAR(60),
+    expire_time TIMESTAMP NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_pending_operation PRIMARY KEY(operation_id)
+);
+
+CREATE INDEX idx_pending_operation_status ON wallet_app.pending_operation(operation_status, expire_time);
+
+CREATE TABLE wallet_app.pending_operation_audit (
+    audit_id VARCHAR(60) NOT NULL,
+    operation_id VARCHAR(60) NOT NULL,
+    audit_action VARCHAR(10) NOT NULL,
+    actor_id VARCHAR(60) NOT NULL,
+    audit_note VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_pending_operation_audit PRIMARY KEY(audit_id)
+);
+
+CREATE INDEX idx_pending_operation_audit_operation_id ON wallet_app.pending_operation_audit(operation_id, create_time);
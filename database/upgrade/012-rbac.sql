@@ -0,0 +1,23 @@
+/* Upgrade an existing database to support the user roles and the admin audit log */
+
+/* Add user role, the staff roles (support, compliance, admin) can call the /admin endpoints */
+ALTER TABLE wallet_app.user ADD COLUMN user_role VARCHAR(20) NOT NULL DEFAULT 'customer';
+
+/* The admins used to be configured in the Admin section of config.toml, give them the admin role, e.g.
+UPDATE wallet_app.user SET user_role = 'admin' WHERE user_id IN ('<admin user ID>');
+*/
+
+CREATE TABLE wallet_app.admin_audit_log (
+    audit_id VARCHAR(60) NOT NULL,
+    operator_id VARCHAR(60) NOT NULL,
+    operator_role VARCHAR(20) NOT NULL,
+    audit_action VARCHAR(100) NOT NULL,
+    request_path VARCHAR(255),
+    status_code INTEGER,
+    client_ip VARCHAR(45),
+    audit_detail VARCHAR(500),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_admin_audit_log PRIMARY KEY(audit_id)
+);
+
+CREATE INDEX idx_admin_audit_log_operator_id ON wallet_app.admin_audit_log(operator_id, create_time);
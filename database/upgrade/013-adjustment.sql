@@ -0,0 +1,6 @@
+/* Upgrade an existing database to support the manual balance adjustments */
+
+/* The system adjustment wallet is the ledger counterparty of the adjustments made by the staff */
+INSERT INTO wallet_app.wallet (wallet_id, wallet_name, wallet_type, balance, create_time)
+VALUES
+('system-adjustment', 'system adjustment account', 'system', 0, NOW());
@@ -1,3 +1,6 @@
+/* The full schema for a new database, the same as applying all the migrations of database/migrations */
+/* Keep it up to date when adding a migration */
+
 /* Create Database */
 CREATE DATABASE wallet_app;
 
@@ -9,6 +12,10 @@ CREATE TABLE wallet_app.user (
     user_id VARCHAR(60) NOT NULL,
     user_name VARCHAR(60) UNIQUE NOT NULL,
     user_hash VARCHAR(100) NOT NULL,
+    user_tier VARCHAR(20) NOT NULL DEFAULT 'standard',
+    full_name VARCHAR(140),
+    kyc_level VARCHAR(20) NOT NULL DEFAULT 'unverified',
+    user_role VARCHAR(20) NOT NULL DEFAULT 'customer',
     create_time TIMESTAMP NOT NULL,
     update_time TIMESTAMP,
     CONSTRAINT pk_user PRIMARY KEY(user_id)
@@ -17,6 +24,9 @@ CREATE TABLE wallet_app.user (
 CREATE TABLE wallet_app.wallet (
     wallet_id VARCHAR(60) NOT NULL,
     wallet_name VARCHAR(60) NOT NULL,
+    wallet_type VARCHAR(10) NOT NULL DEFAULT 'user',
+    wallet_status VARCHAR(10) NOT NULL DEFAULT 'active',
+    reference_code VARCHAR(20) UNIQUE,
     balance NUMERIC(15, 2) NOT NULL,
     create_time TIMESTAMP NOT NULL,
     update_time TIMESTAMP,
@@ -34,7 +44,7 @@ CREATE TABLE wallet_app.user_wallet_bridge (
 CREATE TABLE wallet_app.user_activity (
     user_act_id VARCHAR(60) NOT NULL,
     user_id VARCHAR(60) NOT NULL,
-    user_act_type VARCHAR(10) NOT NULL,
+    user_act_type VARCHAR(20) NOT NULL,
     user_act_detail VARCHAR(255) NOT NULL,
     user_wallet_id VARCHAR(60),
     user_act_time TIMESTAMP NOT NULL,
@@ -45,8 +55,298 @@ CREATE TABLE wallet_app.txn_history (
     txn_id VARCHAR(60) NOT NULL,
     from_wallet_id VARCHAR(60) NOT NULL,
     to_wallet_id VARCHAR(60) NOT NULL,
-    txn_type VARCHAR(10) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
     txn_amount NUMERIC(15, 2) NOT NULL,
     txn_time TIMESTAMP,
+    parent_txn_id VARCHAR(60),
     CONSTRAINT pk_txn_history PRIMARY KEY(txn_id)
-);
\ No newline at end of file
+);
+
+CREATE INDEX idx_txn_history_parent_txn_id ON wallet_app.txn_history(parent_txn_id);
+CREATE INDEX idx_txn_history_from_wallet_id ON wallet_app.txn_history(from_wallet_id, txn_type, txn_time);
+
+CREATE TABLE wallet_app.txn_posting (
+    posting_id VARCHAR(60) NOT NULL,
+    txn_id VARCHAR(60) NOT NULL,
+    wallet_id VARCHAR(60) NOT NULL,
+    posting_amount NUMERIC(15, 2) NOT NULL,
+    posting_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_txn_posting PRIMARY KEY(posting_id)
+);
+
+CREATE INDEX idx_txn_posting_txn_id ON wallet_app.txn_posting(txn_id);
+CREATE INDEX idx_txn_posting_wallet_id ON wallet_app.txn_posting(wallet_id, posting_time);
+
+CREATE TABLE wallet_app.wallet_balance_snapshot (
+    wallet_id VARCHAR(60) NOT NULL,
+    snapshot_time TIMESTAMP NOT NULL,
+    balance NUMERIC(15, 2) NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_wallet_balance_snapshot PRIMARY KEY(wallet_id, snapshot_time)
+);
+
+CREATE TABLE wallet_app.bank_statement_import (
+    import_id VARCHAR(60) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_format VARCHAR(10) NOT NULL,
+    file_hash VARCHAR(64) NOT NULL,
+    line_count INT NOT NULL,
+    matched_count INT NOT NULL,
+    unmatched_count INT NOT NULL,
+    duplicate_count INT NOT NULL,
+    imported_by VARCHAR(60) NOT NULL,
+    import_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_bank_statement_import PRIMARY KEY(import_id)
+);
+
+CREATE TABLE wallet_app.bank_statement_line (
+    line_id VARCHAR(60) NOT NULL,
+    import_id VARCHAR(60) NOT NULL,
+    line_hash VARCHAR(64) UNIQUE NOT NULL,
+    account VARCHAR(34) NOT NULL DEFAULT '',
+    content_hash VARCHAR(64),
+    bank_ref VARCHAR(100) NOT NULL,
+    booking_time TIMESTAMP NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reference VARCHAR(255) NOT NULL,
+    debtor_name VARCHAR(140) NOT NULL,
+    line_status VARCHAR(10) NOT NULL,
+    wallet_id VARCHAR(60),
+    txn_id VARCHAR(60),
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    review_time TIMESTAMP,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_bank_statement_line PRIMARY KEY(line_id)
+);
+
+CREATE INDEX idx_bank_statement_line_status ON wallet_app.bank_statement_line(line_status, booking_time);
+CREATE INDEX idx_bank_statement_line_content_hash ON wallet_app.bank_statement_line(content_hash);
+
+CREATE TABLE wallet_app.payout_destination (
+    destination_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    iban VARCHAR(34) NOT NULL,
+    holder_name VARCHAR(140) NOT NULL,
+    bic VARCHAR(11),
+    destination_status VARCHAR(10) NOT NULL,
+    verified_by VARCHAR(60),
+    verified_time TIMESTAMP,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_payout_destination PRIMARY KEY(destination_id)
+);
+
+CREATE INDEX idx_payout_destination_user_id ON wallet_app.payout_destination(user_id);
+
+CREATE TABLE wallet_app.payout_batch (
+    batch_id VARCHAR(60) NOT NULL,
+    msg_id VARCHAR(35) NOT NULL,
+    payout_count INT NOT NULL,
+    total_amount NUMERIC(15, 2) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_payout_batch PRIMARY KEY(batch_id)
+);
+
+CREATE TABLE wallet_app.payout (
+    payout_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    wallet_id VARCHAR(60) NOT NULL,
+    destination_id VARCHAR(60) NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    txn_id VARCHAR(60) NOT NULL,
+    payout_status VARCHAR(10) NOT NULL,
+    batch_id VARCHAR(60),
+    return_reason VARCHAR(255),
+    return_txn_id VARCHAR(60),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    screening_list_version VARCHAR(16),
+    CONSTRAINT pk_payout PRIMARY KEY(payout_id)
+);
+
+CREATE INDEX idx_payout_status ON wallet_app.payout(payout_status, create_time);
+CREATE INDEX idx_payout_wallet_id ON wallet_app.payout(wallet_id);
+
+CREATE TABLE wallet_app.user_limit (
+    user_id VARCHAR(60) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
+    per_txn_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    daily_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    daily_count INTEGER NOT NULL DEFAULT 0,
+    monthly_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    monthly_count INTEGER NOT NULL DEFAULT 0,
+    update_by VARCHAR(60) NOT NULL,
+    update_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_user_limit PRIMARY KEY(user_id, txn_type)
+);
+
+CREATE TABLE wallet_app.user_device (
+    user_id VARCHAR(60) NOT NULL,
+    device_id VARCHAR(100) NOT NULL,
+    first_seen_time TIMESTAMP NOT NULL,
+    last_seen_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_user_device PRIMARY KEY(user_id, device_id)
+);
+
+CREATE TABLE wallet_app.risk_review (
+    review_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
+    from_wallet_id VARCHAR(60) NOT NULL,
+    to_wallet_id VARCHAR(60),
+    amount NUMERIC(15, 2) NOT NULL,
+    device_id VARCHAR(100),
+    risk_score INTEGER NOT NULL,
+    matched_rules TEXT NOT NULL,
+    review_status VARCHAR(10) NOT NULL,
+    txn_id VARCHAR(60),
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    payout_destination_id VARCHAR(60),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_risk_review PRIMARY KEY(review_id)
+);
+
+CREATE INDEX idx_risk_review_status ON wallet_app.risk_review(review_status, create_time);
+
+CREATE TABLE wallet_app.risk_decision (
+    decision_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    txn_type VARCHAR(20) NOT NULL,
+    from_wallet_id VARCHAR(60) NOT NULL,
+    to_wallet_id VARCHAR(60),
+    amount NUMERIC(15, 2) NOT NULL,
+    device_id VARCHAR(100),
+    risk_score INTEGER NOT NULL,
+    decision VARCHAR(10) NOT NULL,
+    matched_rules TEXT NOT NULL,
+    txn_id VARCHAR(60),
+    review_id VARCHAR(60),
+    outcome VARCHAR(10),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_risk_decision PRIMARY KEY(decision_id)
+);
+
+CREATE INDEX idx_risk_decision_user_id ON wallet_app.risk_decision(user_id, create_time);
+
+CREATE TABLE wallet_app.screening_list_version (
+    list_version VARCHAR(16) NOT NULL,
+    entry_count INTEGER NOT NULL,
+    load_by VARCHAR(60) NOT NULL,
+    load_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_screening_list_version PRIMARY KEY(list_version, load_time)
+);
+
+CREATE TABLE wallet_app.screening_case (
+    case_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60),
+    subject_ref VARCHAR(60) NOT NULL,
+    screened_name VARCHAR(140) NOT NULL,
+    screening_context VARCHAR(20) NOT NULL,
+    list_version VARCHAR(16) NOT NULL,
+    match_score NUMERIC(5, 4) NOT NULL,
+    matches TEXT NOT NULL,
+    case_status VARCHAR(10) NOT NULL,
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_screening_case PRIMARY KEY(case_id)
+);
+
+CREATE INDEX idx_screening_case_status ON wallet_app.screening_case(case_status, create_time);
+CREATE INDEX idx_screening_case_subject_ref ON wallet_app.screening_case(subject_ref, list_version);
+
+CREATE TABLE wallet_app.kyc_submission (
+    submission_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    target_level VARCHAR(20) NOT NULL,
+    submission_status VARCHAR(10) NOT NULL,
+    review_by VARCHAR(60),
+    review_reason VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_kyc_submission PRIMARY KEY(submission_id)
+);
+
+CREATE INDEX idx_kyc_submission_status ON wallet_app.kyc_submission(submission_status, create_time);
+CREATE INDEX idx_kyc_submission_user_id ON wallet_app.kyc_submission(user_id, create_time);
+
+CREATE TABLE wallet_app.kyc_document (
+    document_id VARCHAR(60) NOT NULL,
+    user_id VARCHAR(60) NOT NULL,
+    document_type VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    file_size BIGINT NOT NULL,
+    file_hash VARCHAR(64) NOT NULL,
+    blob_key VARCHAR(255) NOT NULL,
+    submission_id VARCHAR(60),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_kyc_document PRIMARY KEY(document_id)
+);
+
+CREATE INDEX idx_kyc_document_user_id ON wallet_app.kyc_document(user_id, create_time);
+CREATE INDEX idx_kyc_document_submission_id ON wallet_app.kyc_document(submission_id);
+
+CREATE TABLE wallet_app.pending_operation (
+    operation_id VARCHAR(60) NOT NULL,
+    operation_type VARCHAR(30) NOT NULL,
+    payload TEXT NOT NULL,
+    summary VARCHAR(255) NOT NULL,
+    operation_status VARCHAR(10) NOT NULL,
+    request_by VARCHAR(60) NOT NULL,
+    request_note VARCHAR(255),
+    review_by VARCHAR(60),
+    review_note VARCHAR(255),
+    result_ref VARCHAR(60),
+    expire_time TIMESTAMP NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    update_time TIMESTAMP,
+    CONSTRAINT pk_pending_operation PRIMARY KEY(operation_id)
+);
+
+CREATE INDEX idx_pending_operation_status ON wallet_app.pending_operation(operation_status, expire_time);
+
+CREATE TABLE wallet_app.pending_operation_audit (
+    audit_id VARCHAR(60) NOT NULL,
+    operation_id VARCHAR(60) NOT NULL,
+    audit_action VARCHAR(10) NOT NULL,
+    actor_id VARCHAR(60) NOT NULL,
+    audit_note VARCHAR(255),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_pending_operation_audit PRIMARY KEY(audit_id)
+);
+
+CREATE INDEX idx_pending_operation_audit_operation_id ON wallet_app.pending_operation_audit(operation_id, create_time);
+
+CREATE TABLE wallet_app.admin_audit_log (
+    audit_id VARCHAR(60) NOT NULL,
+    operator_id VARCHAR(60) NOT NULL,
+    operator_role VARCHAR(20) NOT NULL,
+    audit_action VARCHAR(100) NOT NULL,
+    request_path VARCHAR(255),
+    status_code INTEGER,
+    client_ip VARCHAR(45),
+    audit_detail VARCHAR(500),
+    create_time TIMESTAMP NOT NULL,
+    CONSTRAINT pk_admin_audit_log PRIMARY KEY(audit_id)
+);
+
+CREATE INDEX idx_admin_audit_log_operator_id ON wallet_app.admin_audit_log(operator_id, create_time);
+
+/* Create System Wallets */
+/* System wallets are the ledger counterparties for money entering or leaving the system */
+/* Their balance is derived from their postings, the balance column stays 0 */
+INSERT INTO wallet_app.wallet (wallet_id, wallet_name, wallet_type, balance, create_time)
+VALUES
+('system-cash-in', 'system cash-in account', 'system', 0, NOW()),
+('system-cash-out', 'system cash-out account', 'system', 0, NOW()),
+('system-fee', 'system fee account', 'system', 0, NOW()),
+('system-fx', 'system FX account', 'system', 0, NOW()),
+('system-adjustment', 'system adjustment account', 'system', 0, NOW()),
+('system-hold', 'system hold account', 'system', 0, NOW());
\ No newline at end of file
@@ -4,7 +4,9 @@ docker-compose down;
 docker-compose up -d;
 # wait for DB start
 sleep 3;
-# create tables
-docker exec --user postgres -it end2end-db-1 bash -c "psql -f /wallet-app-server/database/wallet-app.sql";
+# create tables with the migrations, as the server does
+(cd ../.. && go run ./cmd -c tests/end2end/config.toml migrate up);
 # insert test data
-docker exec --user postgres -it end2end-db-1 bash -c "psql -f /wallet-app-server/tests/end2end/test_data.sql";
\ No newline at end of file
+docker exec --user postgres -it end2end-db-1 bash -c "psql -f /wallet-app-server/tests/end2end/test_data.sql";
+# the test users are existing users from before the KYC levels, grandfathered like database/upgrade/010-kyc.sql does
+docker exec --user postgres -it end2end-db-1 bash -c "psql -c \"UPDATE wallet_app.user SET kyc_level = 'grandfathered'\"";
\ No newline at end of file
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"wallet-app-server/app/alert"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/service"
+)
+
+func main() {
+	// Parse commandline flags
+	var configPath string
+	var freeze bool
+	flag.StringVar(&configPath, "c", "config.toml", "Configutation file path")
+	flag.BoolVar(&freeze, "freeze", false, "Freeze the wallets whose balance doesn't match the transaction history")
+	flag.Usage = func() {
+		fmt.Println("This is a small tool to reconcile the wallet balances against the transaction history of wallet-app system")
+		fmt.Println("Usage: reconcile [-c <config_path>] [-freeze]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Init configuration, logger, alerter and DB
+	if err := config.LoadConfig(configPath); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(-1)
+	}
+	if err := logger.Init(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(-1)
+	}
+	alert.Init()
+	if err := db.Init(); err != nil {
+		fmt.Println("DB init error:", err.Error())
+		os.Exit(-1)
+	}
+
+	// Reconcile and print the report
+	report, _, err := service.ReconcileService.Reconcile(context.Background(), freeze)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(-1)
+	}
+	content, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(content))
+
+	// Exit with code 1 if any mismatch found, so that it can be used in scripts
+	if report.MismatchCount > 0 {
+		os.Exit(1)
+	}
+}
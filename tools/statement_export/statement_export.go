@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"wallet-app-server/app/config"
+	"wallet-app-server/app/db"
+	"wallet-app-server/app/logger"
+	"wallet-app-server/app/service"
+	"wallet-app-server/app/statement"
+)
+
+func main() {
+	// Parse commandline flags
+	var configPath, walletID, from, to, format, output string
+	flag.StringVar(&configPath, "c", "config.toml", "Configutation file path")
+	flag.StringVar(&walletID, "wallet", "", "Wallet ID")
+	flag.StringVar(&from, "from", "", "Start of the time range in RFC3339 format (exclusive)")
+	flag.StringVar(&to, "to", "", "End of the time range in RFC3339 format (inclusive), default to the current time")
+	flag.StringVar(&format, "format", statement.FormatOFX, "Export format: ofx, camt053, csv, ndjson or pdf")
+	flag.StringVar(&output, "o", "", "Output file path")
+	flag.Usage = func() {
+		fmt.Println("This is a small tool to export the wallet activity of wallet-app system for accounting software")
+		fmt.Println("Usage: statement_export -wallet <wallet_id> -from <time> [-to <time>] [-format ofx|camt053|csv|ndjson|pdf] -o <output_path> [-c <config_path>]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	// The output must be a file, since the logs are written to stdout
+	if walletID == "" || from == "" || output == "" {
+		flag.Usage()
+		os.Exit(-1)
+	}
+	fromTime, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		fmt.Println("Invalid from time:", err.Error())
+		os.Exit(-1)
+	}
+	toTime := time.Now()
+	if to != "" {
+		if toTime, err = time.Parse(time.RFC3339, to); err != nil {
+			fmt.Println("Invalid to time:", err.Error())
+			os.Exit(-1)
+		}
+	}
+
+	// Open output
+	file, err := os.Create(output)
+	if err != nil {
+		fmt.Println("Failed to create output file:", err.Error())
+		os.Exit(-1)
+	}
+	defer file.Close()
+	bufferedOut := bufio.NewWriter(file)
+	writer, err := statement.NewWriter(format, bufferedOut)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(-1)
+	}
+
+	// Init configuration, logger and DB
+	if err := config.LoadConfig(configPath); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(-1)
+	}
+	if err := logger.Init(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(-1)
+	}
+	if err := db.Init(); err != nil {
+		fmt.Println("DB init error:", err.Error())
+		os.Exit(-1)
+	}
+
+	// Export
+	if _, err := service.StatementService.ExportWalletStatement(context.Background(), walletID, fromTime, toTime, writer); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(-1)
+	}
+	if err := bufferedOut.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(-1)
+	}
+}